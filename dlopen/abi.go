@@ -0,0 +1,314 @@
+//go:build cgo
+
+package dlopen
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"unsafe"
+)
+
+// Return classes, which tell syscallN where the result of a call is.
+const (
+	retInt        = iota // integer register
+	retFloat             // floating-point register
+	retIntInt            // two integer registers
+	retFloatFloat        // two floating-point registers (amd64)
+	retIntFloat          // integer and floating-point register (amd64)
+	retFloatInt          // floating-point and integer register (amd64)
+	retHFA               // up to four floating-point registers (arm64)
+	retMemory            // memory provided by the caller
+)
+
+// maxRetSize is the largest struct which can be returned by value.
+const maxRetSize = 256
+
+// eightbyte classes of the System V AMD64 ABI.
+const (
+	classNone = iota
+	classInteger
+	classSSE
+)
+
+// callFrame collects the registers and stack slots of a call.
+type callFrame struct {
+	sysargs   [maxArgs]uintptr // integer registers, then stack slots
+	floats    [numOfFloats]uintptr
+	intRegs   int
+	numInts   int
+	numFloats int
+	numStack  int
+	ret       int
+	// numbered is set if arguments are passed in the numbered registers like on
+	// Windows amd64, i.e. in sysargs only.
+	numbered  bool
+	keepAlive []interface{}
+}
+
+// newCallFrame creates the frame of a call of a function returning out, which is
+// nil for functions without a result.
+func newCallFrame(out reflect.Type) *callFrame {
+	f := &callFrame{intRegs: numOfIntegerRegisters()}
+	if runtime.GOOS == "windows" && runtime.GOARCH != "arm64" {
+		// On Windows amd64 the arguments are passed in the numbered registered.
+		// So the first int is in the first integer register and the first float
+		// is in the second floating register if there is already a first int.
+		// This is in contrast to how macOS and Linux pass arguments which
+		// tries to use as many registers as possible in the calling convention.
+		f.numbered = true
+		f.intRegs = 0
+	}
+	if out != nil {
+		f.ret = returnClass(out)
+		if f.ret == retMemory && runtime.GOARCH == "amd64" {
+			// The address of the result is passed in the first integer register
+			f.intRegs--
+		}
+	}
+	return f
+}
+
+func (f *callFrame) addStack(x uintptr) {
+	if f.intRegs+f.numStack >= maxArgs {
+		panic("purego: too many arguments")
+	}
+	f.sysargs[f.intRegs+f.numStack] = x
+	f.numStack++
+}
+
+func (f *callFrame) addInt(x uintptr) {
+	if f.numInts >= f.intRegs {
+		f.addStack(x)
+	} else {
+		f.sysargs[f.numInts] = x
+		f.numInts++
+	}
+}
+
+func (f *callFrame) addFloat(x uintptr) {
+	if f.numbered || f.numFloats >= len(f.floats) {
+		f.addStack(x)
+	} else {
+		f.floats[f.numFloats] = x
+		f.numFloats++
+	}
+}
+
+// addStruct passes the struct v by value.
+func (f *callFrame) addStruct(v reflect.Value) {
+	t := v.Type()
+	p := reflect.New(t)
+	p.Elem().Set(v)
+	f.keepAlive = append(f.keepAlive, p.Interface())
+	words := structWords(p.UnsafePointer(), t.Size())
+
+	switch {
+	case f.numbered:
+		// Structs of 1, 2, 4 or 8 bytes are passed as integers, others by reference
+		switch t.Size() {
+		case 1, 2, 4, 8:
+			f.addStack(words[0])
+		default:
+			f.addStack(p.Pointer())
+		}
+	case runtime.GOARCH == "arm64":
+		if n, float32s := homogeneousFloats(t); n > 0 {
+			if f.numFloats+n > len(f.floats) {
+				// The struct and all following floats are passed on the stack
+				f.numFloats = len(f.floats)
+				for _, w := range words {
+					f.addStack(w)
+				}
+				return
+			}
+			for i := 0; i < n; i++ {
+				if float32s {
+					f.addFloat(uintptr(*(*uint32)(unsafe.Add(p.UnsafePointer(), 4*i))))
+				} else {
+					f.addFloat(uintptr(*(*uint64)(unsafe.Add(p.UnsafePointer(), 8*i))))
+				}
+			}
+			return
+		}
+		if t.Size() > 16 {
+			// Larger structs are passed by reference to a copy
+			f.addInt(p.Pointer())
+			return
+		}
+		if f.numInts+len(words) > f.intRegs {
+			// The struct and all following integers are passed on the stack
+			f.numInts = f.intRegs
+			for _, w := range words {
+				f.addStack(w)
+			}
+			return
+		}
+		for _, w := range words {
+			f.addInt(w)
+		}
+	default:
+		classes := classifyAMD64(t)
+		var ints, floats int
+		for _, class := range classes {
+			if class == classSSE {
+				floats++
+			} else {
+				ints++
+			}
+		}
+		if classes == nil || f.numInts+ints > f.intRegs || f.numFloats+floats > len(f.floats) {
+			// The struct is passed in memory
+			for _, w := range words {
+				f.addStack(w)
+			}
+			return
+		}
+		for i, class := range classes {
+			if class == classSSE {
+				f.addFloat(words[i])
+			} else {
+				f.addInt(words[i])
+			}
+		}
+	}
+}
+
+// structWords returns the struct of size bytes at p in words, the last one padded.
+func structWords(p unsafe.Pointer, size uintptr) []uintptr {
+	words := make([]uintptr, (size+7)/8)
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(words))), 8*len(words)), unsafe.Slice((*byte)(p), size))
+	return words
+}
+
+// returnClass returns where a result of type t is returned.
+func returnClass(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return retFloat
+	case reflect.Struct:
+	default:
+		return retInt
+	}
+	if t.Size() > maxRetSize {
+		panic("purego: struct result is larger than " + strconv.Itoa(maxRetSize) + " bytes")
+	}
+	if runtime.GOOS == "windows" && runtime.GOARCH != "arm64" {
+		switch t.Size() {
+		case 1, 2, 4, 8:
+			return retInt
+		}
+		panic("purego: struct result must have 1, 2, 4 or 8 bytes on windows")
+	}
+	if runtime.GOARCH == "arm64" {
+		if n, _ := homogeneousFloats(t); n > 0 {
+			return retHFA
+		}
+		if t.Size() > 16 {
+			return retMemory
+		}
+		return retIntInt
+	}
+	classes := classifyAMD64(t)
+	switch {
+	case classes == nil:
+		return retMemory
+	case len(classes) == 0:
+		return retInt
+	case len(classes) == 1 && classes[0] == classSSE:
+		return retFloat
+	case len(classes) == 1:
+		return retInt
+	case classes[0] == classSSE && classes[1] == classSSE:
+		return retFloatFloat
+	case classes[0] == classSSE:
+		return retFloatInt
+	case classes[1] == classSSE:
+		return retIntFloat
+	}
+	return retIntInt
+}
+
+// structResult returns the struct of type t returned as ret with class.
+func structResult(t reflect.Type, class int, ret *[maxRetSize]byte) reflect.Value {
+	v := reflect.New(t)
+	dst := unsafe.Slice((*byte)(v.UnsafePointer()), t.Size())
+	if class == retHFA {
+		// Each member is in the low bits of its own register
+		n, float32s := homogeneousFloats(t)
+		for i := 0; i < n; i++ {
+			if float32s {
+				copy(dst[4*i:], ret[8*i:8*i+4])
+			} else {
+				copy(dst[8*i:], ret[8*i:8*i+8])
+			}
+		}
+	} else {
+		copy(dst, ret[:])
+	}
+	return v.Elem()
+}
+
+// classifyAMD64 returns the classes of the eightbytes of the struct type t, or nil
+// if it is passed in memory.
+func classifyAMD64(t reflect.Type) []int {
+	if t.Size() > 16 {
+		return nil
+	}
+	classes := make([]int, (t.Size()+7)/8)
+	structFields(t, 0, func(offset uintptr, kind reflect.Kind) {
+		i := offset / 8
+		if kind != reflect.Float32 && kind != reflect.Float64 {
+			classes[i] = classInteger
+		} else if classes[i] == classNone {
+			classes[i] = classSSE
+		}
+	})
+	for i, class := range classes {
+		if class == classNone {
+			classes[i] = classSSE
+		}
+	}
+	return classes
+}
+
+// homogeneousFloats returns the number of members of the struct type t if it is a
+// homogeneous floating-point aggregate of the ARM64 ABI, i.e. has one to four
+// members of the same floating-point type, and whether they are float32.
+func homogeneousFloats(t reflect.Type) (n int, float32s bool) {
+	var kind reflect.Kind
+	homogeneous := true
+	structFields(t, 0, func(offset uintptr, k reflect.Kind) {
+		if n == 0 {
+			kind = k
+		}
+		homogeneous = homogeneous && k == kind && (k == reflect.Float32 || k == reflect.Float64)
+		n++
+	})
+	if !homogeneous || n < 1 || n > 4 {
+		return 0, false
+	}
+	return n, kind == reflect.Float32
+}
+
+// structFields calls fn with the offset and kind of each scalar field of the
+// struct or array type t at offset, in order.
+func structFields(t reflect.Type, offset uintptr, fn func(offset uintptr, kind reflect.Kind)) {
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			structFields(field.Type, offset+field.Offset, fn)
+		}
+	case reflect.Array:
+		for i := 0; i < t.Len(); i++ {
+			structFields(t.Elem(), offset+uintptr(i)*t.Elem().Size(), fn)
+		}
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Ptr, reflect.UnsafePointer:
+		fn(offset, t.Kind())
+	default:
+		panic("purego: unsupported struct field kind " + t.Kind().String())
+	}
+}
@@ -0,0 +1,181 @@
+//go:build cgo
+
+package dlopen
+
+import (
+	"math"
+	"reflect"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestClassifyAMD64(t *testing.T) {
+	tests := []struct {
+		name string
+		v    interface{}
+		want []int
+	}{
+		{"empty", struct{}{}, []int{}},
+		{"int", struct{ A int32 }{}, []int{classInteger}},
+		{"two ints", struct{ A, B int32 }{}, []int{classInteger}},
+		{"double", struct{ A float64 }{}, []int{classSSE}},
+		{"two floats", struct{ A, B float32 }{}, []int{classSSE}},
+		{"float and int in one eightbyte", struct {
+			A float32
+			B int32
+		}{}, []int{classInteger}},
+		{"int and double", struct {
+			A int64
+			B float64
+		}{}, []int{classInteger, classSSE}},
+		{"double and int", struct {
+			A float64
+			B int8
+		}{}, []int{classSSE, classInteger}},
+		{"two doubles", struct{ A, B float64 }{}, []int{classSSE, classSSE}},
+		{"three floats", struct{ A, B, C float32 }{}, []int{classSSE, classSSE}},
+		{"floats and int", struct {
+			A, B float32
+			C    int32
+		}{}, []int{classSSE, classInteger}},
+		{"array", struct{ A [3]int16 }{}, []int{classInteger}},
+		{"nested", struct {
+			A struct{ X float64 }
+			B struct{ Y *byte }
+		}{}, []int{classSSE, classInteger}},
+		{"larger than 16 bytes", struct{ A, B, C int64 }{}, nil},
+		{"three doubles", struct{ A, B, C float64 }{}, nil},
+	}
+	for _, test := range tests {
+		got := classifyAMD64(reflect.TypeOf(test.v))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: classes %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReturnClass(t *testing.T) {
+	if runtime.GOARCH != "amd64" || runtime.GOOS == "windows" {
+		t.Skip("the return classes are those of the System V AMD64 ABI")
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		want int
+	}{
+		{"int", int32(0), retInt},
+		{"pointer", (*byte)(nil), retInt},
+		{"float", float32(0), retFloat},
+		{"double", float64(0), retFloat},
+		{"empty struct", struct{}{}, retInt},
+		{"struct of an int", struct{ A int64 }{}, retInt},
+		{"struct of two floats", struct{ A, B float32 }{}, retFloat},
+		{"struct of two ints", struct{ A, B int64 }{}, retIntInt},
+		{"struct of two doubles", struct{ A, B float64 }{}, retFloatFloat},
+		{"int and double", struct {
+			A int64
+			B float64
+		}{}, retIntFloat},
+		{"double and int", struct {
+			A float64
+			B int64
+		}{}, retFloatInt},
+		{"struct in memory", struct{ A, B, C int64 }{}, retMemory},
+	}
+	for _, test := range tests {
+		if got := returnClass(reflect.TypeOf(test.v)); got != test.want {
+			t.Errorf("%s: class %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAddStruct(t *testing.T) {
+	if runtime.GOARCH != "amd64" || runtime.GOOS == "windows" {
+		t.Skip("the words are placed like in the System V AMD64 ABI")
+	}
+	type intDouble struct {
+		A int64
+		B float64
+	}
+	type large struct{ A, B, C int64 }
+	type floats struct {
+		A, B float32
+		C    int32
+	}
+	double := func(f float64) uintptr { return uintptr(math.Float64bits(f)) }
+
+	tests := []struct {
+		name   string
+		ints   int // integer arguments before the struct
+		out    reflect.Type
+		v      interface{}
+		args   []uintptr // sysargs up to the last used slot
+		floats []uintptr
+		stack  int
+	}{
+		{
+			name:   "int and double",
+			v:      intDouble{1, 2.5},
+			args:   []uintptr{1},
+			floats: []uintptr{double(2.5)},
+		},
+		{
+			name:   "two floats in one register",
+			v:      floats{1, 2, 3},
+			args:   []uintptr{3},
+			floats: []uintptr{uintptr(math.Float32bits(2))<<32 | uintptr(math.Float32bits(1))},
+		},
+		{
+			name:  "larger than 16 bytes",
+			v:     large{1, 2, 3},
+			args:  []uintptr{0, 0, 0, 0, 0, 0, 1, 2, 3},
+			stack: 3,
+		},
+		{
+			// The struct doesn't fit in the last integer register, which
+			// remains free for the next integer
+			name:  "integer registers exhausted",
+			ints:  5,
+			v:     struct{ A, B int64 }{7, 8},
+			args:  []uintptr{1, 2, 3, 4, 5, 0, 7, 8},
+			stack: 2,
+		},
+		{
+			// The address of the result takes the first integer register
+			name:  "struct result in memory",
+			ints:  5,
+			out:   reflect.TypeOf(large{}),
+			v:     struct{ A int64 }{7},
+			args:  []uintptr{1, 2, 3, 4, 5, 7},
+			stack: 1,
+		},
+	}
+	for _, test := range tests {
+		f := newCallFrame(test.out)
+		for i := 1; i <= test.ints; i++ {
+			f.addInt(uintptr(i))
+		}
+		f.addStruct(reflect.ValueOf(test.v))
+		if got := f.sysargs[:len(test.args)]; !slices.Equal(got, test.args) {
+			t.Errorf("%s: integer words %v, want %v", test.name, got, test.args)
+		}
+		if got := f.floats[:len(test.floats)]; !slices.Equal(got, test.floats) {
+			t.Errorf("%s: float words %v, want %v", test.name, got, test.floats)
+		}
+		if f.numStack != test.stack || f.numFloats != len(test.floats) {
+			t.Errorf("%s: %d stack slots and %d float registers, want %d and %d", test.name, f.numStack, f.numFloats, test.stack, len(test.floats))
+		}
+	}
+
+	// An integer after a struct passed in memory still uses a register
+	f := newCallFrame(nil)
+	for i := 1; i <= 5; i++ {
+		f.addInt(uintptr(i))
+	}
+	f.addStruct(reflect.ValueOf(struct{ A, B int64 }{7, 8}))
+	f.addInt(9)
+	if f.sysargs[5] != 9 || f.numInts != 6 {
+		t.Errorf("integer after the struct: registers %v", f.sysargs[:f.numInts])
+	}
+}
@@ -0,0 +1,19 @@
+//go:build cgo
+
+package dlopen
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// syscallN calls fn with the arguments of frame. Note this may not support floats
+func syscallN(fn uintptr, frame *callFrame) (ret [maxRetSize]byte, err uintptr) {
+	r1, r2, errno := syscall.SyscallN(fn, frame.sysargs[:frame.intRegs+frame.numStack]...)
+	if frame.ret == retFloat {
+		// NOTE: r2 is only the floating return value on 64bit platforms.
+		r1 = r2
+	}
+	*(*uintptr)(unsafe.Pointer(&ret[0])) = r1
+	return ret, uintptr(errno)
+}
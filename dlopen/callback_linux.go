@@ -0,0 +1,181 @@
+package dlopen
+
+/*
+#include <stdint.h>
+
+typedef struct callbackArgs {
+	uintptr_t args[15];
+	uint64_t floats[8];
+	uintptr_t result;
+} callbackArgs;
+
+extern void *callbackPointer(int index, int isFloat);
+*/
+import "C"
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// maxCallbacks is the number of trampolines in trampoline_linux.go.
+const maxCallbacks = 1000
+
+var callbacks struct {
+	mu    sync.Mutex
+	funcs []reflect.Value
+	// index maps the function values to their trampolines.
+	index map[callbackKey]int
+}
+
+// callbackKey identifies a function value: closures of the same function with
+// different captured variables are different values.
+type callbackKey struct {
+	ty reflect.Type
+	fn unsafe.Pointer
+}
+
+// NewCallback converts the Go function fn into a C function pointer which can be
+// passed to C code, e.g. as an argument of a function of GetFn. The arguments and
+// the result of fn may be integers, floats, bools and pointers, and string
+// arguments receive a null-terminated char*. Only a limited number of callbacks
+// can be created and the memory they use is never released, but the same
+// function value always returns the same pointer. Closures which capture
+// variables are new function values each time they are evaluated.
+func NewCallback(fn interface{}) uintptr {
+	v := reflect.ValueOf(fn)
+	ty := v.Type()
+	if ty.Kind() != reflect.Func {
+		panic("purego: callback must be a function")
+	}
+	if ty.NumOut() > 1 {
+		panic("purego: callback can only return zero or one values")
+	}
+	for i := 0; i < ty.NumIn(); i++ {
+		switch ty.In(i).Kind() {
+		case reflect.String, reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer,
+			reflect.Bool, reflect.Float32, reflect.Float64:
+		default:
+			panic("purego: unsupported callback argument kind " + ty.In(i).Kind().String())
+		}
+	}
+	isFloat := 0
+	if ty.NumOut() == 1 {
+		switch ty.Out(0).Kind() {
+		case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer,
+			reflect.Bool:
+		case reflect.Float32, reflect.Float64:
+			isFloat = 1
+		default:
+			panic("purego: unsupported callback return kind " + ty.Out(0).Kind().String())
+		}
+	}
+
+	// The data word of the interface is the pointer to the function value, which
+	// stays valid as long as callbacks.funcs keeps it alive.
+	key := callbackKey{ty, (*[2]unsafe.Pointer)(unsafe.Pointer(&fn))[1]}
+
+	callbacks.mu.Lock()
+	defer callbacks.mu.Unlock()
+	index, ok := callbacks.index[key]
+	if !ok {
+		if len(callbacks.funcs) >= maxCallbacks {
+			panic("purego: too many callbacks")
+		}
+		if callbacks.index == nil {
+			callbacks.index = make(map[callbackKey]int)
+		}
+		index = len(callbacks.funcs)
+		callbacks.funcs = append(callbacks.funcs, v)
+		callbacks.index[key] = index
+	}
+	return uintptr(C.callbackPointer(C.int(index), C.int(isFloat)))
+}
+
+// dlopenCallback is called by the trampoline of the callback index with its
+// registers and stack slots.
+//
+//export dlopenCallback
+func dlopenCallback(index C.int, args *C.callbackArgs) {
+	callbacks.mu.Lock()
+	fn := callbacks.funcs[index]
+	callbacks.mu.Unlock()
+
+	ty := fn.Type()
+	var numInts, numFloats, numStack int
+	stack := func() uintptr {
+		if numOfIntegerRegisters()+numStack >= len(args.args) {
+			panic("purego: too many callback arguments")
+		}
+		x := uintptr(args.args[numOfIntegerRegisters()+numStack])
+		numStack++
+		return x
+	}
+	nextInt := func() uintptr {
+		if numInts >= numOfIntegerRegisters() {
+			return stack()
+		}
+		x := uintptr(args.args[numInts])
+		numInts++
+		return x
+	}
+	nextFloat := func() uint64 {
+		if numFloats >= len(args.floats) {
+			return uint64(stack())
+		}
+		x := uint64(args.floats[numFloats])
+		numFloats++
+		return x
+	}
+
+	in := make([]reflect.Value, ty.NumIn())
+	for i := range in {
+		v := reflect.New(ty.In(i)).Elem()
+		switch v.Kind() {
+		case reflect.String:
+			v.SetString(GoString(nextInt()))
+		case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(uint64(nextInt()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(int64(nextInt()))
+		case reflect.Bool:
+			v.SetBool(byte(nextInt()) != 0)
+		case reflect.Ptr, reflect.UnsafePointer:
+			x := nextInt()
+			// We take the address and then dereference it to trick go vet from creating a possible miss-use of unsafe.Pointer
+			v = reflect.NewAt(v.Type(), unsafe.Pointer(&x)).Elem()
+		case reflect.Float32:
+			v.SetFloat(float64(math.Float32frombits(uint32(nextFloat()))))
+		case reflect.Float64:
+			v.SetFloat(math.Float64frombits(nextFloat()))
+		}
+		in[i] = v
+	}
+
+	out := fn.Call(in)
+	if len(out) == 0 {
+		return
+	}
+	var result uintptr
+	switch v := out[0]; v.Kind() {
+	case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		result = uintptr(v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		result = uintptr(v.Int())
+	case reflect.Bool:
+		if v.Bool() {
+			result = 1
+		}
+	case reflect.Ptr, reflect.UnsafePointer:
+		result = v.Pointer()
+	case reflect.Float32:
+		result = uintptr(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		result = uintptr(math.Float64bits(v.Float()))
+	}
+	args.result = C.uintptr_t(result)
+}
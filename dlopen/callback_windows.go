@@ -0,0 +1,9 @@
+package dlopen
+
+import "syscall"
+
+// NewCallback converts the Go function fn into a C function pointer which can be
+// passed to C code. See syscall.NewCallback for the restrictions on fn.
+func NewCallback(fn interface{}) uintptr {
+	return syscall.NewCallback(fn)
+}
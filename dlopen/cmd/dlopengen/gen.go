@@ -0,0 +1,757 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/format"
+	goparser "go/parser"
+	gotoken "go/token"
+	"strconv"
+	"strings"
+)
+
+// config configures the generated file.
+type config struct {
+	pkg        string   // package name
+	importPath string   // import path of the dlopen package
+	typeName   string   // name of the struct of the functions
+	prefixes   []string // prefixes removed from the C names
+	libs       []string // library names opened by Open<typeName>
+	source     string   // header file name for the file comment
+	goos       string   // target operating system
+}
+
+type generator struct {
+	cfg     config
+	h       *header
+	buf     bytes.Buffer
+	used    map[string]bool           // package-level Go names
+	consts  map[string]string         // C constant to Go name
+	values  map[string]constant.Value // Go constant to value
+	types   map[string]string         // C typedef to Go name
+	tags    map[string]string         // C struct or enum tag to Go name
+	structs map[string]*cType         // C struct tag to definition
+	defs    map[string]*cType         // C typedef to type
+	unsafe  bool
+}
+
+// generate returns the Go source of the bindings of h.
+func generate(h *header, cfg config) ([]byte, error) {
+	g := &generator{
+		cfg:     cfg,
+		h:       h,
+		used:    make(map[string]bool),
+		consts:  make(map[string]string),
+		values:  make(map[string]constant.Value),
+		types:   make(map[string]string),
+		tags:    make(map[string]string),
+		structs: make(map[string]*cType),
+		defs:    make(map[string]*cType),
+	}
+	g.used[cfg.typeName] = true
+	g.used["New"+cfg.typeName] = true
+	if len(cfg.libs) > 0 {
+		g.used["Open"+cfg.typeName] = true
+	}
+
+	// Name all types first, they can be used before their definition
+	for _, d := range h.decls {
+		switch d.kind {
+		case declStruct:
+			if d.typ.defined {
+				g.structs[d.name] = d.typ
+			}
+			if _, ok := g.tags[d.name]; !ok {
+				g.tags[d.name] = g.unique(goName(d.name, cfg.prefixes, true))
+			}
+		case declEnum:
+			if _, ok := g.tags[d.name]; !ok {
+				g.tags[d.name] = g.unique(goName(d.name, cfg.prefixes, true))
+			}
+		case declTypedef:
+			g.defs[d.name] = d.typ
+			if (d.typ.kind == typeStruct || d.typ.kind == typeEnum) && goName(d.name, cfg.prefixes, true) == g.tags[d.typ.name] {
+				// typedef struct foo {...} foo_t
+				g.types[d.name] = g.tags[d.typ.name]
+				continue
+			}
+			g.types[d.name] = g.unique(goName(d.name, cfg.prefixes, true))
+		}
+	}
+
+	var body bytes.Buffer
+	g.defines(&body)
+	g.declarations(&body)
+	g.functions(&body)
+
+	fmt.Fprintf(&g.buf, "// Code generated by dlopengen from %s. DO NOT EDIT.\n\n", cfg.source)
+	if h.long {
+		// long is 32-bit on windows and 64-bit on the other 64-bit platforms
+		constraint := "!windows"
+		if cfg.goos == "windows" {
+			constraint = "windows"
+		}
+		fmt.Fprintf(&g.buf, "//go:build %s\n\n", constraint)
+	}
+	fmt.Fprintf(&g.buf, "package %s\n\nimport (\n", cfg.pkg)
+	if g.unsafe {
+		fmt.Fprintf(&g.buf, "\t\"unsafe\"\n\n")
+	}
+	fmt.Fprintf(&g.buf, "\t%q\n)\n", cfg.importPath)
+	g.buf.Write(body.Bytes())
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+// unique returns name, or name with a suffix if it is used.
+func (g *generator) unique(name string) string {
+	for i := 2; g.used[name]; i++ {
+		name = strings.TrimRight(name, "0123456789") + strconv.Itoa(i)
+	}
+	g.used[name] = true
+	return name
+}
+
+// defines generates the constants of the object-like macros which are constant
+// expressions.
+func (g *generator) defines(w *bytes.Buffer) {
+	var lines []string
+	for _, d := range g.h.defines {
+		if _, ok := g.consts[d.name]; ok {
+			continue
+		}
+		expr, ok := g.expr(d.value)
+		if !ok {
+			continue
+		}
+		name := g.unique(goName(d.name, g.cfg.prefixes, false))
+		if !g.constant(name, expr) {
+			delete(g.used, name)
+			continue
+		}
+		g.consts[d.name] = name
+		lines = append(lines, fmt.Sprintf("\t%s = %s // %s\n", name, expr, d.name))
+	}
+	if len(lines) > 0 {
+		fmt.Fprintf(w, "\n// Constants of the macros of %s.\nconst (\n%s)\n", g.cfg.source, strings.Join(lines, ""))
+	}
+}
+
+// constant records the value of the Go constant name if expr is a valid
+// constant expression.
+func (g *generator) constant(name, expr string) bool {
+	e, err := goparser.ParseExpr(expr)
+	if err != nil {
+		return false
+	}
+	v := g.eval(e)
+	if v.Kind() == constant.Unknown {
+		return false
+	}
+	g.values[name] = v
+	return true
+}
+
+// eval evaluates the constant expression e.
+func (g *generator) eval(e ast.Expr) constant.Value {
+	unknown := constant.MakeUnknown()
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.Ident:
+		if v, ok := g.values[e.Name]; ok {
+			return v
+		}
+	case *ast.ParenExpr:
+		return g.eval(e.X)
+	case *ast.UnaryExpr:
+		x := g.eval(e.X)
+		if x.Kind() == constant.Unknown || e.Op == gotoken.XOR && x.Kind() != constant.Int {
+			return unknown
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x, y := g.eval(e.X), g.eval(e.Y)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			return unknown
+		}
+		switch e.Op {
+		case gotoken.SHL, gotoken.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok || s > 64 || x.Kind() != constant.Int {
+				return unknown
+			}
+			return constant.Shift(x, e.Op, uint(s))
+		case gotoken.EQL, gotoken.NEQ, gotoken.LSS, gotoken.LEQ, gotoken.GTR, gotoken.GEQ, gotoken.LAND, gotoken.LOR:
+			return unknown
+		case gotoken.QUO:
+			if constant.Sign(y) == 0 {
+				return unknown
+			}
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				return constant.BinaryOp(x, gotoken.QUO_ASSIGN, y)
+			}
+		case gotoken.REM, gotoken.AND, gotoken.OR, gotoken.XOR, gotoken.AND_NOT:
+			if x.Kind() != constant.Int || y.Kind() != constant.Int {
+				return unknown
+			}
+		}
+		if (x.Kind() == constant.String) != (y.Kind() == constant.String) {
+			return unknown
+		}
+		return constant.BinaryOp(x, e.Op, y)
+	}
+	return unknown
+}
+
+// expr translates the tokens of a C constant expression to Go.
+func (g *generator) expr(toks []token) (string, bool) {
+	var b strings.Builder
+	for i, t := range toks {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		switch t.kind {
+		case tokNumber:
+			n, ok := goNumber(t.text)
+			if !ok {
+				return "", false
+			}
+			b.WriteString(n)
+		case tokString:
+			if i > 0 && toks[i-1].kind == tokString {
+				// Concatenated string literals
+				b.WriteString("+ ")
+			}
+			b.WriteString(t.text)
+		case tokChar:
+			b.WriteString(t.text)
+		case tokIdent:
+			name, ok := g.consts[t.text]
+			if !ok {
+				return "", false
+			}
+			b.WriteString(name)
+		case tokPunct:
+			switch t.text {
+			case "~":
+				b.WriteString("^")
+			case "+", "-", "*", "/", "%", "<<", ">>", "&", "|", "^", "(", ")":
+				b.WriteString(t.text)
+			default:
+				return "", false
+			}
+		}
+	}
+	return b.String(), b.Len() > 0
+}
+
+// goNumber translates a C number literal to Go.
+func goNumber(s string) (string, bool) {
+	hex := isHex(s)
+	if strings.ContainsAny(s, ".") || !hex && strings.ContainsAny(s, "eE") || hex && strings.ContainsAny(s, "pP") {
+		s = strings.TrimRight(s, "fFlL")
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return "", false
+		}
+		return s, true
+	}
+	s = strings.TrimRight(s, "uUlL")
+	if len(s) > 1 && s[0] == '0' && !hex && s[1] != 'b' && s[1] != 'B' {
+		s = "0o" + s[1:]
+	}
+	if _, err := strconv.ParseUint(s, 0, 64); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// declarations generates the enums, structs and typedefs. Structs which cannot
+// be translated are opaque, as they are normally only used by pointers.
+func (g *generator) declarations(w *bytes.Buffer) {
+	done := make(map[string]bool)
+	for _, d := range g.h.decls {
+		switch d.kind {
+		case declEnum:
+			name := g.tags[d.name]
+			if done[name] || !d.typ.defined {
+				continue
+			}
+			done[name] = true
+			fmt.Fprintf(w, "\n// %s is enum %s.\ntype %s int32\n\nconst (\n", name, d.name, name)
+			prev := ""
+			for _, item := range d.typ.consts {
+				value := "0"
+				if prev != "" {
+					value = prev + " + 1"
+				}
+				if len(item.value) > 0 {
+					expr, ok := g.expr(item.value)
+					if !ok {
+						warnf("skipping enum %s: unsupported value of %s", d.name, item.name)
+						break
+					}
+					value = expr
+				}
+				c := g.unique(goName(item.name, g.cfg.prefixes, false))
+				if !g.constant(c, value) {
+					warnf("skipping enumerator %s: unsupported value", item.name)
+					delete(g.used, c)
+					continue
+				}
+				g.consts[item.name] = c
+				fmt.Fprintf(w, "\t%s %s = %s\n", c, name, value)
+				prev = c
+			}
+			fmt.Fprintf(w, ")\n")
+		case declStruct:
+			name := g.tags[d.name]
+			if done[name] {
+				continue
+			}
+			if !d.typ.defined {
+				if _, ok := g.structs[d.name]; ok {
+					continue
+				}
+				// Opaque structs are only used by pointers
+				done[name] = true
+				fmt.Fprintf(w, "\n// %s is the opaque struct %s.\ntype %s struct{}\n", name, d.name, name)
+				continue
+			}
+			done[name] = true
+			s, err := g.structType(d.typ)
+			if err != nil {
+				warnf("struct %s is opaque: %v", d.name, err)
+				fmt.Fprintf(w, "\n// %s is the opaque struct %s.\ntype %s struct{}\n", name, d.name, name)
+				continue
+			}
+			fmt.Fprintf(w, "\n// %s is struct %s.\ntype %s %s\n", name, d.name, name, s)
+		case declTypedef:
+			name := g.types[d.name]
+			if done[name] {
+				continue
+			}
+			done[name] = true
+			t := d.typ
+			if t.kind == typeStruct || t.kind == typeEnum {
+				if _, ok := g.tags[t.name]; !ok {
+					// Never defined
+					g.tags[t.name] = name
+					fmt.Fprintf(w, "\n// %s is the opaque struct %s.\ntype %s struct{}\n", name, t.name, name)
+					continue
+				}
+				if g.tags[t.name] != name {
+					fmt.Fprintf(w, "\n// %s is %s.\ntype %s = %s\n", name, d.name, name, g.tags[t.name])
+				}
+				continue
+			}
+			goType, err := g.goType(t, ctxField)
+			if ptr := g.resolve(t); ptr.kind == typePtr && g.resolve(ptr.elem).kind == typeFunc {
+				goType, err = g.funcType(g.resolve(ptr.elem), false)
+			}
+			if err != nil {
+				warnf("skipping typedef %s: %v", d.name, err)
+				goType = "unsafe.Pointer"
+				g.unsafe = true
+			}
+			fmt.Fprintf(w, "\n// %s is %s.\ntype %s %s\n", name, d.name, name, goType)
+		}
+	}
+}
+
+// structType returns the Go struct type of the C struct or union t.
+func (g *generator) structType(t *cType) (string, error) {
+	if t.bitField {
+		return "", fmt.Errorf("bit-fields are not supported")
+	}
+	if t.union {
+		size, align, err := g.sizeAlign(t)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("struct {\n_ [0]uint%d\nRaw [%d]byte\n}", 8*align, size), nil
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	used := make(map[string]bool)
+	for i, f := range t.fields {
+		name := "_"
+		if f.name != "" {
+			name = goName(f.name, nil, false)
+			for used[name] {
+				name += "_"
+			}
+			used[name] = true
+		} else if f.typ.kind == typeStruct && f.typ.name == "" {
+			name = fmt.Sprintf("Anon%d", i)
+		}
+		ft, err := g.goType(f.typ, ctxField)
+		if err != nil {
+			return "", fmt.Errorf("field %s: %v", f.name, err)
+		}
+		fmt.Fprintf(&b, "%s %s\n", name, ft)
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// sizeAlign returns the size and alignment of t on 64-bit platforms, where the
+// size of long is already resolved to int32 or int64.
+func (g *generator) sizeAlign(t *cType) (size, align int64, err error) {
+	t = g.resolve(t)
+	switch t.kind {
+	case typePrim:
+		switch t.name {
+		case "int8", "uint8", "byte", "bool":
+			return 1, 1, nil
+		case "int16", "uint16":
+			return 2, 2, nil
+		case "int32", "uint32", "float32":
+			return 4, 4, nil
+		}
+		return 8, 8, nil
+	case typeEnum:
+		return 4, 4, nil
+	case typePtr:
+		return 8, 8, nil
+	case typeArray:
+		n, err := g.arrayLen(t)
+		if err != nil {
+			return 0, 0, err
+		}
+		size, align, err := g.sizeAlign(t.elem)
+		return n * size, align, err
+	case typeStruct:
+		if def, ok := g.structs[t.name]; ok && !t.defined {
+			t = def
+		}
+		if !t.defined || t.bitField {
+			return 0, 0, fmt.Errorf("unknown size of struct %s", t.name)
+		}
+		align = 1
+		for _, f := range t.fields {
+			fs, fa, err := g.sizeAlign(f.typ)
+			if err != nil {
+				return 0, 0, err
+			}
+			align = max(align, fa)
+			if t.union {
+				size = max(size, fs)
+			} else {
+				size = (size+fa-1)/fa*fa + fs
+			}
+		}
+		return (size + align - 1) / align * align, align, nil
+	}
+	return 0, 0, fmt.Errorf("unknown size of %s", t.name)
+}
+
+// arrayLen evaluates the length of the array t.
+func (g *generator) arrayLen(t *cType) (int64, error) {
+	expr, ok := g.expr(t.len)
+	if ok {
+		if e, err := goparser.ParseExpr(expr); err == nil {
+			if n, ok := constant.Int64Val(constant.ToInt(g.eval(e))); ok {
+				return n, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unsupported array length")
+}
+
+// resolve returns the type of the typedef t, or t.
+func (g *generator) resolve(t *cType) *cType {
+	for i := 0; t.kind == typeNamed && i < 100; i++ {
+		def, ok := g.defs[t.name]
+		if !ok {
+			break
+		}
+		t = def
+	}
+	return t
+}
+
+type typeContext int
+
+const (
+	ctxField typeContext = iota
+	ctxParam
+	ctxResult
+	ctxCallback
+)
+
+// goType returns the Go type of the C type t used in ctx.
+func (g *generator) goType(t *cType, ctx typeContext) (string, error) {
+	switch t.kind {
+	case typeVoid:
+		if ctx == ctxResult {
+			return "", nil
+		}
+		return "", fmt.Errorf("void value")
+	case typePrim:
+		return t.name, nil
+	case typeNamed:
+		if name, ok := g.types[t.name]; ok {
+			def := g.resolve(t)
+			if def.kind == typeFunc || def.kind == typePtr && g.resolve(def.elem).kind == typeFunc {
+				// Like inline function pointers, the Go func type is only
+				// converted to a callback when it is passed to C
+				if ctx == ctxField || ctx == ctxCallback {
+					return "uintptr", nil
+				}
+			}
+			if ctx == ctxCallback && !g.scalar(def) {
+				return "", fmt.Errorf("unsupported callback type %s", t.name)
+			}
+			return name, nil
+		}
+		return "", fmt.Errorf("unknown type %s", t.name)
+	case typeEnum:
+		if name, ok := g.tags[t.name]; ok {
+			return name, nil
+		}
+		return "int32", nil
+	case typeStruct:
+		if ctx == ctxCallback {
+			return "", fmt.Errorf("unsupported callback type struct %s", t.name)
+		}
+		if t.name == "" {
+			return g.structType(t)
+		}
+		if _, ok := g.structs[t.name]; !ok {
+			return "", fmt.Errorf("incomplete struct %s", t.name)
+		}
+		return g.tags[t.name], nil
+	case typeArray:
+		elem, err := g.goType(t.elem, ctxField)
+		if err != nil {
+			return "", err
+		}
+		if ctx != ctxField {
+			return "*" + elem, nil
+		}
+		n, err := g.arrayLen(t)
+		if err != nil {
+			return "", err
+		}
+		if expr, ok := g.expr(t.len); ok && len(t.len) == 1 && t.len[0].kind == tokIdent {
+			return "[" + expr + "]" + elem, nil
+		}
+		return "[" + strconv.FormatInt(n, 10) + "]" + elem, nil
+	case typeFunc:
+		if ctx == ctxField || ctx == ctxCallback {
+			return "uintptr", nil
+		}
+		return g.funcType(t, true)
+	case typePtr:
+		elem := t.elem
+		resolved := g.resolve(elem)
+		switch {
+		case resolved.kind == typeVoid:
+			g.unsafe = true
+			return "unsafe.Pointer", nil
+		case resolved.kind == typeFunc:
+			if ctx == ctxField || ctx == ctxCallback {
+				return "uintptr", nil
+			}
+			return g.funcType(resolved, true)
+		case resolved.kind == typePrim && resolved.name == "byte" && elem.isConst && ctx != ctxField:
+			return "string", nil
+		case resolved.kind == typeStruct:
+			name := g.tags[resolved.name]
+			if elem.kind == typeNamed {
+				name = g.types[elem.name]
+			}
+			if name == "" {
+				// Never declared
+				g.unsafe = true
+				return "unsafe.Pointer", nil
+			}
+			return "*" + name, nil
+		case elem.kind == typeNamed && g.types[elem.name] == "":
+			g.unsafe = true
+			return "unsafe.Pointer", nil
+		}
+		s, err := g.goType(elem, ctxField)
+		if err != nil {
+			return "", err
+		}
+		return "*" + s, nil
+	}
+	return "", fmt.Errorf("unsupported type")
+}
+
+// scalar returns whether t can be an argument or result of a callback.
+func (g *generator) scalar(t *cType) bool {
+	switch g.resolve(t).kind {
+	case typePrim, typeEnum, typePtr:
+		return true
+	}
+	return false
+}
+
+// funcType returns the Go func type of the C function t. Function pointers
+// passed to C are callbacks, which only support scalars, otherwise they are
+// passed as uintptr.
+func (g *generator) funcType(t *cType, param bool) (string, error) {
+	var params []string
+	for _, p := range t.params {
+		s, err := g.goType(p.typ, ctxCallback)
+		if err != nil {
+			if param {
+				return "uintptr", nil
+			}
+			return "", err
+		}
+		params = append(params, s)
+	}
+	if t.variadic {
+		if param {
+			return "uintptr", nil
+		}
+		return "", fmt.Errorf("variadic callbacks are not supported")
+	}
+	result, err := g.goType(t.elem, ctxResult)
+	if err == nil && result != "" && !g.scalar(t.elem) {
+		err = fmt.Errorf("unsupported callback result")
+	}
+	if err != nil {
+		if param {
+			return "uintptr", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace("func(" + strings.Join(params, ", ") + ") " + result), nil
+}
+
+// functions generates the struct of the functions and its constructors.
+func (g *generator) functions(w *bytes.Buffer) {
+	type function struct {
+		cName, field, local string
+		params, args        []string
+		fnType, result      string
+	}
+	var funcs []function
+	fields := map[string]bool{"Handle": true}
+	seen := make(map[string]bool)
+	for _, d := range g.h.decls {
+		if d.kind != declFunc || seen[d.name] {
+			continue
+		}
+		seen[d.name] = true
+		f := function{cName: d.name}
+		var types []string
+		var err error
+		for i, p := range d.typ.params {
+			var s string
+			if s, err = g.goType(p.typ, ctxParam); err != nil {
+				break
+			}
+			name := lowerName(p.name)
+			if p.name == "" || strings.HasPrefix(name, "sym") {
+				name = "arg" + strconv.Itoa(i)
+			}
+			f.params = append(f.params, name+" "+s)
+			f.args = append(f.args, name)
+			types = append(types, s)
+		}
+		if err == nil && d.typ.variadic {
+			f.params = append(f.params, "args ...interface{}")
+			f.args = append(f.args, "args...")
+			types = append(types, "...interface{}")
+		}
+		if err == nil {
+			f.result, err = g.goType(d.typ.elem, ctxResult)
+		}
+		if err != nil {
+			warnf("skipping function %s: %v", d.name, err)
+			continue
+		}
+		f.fnType = strings.TrimSpace("func(" + strings.Join(types, ", ") + ") " + f.result)
+		f.field = goName(d.name, g.cfg.prefixes, false)
+		for fields[f.field] {
+			f.field += "_"
+		}
+		fields[f.field] = true
+		f.local = "sym" + f.field
+		funcs = append(funcs, f)
+	}
+
+	name := g.cfg.typeName
+	fmt.Fprintf(w, "\n// %s holds the functions of %s.\ntype %s struct {\n", name, g.cfg.source, name)
+	fmt.Fprintf(w, "\tHandle dlopen.Handle\n\n")
+	for _, f := range funcs {
+		fmt.Fprintf(w, "\t%s %s // %s\n", f.field, f.fnType, f.cName)
+	}
+	fmt.Fprintf(w, "}\n")
+
+	fmt.Fprintf(w, "\n// New%s returns the functions of the library of handle, which are resolved\n// when they are first called.\n", name)
+	fmt.Fprintf(w, "func New%s(handle dlopen.Handle) *%s {\n\tl := &%s{Handle: handle}\n", name, name, name)
+	for _, f := range funcs {
+		fmt.Fprintf(w, "\t%s := dlopen.LazySymbolFn[%s](handle, %q)\n", f.local, f.fnType, f.cName)
+		call := f.local + "()(" + strings.Join(f.args, ", ") + ")"
+		if f.result != "" {
+			call = "return " + call
+		}
+		fmt.Fprintf(w, "\tl.%s = func(%s) %s {\n\t\t%s\n\t}\n", f.field, strings.Join(f.params, ", "), f.result, call)
+	}
+	fmt.Fprintf(w, "\treturn l\n}\n")
+
+	if len(g.cfg.libs) > 0 {
+		var libs []string
+		for _, l := range g.cfg.libs {
+			libs = append(libs, strconv.Quote(l))
+		}
+		fmt.Fprintf(w, "\n// Open%s returns the functions of the first library which can be opened.\n", name)
+		fmt.Fprintf(w, "func Open%s() (*%s, error) {\n\thandle, err := dlopen.GetHandle(%s)\n", name, name, strings.Join(libs, ", "))
+		fmt.Fprintf(w, "\tif err != nil {\n\t\treturn nil, err\n\t}\n\treturn New%s(handle), nil\n}\n", name)
+	}
+}
+
+// goName returns the exported Go name of the C name s without one of the
+// prefixes and, for types, the suffix _t, in camel case.
+func goName(s string, prefixes []string, isType bool) string {
+	name := s
+	for _, p := range prefixes {
+		if strings.HasPrefix(name, p) && len(name) > len(p) {
+			name = name[len(p):]
+			break
+		}
+	}
+	if isType && strings.HasSuffix(name, "_t") && len(name) > 2 {
+		name = name[:len(name)-2]
+	}
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if isMacroName(part) && len(part) > 1 {
+			part = part[:1] + strings.ToLower(part[1:])
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name = b.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "C" + name
+	}
+	return name
+}
+
+// lowerName returns the C name s as an unexported Go name which is not a
+// keyword.
+func lowerName(s string) string {
+	if s == "" {
+		return s
+	}
+	s = strings.ToLower(s[:1]) + s[1:]
+	if gotoken.IsKeyword(s) || s == "l" {
+		s += "_"
+	}
+	return s
+}
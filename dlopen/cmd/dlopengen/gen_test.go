@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		header string
+		ignore []string
+		goos   string
+	}{
+		{"zlib.golden", "zlib/zlib.h", nil, "linux"},
+		{"zlib_windows.golden", "zlib/zlib.h", nil, "windows"},
+		// The untrimmed headers of zlib 1.2.13
+		{"zlib-1.2.13.golden", "zlib-1.2.13/zlib.h", nil, "linux"},
+		{"expat.golden", "expat/expat.h", []string{"XMLPARSEAPI"}, "linux"},
+		{"bzlib.golden", "bzlib/bzlib.h", []string{"BZ_API"}, "linux"},
+	}
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			path := filepath.Join("testdata", test.header)
+			h, err := parseHeader(path, test.ignore, test.goos)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, err := generate(h, config{
+				pkg:        "lib",
+				importPath: "dlopen",
+				typeName:   "Lib",
+				source:     filepath.Base(path),
+				goos:       test.goos,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", test.golden)
+			if *update {
+				if err := os.WriteFile(golden, out, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out, want) {
+				t.Errorf("output differs from %s, run go test -update:\n%s", golden, out)
+			}
+		})
+	}
+}
+
+func TestGenerateUnknownFieldType(t *testing.T) {
+	h, err := parseHeader(filepath.Join("testdata", "unknown.h"), nil, "linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := generate(h, config{pkg: "lib", importPath: "dlopen", typeName: "Lib"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The struct is opaque, its functions take it by pointer
+	for _, want := range []string{"type File struct{}", "FileRead func(*File, unsafe.Pointer, int32) int32"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestUnknownFunctionLikeMacro(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "in.h")
+	// Without zconf.h, OF is unknown
+	src := "typedef unsigned (*in_func) OF((void *, unsigned char **));\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := parseHeader(path, nil, "linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range h.decls {
+		if d.name == "in_func" {
+			t.Fatalf("in_func declared as %v", d.typ)
+		}
+	}
+
+	h, err = parseHeader(path, []string{"OF"}, "linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.decls) != 1 || h.decls[0].typ.kind != typePtr || h.decls[0].typ.elem.kind != typeFunc {
+		t.Fatalf("in_func is not a function pointer")
+	}
+}
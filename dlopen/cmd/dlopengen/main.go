@@ -0,0 +1,99 @@
+// Command dlopengen generates Go bindings for the functions, types and
+// constants of a C header, which call a shared library through dlopen.
+//
+// Usage:
+//
+//	dlopengen -package foo -lib libfoo.so.1,libfoo.so -prefix foo_,FOO_ -o foo.go foo.h
+//
+// The generated struct has a field for each function of the header, which is
+// resolved in the library when it is first called. Structs, enums, typedefs
+// and object-like macros with constant values are translated to Go types and
+// constants. Declarations which cannot be translated are reported and skipped,
+// structs which cannot be translated are opaque.
+//
+// The headers included with quotes are read from the directory of the header,
+// and function-like macros defined in them are expanded. Macros in -ignore are
+// skipped, or replaced by their argument where they are used like
+// function-like macros, e.g. XMLPARSEAPI(void) or BZ_API(func).
+//
+// C long is 32-bit on windows and 64-bit on the other 64-bit platforms. If the
+// header uses it, the generated file is constrained to either windows or
+// !windows depending on -goos.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+func main() {
+	pkg := flag.String("package", "main", "package name of the generated file")
+	importPath := flag.String("import", "dlopen", "import path of the dlopen package")
+	typeName := flag.String("type", "Lib", "name of the struct of the functions")
+	prefix := flag.String("prefix", "", "comma-separated prefixes removed from the C names")
+	libs := flag.String("lib", "", "comma-separated library names to generate an Open function for")
+	ignore := flag.String("ignore", "", "comma-separated macros to ignore in declarations, e.g. export macros")
+	defaultGOOS := os.Getenv("GOOS") // set by go generate
+	if defaultGOOS == "" {
+		defaultGOOS = runtime.GOOS
+	}
+	goos := flag.String("goos", defaultGOOS, "target operating system")
+	output := flag.String("o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: dlopengen [flags] header.h\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	path := flag.Arg(0)
+	h, err := parseHeader(path, split(*ignore), *goos)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlopengen: %v\n", err)
+		os.Exit(1)
+	}
+	out, err := generate(h, config{
+		pkg:        *pkg,
+		importPath: *importPath,
+		typeName:   *typeName,
+		prefixes:   split(*prefix),
+		libs:       split(*libs),
+		source:     filepath.Base(path),
+		goos:       *goos,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dlopengen: %v\n", err)
+		os.Exit(1)
+	}
+	if *output == "" {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "dlopengen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// warnf reports a declaration which is skipped.
+func warnf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "dlopengen: "+format+"\n", args...)
+}
+
+// split splits the comma-separated list s.
+func split(s string) []string {
+	var list []string
+	for _, e := range strings.Split(s, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			list = append(list, e)
+		}
+	}
+	return list
+}
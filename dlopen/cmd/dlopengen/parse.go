@@ -0,0 +1,1026 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokChar
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+type typeKind int
+
+const (
+	typeVoid  typeKind = iota
+	typePrim           // name is the Go type
+	typeNamed          // typedef name
+	typePtr
+	typeArray
+	typeFunc
+	typeStruct
+	typeEnum
+)
+
+// cType is a parsed C type.
+type cType struct {
+	kind     typeKind
+	name     string // Go type of typePrim, C name of typeNamed, tag of typeStruct and typeEnum
+	isConst  bool
+	elem     *cType   // typePtr, typeArray and the result of typeFunc
+	len      []token  // typeArray
+	params   []*field // typeFunc
+	variadic bool     // typeFunc
+	fields   []*field // typeStruct with a body
+	union    bool
+	bitField bool       // typeStruct with bit-fields
+	consts   []enumItem // typeEnum with a body
+	defined  bool       // typeStruct or typeEnum with a body
+}
+
+type field struct {
+	name string
+	typ  *cType
+}
+
+type enumItem struct {
+	name  string
+	value []token
+}
+
+type declKind int
+
+const (
+	declTypedef declKind = iota
+	declStruct
+	declEnum
+	declFunc
+)
+
+// decl is a top-level declaration of a header.
+type decl struct {
+	kind declKind
+	name string
+	typ  *cType
+}
+
+type define struct {
+	name  string
+	value []token
+}
+
+// macro is a function-like macro.
+type macro struct {
+	params []string
+	body   []token
+	line   int // the macro is expanded after this line
+}
+
+// header is a parsed C header.
+type header struct {
+	defines []define
+	decls   []*decl
+	long    bool // long is used, its size depends on the target
+}
+
+// builtinTypes maps common typedefs of the C library to Go types.
+var builtinTypes = map[string]string{
+	"int8_t": "int8", "int16_t": "int16", "int32_t": "int32", "int64_t": "int64",
+	"uint8_t": "uint8", "uint16_t": "uint16", "uint32_t": "uint32", "uint64_t": "uint64",
+	"size_t": "uintptr", "ssize_t": "int", "ptrdiff_t": "int", "intptr_t": "int", "uintptr_t": "uintptr",
+	"off_t": "int64", "time_t": "int64", "wchar_t": "int32", "bool": "bool", "_Bool": "bool",
+}
+
+// qualifiers are skipped in declarations.
+var qualifiers = map[string]bool{
+	"const": true, "volatile": true, "restrict": true, "__restrict": true, "__restrict__": true,
+	"register": true, "__const": true, "extern": true, "static": true, "inline": true,
+	"__inline": true, "__inline__": true, "__extension__": true, "_Noreturn": true,
+	"__cdecl": true, "__stdcall": true,
+}
+
+type parseError struct {
+	err error
+}
+
+type parser struct {
+	lines    []line // the line of a token is an index into lines, starting with 1
+	toks     []token
+	pos      int
+	typedefs map[string]*cType
+	ignore   map[string]bool
+	macros   map[string]*macro
+	long     string // Go type of long
+	h        *header
+}
+
+// parseHeader parses the C header at path for the operating system goos. The
+// identifiers in ignore, like export macros, are skipped, or replaced by their
+// argument if they are used like function-like macros, like XMLPARSEAPI(void).
+func parseHeader(path string, ignore []string, goos string) (h *header, err error) {
+	p := &parser{
+		typedefs: make(map[string]*cType),
+		ignore:   make(map[string]bool),
+		macros:   make(map[string]*macro),
+		long:     "int64",
+		h:        &header{},
+	}
+	if goos == "windows" {
+		// LLP64
+		p.long = "int32"
+	}
+	for _, name := range ignore {
+		p.ignore[name] = true
+		p.macros[name] = &macro{params: []string{"x"}, body: []token{{kind: tokIdent, text: "x"}}}
+	}
+	if err := p.read(path, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	var code strings.Builder
+	for i, l := range p.lines {
+		if strings.HasPrefix(l.text, "#") {
+			p.directive(l.text, i+1)
+			code.WriteString("\n")
+			continue
+		}
+		code.WriteString(l.text)
+		code.WriteString("\n")
+	}
+	p.toks, err = tokenize(code.String())
+	if err != nil {
+		return nil, err
+	}
+	p.toks = p.expand(p.toks, nil)
+
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			err = pe.err
+		}
+	}()
+	p.file()
+	return p.h, nil
+}
+
+type line struct {
+	file string
+	text string
+	line int
+}
+
+// read appends the lines of the header at path to p.lines. The headers it
+// includes with quotes are searched in its directory and read in place of
+// their #include directive, each once.
+func (p *parser) read(path string, visited map[string]bool) error {
+	visited[path] = true
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines, err := preprocess(string(src))
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, l := range lines {
+		l.file = path
+		p.lines = append(p.lines, l)
+		name, ok := includeName(l.text)
+		if !ok {
+			continue
+		}
+		inc := filepath.Join(filepath.Dir(path), name)
+		if visited[inc] {
+			continue
+		}
+		if err := p.read(inc, visited); errors.Is(err, fs.ErrNotExist) {
+			warnf("%s:%d: skipping #include: %v", path, l.line, err)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includeName returns the header name of the directive #include "name".
+func includeName(text string) (string, bool) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "#"))
+	if !strings.HasPrefix(text, "include") {
+		return "", false
+	}
+	text = strings.TrimSpace(strings.TrimPrefix(text, "include"))
+	if !strings.HasPrefix(text, `"`) {
+		// System headers
+		return "", false
+	}
+	name, _, ok := strings.Cut(text[1:], `"`)
+	return name, ok && name != ""
+}
+
+// preprocess removes the comments of src and joins continued lines.
+func preprocess(src string) ([]line, error) {
+	var b strings.Builder
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(src) && src[j] != c && src[j] != '\n'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) || src[j] != c {
+				return nil, fmt.Errorf("unterminated literal at line %d", strings.Count(src[:i], "\n")+1)
+			}
+			b.WriteString(src[i : j+1])
+			i = j
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i--
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at line %d", strings.Count(src[:i], "\n")+1)
+			}
+			comment := src[i : i+2+end+2]
+			// Keep the line numbers
+			b.WriteString(" " + strings.Repeat("\n", strings.Count(comment, "\n")))
+			i += 2 + end + 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	var lines []line
+	var cont strings.Builder
+	start := 0
+	for i, l := range strings.Split(b.String(), "\n") {
+		if cont.Len() == 0 {
+			start = i + 1
+		}
+		if strings.HasSuffix(l, "\\") {
+			cont.WriteString(strings.TrimSuffix(l, "\\") + " ")
+			continue
+		}
+		cont.WriteString(l)
+		lines = append(lines, line{text: strings.TrimSpace(cont.String()), line: start})
+		cont.Reset()
+	}
+	return lines, nil
+}
+
+// directive handles the preprocessor directive text. Object-like macros are
+// recorded as defines, or ignored in declarations if they are empty or
+// attributes, and function-like macros are expanded in the declarations after
+// them. All other directives are skipped.
+func (p *parser) directive(text string, line int) {
+	text = strings.TrimSpace(strings.TrimPrefix(text, "#"))
+	if !strings.HasPrefix(text, "define") {
+		return
+	}
+	text = strings.TrimSpace(strings.TrimPrefix(text, "define"))
+	i := 0
+	for i < len(text) && isIdentByte(text[i]) {
+		i++
+	}
+	name := text[:i]
+	if name == "" {
+		return
+	}
+	if i < len(text) && text[i] == '(' {
+		p.functionMacro(name, text[i:], line)
+		return
+	}
+	toks, err := tokenize(text[i:])
+	if err != nil {
+		return
+	}
+	for j := range toks {
+		toks[j].line = line
+	}
+	toks = toks[:len(toks)-1] // EOF
+	if len(toks) == 0 || toks[0].text == "__attribute__" || toks[0].text == "__declspec" {
+		p.ignore[name] = true
+		return
+	}
+	p.h.defines = append(p.h.defines, define{name: name, value: toks})
+}
+
+// functionMacro records the function-like macro name with the parameters and
+// body text. Macros with variadic parameters or the # and ## operators are not
+// supported. As the conditional directives are skipped, the first definition
+// of a macro is used.
+func (p *parser) functionMacro(name, text string, line int) {
+	if _, ok := p.macros[name]; ok {
+		return
+	}
+	toks, err := tokenize(text)
+	if err != nil {
+		return
+	}
+	toks = toks[:len(toks)-1] // EOF
+	m := &macro{line: line}
+	i := 1
+	for i < len(toks) && toks[i].text != ")" {
+		if toks[i].kind != tokIdent {
+			return
+		}
+		m.params = append(m.params, toks[i].text)
+		i++
+		if i < len(toks) && toks[i].text == "," {
+			i++
+		}
+	}
+	if i == len(toks) {
+		return
+	}
+	for _, t := range toks[i+1:] {
+		if t.kind == tokPunct && (t.text == "#" || t.text == "##") {
+			return
+		}
+		m.body = append(m.body, t)
+	}
+	p.macros[name] = m
+}
+
+// expand replaces the calls of function-like macros in toks by their
+// expansion, except of the macros in disabled, which are being expanded.
+func (p *parser) expand(toks []token, disabled map[string]bool) []token {
+	var out []token
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		m, ok := p.macros[t.text]
+		if !ok || t.kind != tokIdent || t.line <= m.line || disabled[t.text] || i+1 == len(toks) || toks[i+1].text != "(" {
+			out = append(out, t)
+			continue
+		}
+		args, end := macroArgs(toks, i+1)
+		if len(m.params) == 0 && len(args) == 1 && len(args[0]) == 0 {
+			args = nil
+		}
+		if end < 0 || len(args) != len(m.params) {
+			out = append(out, t)
+			continue
+		}
+		var body []token
+		for _, b := range m.body {
+			arg := -1
+			for j, param := range m.params {
+				if b.kind == tokIdent && b.text == param {
+					arg = j
+				}
+			}
+			if arg < 0 {
+				b.line = t.line
+				body = append(body, b)
+				continue
+			}
+			body = append(body, args[arg]...)
+		}
+		inner := map[string]bool{t.text: true}
+		for name := range disabled {
+			inner[name] = true
+		}
+		out = append(out, p.expand(body, inner)...)
+		i = end
+	}
+	return out
+}
+
+// macroArgs returns the arguments of the macro call with the opening
+// parenthesis toks[open] and the index of the closing one, or -1 if it is
+// missing.
+func macroArgs(toks []token, open int) ([][]token, int) {
+	args := [][]token{nil}
+	depth := 0
+	for i := open + 1; i < len(toks); i++ {
+		switch t := toks[i]; {
+		case t.kind != tokPunct:
+		case t.text == "(":
+			depth++
+		case t.text == ")" && depth == 0:
+			return args, i
+		case t.text == ")":
+			depth--
+		case t.text == "," && depth == 0:
+			args = append(args, nil)
+			continue
+		}
+		args[len(args)-1] = append(args[len(args)-1], toks[i])
+	}
+	return nil, -1
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// tokenize splits src into tokens, ending with a tokEOF.
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	ln := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			ln++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && (isIdentByte(src[j]) || src[j] == '.' ||
+				(src[j] == '+' || src[j] == '-') && strings.ContainsRune("eEpP", rune(src[j-1])) && !isHex(src[i:j])) {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], ln})
+			i = j
+		case isIdentByte(c):
+			j := i
+			for j < len(src) && isIdentByte(src[j]) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], ln})
+			i = j
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated literal", ln)
+			}
+			kind := tokString
+			if c == '\'' {
+				kind = tokChar
+			}
+			toks = append(toks, token{kind, src[i : j+1], ln})
+			i = j + 1
+		default:
+			n := 1
+			for _, op := range []string{"...", "<<", ">>", "->", "&&", "||", "==", "!=", "<=", ">="} {
+				if strings.HasPrefix(src[i:], op) {
+					n = len(op)
+					break
+				}
+			}
+			toks = append(toks, token{tokPunct, src[i : i+n], ln})
+			i += n
+		}
+	}
+	return append(toks, token{kind: tokEOF, line: ln}), nil
+}
+
+func isHex(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	// The EOF token is after the last line
+	l := p.lines[min(p.peek().line, len(p.lines))-1]
+	panic(parseError{fmt.Errorf("%s:%d: %s", l.file, l.line, fmt.Sprintf(format, args...))})
+}
+
+func (p *parser) peek() token {
+	return p.peekN(0)
+}
+
+func (p *parser) peekN(n int) token {
+	if p.pos+n >= len(p.toks) {
+		return p.toks[len(p.toks)-1]
+	}
+	return p.toks[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); t.kind != tokString && t.kind != tokChar && t.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) {
+	if !p.accept(text) {
+		p.fail("expected %q, found %q", text, p.peek().text)
+	}
+}
+
+// skipBalanced skips the tokens up to and including the closing bracket of the
+// opening bracket, which must be the next token.
+func (p *parser) skipBalanced() {
+	depth := 0
+	for {
+		t := p.next()
+		switch t.text {
+		case "(", "[", "{":
+			depth++
+		case ")", "]", "}":
+			depth--
+		}
+		if t.kind == tokEOF {
+			p.fail("unbalanced brackets")
+		}
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// skipAttributes skips GCC and MSVC attributes.
+func (p *parser) skipAttributes() {
+	for {
+		switch p.peek().text {
+		case "__attribute__", "__attribute", "__declspec", "__asm__", "__asm", "asm":
+			p.next()
+			p.skipBalanced()
+		default:
+			return
+		}
+	}
+}
+
+// skipTrailingMacros skips attributes and macros like __THROW or
+// __nonnull((1)) after a declarator, which is never followed by an identifier.
+func (p *parser) skipTrailingMacros() {
+	for {
+		p.skipAttributes()
+		if p.peek().kind != tokIdent {
+			return
+		}
+		p.next()
+		if p.peek().text == "(" {
+			p.skipBalanced()
+		}
+	}
+}
+
+func (p *parser) file() {
+	for p.peek().kind != tokEOF {
+		switch {
+		case p.accept(";"), p.accept("}"):
+		case p.peek().text == "extern" && p.peekN(1).kind == tokString:
+			// extern "C" and its block
+			p.next()
+			p.next()
+			p.accept("{")
+		default:
+			p.recoverDeclaration(p.declaration)
+		}
+	}
+}
+
+// recoverDeclaration calls parse and, if it fails, reports the error and skips
+// to the end of the declaration, so that macros the parser does not know only
+// lose their declaration.
+func (p *parser) recoverDeclaration(parse func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			warnf("skipping declaration: %v", pe.err)
+			for {
+				switch open := p.peek().text; open {
+				case "(", "[", "{":
+					p.skipBalanced()
+					if open == "{" && p.peek().text != ";" {
+						// Function body
+						return
+					}
+					continue
+				}
+				if t := p.next(); t.kind == tokEOF || t.text == ";" {
+					return
+				}
+			}
+		}
+	}()
+	parse()
+}
+
+// declaration parses a top-level declaration.
+func (p *parser) declaration() {
+	typedef, static := false, false
+	for {
+		p.skipAttributes()
+		if p.accept("typedef") {
+			typedef = true
+			continue
+		}
+		if p.accept("static") {
+			// Functions with internal linkage are not in the library
+			static = true
+			continue
+		}
+		if t := p.peek(); t.text != "const" && (qualifiers[t.text] || p.ignore[t.text]) {
+			p.next()
+			continue
+		}
+		break
+	}
+	base := p.baseType()
+	if p.accept(";") {
+		return
+	}
+	for {
+		name, t := p.declarator(base)
+		p.skipAttributes()
+		if tok := p.peek(); t.kind != typeFunc && tok.kind == tokIdent && p.peekN(1).text == "(" {
+			// Like OF((args)) after a function pointer, which would be mistaken for a pointer
+			p.fail("unknown function-like macro %s", tok.text)
+		}
+		p.skipTrailingMacros()
+		switch {
+		case name == "":
+			p.fail("missing declarator name")
+		case typedef:
+			p.typedef(name, t)
+		case t.kind == typeFunc && !static && p.peek().text != "{":
+			p.h.decls = append(p.h.decls, &decl{kind: declFunc, name: name, typ: t})
+		}
+		if p.peek().text == "{" {
+			// Function body, the function is inline
+			p.skipBalanced()
+			return
+		}
+		if p.accept("=") {
+			for p.peek().text != "," && p.peek().text != ";" && p.peek().kind != tokEOF {
+				if t := p.peek().text; t == "{" || t == "(" {
+					p.skipBalanced()
+				} else {
+					p.next()
+				}
+			}
+		}
+		if !p.accept(",") {
+			p.expect(";")
+			return
+		}
+	}
+}
+
+// typedef declares name as type t.
+func (p *parser) typedef(name string, t *cType) {
+	if _, ok := p.typedefs[name]; ok {
+		return
+	}
+	if (t.kind == typeStruct || t.kind == typeEnum) && t.name == "" {
+		// Anonymous structs and enums are named after their typedef
+		t.name = name
+		kind := declStruct
+		if t.kind == typeEnum {
+			kind = declEnum
+		}
+		p.h.decls = append(p.h.decls, &decl{kind: kind, name: name, typ: t})
+	}
+	p.typedefs[name] = t
+	p.h.decls = append(p.h.decls, &decl{kind: declTypedef, name: name, typ: t})
+}
+
+// baseType parses the type specifiers and qualifiers of a declaration.
+func (p *parser) baseType() *cType {
+	var words []string
+	var t *cType
+	isConst := false
+	for {
+		p.skipAttributes()
+		tok := p.peek()
+		if tok.kind != tokIdent {
+			break
+		}
+		switch tok.text {
+		case "const", "__const":
+			isConst = true
+			p.next()
+			continue
+		case "signed", "__signed__", "unsigned", "short", "long", "int", "char", "float", "double", "void":
+			if t != nil {
+				break
+			}
+			words = append(words, tok.text)
+			p.next()
+			continue
+		case "struct", "union":
+			if t != nil || len(words) > 0 {
+				break
+			}
+			p.next()
+			t = p.structType(tok.text == "union")
+			continue
+		case "enum":
+			if t != nil || len(words) > 0 {
+				break
+			}
+			p.next()
+			t = p.enumType()
+			continue
+		}
+		if qualifiers[tok.text] || p.ignore[tok.text] {
+			p.next()
+			continue
+		}
+		if t != nil || len(words) > 0 {
+			break
+		}
+		if _, ok := p.typedefs[tok.text]; !ok && isMacroName(tok.text) && p.peekN(1).kind == tokIdent {
+			// Likely an export or calling convention macro
+			p.next()
+			continue
+		}
+		p.next()
+		if goType, ok := builtinTypes[tok.text]; ok {
+			t = &cType{kind: typePrim, name: goType}
+		} else {
+			t = &cType{kind: typeNamed, name: tok.text}
+		}
+	}
+	if t == nil {
+		if len(words) == 0 {
+			p.fail("expected type, found %q", p.peek().text)
+		}
+		t = p.primType(words)
+		if t == nil {
+			// Unknown to the generator, which skips its uses
+			t = &cType{kind: typeNamed, name: strings.Join(words, " ")}
+		}
+	}
+	if isConst {
+		c := *t
+		c.isConst = true
+		t = &c
+	}
+	return t
+}
+
+// isMacroName returns whether s is all upper case.
+func isMacroName(s string) bool {
+	return strings.ToUpper(s) == s && strings.ToLower(s) != s
+}
+
+// primType returns the type of the builtin type specifiers words, or nil.
+func (p *parser) primType(words []string) *cType {
+	var unsigned, signed, char, short, float, double, void bool
+	longs := 0
+	for _, w := range words {
+		switch w {
+		case "unsigned":
+			unsigned = true
+		case "signed", "__signed__":
+			signed = true
+		case "char":
+			char = true
+		case "short":
+			short = true
+		case "long":
+			longs++
+		case "float":
+			float = true
+		case "double":
+			double = true
+		case "void":
+			void = true
+		}
+	}
+	name := "int32"
+	switch {
+	case void:
+		return &cType{kind: typeVoid}
+	case float:
+		name = "float32"
+	case double && longs > 0:
+		return nil // long double
+	case double:
+		name = "float64"
+	case char && !unsigned && !signed:
+		name = "byte" // plain char
+	case char:
+		name = "int8"
+	case short:
+		name = "int16"
+	case longs > 1:
+		name = "int64"
+	case longs == 1:
+		name = p.long
+		p.h.long = true
+	}
+	if unsigned && name != "byte" {
+		name = "u" + name
+	}
+	return &cType{kind: typePrim, name: name}
+}
+
+// structType parses a struct or union after its keyword.
+func (p *parser) structType(union bool) *cType {
+	p.skipAttributes()
+	t := &cType{kind: typeStruct, union: union}
+	if p.peek().kind == tokIdent {
+		t.name = p.next().text
+	}
+	p.skipAttributes()
+	if !p.accept("{") {
+		if t.name == "" {
+			p.fail("expected struct body")
+		}
+		return t
+	}
+	t.defined = true
+	for !p.accept("}") {
+		base := p.baseType()
+		if p.accept(";") {
+			// Anonymous member struct or union
+			t.fields = append(t.fields, &field{typ: base})
+			continue
+		}
+		for {
+			var name string
+			ft := base
+			if p.peek().text != ":" {
+				name, ft = p.declarator(base)
+			}
+			if p.accept(":") {
+				t.bitField = true
+				for p.peek().text != "," && p.peek().text != ";" && p.peek().kind != tokEOF {
+					p.next()
+				}
+			}
+			p.skipAttributes()
+			t.fields = append(t.fields, &field{name: name, typ: ft})
+			if !p.accept(",") {
+				p.expect(";")
+				break
+			}
+		}
+	}
+	p.skipAttributes()
+	if t.name != "" {
+		p.h.decls = append(p.h.decls, &decl{kind: declStruct, name: t.name, typ: t})
+	}
+	return t
+}
+
+// enumType parses an enum after its keyword.
+func (p *parser) enumType() *cType {
+	p.skipAttributes()
+	t := &cType{kind: typeEnum}
+	if p.peek().kind == tokIdent {
+		t.name = p.next().text
+	}
+	if !p.accept("{") {
+		if t.name == "" {
+			p.fail("expected enum body")
+		}
+		return t
+	}
+	t.defined = true
+	for !p.accept("}") {
+		tok := p.next()
+		if tok.kind != tokIdent {
+			p.fail("expected enumerator, found %q", tok.text)
+		}
+		p.skipAttributes()
+		item := enumItem{name: tok.text}
+		if p.accept("=") {
+			depth := 0
+			for {
+				t := p.peek()
+				if t.kind == tokEOF || depth == 0 && (t.text == "," || t.text == "}") {
+					break
+				}
+				switch t.text {
+				case "(":
+					depth++
+				case ")":
+					depth--
+				}
+				item.value = append(item.value, p.next())
+			}
+		}
+		t.consts = append(t.consts, item)
+		if !p.accept(",") {
+			p.expect("}")
+			break
+		}
+	}
+	if t.name != "" {
+		p.h.decls = append(p.h.decls, &decl{kind: declEnum, name: t.name, typ: t})
+	}
+	return t
+}
+
+// declarator parses a declarator of the type base and returns its name, which
+// is empty for abstract declarators, and type.
+func (p *parser) declarator(base *cType) (string, *cType) {
+	t := base
+	for {
+		p.skipAttributes()
+		if p.accept("*") {
+			t = &cType{kind: typePtr, elem: t}
+			continue
+		}
+		if tok := p.peek(); qualifiers[tok.text] || p.ignore[tok.text] {
+			if tok.text == "const" && t.kind == typePtr {
+				t.isConst = true
+			}
+			p.next()
+			continue
+		}
+		break
+	}
+	if p.peek().text == "(" && (p.peekN(1).text == "*" || p.peekN(1).text == "^" || p.ignore[p.peekN(1).text] || qualifiers[p.peekN(1).text]) {
+		// Nested declarator like the function pointer (*name)(params)
+		p.next()
+		placeholder := &cType{}
+		name, nested := p.declarator(placeholder)
+		p.expect(")")
+		*placeholder = *p.suffixes(t)
+		return name, nested
+	}
+	var name string
+	if p.peek().kind == tokIdent {
+		name = p.next().text
+	}
+	return name, p.suffixes(t)
+}
+
+// suffixes parses the array and function suffixes of a declarator of type t.
+func (p *parser) suffixes(t *cType) *cType {
+	p.skipAttributes()
+	switch {
+	case p.peek().text == "[":
+		var dims [][]token
+		for p.accept("[") {
+			var dim []token
+			for !p.accept("]") {
+				if p.peek().kind == tokEOF {
+					p.fail("unterminated array")
+				}
+				dim = append(dim, p.next())
+			}
+			dims = append(dims, dim)
+		}
+		for i := len(dims) - 1; i >= 0; i-- {
+			t = &cType{kind: typeArray, elem: t, len: dims[i]}
+		}
+		return t
+	case p.accept("("):
+		f := &cType{kind: typeFunc, elem: t}
+		if p.peek().text == "void" && p.peekN(1).text == ")" {
+			p.next()
+		}
+		for !p.accept(")") {
+			if p.accept("...") {
+				f.variadic = true
+				continue
+			}
+			base := p.baseType()
+			name, pt := p.declarator(base)
+			switch pt.kind {
+			case typeArray:
+				pt = &cType{kind: typePtr, elem: pt.elem}
+			case typeFunc:
+				pt = &cType{kind: typePtr, elem: pt}
+			}
+			f.params = append(f.params, &field{name: name, typ: pt})
+			if !p.accept(",") {
+				p.expect(")")
+				break
+			}
+		}
+		return f
+	}
+	return t
+}
@@ -0,0 +1,61 @@
+/* bzlib.h -- test header with the declarations of bzip2 */
+
+#ifndef _BZLIB_H
+#define _BZLIB_H
+
+#define BZ_RUN               0
+#define BZ_FINISH            2
+
+#define BZ_OK                0
+#define BZ_STREAM_END        4
+
+typedef
+   struct {
+      char *next_in;
+      unsigned int avail_in;
+      unsigned int total_in_lo32;
+      unsigned int total_in_hi32;
+
+      char *next_out;
+      unsigned int avail_out;
+      unsigned int total_out_lo32;
+      unsigned int total_out_hi32;
+
+      void *state;
+
+      void *(*bzalloc)(void *,int,int);
+      void (*bzfree)(void *,void *);
+      void *opaque;
+   }
+   bz_stream;
+
+#ifdef _WIN32
+#   include <windows.h>
+#   define BZ_API(func) WINAPI func
+#   define BZ_EXTERN extern
+#else
+#   define BZ_API(func) func
+#   define BZ_EXTERN extern
+#endif
+
+BZ_EXTERN int BZ_API(BZ2_bzCompressInit) (
+      bz_stream* strm,
+      int        blockSize100k,
+      int        verbosity,
+      int        workFactor
+   );
+
+BZ_EXTERN int BZ_API(BZ2_bzCompress) (
+      bz_stream* strm,
+      int action
+   );
+
+BZ_EXTERN int BZ_API(BZ2_bzCompressEnd) (
+      bz_stream* strm
+   );
+
+BZ_EXTERN const char * BZ_API(BZ2_bzlibVersion) (
+      void
+   );
+
+#endif
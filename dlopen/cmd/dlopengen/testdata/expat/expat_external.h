@@ -0,0 +1,23 @@
+/* expat_external.h -- export macros of the expat test header */
+
+#ifndef Expat_External_INCLUDED
+#define Expat_External_INCLUDED 1
+
+#ifndef XMLCALL
+#  if defined(_MSC_VER)
+#    define XMLCALL __cdecl
+#  else
+#    define XMLCALL
+#  endif
+#endif
+
+#ifndef XMLIMPORT
+#  define XMLIMPORT __attribute__((visibility("default")))
+#endif
+
+#define XMLPARSEAPI(type) XMLIMPORT type XMLCALL
+
+typedef char XML_Char;
+typedef char XML_LChar;
+
+#endif
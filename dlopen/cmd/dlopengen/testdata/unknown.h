@@ -0,0 +1,13 @@
+/* unknown.h -- test header with a struct with a field of an unknown type */
+
+struct point {
+    int x;
+    int y;
+};
+
+struct file {
+    file_handle_t handle;
+    struct point pos;
+};
+
+int file_read(struct file *f, void *buf, int n);
@@ -0,0 +1,40 @@
+/* zconf.h -- configuration of the zlib test header */
+
+#ifndef ZCONF_H
+#define ZCONF_H
+
+#if defined(_WIN32) && defined(ZLIB_DLL)
+#  define ZEXPORT WINAPI
+#endif
+#ifndef ZEXPORT
+#  define ZEXPORT
+#endif
+#ifndef ZEXTERN
+#  define ZEXTERN extern
+#endif
+
+#ifndef FAR
+#  define FAR
+#endif
+
+#ifdef ZLIB_CONST
+#  define z_const const
+#else
+#  define z_const
+#endif
+
+#ifndef OF
+#  ifdef STDC
+#    define OF(args)  args
+#  else
+#    define OF(args)  ()
+#  endif
+#endif
+
+typedef unsigned char  Byte;
+typedef unsigned int   uInt;
+typedef unsigned long  uLong;
+typedef Byte  FAR Bytef;
+typedef void FAR *voidpf;
+
+#endif /* ZCONF_H */
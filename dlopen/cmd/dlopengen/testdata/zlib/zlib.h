@@ -0,0 +1,68 @@
+/* zlib.h -- test header with the declarations of zlib */
+
+#ifndef ZLIB_H
+#define ZLIB_H
+
+#include "zconf.h"
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+#define ZLIB_VERSION "1.3.1"
+#define ZLIB_VERNUM 0x1310
+
+typedef voidpf (*alloc_func) OF((voidpf opaque, uInt items, uInt size));
+typedef void   (*free_func)  OF((voidpf opaque, voidpf address));
+
+typedef struct z_stream_s {
+    z_const Bytef *next_in;
+    uInt     avail_in;
+    uLong    total_in;
+
+    Bytef    *next_out;
+    uInt     avail_out;
+    uLong    total_out;
+
+    z_const char *msg;
+    struct internal_state FAR *state;
+
+    alloc_func zalloc;
+    free_func  zfree;
+    voidpf     opaque;
+
+    int     data_type;
+    uLong   adler;
+    uLong   reserved;
+} z_stream;
+
+typedef z_stream FAR *z_streamp;
+
+#define Z_NO_FLUSH      0
+#define Z_FINISH        4
+
+#define Z_OK            0
+#define Z_STREAM_END    1
+
+typedef unsigned (*in_func) OF((void FAR *,
+                                z_const unsigned char FAR * FAR *));
+typedef int (*out_func) OF((void FAR *, unsigned char FAR *, unsigned));
+
+ZEXTERN const char * ZEXPORT zlibVersion OF((void));
+ZEXTERN int ZEXPORT deflate OF((z_streamp strm, int flush));
+ZEXTERN int ZEXPORT deflateEnd OF((z_streamp strm));
+ZEXTERN int ZEXPORT inflateBack OF((z_streamp strm,
+                                    in_func in, void FAR *in_desc,
+                                    out_func out, void FAR *out_desc));
+ZEXTERN uLong ZEXPORT crc32 OF((uLong crc, const Bytef *buf, uInt len));
+
+ZEXTERN int ZEXPORT deflateInit_ OF((z_streamp strm, int level,
+                                     const char *version, int stream_size));
+#define deflateInit(strm, level) \
+        deflateInit_((strm), (level), ZLIB_VERSION, (int)sizeof(z_stream))
+
+#ifdef __cplusplus
+}
+#endif
+
+#endif /* ZLIB_H */
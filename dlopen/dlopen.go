@@ -4,6 +4,7 @@ import (
 	"math"
 	"reflect"
 	"runtime"
+	"sync"
 	"unsafe"
 )
 
@@ -15,8 +16,8 @@ import (
 import "C"
 
 const (
-	maxArgs     = 15
-	numOfFloats = 8 // arm64 and amd64 both have 8 float registers
+	maxArgs     = 64 // integer registers and stack slots
+	numOfFloats = 8  // arm64 and amd64 both have 8 float registers
 )
 
 //go:linkname runtime_noescape runtime.noescape
@@ -53,111 +54,82 @@ func GetFn[Fn any](fnPtr unsafe.Pointer, typePtr uintptr) Fn {
 	}
 	{
 		// this code checks how many registers and stack this function will use
-		// to avoid crashing with too many arguments
-		var ints int
-		var floats int
-		var stack int
-		if typePtr != 0 {
-			if ints < numOfIntegerRegisters() {
-				ints++
-			} else {
-				stack++
+		// to avoid crashing with too many arguments or unsupported kinds
+		var out reflect.Type
+		if ty.NumOut() == 1 {
+			out = ty.Out(0)
+			switch out.Kind() {
+			case reflect.String, reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer,
+				reflect.Bool, reflect.Float32, reflect.Float64, reflect.Struct:
+			default:
+				panic("purego: unsupported return kind: " + out.Kind().String())
 			}
 		}
+		frame := newCallFrame(out)
+		if typePtr != 0 {
+			frame.addInt(typePtr)
+		}
 		for i := 0; i < ty.NumIn(); i++ {
 			arg := ty.In(i)
+			if ty.IsVariadic() && i == ty.NumIn()-1 {
+				// The variadic arguments are checked when they are passed
+				if arg = arg.Elem(); arg.Kind() == reflect.Interface {
+					continue
+				}
+			}
 			switch arg.Kind() {
 			case reflect.String, reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 				reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Ptr, reflect.UnsafePointer, reflect.Slice,
 				reflect.Func, reflect.Bool:
-				if ints < numOfIntegerRegisters() {
-					ints++
-				} else {
-					stack++
-				}
+				frame.addInt(0)
 			case reflect.Float32, reflect.Float64:
-				if floats < numOfFloats {
-					floats++
-				} else {
-					stack++
-				}
+				frame.addFloat(0)
+			case reflect.Struct:
+				frame.addStruct(reflect.Zero(arg))
 			default:
 				panic("purego: unsupported kind " + arg.Kind().String())
 			}
 		}
-		sizeOfStack := maxArgs - numOfIntegerRegisters()
-		if stack > sizeOfStack {
-			panic("purego: too many arguments")
-		}
 	}
 	return (reflect.MakeFunc(ty, func(args []reflect.Value) (results []reflect.Value) {
-		if len(args) > 0 {
-			if variadic, ok := args[len(args)-1].Interface().([]interface{}); ok {
-				// subtract one from args bc the last argument in args is []interface{}
-				// which we are currently expanding
-				tmp := make([]reflect.Value, len(args)-1+len(variadic))
-				n := copy(tmp, args[:len(args)-1])
-				for i, v := range variadic {
-					tmp[n+i] = reflect.ValueOf(v)
+		numFixed := len(args)
+		if ty.IsVariadic() {
+			// subtract one from args bc the last argument in args is the slice of
+			// variadic arguments which we are currently expanding
+			variadic := args[len(args)-1]
+			tmp := make([]reflect.Value, len(args)-1+variadic.Len())
+			numFixed = copy(tmp, args[:len(args)-1])
+			for i := 0; i < variadic.Len(); i++ {
+				v := variadic.Index(i)
+				if v.Kind() == reflect.Interface {
+					v = v.Elem()
 				}
-				args = tmp
+				tmp[numFixed+i] = v
 			}
+			args = tmp
 		}
-		var sysargs [maxArgs]uintptr
-		stack := sysargs[numOfIntegerRegisters():]
-		var floats [numOfFloats]uintptr
-		var numInts int
-		var numFloats int
-		var numStack int
-		var addStack, addInt, addFloat func(x uintptr)
-		if runtime.GOARCH == "arm64" || runtime.GOOS != "windows" {
-			// Windows arm64 uses the same calling convention as macOS and Linux
-			addStack = func(x uintptr) {
-				stack[numStack] = x
-				numStack++
-			}
-			addInt = func(x uintptr) {
-				if numInts >= numOfIntegerRegisters() {
-					addStack(x)
-				} else {
-					sysargs[numInts] = x
-					numInts++
-				}
-			}
-			addFloat = func(x uintptr) {
-				if numFloats < len(floats) {
-					floats[numFloats] = x
-					numFloats++
-				} else {
-					addStack(x)
-				}
-			}
-		} else {
-			// On Windows amd64 the arguments are passed in the numbered registered.
-			// So the first int is in the first integer register and the first float
-			// is in the second floating register if there is already a first int.
-			// This is in contrast to how macOS and Linux pass arguments which
-			// tries to use as many registers as possible in the calling convention.
-			addStack = func(x uintptr) {
-				sysargs[numStack] = x
-				numStack++
-			}
-			addInt = addStack
-			addFloat = addStack
+		var out reflect.Type
+		if ty.NumOut() == 1 {
+			out = ty.Out(0)
 		}
-		var keepAlive []interface{}
+		frame := newCallFrame(out)
+		addInt, addFloat := frame.addInt, frame.addFloat
 		defer func() {
-			runtime.KeepAlive(keepAlive)
+			runtime.KeepAlive(frame.keepAlive)
 			runtime.KeepAlive(args)
 		}()
 		if typePtr != 0 {
 			addInt(typePtr)
 		}
-		for _, v := range args {
+		for i, v := range args {
 			switch v.Kind() {
+			case reflect.Invalid:
+				// nil passed as a variadic argument
+				addInt(0)
 			case reflect.String:
 				ptr := CString(v.String())
-				keepAlive = append(keepAlive, ptr)
+				frame.keepAlive = append(frame.keepAlive, ptr)
 				addInt(uintptr(unsafe.Pointer(ptr)))
 			case reflect.Uintptr, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 				addInt(uintptr(v.Uint()))
@@ -173,22 +145,30 @@ func GetFn[Fn any](fnPtr unsafe.Pointer, typePtr uintptr) Fn {
 					addInt(0)
 				}
 			case reflect.Float32:
-				addFloat(uintptr(math.Float32bits(float32(v.Float()))))
+				if i >= numFixed {
+					// C promotes variadic floats to double
+					addFloat(uintptr(math.Float64bits(v.Float())))
+				} else {
+					addFloat(uintptr(math.Float32bits(float32(v.Float()))))
+				}
 			case reflect.Float64:
 				addFloat(uintptr(math.Float64bits(v.Float())))
+			case reflect.Func:
+				// The trampoline of a function value is reused by further calls
+				addInt(NewCallback(v.Interface()))
+			case reflect.Struct:
+				frame.addStruct(v)
 			default:
 				panic("purego: unsupported kind: " + v.Kind().String())
 			}
 		}
 
-		// This is a fallback for Windows amd64, 386, and arm. Note this may not support floats
-		r1, r2, _ := Syscall_syscall15X(uintptr(fnPtr), sysargs[0], sysargs[1], sysargs[2], sysargs[3], sysargs[4],
-			sysargs[5], sysargs[6], sysargs[7], sysargs[8], sysargs[9], sysargs[10], sysargs[11],
-			sysargs[12], sysargs[13], sysargs[14])
+		ret, _ := syscallN(uintptr(fnPtr), frame)
 
 		if ty.NumOut() == 0 {
 			return nil
 		}
+		r1 := *(*uintptr)(unsafe.Pointer(&ret[0]))
 		outType := ty.Out(0)
 		v := reflect.New(outType).Elem()
 		switch outType.Kind() {
@@ -207,13 +187,11 @@ func GetFn[Fn any](fnPtr unsafe.Pointer, typePtr uintptr) Fn {
 		case reflect.String:
 			v.SetString(GoString(r1))
 		case reflect.Float32:
-			// NOTE: r2 is only the floating return value on 64bit platforms.
-			// On 32bit platforms r2 is the upper part of a 64bit return.
-			v.SetFloat(float64(math.Float32frombits(uint32(r2))))
+			v.SetFloat(float64(math.Float32frombits(uint32(r1))))
 		case reflect.Float64:
-			// NOTE: r2 is only the floating return value on 64bit platforms.
-			// On 32bit platforms r2 is the upper part of a 64bit return.
-			v.SetFloat(math.Float64frombits(uint64(r2)))
+			v.SetFloat(math.Float64frombits(uint64(r1)))
+		case reflect.Struct:
+			v = structResult(outType, frame.ret, &ret)
 		default:
 			panic("purego: unsupported return kind: " + outType.Kind().String())
 		}
@@ -235,6 +213,14 @@ func GetSymbolTypeFn[Fn any](handle Handle, typePtr unsafe.Pointer, symbol strin
 	return GetFn[Fn](handlePtr, uintptr(typePtr))
 }
 
+// LazySymbolFn returns a function which resolves the function of symbol like
+// GetSymbolFn when it is first called and then returns it.
+func LazySymbolFn[Fn any](handle Handle, symbol string) func() Fn {
+	return sync.OnceValue(func() Fn {
+		return GetSymbolFn[Fn](handle, symbol)
+	})
+}
+
 func numOfIntegerRegisters() int {
 	switch runtime.GOARCH {
 	case "arm64":
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"runtime"
+	"sync"
 
 	"unsafe"
 )
@@ -15,6 +17,7 @@ import (
 #include <dlfcn.h>
 #include <errno.h>
 #include <assert.h>
+#include <string.h>
 
 typedef struct syscall15Args {
 	uintptr_t fn;
@@ -34,6 +37,94 @@ void syscall15(struct syscall15Args *args) {
 	args->r1 = r1;
 	args->err = errno;
 }
+
+// callArgs holds the registers and stack slots of a call of syscallN. The integer
+// registers come first in args, followed by the stack slots. Calls with more than
+// CALL_STACK stack slots pass all CALL_MAX_ARGS - CALL_INTS of them.
+#define CALL_MAX_ARGS 64
+#define WORDS8 uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t
+#define ARGS8(a, i) a->args[i], a->args[i + 1], a->args[i + 2], a->args[i + 3], \
+	a->args[i + 4], a->args[i + 5], a->args[i + 6], a->args[i + 7]
+#define FLOAT_PARAMS double, double, double, double, double, double, double, double
+#define FLOAT_ARGS(a) a->floats[0], a->floats[1], a->floats[2], a->floats[3], \
+	a->floats[4], a->floats[5], a->floats[6], a->floats[7]
+#if defined(__x86_64__)
+#define CALL_INTS 6
+#define CALL_STACK 9
+// fn is called through a variadic prototype, which passes the arguments in the
+// same registers and stack slots as the full prototype and also sets AL to the
+// number of vector registers used, as variadic functions require it.
+#define CALL_PARAMS uintptr_t, ...
+#define WIDE_CALL_PARAMS uintptr_t, ...
+#define CALL_ARGS(a) a->args[0], a->args[1], a->args[2], a->args[3], a->args[4], a->args[5], \
+	FLOAT_ARGS(a), ARGS8(a, 6), a->args[14]
+#define WIDE_CALL_ARGS(a) a->args[0], a->args[1], a->args[2], a->args[3], a->args[4], a->args[5], \
+	FLOAT_ARGS(a), ARGS8(a, 6), ARGS8(a, 14), ARGS8(a, 22), ARGS8(a, 30), ARGS8(a, 38), ARGS8(a, 46), \
+	a->args[54], a->args[55], a->args[56], a->args[57], a->args[58], a->args[59], a->args[60], a->args[61], \
+	a->args[62], a->args[63]
+#elif defined(__aarch64__)
+#define CALL_INTS 8
+#define CALL_STACK 7
+// On linux arm64 variadic arguments are passed like the named ones, so no
+// variadic prototype is needed.
+#define CALL_PARAMS WORDS8, FLOAT_PARAMS, \
+	uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t, uintptr_t
+#define WIDE_CALL_PARAMS WORDS8, FLOAT_PARAMS, WORDS8, WORDS8, WORDS8, WORDS8, WORDS8, WORDS8, WORDS8
+#define CALL_ARGS(a) ARGS8(a, 0), FLOAT_ARGS(a), \
+	a->args[8], a->args[9], a->args[10], a->args[11], a->args[12], a->args[13], a->args[14]
+#define WIDE_CALL_ARGS(a) ARGS8(a, 0), FLOAT_ARGS(a), \
+	ARGS8(a, 8), ARGS8(a, 16), ARGS8(a, 24), ARGS8(a, 32), ARGS8(a, 40), ARGS8(a, 48), ARGS8(a, 56)
+#else
+#define CALL_INTS 0
+#define CALL_STACK 0
+#endif
+
+#define CALL_RET_SIZE 256
+
+enum { RET_INT, RET_FLOAT, RET_INT_INT, RET_FLOAT_FLOAT, RET_INT_FLOAT, RET_FLOAT_INT, RET_HFA, RET_MEMORY };
+
+typedef struct callArgs {
+	uintptr_t fn;
+	uintptr_t args[CALL_MAX_ARGS];
+	double floats[8];
+	int ret;
+	int wide;
+	uintptr_t err;
+	uint64_t result[CALL_RET_SIZE / 8];
+} callArgs;
+
+// The result types select the registers the result is taken from.
+typedef struct { uint64_t a, b; } retIntInt;
+typedef struct { double a, b; } retFloatFloat;
+typedef struct { uint64_t a; double b; } retIntFloat;
+typedef struct { double a; uint64_t b; } retFloatInt;
+typedef struct { double a, b, c, d; } retHFA;
+typedef struct { char b[CALL_RET_SIZE]; } retMemory;
+
+#define CALL(T, a) { \
+	T r; \
+	if (a->wide) \
+		r = ((T (*)(WIDE_CALL_PARAMS))(void *)a->fn)(WIDE_CALL_ARGS(a)); \
+	else \
+		r = ((T (*)(CALL_PARAMS))(void *)a->fn)(CALL_ARGS(a)); \
+	memcpy(a->result, &r, sizeof(r)); \
+}
+
+void syscallN(struct callArgs *a) {
+#ifdef CALL_PARAMS
+	switch (a->ret) {
+	case RET_INT: CALL(uintptr_t, a) break;
+	case RET_FLOAT: CALL(double, a) break;
+	case RET_INT_INT: CALL(retIntInt, a) break;
+	case RET_FLOAT_FLOAT: CALL(retFloatFloat, a) break;
+	case RET_INT_FLOAT: CALL(retIntFloat, a) break;
+	case RET_FLOAT_INT: CALL(retFloatInt, a) break;
+	case RET_HFA: CALL(retHFA, a) break;
+	case RET_MEMORY: CALL(retMemory, a) break;
+	}
+#endif
+	a->err = errno;
+}
 */
 import "C"
 
@@ -41,6 +132,10 @@ import "C"
 type libHandle struct {
 	Handle  unsafe.Pointer
 	Libname string
+
+	symbolsOnce sync.Once
+	symbols     []Symbol
+	symbolsErr  error
 }
 
 // GetHandle tries to get a handle to a library (.so), attempting to access it
@@ -82,6 +177,14 @@ func (l *libHandle) GetSymbolPointer(symbol string) (unsafe.Pointer, error) {
 	return p, nil
 }
 
+// Symbols returns the exported symbols of the library, which are read once.
+func (l *libHandle) Symbols() ([]Symbol, error) {
+	l.symbolsOnce.Do(func() {
+		l.symbols, l.symbolsErr = ReadSymbols(l.Libname)
+	})
+	return l.symbols, l.symbolsErr
+}
+
 // Close closes a libHandle.
 func (l *libHandle) Close() error {
 	C.dlerror()
@@ -116,3 +219,24 @@ func syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14,
 func Syscall_syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15 uintptr) (r1, r2, err uintptr) {
 	return syscall15X(fn, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14, a15)
 }
+
+// syscallN calls fn with the registers and stack slots of frame and returns the
+// result registers or memory.
+func syscallN(fn uintptr, frame *callFrame) (ret [maxRetSize]byte, err uintptr) {
+	if C.CALL_INTS != numOfIntegerRegisters() || len(frame.sysargs) != len(C.callArgs{}.args) {
+		panic("purego: unknown GOARCH (" + runtime.GOARCH + ")")
+	}
+	args := C.callArgs{fn: C.uintptr_t(fn), ret: C.int(frame.ret)}
+	if frame.numStack > C.CALL_STACK {
+		args.wide = 1
+	}
+	for i, x := range frame.sysargs {
+		args.args[i] = C.uintptr_t(x)
+	}
+	for i, x := range frame.floats {
+		*(*uintptr)(unsafe.Pointer(&args.floats[i])) = x
+	}
+	C.syscallN(&args)
+	ret = *(*[maxRetSize]byte)(unsafe.Pointer(&args.result))
+	return ret, uintptr(args.err)
+}
@@ -0,0 +1,94 @@
+package dlopen
+
+import (
+	"sort"
+	"testing"
+	"unsafe"
+)
+
+const libc = "/lib/x86_64-linux-gnu/libc.so.6"
+
+// openLibc opens the C library, which is closed when the test ends.
+func openLibc(t *testing.T) Handle {
+	t.Helper()
+
+	handle, err := GetHandle(libc)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { handle.Close() })
+	return handle
+}
+
+func TestCallbackQsort(t *testing.T) {
+	handle := openLibc(t)
+	qsort := GetSymbolFn[func(base unsafe.Pointer, n, size uintptr, compare func(a, b unsafe.Pointer) int32)](handle, "qsort")
+
+	values := []int32{5, -3, 9, 0, 42, -17, 8}
+	calls := 0
+	compare := func(a, b unsafe.Pointer) int32 {
+		calls++
+		x, y := *(*int32)(a), *(*int32)(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	qsort(unsafe.Pointer(&values[0]), uintptr(len(values)), 4, compare)
+	if !sort.SliceIsSorted(values, func(i, j int) bool { return values[i] < values[j] }) {
+		t.Errorf("values %v are not sorted", values)
+	}
+	if calls == 0 {
+		t.Error("compare was not called")
+	}
+
+	// The same function value reuses its trampoline
+	if NewCallback(compare) != NewCallback(compare) {
+		t.Error("NewCallback returned different pointers for the same function")
+	}
+}
+
+func TestStructResult(t *testing.T) {
+	handle := openLibc(t)
+
+	type ldivT struct{ Quot, Rem int64 }
+	ldiv := GetSymbolFn[func(num, denom int64) ldivT](handle, "ldiv")
+	if got, want := ldiv(-47, 5), (ldivT{-9, -2}); got != want {
+		t.Errorf("ldiv(-47, 5) = %+v, want %+v", got, want)
+	}
+
+	type divT struct{ Quot, Rem int32 }
+	div := GetSymbolFn[func(num, denom int32) divT](handle, "div")
+	if got, want := div(47, 5), (divT{9, 2}); got != want {
+		t.Errorf("div(47, 5) = %+v, want %+v", got, want)
+	}
+}
+
+func TestVariadic(t *testing.T) {
+	handle := openLibc(t)
+	snprintf := GetSymbolFn[func(buf *byte, size uintptr, format string, args ...interface{}) int32](handle, "snprintf")
+
+	tests := []struct {
+		format string
+		args   []interface{}
+		want   string
+	}{
+		{"%d %s", []interface{}{42, "answer"}, "42 answer"},
+		{"%.2f", []interface{}{3.14159}, "3.14"},
+		// Variadic floats are promoted to double
+		{"%.1f %.3f", []interface{}{float32(2.5), 0.125}, "2.5 0.125"},
+		{"%d %.1f %d %.1f", []interface{}{1, 1.5, 2, 2.5}, "1 1.5 2 2.5"},
+		// More doubles than floating-point registers
+		{"%g %g %g %g %g %g %g %g %g %g", []interface{}{1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0}, "1 2 3 4 5 6 7 8 9 10"},
+	}
+	for _, test := range tests {
+		buf := make([]byte, 64)
+		n := snprintf(&buf[0], uintptr(len(buf)), test.format, test.args...)
+		if got := string(buf[:n]); got != test.want {
+			t.Errorf("snprintf(%q) = %q, want %q", test.format, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+//go:build cgo
+
+package dlopen
+
+import (
+	"debug/elf"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"dlopen/symbol"
+)
+
+// Symbol is an exported symbol of a shared object.
+type Symbol struct {
+	Name      string // mangled name as passed to GetSymbolPointer
+	Demangled string // demangled C++ or Rust name with parameters, or Name for C symbols
+	Function  bool   // whether the symbol is a function, otherwise it is an object
+	Value     uint64 // address of the symbol in the shared object
+}
+
+// signature returns the demangled name of s without the return type of template
+// functions, e.g. "ns::add<int>(int, int)" for "int ns::add<int>(int, int)".
+func (s Symbol) signature() string {
+	name := symbol.Filter(s.Name, symbol.NoParams)
+	if i := strings.Index(s.Demangled, name); i > 0 {
+		return s.Demangled[i:]
+	}
+	return s.Demangled
+}
+
+// AmbiguousSymbolError is returned by LookupSymbol if a signature matches more
+// than one symbol.
+type AmbiguousSymbolError struct {
+	Signature  string
+	Candidates []Symbol
+}
+
+func (e *AmbiguousSymbolError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "symbol %q is ambiguous, candidates are:", e.Signature)
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n\t%s (%s)", c.Demangled, c.Name)
+	}
+	return b.String()
+}
+
+// ReadSymbols returns the exported functions and objects of the dynamic symbol
+// table of the ELF shared object at path.
+func ReadSymbols(path string) ([]Symbol, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	syms, err := f.DynamicSymbols()
+	if err != nil {
+		return nil, fmt.Errorf("error reading symbols of %v: %v", path, err)
+	}
+	var symbols []Symbol
+	for _, s := range syms {
+		typ := elf.ST_TYPE(s.Info)
+		bind := elf.ST_BIND(s.Info)
+		if s.Section == elf.SHN_UNDEF || (typ != elf.STT_FUNC && typ != elf.STT_OBJECT) ||
+			(bind != elf.STB_GLOBAL && bind != elf.STB_WEAK) {
+			continue
+		}
+		symbols = append(symbols, Symbol{
+			Name:      s.Name,
+			Demangled: symbol.Filter(s.Name),
+			Function:  typ == elf.STT_FUNC,
+			Value:     s.Value,
+		})
+	}
+	return symbols, nil
+}
+
+// LookupSymbol finds the symbol matching signature in symbols. The signature is
+// either a mangled name, a demangled C++ name with parameters like
+// "ns::Class::method(int, float)" or "ns::Class::get() const", or a name without
+// parameters like "ns::Class::method" or a Rust path like "mycrate::module::func",
+// which matches all overloads. Whitespace in the signature is not significant.
+// If more than one symbol at different addresses matches, the error is an
+// *AmbiguousSymbolError listing the candidates.
+func LookupSymbol(symbols []Symbol, signature string) (Symbol, error) {
+	sig := normalizeSignature(signature)
+	withParams := strings.Contains(sig, "(")
+	var candidates []Symbol
+	for _, s := range symbols {
+		if s.Name == signature {
+			return s, nil
+		}
+		var match bool
+		if withParams {
+			match = normalizeSignature(s.Demangled) == sig || normalizeSignature(s.signature()) == sig
+		} else {
+			match = normalizeSignature(symbol.Filter(s.Name, symbol.NoParams)) == sig ||
+				normalizeSignature(symbol.Filter(s.Name, symbol.NoParams, symbol.NoTemplateParams)) == sig
+		}
+		if match && !containsAddress(candidates, s.Value) {
+			// Aliases like complete and base object constructors share the address
+			candidates = append(candidates, s)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return Symbol{}, fmt.Errorf("symbol %q not found", signature)
+	case 1:
+		return candidates[0], nil
+	}
+	return Symbol{}, &AmbiguousSymbolError{Signature: signature, Candidates: candidates}
+}
+
+func containsAddress(symbols []Symbol, value uint64) bool {
+	for _, s := range symbols {
+		if s.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeSignature removes the whitespace of s which is not between two words,
+// e.g. "ns::f( unsigned  int , char * )" becomes "ns::f(unsigned int,char*)".
+func normalizeSignature(s string) string {
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	var b strings.Builder
+	fields := strings.Fields(s)
+	for i, f := range fields {
+		if i > 0 && isWord(fields[i-1][len(fields[i-1])-1]) && isWord(f[0]) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f)
+	}
+	return b.String()
+}
+
+// symbolReader is implemented by handles which know the path of their library.
+type symbolReader interface {
+	Symbols() ([]Symbol, error)
+}
+
+// GetDemangledSymbolPointer looks up the symbol matching signature like
+// LookupSymbol in the exported symbols of the library of handle and returns a
+// pointer to it.
+func GetDemangledSymbolPointer(handle Handle, signature string) (unsafe.Pointer, error) {
+	r, ok := handle.(symbolReader)
+	if !ok {
+		return nil, fmt.Errorf("error resolving symbol %q: handle cannot list its symbols", signature)
+	}
+	symbols, err := r.Symbols()
+	if err != nil {
+		return nil, err
+	}
+	s, err := LookupSymbol(symbols, signature)
+	if err != nil {
+		return nil, err
+	}
+	return handle.GetSymbolPointer(s.Name)
+}
+
+// GetDemangledSymbolFn is GetSymbolFn with a symbol looked up by signature like
+// GetDemangledSymbolPointer.
+func GetDemangledSymbolFn[Fn any](handle Handle, signature string) Fn {
+	return GetDemangledSymbolTypeFn[Fn](handle, nil, signature)
+}
+
+// GetDemangledSymbolTypeFn is GetSymbolTypeFn with a symbol looked up by
+// signature like GetDemangledSymbolPointer, e.g. for calling C++ methods on the
+// object typePtr.
+func GetDemangledSymbolTypeFn[Fn any](handle Handle, typePtr unsafe.Pointer, signature string) Fn {
+	handlePtr, err := GetDemangledSymbolPointer(handle, signature)
+	if err != nil {
+		panic(err)
+	}
+	return GetFn[Fn](handlePtr, uintptr(typePtr))
+}
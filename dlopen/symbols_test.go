@@ -0,0 +1,110 @@
+//go:build cgo
+
+package dlopen
+
+import (
+	"errors"
+	"testing"
+
+	"dlopen/symbol"
+)
+
+// testSymbol returns the function with the mangled name at value, like ReadSymbols.
+func testSymbol(name string, value uint64) Symbol {
+	return Symbol{Name: name, Demangled: symbol.Filter(name), Function: true, Value: value}
+}
+
+func TestLookupSymbol(t *testing.T) {
+	symbols := []Symbol{
+		testSymbol("puts", 0x1000),
+		testSymbol("_ZN2ns3addEii", 0x1010),       // ns::add(int, int)
+		testSymbol("_ZN2ns3addEdd", 0x1020),       // ns::add(double, double)
+		testSymbol("_ZNK2ns5Class3getEv", 0x1030), // ns::Class::get() const
+		testSymbol("_ZN2ns5Class3getEv", 0x1040),  // ns::Class::get()
+		// The complete and base object constructors are aliases
+		testSymbol("_ZN2ns5ClassC1Ei", 0x1050),                          // ns::Class::Class(int)
+		testSymbol("_ZN2ns5ClassC2Ei", 0x1050),                          // ns::Class::Class(int)
+		testSymbol("_ZN2ns3maxIiEET_S1_S1_", 0x1060),                    // int ns::max<int>(int, int)
+		testSymbol("_RNvNtCs1234_7mycrate6module3run", 0x1070),          // mycrate::module::run
+		testSymbol("_ZN5other6module4func17h0123456789abcdefE", 0x1080), // other::module::func
+	}
+
+	tests := []struct {
+		signature string
+		want      string
+	}{
+		{"puts", "puts"},
+		{"_ZN2ns3addEdd", "_ZN2ns3addEdd"},
+		{"ns::add(int, int)", "_ZN2ns3addEii"},
+		{"ns::add( double,double )", "_ZN2ns3addEdd"},
+		{"ns::Class::get() const", "_ZNK2ns5Class3getEv"},
+		{"ns::Class::get()", "_ZN2ns5Class3getEv"},
+		{"ns::Class::Class(int)", "_ZN2ns5ClassC1Ei"},
+		{"ns::Class::Class", "_ZN2ns5ClassC1Ei"},
+		{"ns::max<int>(int, int)", "_ZN2ns3maxIiEET_S1_S1_"},
+		{"int ns::max<int>(int, int)", "_ZN2ns3maxIiEET_S1_S1_"},
+		{"ns::max<int>", "_ZN2ns3maxIiEET_S1_S1_"},
+		{"ns::max", "_ZN2ns3maxIiEET_S1_S1_"},
+		{"mycrate::module::run", "_RNvNtCs1234_7mycrate6module3run"},
+		{"other::module::func", "_ZN5other6module4func17h0123456789abcdefE"},
+	}
+	for _, test := range tests {
+		s, err := LookupSymbol(symbols, test.signature)
+		if err != nil {
+			t.Errorf("LookupSymbol(%q): %v", test.signature, err)
+			continue
+		}
+		if s.Name != test.want {
+			t.Errorf("LookupSymbol(%q) = %s, want %s", test.signature, s.Name, test.want)
+		}
+	}
+
+	ambiguous := []struct {
+		signature  string
+		candidates []string
+	}{
+		{"ns::add", []string{"_ZN2ns3addEii", "_ZN2ns3addEdd"}},
+		{"ns::Class::get", []string{"_ZNK2ns5Class3getEv", "_ZN2ns5Class3getEv"}},
+	}
+	for _, test := range ambiguous {
+		_, err := LookupSymbol(symbols, test.signature)
+		var ambiguousErr *AmbiguousSymbolError
+		if !errors.As(err, &ambiguousErr) {
+			t.Errorf("LookupSymbol(%q): error %v, want *AmbiguousSymbolError", test.signature, err)
+			continue
+		}
+		if ambiguousErr.Signature != test.signature || len(ambiguousErr.Candidates) != len(test.candidates) {
+			t.Errorf("LookupSymbol(%q): %v", test.signature, err)
+			continue
+		}
+		for i, c := range ambiguousErr.Candidates {
+			if c.Name != test.candidates[i] {
+				t.Errorf("LookupSymbol(%q): candidate %d is %s, want %s", test.signature, i, c.Name, test.candidates[i])
+			}
+		}
+	}
+
+	for _, signature := range []string{"ns::sub", "ns::add(long)", "ns::Class::get() volatile", "mycrate::module"} {
+		if s, err := LookupSymbol(symbols, signature); err == nil {
+			t.Errorf("LookupSymbol(%q) = %s, want not found", signature, s.Name)
+		}
+	}
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ns::f", "ns::f"},
+		{"  ns::f( unsigned  int , char * )", "ns::f(unsigned int,char*)"},
+		{"ns::Class::get ( ) const", "ns::Class::get()const"},
+		{"int ns::max<int>(int, int)", "int ns::max<int>(int,int)"},
+		{"std::vector<std::pair<int, long> >::size() const", "std::vector<std::pair<int,long>>::size()const"},
+		{"f(long long, unsigned long\tlong)", "f(long long,unsigned long long)"},
+	}
+	for _, test := range tests {
+		if got := normalizeSignature(test.in); got != test.want {
+			t.Errorf("normalizeSignature(%q) = %q, want %q", test.in, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+#include <stdint.h>
+#include <string.h>
+#include "_cgo_export.h"
+
+// The trampolines receive the registers and stack slots the same way syscallN
+// passes them and hand them to dlopenCallback.
+#if defined(__x86_64__)
+#define CALLBACK_PARAMS uintptr_t a0, uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, \
+	double f0, double f1, double f2, double f3, double f4, double f5, double f6, double f7, \
+	uintptr_t a6, uintptr_t a7, uintptr_t a8, uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12, uintptr_t a13, uintptr_t a14
+#elif defined(__aarch64__)
+#define CALLBACK_PARAMS uintptr_t a0, uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, uintptr_t a6, uintptr_t a7, \
+	double f0, double f1, double f2, double f3, double f4, double f5, double f6, double f7, \
+	uintptr_t a8, uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12, uintptr_t a13, uintptr_t a14
+#else
+#define CALLBACK_PARAMS uintptr_t a0, uintptr_t a1, uintptr_t a2, uintptr_t a3, uintptr_t a4, uintptr_t a5, uintptr_t a6, uintptr_t a7, \
+	uintptr_t a8, uintptr_t a9, uintptr_t a10, uintptr_t a11, uintptr_t a12, uintptr_t a13, uintptr_t a14, \
+	double f0, double f1, double f2, double f3, double f4, double f5, double f6, double f7
+#endif
+
+static uintptr_t callback(int index, uintptr_t *args, double *floats) {
+	callbackArgs c;
+	memcpy(c.args, args, sizeof(c.args));
+	memcpy(c.floats, floats, sizeof(c.floats));
+	c.result = 0;
+	dlopenCallback(index, &c);
+	return c.result;
+}
+
+#define CALLBACK_ARGS \
+	uintptr_t args[15] = {a0, a1, a2, a3, a4, a5, a6, a7, a8, a9, a10, a11, a12, a13, a14}; \
+	double floats[8] = {f0, f1, f2, f3, f4, f5, f6, f7};
+
+#define TRAMPOLINE(x, y, z) \
+static uintptr_t callbackInt##x##y##z(CALLBACK_PARAMS) { \
+	CALLBACK_ARGS \
+	return callback(x * 100 + y * 10 + z, args, floats); \
+} \
+static double callbackFloat##x##y##z(CALLBACK_PARAMS) { \
+	CALLBACK_ARGS \
+	uintptr_t r = callback(x * 100 + y * 10 + z, args, floats); \
+	double d; \
+	memcpy(&d, &r, sizeof(d)); \
+	return d; \
+}
+#define INT_POINTER(x, y, z) (void *)callbackInt##x##y##z,
+#define FLOAT_POINTER(x, y, z) (void *)callbackFloat##x##y##z,
+
+#define REPEAT10(M, x, y) M(x, y, 0) M(x, y, 1) M(x, y, 2) M(x, y, 3) M(x, y, 4) \
+	M(x, y, 5) M(x, y, 6) M(x, y, 7) M(x, y, 8) M(x, y, 9)
+#define REPEAT100(M, x) REPEAT10(M, x, 0) REPEAT10(M, x, 1) REPEAT10(M, x, 2) REPEAT10(M, x, 3) REPEAT10(M, x, 4) \
+	REPEAT10(M, x, 5) REPEAT10(M, x, 6) REPEAT10(M, x, 7) REPEAT10(M, x, 8) REPEAT10(M, x, 9)
+#define REPEAT1000(M) REPEAT100(M, 0) REPEAT100(M, 1) REPEAT100(M, 2) REPEAT100(M, 3) REPEAT100(M, 4) \
+	REPEAT100(M, 5) REPEAT100(M, 6) REPEAT100(M, 7) REPEAT100(M, 8) REPEAT100(M, 9)
+
+REPEAT1000(TRAMPOLINE)
+
+static void *callbackInts[] = { REPEAT1000(INT_POINTER) };
+static void *callbackFloats[] = { REPEAT1000(FLOAT_POINTER) };
+
+void *callbackPointer(int index, int isFloat) {
+	return isFloat ? callbackFloats[index] : callbackInts[index];
+}
@@ -0,0 +1,80 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// LevelEntry 目录中的日志等级
+type LevelEntry struct {
+	Level LogLevel `json:"level"`
+	Name  string   `json:"name"`
+	File  string   `json:"file,omitempty"` // 日志文件
+}
+
+// CodeEntry 目录中的日志代码
+type CodeEntry struct {
+	Code        LogCode  `json:"code"`
+	Level       LogLevel `json:"level"`
+	LevelName   string   `json:"level_name"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Catalog 已注册的日志等级和代码
+type Catalog struct {
+	Levels []LevelEntry `json:"levels"`
+	Codes  []CodeEntry  `json:"codes"`
+}
+
+// Registry 导出已注册的日志等级和代码, 按数值排序
+func Registry() Catalog {
+	var c Catalog
+	Std.LogMap.Range(func(_, v any) bool {
+		info := v.(*LogInfo)
+		entry := LevelEntry{Level: info.Level, Name: info.Name}
+		if info.LogFile != nil {
+			entry.File = info.LogFile.Name()
+		}
+		c.Levels = append(c.Levels, entry)
+		return true
+	})
+	Std.LogCode.Range(func(k, v any) bool {
+		code, level := k.(LogCode), v.(LogLevel)
+		c.Codes = append(c.Codes, CodeEntry{Code: code, Level: level, LevelName: level.String(), Description: code.Description()})
+		return true
+	})
+	sort.Slice(c.Levels, func(i, j int) bool { return c.Levels[i].Level < c.Levels[j].Level })
+	sort.Slice(c.Codes, func(i, j int) bool { return c.Codes[i].Code < c.Codes[j].Code })
+	return c
+}
+
+// WriteJSON 写入 JSON 格式的目录
+func (c Catalog) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+// WriteMarkdown 写入 Markdown 表格格式的目录
+func (c Catalog) WriteMarkdown(w io.Writer) error {
+	buffer := Std.Pool.Get()
+	defer buffer.Free()
+	buffer.WriteString("# 日志代码目录\n\n## 日志等级\n\n| 等级 | 名称 | 日志文件 |\n| ---: | --- | --- |\n")
+	for _, e := range c.Levels {
+		buffer.WriteString(fmt.Sprintf("| %d | %s | %s |\n", e.Level, markdownCell(e.Name), markdownCell(e.File)))
+	}
+	buffer.WriteString("\n## 日志代码\n\n| 代码 | 等级 | 说明 |\n| ---: | --- | --- |\n")
+	for _, e := range c.Codes {
+		buffer.WriteString(fmt.Sprintf("| %d | %s | %s |\n", e.Code, markdownCell(e.LevelName), markdownCell(e.Description)))
+	}
+	_, err := w.Write(buffer.Bytes())
+	return err
+}
+
+// markdownCell 转义表格单元格中的竖线和换行
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
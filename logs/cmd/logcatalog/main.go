@@ -0,0 +1,113 @@
+// Command logcatalog 导出 Go 包注册的日志等级和代码目录, 例如提供给运维支持
+//
+// 用法:
+//
+//	logcatalog -format md -o codes.md ./errcode ./service/...
+//
+// 日志代码由 LogLevel.NewCode 在运行时注册, 因此 logcatalog 在当前模块中编译并运行
+// 一个导入指定包的临时程序, 由其写入 logs.Registry(). 代码说明由 LogCode.Describe 设置
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	format := flag.String("format", "md", "输出格式: md 或 json")
+	importPath := flag.String("import", "logs", "logs 包的导入路径")
+	output := flag.String("o", "", "输出文件 (默认标准输出)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: logcatalog [flags] packages\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var method string
+	switch *format {
+	case "md", "markdown":
+		method = "WriteMarkdown"
+	case "json":
+		method = "WriteJSON"
+	default:
+		fatalf("unknown format %q", *format)
+	}
+
+	pkgs, err := goList(flag.Args())
+	if err != nil {
+		fatalf("%v", err)
+	}
+	out, err := run(program(*importPath, method, pkgs))
+	if err != nil {
+		fatalf("%v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(out)
+		return
+	}
+	if err := os.WriteFile(*output, out, 0o644); err != nil {
+		fatalf("%v", err)
+	}
+}
+
+// goList 将包模式解析为导入路径, 跳过无法导入的 main 包
+func goList(patterns []string) ([]string, error) {
+	args := []string{"list", "-f", `{{if ne .Name "main"}}{{.ImportPath}}{{end}}`, "--"}
+	cmd := exec.Command("go", append(args, patterns...)...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v", err)
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// program 生成写入目录的程序源码
+func program(importPath, method string, pkgs []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n")
+	fmt.Fprintf(&b, "\tlogs %s\n", strconv.Quote(importPath))
+	for _, pkg := range pkgs {
+		if pkg != importPath {
+			fmt.Fprintf(&b, "\t_ %s\n", strconv.Quote(pkg))
+		}
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+	fmt.Fprintf(&b, "\tif err := logs.Registry().%s(os.Stdout); err != nil {\n", method)
+	b.WriteString("\t\tfmt.Fprintln(os.Stderr, err)\n\t\tos.Exit(1)\n\t}\n}\n")
+	return b.Bytes()
+}
+
+// run 在当前模块的临时目录中编译并运行程序, 返回其输出
+func run(src []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp(".", "_logcatalog")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src, 0o644); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("go", "run", "."+string(filepath.Separator)+filepath.Base(dir))
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go run: %v", err)
+	}
+	return out, nil
+}
+
+// fatalf 输出错误并退出
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "logcatalog: "+format+"\n", args...)
+	os.Exit(1)
+}
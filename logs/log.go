@@ -1,8 +1,13 @@
 package logs
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
+	"sync/atomic"
+	"time"
 )
 
 // LogLevel 日志等级
@@ -22,7 +27,33 @@ var (
 type LogInfo struct {
 	Name    string
 	Level   LogLevel
-	LogFile *os.File
+	LogFile *os.File // 未设置输出时以文本写入该文件
+
+	sink atomic.Value // sinkHolder
+}
+
+type sinkHolder struct {
+	Sink
+	isDefault bool     // 写入 LogFile 的默认输出
+	file      *os.File // 默认输出写入的文件, LogFile 修改后重新创建
+}
+
+// Sink 日志输出, 未设置时为写入 LogFile 的文本输出, 首次使用时创建
+func (info *LogInfo) Sink() Sink {
+	old := info.sink.Load()
+	if h, ok := old.(sinkHolder); ok && h.Sink != nil && (!h.isDefault || h.file == info.LogFile) {
+		return h.Sink
+	}
+	h := sinkHolder{NewTextSink(info.LogFile), true, info.LogFile}
+	if !info.sink.CompareAndSwap(old, h) {
+		return info.Sink()
+	}
+	return h.Sink
+}
+
+// SetSink 设置日志输出, nil 恢复为写入 LogFile
+func (info *LogInfo) SetSink(sink Sink) {
+	info.sink.Store(sinkHolder{Sink: sink})
 }
 
 // NewLevel 创建新日志等级
@@ -37,6 +68,21 @@ func NewLevel(Level LogLevel, Name string, File *os.File) LogLevel {
 	panic(fmt.Sprintf("函数 NewLevel(Level:%d Name:%s File%s) 重复创建", Level, Name, File.Name()))
 }
 
+// SetSink 设置该等级的日志输出
+func (level LogLevel) SetSink(sink Sink) {
+	if info := level.Info(); info != nil {
+		info.SetSink(sink)
+	}
+}
+
+// SetSink 设置所有等级的日志输出
+func SetSink(sink Sink) {
+	Std.LogMap.Range(func(_, info any) bool {
+		info.(*LogInfo).SetSink(sink)
+		return true
+	})
+}
+
 // NewCode 创建新日志代码
 func (Level LogLevel) NewCode(Code LogCode) LogCode {
 	if _, ok := Std.LogCode.Load(Code); !ok {
@@ -83,40 +129,137 @@ func (code LogCode) String() string {
 	return fmt.Sprintf("Code:%d Level:%s", code, code.Level().String())
 }
 
-// Print 写入信息
+// Describe 设置代码说明, 用于导出代码目录
+func (code LogCode) Describe(text string) LogCode {
+	Std.LogDesc.Store(code, text)
+	return code
+}
+
+// Description 代码说明
+func (code LogCode) Description() string {
+	if text, ok := Std.LogDesc.Load(code); ok {
+		return text.(string)
+	}
+	return ""
+}
+
+// Is 错误链中是否有该代码的 LogDetails
+func (code LogCode) Is(err error) bool {
+	return errors.Is(err, LogDetails{Code: code})
+}
+
+// Print 写入信息, Field 类型的参数写为键值字段
 func (code LogCode) Print(a ...any) {
-	code.Info().LogFile.WriteString(fmt.Sprintf("%s,Details:%v\n", code.String(), a))
+	code.output(2, a)
+}
+
+// output 写入日志记录, skip 为调用位置相对 output 的层数
+func (code LogCode) output(skip int, a []any) {
+	r := &Record{Time: time.Now(), Code: code, Level: code.Level()}
+	for _, v := range a {
+		if field, ok := v.(Field); ok {
+			r.Fields = append(r.Fields, field)
+		} else {
+			r.Details = append(r.Details, v)
+		}
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) > 0 {
+		r.PC = pcs[0]
+	}
+	info := r.Level.Info()
+	if info == nil {
+		info = UnknownLevel.Info()
+	}
+	if err := info.Sink().Write(r); err != nil {
+		fmt.Fprintf(os.Stderr, "logs: %v\n", err)
+	}
 }
 
 // Error 输出错误
 func (code LogCode) Error(err error) {
-	code.Print(err.Error())
+	code.output(2, []any{err.Error()})
 }
 
 // IsError 检测错误
 func (code LogCode) IsError(err error, info ...string) {
 	if err != nil {
-		code.Print(fmt.Sprintf("%s,info:%v\n", err.Error(), info))
+		code.output(2, []any{fmt.Sprintf("%s,info:%v", err.Error(), info)})
 	}
 }
 
 // Defer 拦截错误
 func (code LogCode) Defer() {
 	if err := recover(); err != nil {
-		code.Print(err)
+		code.output(2, []any{err})
 	}
 }
 
-// New 构建错误
+// New 构建错误, 记录调用栈
 func (code LogCode) New(a any, Info ...any) error {
-	return &LogDetails{Code: code, Details: a, Info: Info}
+	var pcs [32]uintptr
+	n := runtime.Callers(2, pcs[:])
+	return &LogDetails{Code: code, Details: a, Info: Info, Stack: append([]uintptr(nil), pcs[:n]...)}
 }
 
 // LogDetails 日志记录
 type LogDetails struct {
-	Code    LogCode // 日志代码
-	Details any     // 日志内容
-	Info    []any   // 附加信息
+	Code    LogCode   // 日志代码
+	Details any       // 日志内容, 为 error 时作为被包装的错误
+	Info    []any     // 附加信息
+	Stack   []uintptr // 构建时的调用栈
+}
+
+// Unwrap 内容为 error 时返回该错误, 用于 errors.Is/As
+func (log LogDetails) Unwrap() error {
+	if err, ok := log.Details.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Is 目标为相同代码的 LogDetails 时匹配, 不比较内容, 用于 errors.Is
+func (log LogDetails) Is(target error) bool {
+	switch v := target.(type) {
+	case *LogDetails:
+		return v != nil && v.Code == log.Code
+	case LogDetails:
+		return v.Code == log.Code
+	}
+	return false
+}
+
+// StackTrace 构建时的调用栈
+func (log LogDetails) StackTrace() []runtime.Frame {
+	var list []runtime.Frame
+	frames := runtime.CallersFrames(log.Stack)
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			list = append(list, frame)
+		}
+		if !more {
+			return list
+		}
+	}
+}
+
+// MarshalJSON 编码为 JSON, 内容为 LogDetails 时嵌套编码整个错误链
+func (log LogDetails) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Code    LogCode           `json:"code"`
+		Level   string            `json:"level"`
+		Info    []json.RawMessage `json:"info,omitempty"`
+		Details json.RawMessage   `json:"details"`
+		Stack   []string          `json:"stack,omitempty"`
+	}{Code: log.Code, Level: log.Code.Level().String(), Details: jsonRaw(log.Details)}
+	for _, info := range log.Info {
+		v.Info = append(v.Info, jsonRaw(info))
+	}
+	for _, frame := range log.StackTrace() {
+		v.Stack = append(v.Stack, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+	}
+	return json.Marshal(v)
 }
 
 func (log LogDetails) Error() string {
@@ -156,9 +299,9 @@ func IfPrint(is bool, err error) {
 	if is && err != nil {
 		switch v := err.(type) {
 		case LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		case *LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		default:
 			print(v)
 		}
@@ -170,9 +313,9 @@ func Print(err error) {
 	if err != nil {
 		switch v := err.(type) {
 		case LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		case *LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		default:
 			print(v)
 		}
@@ -184,9 +327,9 @@ func Panic(err error) {
 	if err != nil {
 		switch v := err.(type) {
 		case LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		case *LogDetails:
-			v.Code.Error(v)
+			v.Code.output(2, []any{v.Error()})
 		default:
 			panic(v)
 		}
@@ -0,0 +1,260 @@
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateFile 按大小和/或时间切分的日志文件.
+// 切分后的文件命名为 name-20060102T150405.000.ext, 可选 gzip 压缩, 按数量和时间清理.
+// 压缩在后台进行, 其错误由下次切分的 Write, Rotate 或 Close 返回.
+type RotateFile struct {
+	Filename   string        // 日志文件路径
+	MaxSize    int64         // 文件超过该字节数时切分, 0 不按大小切分
+	Interval   time.Duration // 按时间切分的周期(以 UTC 对齐), 0 不按时间切分
+	MaxBackups int           // 保留的切分文件数, 0 不限制
+	MaxAge     time.Duration // 切分文件保留时长, 0 不限制
+	Compress   bool          // gzip 压缩切分文件
+	Perm       os.FileMode   // 新建文件权限, 0 为 0644
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	deadline time.Time // 下次按时间切分的时间
+	mill     sync.WaitGroup
+	millMu   sync.Mutex
+	errMu    sync.Mutex
+	millErr  error // 后台压缩的错误
+}
+
+// NewRotateFile 创建切分文件
+func NewRotateFile(filename string, maxSize int64, interval time.Duration) *RotateFile {
+	return &RotateFile{Filename: filename, MaxSize: maxSize, Interval: interval}
+}
+
+// Write 写入数据, 需要时先切分
+func (f *RotateFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := time.Now()
+	var rotateErr error
+	if f.size > 0 && (f.MaxSize > 0 && f.size+int64(len(p)) > f.MaxSize ||
+		f.Interval > 0 && !now.Before(f.deadline)) {
+		// 只有压缩出错时仍写入新文件
+		if rotateErr = f.rotate(now); f.file == nil {
+			return 0, rotateErr
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Rotate 立即切分
+func (f *RotateFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	return f.rotate(time.Now())
+}
+
+// Close 关闭文件并等待压缩和清理完成
+func (f *RotateFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mill.Wait()
+	err := f.takeMillErr()
+	if f.file == nil {
+		return err
+	}
+	if e := f.file.Close(); e != nil {
+		err = e
+	}
+	f.file = nil
+	return err
+}
+
+// takeMillErr 取出后台压缩的错误
+func (f *RotateFile) takeMillErr() error {
+	f.errMu.Lock()
+	defer f.errMu.Unlock()
+	err := f.millErr
+	f.millErr = nil
+	return err
+}
+
+// open 打开或创建日志文件
+func (f *RotateFile) open() error {
+	perm := f.Perm
+	if perm == 0 {
+		perm = 0o644
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, perm)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	if f.Interval > 0 {
+		// 已有文件从其修改时间所在周期开始
+		f.deadline = info.ModTime().Truncate(f.Interval).Add(f.Interval)
+		if info.Size() == 0 {
+			f.deadline = time.Now().Truncate(f.Interval).Add(f.Interval)
+		}
+	}
+	return nil
+}
+
+// rotate 将当前文件改名为切分文件并新建文件, 返回之前后台压缩的错误
+func (f *RotateFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	backup := f.backupName(now)
+	for t := now; fileExists(backup) || fileExists(backup+".gz"); {
+		t = t.Add(time.Millisecond)
+		backup = f.backupName(t)
+	}
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	if f.Interval > 0 {
+		f.deadline = now.Truncate(f.Interval).Add(f.Interval)
+	}
+	f.mill.Add(1)
+	go func() {
+		defer f.mill.Done()
+		f.millMu.Lock()
+		defer f.millMu.Unlock()
+		if f.Compress {
+			if err := compressFile(backup); err != nil {
+				f.errMu.Lock()
+				if f.millErr == nil {
+					f.millErr = err
+				}
+				f.errMu.Unlock()
+			}
+		}
+		f.cleanup(now)
+	}()
+	return f.takeMillErr()
+}
+
+// backupName 切分文件名
+func (f *RotateFile) backupName(t time.Time) string {
+	dir, name := filepath.Split(f.Filename)
+	ext := filepath.Ext(name)
+	return filepath.Join(dir, strings.TrimSuffix(name, ext)+"-"+t.Format(backupTimeFormat)+ext)
+}
+
+func fileExists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// backups 已有的切分文件, 按时间从新到旧
+func (f *RotateFile) backups() ([]string, []time.Time) {
+	dir, name := filepath.Split(f.Filename)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil
+	}
+	type backup struct {
+		name string
+		time time.Time
+	}
+	var list []backup
+	for _, e := range entries {
+		s := strings.TrimSuffix(e.Name(), ".gz")
+		if e.IsDir() || !strings.HasPrefix(s, prefix) || !strings.HasSuffix(s, ext) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(s, prefix), ext), time.Local)
+		if err != nil {
+			continue
+		}
+		list = append(list, backup{filepath.Join(dir, e.Name()), t})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].time.After(list[j].time) })
+	names := make([]string, len(list))
+	times := make([]time.Time, len(list))
+	for i, b := range list {
+		names[i], times[i] = b.name, b.time
+	}
+	return names, times
+}
+
+// cleanup 按数量和时间删除切分文件
+func (f *RotateFile) cleanup(now time.Time) {
+	if f.MaxBackups <= 0 && f.MaxAge <= 0 {
+		return
+	}
+	names, times := f.backups()
+	for i, name := range names {
+		if f.MaxBackups > 0 && i >= f.MaxBackups || f.MaxAge > 0 && now.Sub(times[i]) > f.MaxAge {
+			os.Remove(name)
+		}
+	}
+}
+
+// compressFile 将文件压缩为 .gz 并删除原文件
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,200 @@
+package logs
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFile 读取文件, .gz 文件先解压
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = zr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func writeString(t *testing.T, f *RotateFile, s string) {
+	t.Helper()
+	if n, err := f.Write([]byte(s)); err != nil || n != len(s) {
+		t.Fatalf("Write(%q) = %d, %v", s, n, err)
+	}
+}
+
+func TestRotateFileSize(t *testing.T) {
+	f := NewRotateFile(filepath.Join(t.TempDir(), "logs", "app.log"), 10, 0)
+	defer f.Close()
+	for _, s := range []string{"one\n", "two\n", "three\n", "six\n", "0123456789abc\n"} {
+		writeString(t, f, s)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 恰好达到 MaxSize 时不切分, 超过大小的单条写入不切分空文件
+	names, _ := f.backups()
+	var got []string
+	for i := len(names) - 1; i >= 0; i-- {
+		got = append(got, readFile(t, names[i]))
+	}
+	got = append(got, readFile(t, f.Filename))
+	want := []string{"one\ntwo\n", "three\nsix\n", "0123456789abc\n"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("files %q, want %q", got, want)
+	}
+}
+
+func TestRotateFileInterval(t *testing.T) {
+	f := NewRotateFile(filepath.Join(t.TempDir(), "app.log"), 0, 50*time.Millisecond)
+	defer f.Close()
+	writeString(t, f, "first\n")
+	time.Sleep(time.Until(f.deadline) + 10*time.Millisecond)
+	writeString(t, f, "second\n")
+
+	names, _ := f.backups()
+	if len(names) != 1 || readFile(t, names[0]) != "first\n" {
+		t.Fatalf("backups %v, want one of the first period", names)
+	}
+	if got := readFile(t, f.Filename); got != "second\n" {
+		t.Errorf("file %q, want %q", got, "second\n")
+	}
+	if !f.deadline.After(time.Now()) {
+		t.Errorf("deadline %v is not in the next period", f.deadline)
+	}
+}
+
+func TestRotateFileNames(t *testing.T) {
+	dir := t.TempDir()
+	f := &RotateFile{Filename: filepath.Join(dir, "app.log"), Compress: true}
+	defer f.Close()
+
+	// 同一毫秒内的切分使用不同的文件名, 包括已压缩的文件
+	const n = 5
+	for i := 0; i < n; i++ {
+		writeString(t, f, "line\n")
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	names, times := f.backups()
+	if len(names) != n {
+		t.Fatalf("%d backups %v, want %d", len(names), names, n)
+	}
+	for i, name := range names {
+		if !strings.HasPrefix(filepath.Base(name), "app-") || !strings.HasSuffix(name, ".log.gz") {
+			t.Errorf("backup name %q", name)
+		}
+		if got := readFile(t, name); got != "line\n" {
+			t.Errorf("backup %s = %q", name, got)
+		}
+		if i > 0 && !times[i].Before(times[i-1]) {
+			t.Errorf("backup times %v are not distinct", times)
+		}
+		if fileExists(strings.TrimSuffix(name, ".gz")) {
+			t.Errorf("compressed backup %s was not removed", name)
+		}
+	}
+}
+
+func TestRotateFileCleanup(t *testing.T) {
+	dir := t.TempDir()
+	f := &RotateFile{Filename: filepath.Join(dir, "app.log"), MaxBackups: 2, MaxAge: time.Hour}
+	defer f.Close()
+
+	// 过期的切分文件和其它文件
+	old := f.backupName(time.Now().Add(-2 * time.Hour))
+	for _, name := range []string{old, filepath.Join(dir, "app-notatime.log"), filepath.Join(dir, "other.log")} {
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	f.mill.Wait()
+	if fileExists(old) {
+		t.Error("the backup older than MaxAge was not removed")
+	}
+
+	for i := 0; i < 3; i++ {
+		writeString(t, f, strings.Repeat("x", i+1))
+		if err := f.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	names, _ := f.backups()
+	if len(names) != 2 || readFile(t, names[0]) != "xxx" || readFile(t, names[1]) != "xx" {
+		t.Errorf("backups %v, want the 2 newest", names)
+	}
+	for _, name := range []string{"app-notatime.log", "other.log"} {
+		if !fileExists(filepath.Join(dir, name)) {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+func TestRotateFileCompressError(t *testing.T) {
+	f := &RotateFile{Filename: filepath.Join(t.TempDir(), "app.log"), MaxSize: 4, Compress: true}
+	defer f.Close()
+
+	// 在压缩前删除切分文件
+	failCompress := func(rotate func() error) error {
+		f.millMu.Lock()
+		err := rotate()
+		names, _ := f.backups()
+		for _, name := range names {
+			os.Remove(name)
+		}
+		f.millMu.Unlock()
+		f.mill.Wait()
+		return err
+	}
+
+	writeString(t, f, "one\n")
+	if err := failCompress(f.Rotate); err != nil {
+		t.Fatal(err)
+	}
+	// 下次切分返回错误, 数据仍然写入
+	writeString(t, f, "two\n")
+	if n, err := f.Write([]byte("three\n")); !os.IsNotExist(err) || n != 6 {
+		t.Errorf("Write after a compression error = %d, %v, want 6 and the compression error", n, err)
+	}
+	if got := readFile(t, f.Filename); got != "three\n" {
+		t.Errorf("file %q, want %q", got, "three\n")
+	}
+
+	if err := failCompress(f.Rotate); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); !os.IsNotExist(err) {
+		t.Errorf("Close after a compression error = %v, want the compression error", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Field 日志键值字段
+type Field struct {
+	Key   string
+	Value any
+}
+
+// KV 创建日志字段, 作为 Print 的参数时写入字段而不是内容
+func KV(key string, value any) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Record 一条日志记录
+type Record struct {
+	Time    time.Time
+	Code    LogCode
+	Level   LogLevel
+	Details []any   // 日志内容
+	Fields  []Field // 键值字段
+	PC      uintptr // 调用位置, 0 表示未知
+}
+
+// Caller 调用位置
+func (r *Record) Caller() runtime.Frame {
+	if r.PC == 0 {
+		return runtime.Frame{}
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return frame
+}
+
+// Sink 日志输出
+type Sink interface {
+	Write(r *Record) error
+}
+
+// SinkFunc 函数形式的日志输出
+type SinkFunc func(r *Record) error
+
+// Write 写入日志
+func (fn SinkFunc) Write(r *Record) error {
+	return fn(r)
+}
+
+// MultiSink 同时写入多个输出, 返回第一个错误
+func MultiSink(sinks ...Sink) Sink {
+	return SinkFunc(func(r *Record) error {
+		var err error
+		for _, sink := range sinks {
+			if e := sink.Write(r); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	})
+}
+
+// TextSink 文本日志输出, 每条一行:
+//
+//	2006-01-02 15:04:05.000 Code:1 Level:InfoLevel,Details:[...] key=value caller=dir/file.go:12
+type TextSink struct {
+	TimeFormat string // 时间格式, 为空时不输出时间
+	NoCaller   bool   // 不输出调用位置
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTextSink 创建文本日志输出
+func NewTextSink(w io.Writer) *TextSink {
+	return &TextSink{TimeFormat: "2006-01-02 15:04:05.000", w: w}
+}
+
+// Write 写入日志
+func (s *TextSink) Write(r *Record) error {
+	buffer := Std.Pool.Get()
+	defer buffer.Free()
+	if s.TimeFormat != "" {
+		buffer.WriteString(r.Time.Format(s.TimeFormat))
+		buffer.WriteString(" ")
+	}
+	buffer.WriteString(r.Code.String())
+	buffer.WriteString(",Details:")
+	buffer.WriteString(fmt.Sprintf("%v", r.Details))
+	for _, field := range r.Fields {
+		buffer.WriteString(" ")
+		buffer.WriteString(field.Key)
+		buffer.WriteString("=")
+		buffer.WriteTypeString(field.Value)
+	}
+	if !s.NoCaller {
+		if frame := r.Caller(); frame.File != "" {
+			buffer.WriteString(" caller=")
+			buffer.WriteString(shortFile(frame.File))
+			buffer.WriteString(":")
+			buffer.WriteString(strconv.Itoa(frame.Line))
+		}
+	}
+	buffer.WriteString("\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buffer.Bytes())
+	return err
+}
+
+// shortFile 文件名及其所在目录
+func shortFile(file string) string {
+	dir, name := filepath.Split(file)
+	return filepath.Join(filepath.Base(dir), name)
+}
+
+// JSONSink JSON 日志输出, 每条一行 JSON 对象, 字段与 time, level, code, details, caller 并列
+type JSONSink struct {
+	NoCaller bool // 不输出调用位置
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONSink 创建 JSON 日志输出
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+// Write 写入日志
+func (s *JSONSink) Write(r *Record) error {
+	buffer := Std.Pool.Get()
+	defer buffer.Free()
+	writeKey := func(key string) {
+		if buffer.Len() > 1 {
+			buffer.WriteString(",")
+		}
+		data, _ := json.Marshal(key)
+		buffer.Write(data)
+		buffer.WriteString(":")
+	}
+	writeValue := func(key string, value any) {
+		writeKey(key)
+		buffer.Write(jsonRaw(value))
+	}
+	buffer.WriteString("{")
+	writeValue("time", r.Time.Format(time.RFC3339Nano))
+	writeValue("level", r.Level.String())
+	writeValue("code", uint64(r.Code))
+	details := make([]json.RawMessage, len(r.Details))
+	for i, v := range r.Details {
+		details[i] = jsonRaw(v)
+	}
+	if len(details) == 1 {
+		writeValue("details", details[0])
+	} else {
+		writeValue("details", details)
+	}
+	if !s.NoCaller {
+		if frame := r.Caller(); frame.File != "" {
+			writeValue("caller", shortFile(frame.File)+":"+strconv.Itoa(frame.Line))
+		}
+	}
+	for _, field := range r.Fields {
+		writeValue(field.Key, field.Value)
+	}
+	buffer.WriteString("}\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.w.Write(buffer.Bytes())
+	return err
+}
+
+// jsonValue 错误转为字符串, 其余值原样编码
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case json.Marshaler:
+		return v
+	case error:
+		return v.Error()
+	}
+	return v
+}
+
+// jsonRaw 编码值, 无法编码时编码其字符串形式
+func jsonRaw(v any) json.RawMessage {
+	data, err := json.Marshal(jsonValue(v))
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return data
+}
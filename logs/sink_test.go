@@ -0,0 +1,166 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testRecord 未注册代码的日志记录, 调用位置为调用者
+func testRecord(details ...any) (*Record, string) {
+	pc, file, line, _ := runtime.Caller(1)
+	r := &Record{
+		Time:    time.Date(2024, 5, 6, 7, 8, 9, 10e6, time.UTC),
+		Code:    9999,
+		Level:   UnknownLevel,
+		Details: details,
+		Fields:  []Field{KV("user", "peach"), KV("ok", true)},
+		PC:      pc,
+	}
+	return r, filepath.Join(filepath.Base(filepath.Dir(file)), filepath.Base(file)) + ":" + strconv.Itoa(line)
+}
+
+func TestTextSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewTextSink(&buf)
+	r, caller := testRecord("connect", errors.New("refused"))
+	if err := sink.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	want := "2024-05-06 07:08:09.010 Code:9999 Level:UnknownLevel,Details:[connect refused] user=peach ok=true caller=" + caller + "\n"
+	if buf.String() != want {
+		t.Errorf("text %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	sink.TimeFormat = ""
+	sink.NoCaller = true
+	r.Fields = nil
+	if err := sink.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Code:9999 Level:UnknownLevel,Details:[connect refused]\n"; buf.String() != want {
+		t.Errorf("text %q, want %q", buf.String(), want)
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewJSONSink(&buf)
+	r, caller := testRecord("connect", errors.New("refused"), 3)
+	if err := sink.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	// 单个内容不编码为数组
+	r.Details = r.Details[:1]
+	sink.NoCaller = true
+	if err := sink.Write(r); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d lines, want 2: %q", len(lines), buf.String())
+	}
+	wants := []map[string]any{
+		{
+			"time":    "2024-05-06T07:08:09.01Z",
+			"level":   "UnknownLevel",
+			"code":    9999.0,
+			"details": []any{"connect", "refused", 3.0},
+			"caller":  caller,
+			"user":    "peach",
+			"ok":      true,
+		},
+		{
+			"time":    "2024-05-06T07:08:09.01Z",
+			"level":   "UnknownLevel",
+			"code":    9999.0,
+			"details": "connect",
+			"user":    "peach",
+			"ok":      true,
+		},
+	}
+	for i, line := range lines {
+		var got map[string]any
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("line %d %q: %v", i, line, err)
+		}
+		gotData, _ := json.Marshal(got)
+		wantData, _ := json.Marshal(wants[i])
+		if !bytes.Equal(gotData, wantData) {
+			t.Errorf("line %d %s, want %s", i, gotData, wantData)
+		}
+	}
+}
+
+func TestMultiSink(t *testing.T) {
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	var calls []string
+	sink := func(name string, err error) Sink {
+		return SinkFunc(func(*Record) error {
+			calls = append(calls, name)
+			return err
+		})
+	}
+	r, _ := testRecord()
+
+	// 出错后继续写入其余输出
+	err := MultiSink(sink("a", nil), sink("b", errFirst), sink("c", errSecond), sink("d", nil)).Write(r)
+	if err != errFirst || strings.Join(calls, "") != "abcd" {
+		t.Errorf("Write = %v, calls %v, want %v and abcd", err, calls, errFirst)
+	}
+	if err := MultiSink().Write(r); err != nil {
+		t.Errorf("empty Write = %v", err)
+	}
+}
+
+func TestLogInfoSink(t *testing.T) {
+	dir := t.TempDir()
+	open := func(name string) *os.File {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { file.Close() })
+		return file
+	}
+	info := &LogInfo{LogFile: open("a.log")}
+
+	def := info.Sink()
+	if text, ok := def.(*TextSink); !ok || text.w != info.LogFile {
+		t.Fatalf("default sink %#v, want a text sink of LogFile", def)
+	}
+	if info.Sink() != def {
+		t.Error("default sink created again")
+	}
+
+	custom := NewJSONSink(info.LogFile)
+	info.SetSink(custom)
+	if info.Sink() != custom {
+		t.Error("SetSink sink not returned")
+	}
+	info.LogFile = open("b.log")
+	if info.Sink() != custom {
+		t.Error("SetSink sink replaced after LogFile changed")
+	}
+
+	info.SetSink(nil)
+	b := info.Sink()
+	if text, ok := b.(*TextSink); !ok || text.w != info.LogFile || b == def {
+		t.Errorf("sink after SetSink(nil) %#v, want a new text sink of LogFile", b)
+	}
+
+	// LogFile 修改后默认输出写入新文件
+	info.LogFile = open("c.log")
+	if text, ok := info.Sink().(*TextSink); !ok || text.w != info.LogFile {
+		t.Errorf("sink after LogFile changed %#v, want a text sink of the new LogFile", info.Sink())
+	}
+}
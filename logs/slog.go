@@ -0,0 +1,60 @@
+package logs
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLevel 日志等级对应的 slog 等级, 自定义等级按其与 DebugLevel 的距离映射
+func SlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case DebugLevel:
+		return slog.LevelDebug
+	case InfoLevel:
+		return slog.LevelInfo
+	case WarnLevel:
+		return slog.LevelWarn
+	case ErrorLevel:
+		return slog.LevelError
+	case FatalLevel:
+		return slog.LevelError + 4
+	}
+	return slog.LevelDebug + slog.Level(4*int(level))
+}
+
+// SlogSink 通过 slog.Handler 输出日志
+type SlogSink struct {
+	Handler slog.Handler
+}
+
+// NewSlogSink 创建 slog 日志输出
+func NewSlogSink(h slog.Handler) *SlogSink {
+	return &SlogSink{Handler: h}
+}
+
+// Write 写入日志
+func (s *SlogSink) Write(r *Record) error {
+	level := SlogLevel(r.Level)
+	ctx := context.Background()
+	if !s.Handler.Enabled(ctx, level) {
+		return nil
+	}
+	var msg string
+	details := r.Details
+	if len(details) > 0 {
+		if str, ok := details[0].(string); ok {
+			msg, details = str, details[1:]
+		}
+	}
+	record := slog.NewRecord(r.Time, level, msg, r.PC)
+	record.AddAttrs(slog.Uint64("code", uint64(r.Code)))
+	if len(details) == 1 {
+		record.AddAttrs(slog.Any("details", details[0]))
+	} else if len(details) > 1 {
+		record.AddAttrs(slog.Any("details", details))
+	}
+	for _, field := range r.Fields {
+		record.AddAttrs(slog.Any(field.Key, field.Value))
+	}
+	return s.Handler.Handle(ctx, record)
+}
@@ -10,6 +10,7 @@ var Std = struct {
 	LogOut  *os.File
 	LogMap  sync.Map
 	LogCode sync.Map
+	LogDesc sync.Map // 代码说明
 	Exit    chan struct{}
 	Pool    Pool
 }{Exit: make(chan struct{}), LogOut: os.Stdout, Pool: NewPool(1024)}
@@ -24,10 +25,14 @@ func IsExit() <-chan struct{} {
 	return Std.Exit
 }
 
-func init() {
-	// 更改默认输出
-	os.Stdout = InfoLevel.Info().LogFile
-	os.Stderr = ErrorLevel.Info().LogFile
+// RedirectStd 将 os.Stdout 和 os.Stderr 更改为 InfoLevel 和 ErrorLevel 的日志文件
+func RedirectStd() {
+	if file := InfoLevel.Info().LogFile; file != nil {
+		os.Stdout = file
+	}
+	if file := ErrorLevel.Info().LogFile; file != nil {
+		os.Stderr = file
+	}
 }
 
 // Close 用于处理代码中存在多次关联的 Close 过程
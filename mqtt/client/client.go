@@ -80,6 +80,10 @@ type Client interface {
 	// to the specified topic.
 	// Returns a token to track delivery of the message to the broker
 	Publish(topic string, qos byte, retained bool, payload interface{}) Token
+	// PublishWithProperties is like Publish but also sends the MQTT v5 properties
+	// of the message (e.g. response topic, correlation data, message expiry or
+	// user properties). The properties are ignored with MQTT 3.1/3.1.1.
+	PublishWithProperties(topic string, qos byte, retained bool, payload interface{}, props *packets.Properties) Token
 	// Subscribe starts a new subscription. Provide a MessageHandler to be executed when
 	// a message is published on the topic provided, or nil for the default handler.
 	//
@@ -88,6 +92,10 @@ type Client interface {
 	// a new go routine.
 	// callback must be safe for concurrent use by multiple goroutines.
 	Subscribe(topic string, qos byte, callback MessageHandler) Token
+	// SubscribeWithOptions is like Subscribe but also sends the MQTT v5
+	// subscription options and properties (e.g. subscription identifier).
+	// Only options.Qos is used with MQTT 3.1/3.1.1.
+	SubscribeWithOptions(topic string, options SubscribeOptions, callback MessageHandler) Token
 	// SubscribeMultiple starts a new subscription for multiple topics. Provide a MessageHandler to
 	// be executed when a message is published on one of the topics provided, or nil for the
 	// default handler.
@@ -135,6 +143,8 @@ type client struct {
 	options   ClientOptions
 	optionsMu sync.Mutex // Protects the options in a few limited cases where needed for testing
 
+	serverProperties *packets.Properties // properties of the last MQTT v5 connack
+
 	conn   net.Conn   // the network connection, must only be set with connMu locked (only used when starting/stopping workers)
 	connMu sync.Mutex // mutex for the connection (again only used in two functions)
 
@@ -157,7 +167,7 @@ func NewClient(o *ClientOptions) Client {
 		c.options.Store = NewMemoryStore()
 	}
 	switch c.options.ProtocolVersion {
-	case 3, 4:
+	case 3, 4, 5:
 		c.options.protocolVersionExplicit = true
 	case 0x83, 0x84:
 		c.options.protocolVersionExplicit = true
@@ -166,6 +176,9 @@ func NewClient(o *ClientOptions) Client {
 		c.options.protocolVersionExplicit = false
 	}
 	c.persist = c.options.Store
+	if s, ok := c.persist.(interface{ SetProtocolVersion(byte) }); ok {
+		s.SetProtocolVersion(byte(c.options.ProtocolVersion))
+	}
 	c.messageIds = messageIds{index: make(map[uint16]tokenCompletor)}
 	c.msgRouter = newRouter()
 	c.msgRouter.setDefaultHandler(c.options.DefaultPublishHandler)
@@ -294,6 +307,9 @@ func (c *client) Connect() Token {
 		}
 
 		close(inboundFromStore)
+		t.m.Lock()
+		t.properties = c.connackProperties()
+		t.m.Unlock()
 		t.flowComplete()
 		DEBUG.Println(CLI, "exit startClient")
 	}()
@@ -358,6 +374,7 @@ func (c *client) attemptConnection() (net.Conn, byte, bool, error) {
 		conn           net.Conn
 		err            error
 		rc             byte
+		props          *packets.Properties
 	)
 
 	c.optionsMu.Lock() // Protect c.options.Servers so that servers can be added in test cases
@@ -398,7 +415,7 @@ func (c *client) attemptConnection() (net.Conn, byte, bool, error) {
 		}
 
 		// Now we perform the MQTT connection handshake
-		rc, sessionPresent, err = connectMQTT(conn, cm, protocolVersion)
+		rc, sessionPresent, props, err = connectMQTT(conn, cm, protocolVersion)
 		if rc == packets.Accepted {
 			if err := conn.SetDeadline(time.Time{}); err != nil {
 				ERROR.Println(CLI, "reset deadline following handshake ", err)
@@ -422,9 +439,12 @@ func (c *client) attemptConnection() (net.Conn, byte, bool, error) {
 	if rc == packets.Accepted {
 		c.options.ProtocolVersion = protocolVersion
 		c.options.protocolVersionExplicit = true
+		c.applyServerProperties(props)
 	} else {
 		// Maintain same error format as used previously
-		if rc != packets.ErrNetworkError { // mqtt error
+		if protocolVersion == 5 && rc != packets.ErrNetworkError { // mqtt v5 reason code
+			err = packets.ReasonCodeError(rc, props)
+		} else if rc != packets.ErrNetworkError { // mqtt error
 			err = packets.ConnErrors[rc]
 		} else { // network error (if this occurred in ConnectMQTT then err will be nil)
 			err = fmt.Errorf("%s : %s", packets.ConnErrors[rc], err)
@@ -433,6 +453,48 @@ func (c *client) attemptConnection() (net.Conn, byte, bool, error) {
 	return conn, rc, sessionPresent, err
 }
 
+// applyServerProperties records the properties of an MQTT v5 connack and
+// applies the values which override the client options.
+func (c *client) applyServerProperties(props *packets.Properties) {
+	c.optionsMu.Lock()
+	defer c.optionsMu.Unlock()
+
+	c.serverProperties = props
+	if props == nil {
+		return
+	}
+	if props.AssignedClientID != "" {
+		c.options.ClientID = props.AssignedClientID
+	}
+	if props.ServerKeepAlive != nil {
+		c.options.KeepAlive = int64(*props.ServerKeepAlive)
+	}
+}
+
+// connackProperties returns the properties of the last MQTT v5 connack
+func (c *client) connackProperties() *packets.Properties {
+	c.optionsMu.Lock()
+	defer c.optionsMu.Unlock()
+	return c.serverProperties
+}
+
+// protocolVersion returns the MQTT protocol version in use
+func (c *client) protocolVersion() byte {
+	return byte(c.options.ProtocolVersion)
+}
+
+// packetProperties returns the properties to send in a packet: nil with
+// MQTT 3.1/3.1.1, a copy of props (never nil) with MQTT v5.
+func (c *client) packetProperties(props *packets.Properties) *packets.Properties {
+	if c.options.ProtocolVersion != 5 {
+		return nil
+	}
+	if props == nil {
+		return &packets.Properties{}
+	}
+	return props.Copy()
+}
+
 // Disconnect will end the connection with the server, but not before waiting
 // the specified number of milliseconds to wait for existing work to be
 // completed.
@@ -749,6 +811,13 @@ func (c *client) stopCommsWorkers() chan struct{} {
 // to the specified topic.
 // Returns a token to track delivery of the message to the broker
 func (c *client) Publish(topic string, qos byte, retained bool, payload interface{}) Token {
+	return c.PublishWithProperties(topic, qos, retained, payload, nil)
+}
+
+// PublishWithProperties will publish a message with the specified QoS, content
+// and MQTT v5 properties to the specified topic.
+// Returns a token to track delivery of the message to the broker
+func (c *client) PublishWithProperties(topic string, qos byte, retained bool, payload interface{}, props *packets.Properties) Token {
 	token := newToken(packets.Publish).(*PublishToken)
 	DEBUG.Println(CLI, "enter Publish")
 	switch {
@@ -764,6 +833,7 @@ func (c *client) Publish(topic string, qos byte, retained bool, payload interfac
 	pub.Qos = qos
 	pub.TopicName = topic
 	pub.Retain = retained
+	pub.Properties = c.packetProperties(props)
 	switch p := payload.(type) {
 	case string:
 		pub.Payload = []byte(p)
@@ -816,6 +886,39 @@ func (c *client) Publish(topic string, qos byte, retained bool, payload interfac
 // a new go routine.
 // callback must be safe for concurrent use by multiple goroutines.
 func (c *client) Subscribe(topic string, qos byte, callback MessageHandler) Token {
+	return c.SubscribeWithOptions(topic, SubscribeOptions{Qos: qos}, callback)
+}
+
+// SubscribeOptions holds the options of a subscription. With MQTT 3.1/3.1.1
+// only Qos is used.
+type SubscribeOptions struct {
+	Qos               byte
+	NoLocal           bool                // MQTT v5: do not receive messages published by this client
+	RetainAsPublished bool                // MQTT v5: keep the retain flag of forwarded messages
+	RetainHandling    byte                // MQTT v5: 0 - send retained, 1 - send retained if new subscription, 2 - do not send retained
+	Properties        *packets.Properties // MQTT v5: subscription identifier and user properties
+}
+
+// options returns the subscription options byte of the SUBSCRIBE packet
+func (o SubscribeOptions) options(protocolVersion uint) byte {
+	if protocolVersion != 5 {
+		return o.Qos
+	}
+	return o.Qos | boolToByte(o.NoLocal)<<2 | boolToByte(o.RetainAsPublished)<<3 | (o.RetainHandling&0x03)<<4
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// SubscribeWithOptions starts a new subscription with the given options.
+// Provide a MessageHandler to be executed when a message is published on the
+// topic provided, or nil for the default handler.
+func (c *client) SubscribeWithOptions(topic string, options SubscribeOptions, callback MessageHandler) Token {
+	qos := options.Qos
 	token := newToken(packets.Subscribe).(*SubscribeToken)
 	DEBUG.Println(CLI, "enter Subscribe")
 	if !c.IsConnected() {
@@ -840,7 +943,8 @@ func (c *client) Subscribe(topic string, qos byte, callback MessageHandler) Toke
 		return token
 	}
 	sub.Topics = append(sub.Topics, topic)
-	sub.Qoss = append(sub.Qoss, qos)
+	sub.Qoss = append(sub.Qoss, options.options(c.options.ProtocolVersion))
+	sub.Properties = c.packetProperties(options.Properties)
 
 	if strings.HasPrefix(topic, "$share/") {
 		topic = strings.Join(strings.Split(topic, "/")[2:], "/")
@@ -925,6 +1029,7 @@ func (c *client) SubscribeMultiple(filters map[string]byte, callback MessageHand
 		token.setError(err)
 		return token
 	}
+	sub.Properties = c.packetProperties(nil)
 
 	if callback != nil {
 		for topic := range filters {
@@ -1149,6 +1254,7 @@ func (c *client) Unsubscribe(topics ...string) Token {
 	unsub := packets.NewControlPacket(packets.Unsubscribe).(*packets.UnsubscribePacket)
 	unsub.Topics = make([]string, len(topics))
 	copy(unsub.Topics, topics)
+	unsub.Properties = c.packetProperties(nil)
 
 	if unsub.MessageID == 0 {
 		mID := c.getID(token)
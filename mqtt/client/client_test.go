@@ -0,0 +1,200 @@
+// SPDX-License-Identifier: EPL-2.0 OR BSD-3-Clause
+// SPDX-FileCopyrightText: 2026 mqtt authors
+
+package client_test
+
+import (
+	"bytes"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"mqtt/client"
+	"mqtt/client/packets"
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/auth"
+)
+
+const testTimeout = 5 * time.Second
+
+// newServer returns a running broker accepting every client.
+func newServer(t *testing.T) *mqtt.Server {
+	t.Helper()
+
+	s := mqtt.New(&mqtt.Options{InlineClient: true})
+	if err := s.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// connect returns a client connected to the server over a net.Pipe.
+func connect(t *testing.T, s *mqtt.Server, id string, protocolVersion uint) (client.Client, *client.ConnectToken) {
+	t.Helper()
+
+	opts := client.NewClientOptions().
+		AddBroker("tcp://pipe").
+		SetClientID(id).
+		SetProtocolVersion(protocolVersion).
+		SetAutoReconnect(false).
+		SetCustomOpenConnectionFn(func(*url.URL, client.ClientOptions) (net.Conn, error) {
+			local, remote := net.Pipe()
+			go func() { _ = s.EstablishConnection("pipe", remote) }()
+			return local, nil
+		})
+
+	c := client.NewClient(opts)
+	token := c.Connect().(*client.ConnectToken)
+	wait(t, token)
+	t.Cleanup(func() { c.Disconnect(0) })
+	return c, token
+}
+
+// wait waits for a token to complete and fails the test on errors.
+func wait(t *testing.T, token client.Token) {
+	t.Helper()
+
+	if !token.WaitTimeout(testTimeout) {
+		t.Fatal("timed out waiting for token")
+	}
+	if err := token.Error(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive returns the next message of a channel.
+func receive(t *testing.T, ch <-chan client.Message) client.Message {
+	t.Helper()
+
+	select {
+	case m := <-ch:
+		return m
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for message")
+		return nil
+	}
+}
+
+func TestConnectAssignedClientID(t *testing.T) {
+	s := newServer(t)
+	_, token := connect(t, s, "", 5)
+
+	props := token.Properties()
+	if props == nil || props.AssignedClientID == "" {
+		t.Fatalf("connack properties = %+v, want an assigned client id", props)
+	}
+}
+
+func TestPublishSubscribeProperties(t *testing.T) {
+	s := newServer(t)
+	c, _ := connect(t, s, "v5", 5)
+
+	messages := make(chan client.Message, 1)
+	sub := c.SubscribeWithOptions("a/+", client.SubscribeOptions{
+		Qos:        1,
+		Properties: &packets.Properties{SubscriptionIdentifier: []int{7}},
+	}, func(_ client.Client, m client.Message) {
+		messages <- m
+	})
+	wait(t, sub)
+	if got := sub.(*client.SubscribeToken).Result()["a/+"]; got != 1 {
+		t.Fatalf("suback = %d, want 1", got)
+	}
+
+	pub := c.PublishWithProperties("a/b", 1, false, "hello", &packets.Properties{
+		ResponseTopic:   "a/reply",
+		CorrelationData: []byte{1, 2, 3},
+		User:            []packets.UserProperty{{Key: "k", Value: "v"}},
+	})
+	wait(t, pub)
+	if code := pub.(*client.PublishToken).ReasonCode(); code >= 0x80 {
+		t.Errorf("puback reason code = %#x, want a success code", code)
+	}
+
+	m := receive(t, messages)
+	if m.Topic() != "a/b" || string(m.Payload()) != "hello" || m.Qos() != 1 {
+		t.Errorf("message = %s %q qos %d, want a/b \"hello\" qos 1", m.Topic(), m.Payload(), m.Qos())
+	}
+
+	pm, ok := m.(client.PropertiesMessage)
+	if !ok {
+		t.Fatal("message does not implement PropertiesMessage")
+	}
+	props := pm.Properties()
+	if props == nil {
+		t.Fatal("message properties are nil")
+	}
+	if props.ResponseTopic != "a/reply" {
+		t.Errorf("response topic = %q, want a/reply", props.ResponseTopic)
+	}
+	if !bytes.Equal(props.CorrelationData, []byte{1, 2, 3}) {
+		t.Errorf("correlation data = %v, want [1 2 3]", props.CorrelationData)
+	}
+	if len(props.User) != 1 || props.User[0] != (packets.UserProperty{Key: "k", Value: "v"}) {
+		t.Errorf("user properties = %v, want [{k v}]", props.User)
+	}
+	if len(props.SubscriptionIdentifier) != 1 || props.SubscriptionIdentifier[0] != 7 {
+		t.Errorf("subscription identifiers = %v, want [7]", props.SubscriptionIdentifier)
+	}
+}
+
+func TestNoLocal(t *testing.T) {
+	s := newServer(t)
+	c, _ := connect(t, s, "v5", 5)
+
+	messages := make(chan client.Message, 2)
+	wait(t, c.SubscribeWithOptions("a/b", client.SubscribeOptions{Qos: 1, NoLocal: true}, func(_ client.Client, m client.Message) {
+		messages <- m
+	}))
+	wait(t, c.Publish("a/b", 1, false, "local"))
+
+	if err := s.Publish("a/b", []byte("remote"), false, 1); err != nil {
+		t.Fatal(err)
+	}
+	if m := receive(t, messages); string(m.Payload()) != "remote" {
+		t.Errorf("payload = %q, want the remote message", m.Payload())
+	}
+}
+
+func TestUnsubscribeReasonCodes(t *testing.T) {
+	s := newServer(t)
+	c, _ := connect(t, s, "v5", 5)
+
+	wait(t, c.Subscribe("a/b", 0, nil))
+
+	token := c.Unsubscribe("a/b", "c/d")
+	wait(t, token)
+	codes := token.(*client.UnsubscribeToken).ReasonCodes()
+	want := []byte{packets.ReasonSuccess, packets.ReasonNoSubscriptionExisted}
+	if !bytes.Equal(codes, want) {
+		t.Errorf("unsuback reason codes = %#v, want %#v", codes, want)
+	}
+}
+
+func TestMQTT311(t *testing.T) {
+	s := newServer(t)
+	c, token := connect(t, s, "v311", 4)
+
+	if props := token.Properties(); props != nil {
+		t.Errorf("connack properties = %+v, want nil", props)
+	}
+
+	messages := make(chan client.Message, 1)
+	wait(t, c.Subscribe("a/#", 2, func(_ client.Client, m client.Message) {
+		messages <- m
+	}))
+	wait(t, c.Publish("a/b", 2, false, []byte("hello")))
+
+	m := receive(t, messages)
+	if m.Topic() != "a/b" || string(m.Payload()) != "hello" || m.Qos() != 2 {
+		t.Errorf("message = %s %q qos %d, want a/b \"hello\" qos 2", m.Topic(), m.Payload(), m.Qos())
+	}
+	if pm, ok := m.(client.PropertiesMessage); ok && pm.Properties() != nil {
+		t.Errorf("message properties = %+v, want nil", pm.Properties())
+	}
+}
@@ -41,8 +41,9 @@ const (
 // store directories for each.
 type FileStore struct {
 	sync.RWMutex
-	directory string
-	opened    bool
+	directory       string
+	opened          bool
+	protocolVersion byte
 }
 
 // NewFileStore will create a new FileStore which stores its messages in the
@@ -99,6 +100,15 @@ func (store *FileStore) Put(key string, m packets.ControlPacket) {
 	}
 }
 
+// SetProtocolVersion sets the MQTT protocol version used to decode the
+// stored messages. Messages are stored in the wire format of the protocol
+// version in use, so this must match the version of the client.
+func (store *FileStore) SetProtocolVersion(version byte) {
+	store.Lock()
+	defer store.Unlock()
+	store.protocolVersion = version
+}
+
 // Get will retrieve a message from the store, the one associated with
 // the provided key value.
 func (store *FileStore) Get(key string) packets.ControlPacket {
@@ -114,7 +124,7 @@ func (store *FileStore) Get(key string) packets.ControlPacket {
 	}
 	mfile, oerr := os.Open(filepath)
 	chkerr(oerr)
-	msg, rerr := packets.ReadPacket(mfile)
+	msg, rerr := packets.ReadPacketWithVersion(mfile, store.protocolVersion)
 	chkerr(mfile.Close())
 
 	// Message was unreadable, return nil
@@ -38,15 +38,30 @@ type Message interface {
 	Ack()
 }
 
+// PropertiesMessage is implemented by the received messages which carry MQTT v5
+// properties. Use a type assertion on a Message to read them:
+//
+//	if m, ok := msg.(PropertiesMessage); ok {
+//		props := m.Properties()
+//	}
+type PropertiesMessage interface {
+	Message
+	// Properties returns the MQTT v5 properties of the message (e.g. response
+	// topic, correlation data, user properties or subscription identifiers).
+	// It returns nil with MQTT 3.1/3.1.1.
+	Properties() *packets.Properties
+}
+
 type message struct {
-	duplicate bool
-	qos       byte
-	retained  bool
-	topic     string
-	messageID uint16
-	payload   []byte
-	once      sync.Once
-	ack       func()
+	duplicate  bool
+	qos        byte
+	retained   bool
+	topic      string
+	messageID  uint16
+	payload    []byte
+	properties *packets.Properties
+	once       sync.Once
+	ack        func()
 }
 
 func (m *message) Duplicate() bool {
@@ -73,19 +88,24 @@ func (m *message) Payload() []byte {
 	return m.payload
 }
 
+func (m *message) Properties() *packets.Properties {
+	return m.properties
+}
+
 func (m *message) Ack() {
 	m.once.Do(m.ack)
 }
 
 func messageFromPublish(p *packets.PublishPacket, ack func()) Message {
 	return &message{
-		duplicate: p.Dup,
-		qos:       p.Qos,
-		retained:  p.Retain,
-		topic:     p.TopicName,
-		messageID: p.MessageID,
-		payload:   p.Payload,
-		ack:       ack,
+		duplicate:  p.Dup,
+		qos:        p.Qos,
+		retained:   p.Retain,
+		topic:      p.TopicName,
+		messageID:  p.MessageID,
+		payload:    p.Payload,
+		properties: p.Properties,
+		ack:        ack,
 	}
 }
 
@@ -101,7 +121,9 @@ func newConnectMsgFromOptions(options *ClientOptions, broker *url.URL) *packets.
 		m.WillQos = options.WillQos
 		m.WillTopic = options.WillTopic
 		m.WillMessage = options.WillPayload
+		m.WillProperties = options.WillProperties.Copy()
 	}
+	m.Properties = options.ConnectProperties.Copy()
 
 	username := options.Username
 	password := options.Password
@@ -40,11 +40,13 @@ const closedNetConnErrorText = "use of closed network connection" // error strin
 //
 // Note that, for backward compatibility, ConnectMQTT() suppresses the actual connection error (compare to connectMQTT()).
 func ConnectMQTT(conn net.Conn, cm *packets.ConnectPacket, protocolVersion uint) (byte, bool) {
-	rc, sessionPresent, _ := connectMQTT(conn, cm, protocolVersion)
+	rc, sessionPresent, _, _ := connectMQTT(conn, cm, protocolVersion)
 	return rc, sessionPresent
 }
 
-func connectMQTT(conn io.ReadWriter, cm *packets.ConnectPacket, protocolVersion uint) (byte, bool, error) {
+// connectMQTT performs the MQTT handshake; with MQTT v5 it also returns the
+// properties of the CONNACK packet.
+func connectMQTT(conn io.ReadWriter, cm *packets.ConnectPacket, protocolVersion uint) (byte, bool, *packets.Properties, error) {
 	switch protocolVersion {
 	case 3:
 		DEBUG.Println(CLI, "Using MQTT 3.1 protocol")
@@ -58,6 +60,16 @@ func connectMQTT(conn io.ReadWriter, cm *packets.ConnectPacket, protocolVersion
 		DEBUG.Println(CLI, "Using MQTT 3.1.1b protocol")
 		cm.ProtocolName = "MQTT"
 		cm.ProtocolVersion = 0x84
+	case 5:
+		DEBUG.Println(CLI, "Using MQTT 5 protocol")
+		cm.ProtocolName = "MQTT"
+		cm.ProtocolVersion = 5
+		if cm.Properties == nil {
+			cm.Properties = &packets.Properties{}
+		}
+		if cm.WillFlag && cm.WillProperties == nil {
+			cm.WillProperties = &packets.Properties{}
+		}
 	default:
 		DEBUG.Println(CLI, "Using MQTT 3.1.1 protocol")
 		cm.ProtocolName = "MQTT"
@@ -66,39 +78,38 @@ func connectMQTT(conn io.ReadWriter, cm *packets.ConnectPacket, protocolVersion
 
 	if err := cm.Write(conn); err != nil {
 		ERROR.Println(CLI, err)
-		return packets.ErrNetworkError, false, err
+		return packets.ErrNetworkError, false, nil, err
 	}
 
-	rc, sessionPresent, err := verifyCONNACK(conn)
-	return rc, sessionPresent, err
+	return verifyCONNACK(conn, cm.ProtocolVersion)
 }
 
 // This function is only used for receiving a connack
 // when the connection is first started.
 // This prevents receiving incoming data while resume
 // is in progress if clean session is false.
-func verifyCONNACK(conn io.Reader) (byte, bool, error) {
+func verifyCONNACK(conn io.Reader, protocolVersion byte) (byte, bool, *packets.Properties, error) {
 	DEBUG.Println(NET, "connect started")
 
-	ca, err := packets.ReadPacket(conn)
+	ca, err := packets.ReadPacketWithVersion(conn, protocolVersion)
 	if err != nil {
 		ERROR.Println(NET, "connect got error", err)
-		return packets.ErrNetworkError, false, err
+		return packets.ErrNetworkError, false, nil, err
 	}
 
 	if ca == nil {
 		ERROR.Println(NET, "received nil packet")
-		return packets.ErrNetworkError, false, errors.New("nil CONNACK packet")
+		return packets.ErrNetworkError, false, nil, errors.New("nil CONNACK packet")
 	}
 
 	msg, ok := ca.(*packets.ConnackPacket)
 	if !ok {
 		ERROR.Println(NET, "received msg that was not CONNACK")
-		return packets.ErrNetworkError, false, errors.New("non-CONNACK first packet received")
+		return packets.ErrNetworkError, false, nil, errors.New("non-CONNACK first packet received")
 	}
 
 	DEBUG.Println(NET, "received connack")
-	return msg.ReturnCode, msg.SessionPresent, nil
+	return msg.ReturnCode, msg.SessionPresent, msg.Properties, nil
 }
 
 // inbound encapsulates the output from startIncoming.
@@ -112,7 +123,7 @@ type inbound struct {
 // startIncoming initiates a goroutine that reads incoming messages off the wire and sends them to the channel (returned).
 // If there are any issues with the network connection then the returned channel will be closed and the goroutine will exit
 // (so closing the connection will terminate the goroutine)
-func startIncoming(conn io.Reader) <-chan inbound {
+func startIncoming(conn io.Reader, protocolVersion byte) <-chan inbound {
 	var err error
 	var cp packets.ControlPacket
 	ibound := make(chan inbound)
@@ -121,7 +132,7 @@ func startIncoming(conn io.Reader) <-chan inbound {
 
 	go func() {
 		for {
-			if cp, err = packets.ReadPacket(conn); err != nil {
+			if cp, err = packets.ReadPacketWithVersion(conn, protocolVersion); err != nil {
 				// We do not want to log the error if it is due to the network connection having been closed
 				// elsewhere (i.e. after sending DisconnectPacket). Detecting this situation is the subject of
 				// https://github.com/golang/go/issues/4373
@@ -157,8 +168,9 @@ func startIncomingComms(conn io.Reader,
 	c commsFns,
 	inboundFromStore <-chan packets.ControlPacket,
 ) <-chan incomingComms {
-	ibound := startIncoming(conn) // Start goroutine that reads from network connection
+	ibound := startIncoming(conn, c.protocolVersion()) // Start goroutine that reads from network connection
 	output := make(chan incomingComms)
+	topicAliases := make(map[uint16]string) // MQTT v5 topic aliases set by the server for this connection
 
 	DEBUG.Println(NET, "startIncomingComms started")
 	go func() {
@@ -211,23 +223,38 @@ func startIncomingComms(conn io.Reader,
 					for i, qos := range m.ReturnCodes {
 						t.subResult[t.subs[i]] = qos
 					}
+					t.properties = m.Properties
 				}
 
 				token.flowComplete()
 				c.freeID(m.MessageID)
 			case *packets.UnsubackPacket:
 				DEBUG.Println(NET, "startIncomingComms: received unsuback, id:", m.MessageID)
-				c.getToken(m.MessageID).flowComplete()
+				token := c.getToken(m.MessageID)
+				if t, ok := token.(*UnsubscribeToken); ok {
+					t.reasonCodes = m.ReasonCodes
+					t.properties = m.Properties
+				}
+				token.flowComplete()
 				c.freeID(m.MessageID)
 			case *packets.PublishPacket:
 				DEBUG.Println(NET, "startIncomingComms: received publish, msgId:", m.MessageID)
+				if err := resolveTopicAlias(topicAliases, m); err != nil {
+					output <- incomingComms{err: err}
+					continue
+				}
 				output <- incomingComms{incomingPub: m}
 			case *packets.PubackPacket:
 				DEBUG.Println(NET, "startIncomingComms: received puback, id:", m.MessageID)
-				c.getToken(m.MessageID).flowComplete()
+				completePublish(c.getToken(m.MessageID), m.ReasonCode, m.Properties)
 				c.freeID(m.MessageID)
 			case *packets.PubrecPacket:
 				DEBUG.Println(NET, "startIncomingComms: received pubrec, id:", m.MessageID)
+				if m.ReasonCode >= 0x80 { // the server will not continue the QoS 2 flow
+					completePublish(c.getToken(m.MessageID), m.ReasonCode, m.Properties)
+					c.freeID(m.MessageID)
+					continue
+				}
 				prel := packets.NewControlPacket(packets.Pubrel).(*packets.PubrelPacket)
 				prel.MessageID = m.MessageID
 				output <- incomingComms{outbound: &PacketAndToken{p: prel, t: nil}}
@@ -239,14 +266,58 @@ func startIncomingComms(conn io.Reader,
 				output <- incomingComms{outbound: &PacketAndToken{p: pc, t: nil}}
 			case *packets.PubcompPacket:
 				DEBUG.Println(NET, "startIncomingComms: received pubcomp, id:", m.MessageID)
-				c.getToken(m.MessageID).flowComplete()
+				completePublish(c.getToken(m.MessageID), m.ReasonCode, m.Properties)
 				c.freeID(m.MessageID)
+			case *packets.DisconnectPacket:
+				DEBUG.Println(NET, "startIncomingComms: received disconnect, reason:", m.ReasonCode)
+				err := packets.ReasonCodeError(m.ReasonCode, m.Properties)
+				if err == nil {
+					err = errors.New("disconnected by server")
+				}
+				output <- incomingComms{err: err}
+			case *packets.AuthPacket:
+				WARN.Println(NET, "startIncomingComms: received auth, enhanced authentication is not supported")
 			}
 		}
 	}()
 	return output
 }
 
+// resolveTopicAlias sets the topic name of an MQTT v5 publish which uses a
+// topic alias, and records new aliases set by the server.
+func resolveTopicAlias(aliases map[uint16]string, m *packets.PublishPacket) error {
+	if m.Properties == nil || m.Properties.TopicAlias == nil {
+		return nil
+	}
+
+	alias := *m.Properties.TopicAlias
+	if m.TopicName != "" {
+		aliases[alias] = m.TopicName
+		return nil
+	}
+
+	topic, ok := aliases[alias]
+	if !ok {
+		return packets.ReasonCodeError(packets.ReasonTopicAliasInvalid, nil)
+	}
+	m.TopicName = topic
+	return nil
+}
+
+// completePublish completes the flow of a publish token, reporting an MQTT v5
+// failure reason code as an error.
+func completePublish(token tokenCompletor, reasonCode byte, props *packets.Properties) {
+	if t, ok := token.(*PublishToken); ok {
+		t.reasonCode = reasonCode
+		t.properties = props
+	}
+	if err := packets.ReasonCodeError(reasonCode, props); err != nil {
+		token.setError(err)
+		return
+	}
+	token.flowComplete()
+}
+
 // startOutgoingComms initiates a go routine to transmit outgoing packets.
 // Pass in an open network connection and channels for outbound messages (including those triggered
 // directly from incoming comms).
@@ -365,6 +436,7 @@ type commsFns interface {
 	persistOutbound(m packets.ControlPacket) // add the packet to the outbound store
 	persistInbound(m packets.ControlPacket)  // add the packet to the inbound store
 	pingRespReceived()                       // Called when a ping response is received
+	protocolVersion() byte                   // The MQTT protocol version of the connection
 }
 
 // startComms initiates goroutines that handles communications over the network connection
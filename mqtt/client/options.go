@@ -28,6 +28,8 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"mqtt/client/packets"
 )
 
 // CredentialsProvider allows the username and password to be updated
@@ -82,6 +84,8 @@ type ClientOptions struct {
 	WillRetained            bool
 	ProtocolVersion         uint
 	protocolVersionExplicit bool
+	ConnectProperties       *packets.Properties // MQTT v5 only
+	WillProperties          *packets.Properties // MQTT v5 only
 	TLSConfig               *tls.Config
 	KeepAlive               int64 // Warning: Some brokers may reject connections with Keepalive = 0.
 	PingTimeout             time.Duration
@@ -277,15 +281,32 @@ func (o *ClientOptions) SetPingTimeout(k time.Duration) *ClientOptions {
 }
 
 // SetProtocolVersion sets the MQTT version to be used to connect to the
-// broker. Legitimate values are currently 3 - MQTT 3.1 or 4 - MQTT 3.1.1
+// broker. Legitimate values are currently 3 - MQTT 3.1, 4 - MQTT 3.1.1
+// or 5 - MQTT 5
 func (o *ClientOptions) SetProtocolVersion(pv uint) *ClientOptions {
-	if (pv >= 3 && pv <= 4) || (pv > 0x80) {
+	if (pv >= 3 && pv <= 5) || (pv > 0x80) {
 		o.ProtocolVersion = pv
 		o.protocolVersionExplicit = true
 	}
 	return o
 }
 
+// SetConnectProperties sets the MQTT v5 properties sent in the CONNECT packet
+// (e.g. session expiry interval, receive maximum, topic alias maximum or user
+// properties). They are ignored with MQTT 3.1/3.1.1.
+func (o *ClientOptions) SetConnectProperties(p *packets.Properties) *ClientOptions {
+	o.ConnectProperties = p
+	return o
+}
+
+// SetWillProperties sets the MQTT v5 properties of the will message (e.g.
+// will delay interval, message expiry, content type or user properties).
+// They are ignored with MQTT 3.1/3.1.1.
+func (o *ClientOptions) SetWillProperties(p *packets.Properties) *ClientOptions {
+	o.WillProperties = p
+	return o
+}
+
 // UnsetWill will cause any set will message to be disregarded.
 func (o *ClientOptions) UnsetWill() *ClientOptions {
 	o.WillEnabled = false
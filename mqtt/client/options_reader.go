@@ -23,6 +23,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"mqtt/client/packets"
 )
 
 // ClientOptionsReader provides an interface for reading ClientOptions after the client has been initialized.
@@ -107,6 +109,16 @@ func (r *ClientOptionsReader) ProtocolVersion() uint {
 	return s
 }
 
+func (r *ClientOptionsReader) ConnectProperties() *packets.Properties {
+	s := r.options.ConnectProperties.Copy()
+	return s
+}
+
+func (r *ClientOptionsReader) WillProperties() *packets.Properties {
+	s := r.options.WillProperties.Copy()
+	return s
+}
+
 func (r *ClientOptionsReader) TLSConfig() *tls.Config {
 	s := r.options.TLSConfig
 	return s
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: EPL-2.0 OR BSD-3-Clause
+// SPDX-FileCopyrightText: 2026 mqtt authors
+
+package packets
+
+import (
+	"fmt"
+	"io"
+)
+
+// AuthPacket is an internal representation of the fields of the
+// Auth MQTT packet (MQTT v5 only)
+type AuthPacket struct {
+	FixedHeader
+	ReasonCode byte
+	Properties *Properties
+}
+
+func (a *AuthPacket) String() string {
+	return fmt.Sprintf("%s ReasonCode: %d Properties: %s", a.FixedHeader, a.ReasonCode, a.Properties)
+}
+
+func (a *AuthPacket) Write(w io.Writer) error {
+	var body []byte
+	if a.ReasonCode != ReasonSuccess || !a.Properties.isEmpty() {
+		body = append(body, a.ReasonCode)
+		body = append(body, a.Properties.Pack()...)
+	}
+	a.FixedHeader.RemainingLength = len(body)
+	packet := a.FixedHeader.pack()
+	packet.Write(body)
+	_, err := packet.WriteTo(w)
+	return err
+}
+
+// Unpack decodes the details of a ControlPacket after the fixed
+// header has been read
+func (a *AuthPacket) Unpack(b io.Reader) error {
+	var err error
+	a.Properties = &Properties{}
+	if a.RemainingLength > 0 {
+		if a.ReasonCode, err = decodeByte(b); err != nil {
+			return err
+		}
+	}
+	if a.RemainingLength > 1 {
+		err = a.Properties.Unpack(b)
+	}
+	return err
+}
+
+// Details returns a Details struct containing the Qos and
+// MessageID of this ControlPacket
+func (a *AuthPacket) Details() Details {
+	return Details{Qos: 0, MessageID: 0}
+}
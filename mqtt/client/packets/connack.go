@@ -27,7 +27,8 @@ import (
 type ConnackPacket struct {
 	FixedHeader
 	SessionPresent bool
-	ReturnCode     byte
+	ReturnCode     byte        // the MQTT v5 reason code when Properties is set
+	Properties     *Properties // MQTT v5 only
 }
 
 func (ca *ConnackPacket) String() string {
@@ -40,7 +41,10 @@ func (ca *ConnackPacket) Write(w io.Writer) error {
 
 	body.WriteByte(boolToByte(ca.SessionPresent))
 	body.WriteByte(ca.ReturnCode)
-	ca.FixedHeader.RemainingLength = 2
+	if ca.Properties != nil {
+		body.Write(ca.Properties.Pack())
+	}
+	ca.FixedHeader.RemainingLength = body.Len()
 	packet := ca.FixedHeader.pack()
 	packet.Write(body.Bytes())
 	_, err = packet.WriteTo(w)
@@ -57,8 +61,11 @@ func (ca *ConnackPacket) Unpack(b io.Reader) error {
 	}
 	ca.SessionPresent = 1&flags > 0
 	ca.ReturnCode, err = decodeByte(b)
+	if err != nil || ca.Properties == nil {
+		return err
+	}
 
-	return err
+	return ca.Properties.Unpack(b)
 }
 
 // Details returns a Details struct containing the Qos and
@@ -42,6 +42,9 @@ type ConnectPacket struct {
 	WillMessage      []byte
 	Username         string
 	Password         []byte
+
+	Properties     *Properties // MQTT v5 only
+	WillProperties *Properties // MQTT v5 only
 }
 
 func (c *ConnectPacket) String() string {
@@ -60,8 +63,14 @@ func (c *ConnectPacket) Write(w io.Writer) error {
 	body.WriteByte(c.ProtocolVersion)
 	body.WriteByte(boolToByte(c.CleanSession)<<1 | boolToByte(c.WillFlag)<<2 | c.WillQos<<3 | boolToByte(c.WillRetain)<<5 | boolToByte(c.PasswordFlag)<<6 | boolToByte(c.UsernameFlag)<<7)
 	body.Write(encodeUint16(c.Keepalive))
+	if c.ProtocolVersion == 5 {
+		body.Write(c.Properties.Pack())
+	}
 	body.Write(encodeString(c.ClientIdentifier))
 	if c.WillFlag {
+		if c.ProtocolVersion == 5 {
+			body.Write(c.WillProperties.Pack())
+		}
 		body.Write(encodeString(c.WillTopic))
 		body.Write(encodeBytes(c.WillMessage))
 	}
@@ -106,11 +115,23 @@ func (c *ConnectPacket) Unpack(b io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if c.ProtocolVersion == 5 {
+		c.Properties = &Properties{}
+		if err = c.Properties.Unpack(b); err != nil {
+			return err
+		}
+	}
 	c.ClientIdentifier, err = decodeString(b)
 	if err != nil {
 		return err
 	}
 	if c.WillFlag {
+		if c.ProtocolVersion == 5 {
+			c.WillProperties = &Properties{}
+			if err = c.WillProperties.Unpack(b); err != nil {
+				return err
+			}
+		}
 		c.WillTopic, err = decodeString(b)
 		if err != nil {
 			return err
@@ -145,7 +166,7 @@ func (c *ConnectPacket) Validate() byte {
 		// Bad reserved bit
 		return ErrProtocolViolation
 	}
-	if (c.ProtocolName == "MQIsdp" && c.ProtocolVersion != 3) || (c.ProtocolName == "MQTT" && c.ProtocolVersion != 4) {
+	if (c.ProtocolName == "MQIsdp" && c.ProtocolVersion != 3) || (c.ProtocolName == "MQTT" && c.ProtocolVersion != 4 && c.ProtocolVersion != 5) {
 		// Mismatched or unsupported protocol version
 		return ErrRefusedBadProtocolVersion
 	}
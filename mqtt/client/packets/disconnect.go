@@ -17,6 +17,7 @@
 package packets
 
 import (
+	"fmt"
 	"io"
 )
 
@@ -24,23 +25,48 @@ import (
 // Disconnect MQTT packet
 type DisconnectPacket struct {
 	FixedHeader
+	ReasonCode byte        // MQTT v5 only
+	Properties *Properties // MQTT v5 only
 }
 
 func (d *DisconnectPacket) String() string {
+	if d.Properties != nil {
+		return fmt.Sprintf("%s ReasonCode: %d Properties: %s", d.FixedHeader, d.ReasonCode, d.Properties)
+	}
 	return d.FixedHeader.String()
 }
 
 func (d *DisconnectPacket) Write(w io.Writer) error {
+	var body []byte
+	if d.Properties != nil && (d.ReasonCode != ReasonSuccess || !d.Properties.isEmpty()) {
+		body = append(body, d.ReasonCode)
+		if !d.Properties.isEmpty() {
+			body = append(body, d.Properties.Pack()...)
+		}
+	}
+	d.FixedHeader.RemainingLength = len(body)
 	packet := d.FixedHeader.pack()
+	packet.Write(body)
 	_, err := packet.WriteTo(w)
-
 	return err
 }
 
 // Unpack decodes the details of a ControlPacket after the fixed
 // header has been read
 func (d *DisconnectPacket) Unpack(b io.Reader) error {
-	return nil
+	var err error
+	if d.RemainingLength > 0 {
+		if d.Properties == nil {
+			d.Properties = &Properties{}
+		}
+		if d.ReasonCode, err = decodeByte(b); err != nil {
+			return err
+		}
+	}
+	if d.RemainingLength > 1 {
+		err = d.Properties.Unpack(b)
+	}
+	return err
 }
 
 // Details returns a Details struct containing the Qos and
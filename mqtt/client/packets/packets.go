@@ -51,6 +51,7 @@ var PacketNames = map[uint8]string{
 	12: "PINGREQ",
 	13: "PINGRESP",
 	14: "DISCONNECT",
+	15: "AUTH",
 }
 
 // Below are the constants assigned to each of the MQTT packet types
@@ -69,6 +70,7 @@ const (
 	Pingreq     = 12
 	Pingresp    = 13
 	Disconnect  = 14
+	Auth        = 15
 )
 
 // Below are the const definitions for error codes returned by
@@ -125,6 +127,13 @@ var ConnErrors = map[byte]error{
 // representing the decoded MQTT packet and an error. One of these returns will
 // always be nil, a nil ControlPacket indicating an error occurred.
 func ReadPacket(r io.Reader) (ControlPacket, error) {
+	return ReadPacketWithVersion(r, 4)
+}
+
+// ReadPacketWithVersion is like ReadPacket but decodes the packet using the
+// given MQTT protocol version. With version 5, the returned packets carry
+// their MQTT v5 properties and reason codes.
+func ReadPacketWithVersion(r io.Reader, version byte) (ControlPacket, error) {
 	var fh FixedHeader
 	b := make([]byte, 1)
 
@@ -143,6 +152,10 @@ func ReadPacket(r io.Reader) (ControlPacket, error) {
 		return nil, err
 	}
 
+	if version == 5 {
+		setProperties(cp)
+	}
+
 	packetBytes := make([]byte, fh.RemainingLength)
 	n, err := io.ReadFull(r, packetBytes)
 	if err != nil {
@@ -190,6 +203,8 @@ func NewControlPacket(packetType byte) ControlPacket {
 		return &PingreqPacket{FixedHeader: FixedHeader{MessageType: Pingreq}}
 	case Pingresp:
 		return &PingrespPacket{FixedHeader: FixedHeader{MessageType: Pingresp}}
+	case Auth:
+		return &AuthPacket{FixedHeader: FixedHeader{MessageType: Auth}}
 	}
 	return nil
 }
@@ -227,10 +242,43 @@ func NewControlPacketWithHeader(fh FixedHeader) (ControlPacket, error) {
 		return &PingreqPacket{FixedHeader: fh}, nil
 	case Pingresp:
 		return &PingrespPacket{FixedHeader: fh}, nil
+	case Auth:
+		return &AuthPacket{FixedHeader: fh}, nil
 	}
 	return nil, fmt.Errorf("unsupported packet type 0x%x", fh.MessageType)
 }
 
+// setProperties gives the packet empty properties so that it is decoded
+// (and later encoded) using the MQTT v5 format.
+func setProperties(cp ControlPacket) {
+	switch p := cp.(type) {
+	case *ConnackPacket:
+		p.Properties = &Properties{}
+	case *PublishPacket:
+		p.Properties = &Properties{}
+	case *PubackPacket:
+		p.Properties = &Properties{}
+	case *PubrecPacket:
+		p.Properties = &Properties{}
+	case *PubrelPacket:
+		p.Properties = &Properties{}
+	case *PubcompPacket:
+		p.Properties = &Properties{}
+	case *SubscribePacket:
+		p.Properties = &Properties{}
+	case *SubackPacket:
+		p.Properties = &Properties{}
+	case *UnsubscribePacket:
+		p.Properties = &Properties{}
+	case *UnsubackPacket:
+		p.Properties = &Properties{}
+	case *DisconnectPacket:
+		p.Properties = &Properties{}
+	case *AuthPacket:
+		p.Properties = &Properties{}
+	}
+}
+
 // Details struct returned by the Details() function called on
 // ControlPackets to present details of the Qos and MessageID
 // of the ControlPacket
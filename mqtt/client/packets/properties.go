@@ -0,0 +1,375 @@
+// SPDX-License-Identifier: EPL-2.0 OR BSD-3-Clause
+// SPDX-FileCopyrightText: 2026 mqtt authors
+
+package packets
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Below are the identifiers of the MQTT v5 properties
+const (
+	PropPayloadFormat          = 0x01
+	PropMessageExpiry          = 0x02
+	PropContentType            = 0x03
+	PropResponseTopic          = 0x08
+	PropCorrelationData        = 0x09
+	PropSubscriptionIdentifier = 0x0B
+	PropSessionExpiryInterval  = 0x11
+	PropAssignedClientID       = 0x12
+	PropServerKeepAlive        = 0x13
+	PropAuthMethod             = 0x15
+	PropAuthData               = 0x16
+	PropRequestProblemInfo     = 0x17
+	PropWillDelayInterval      = 0x18
+	PropRequestResponseInfo    = 0x19
+	PropResponseInfo           = 0x1A
+	PropServerReference        = 0x1C
+	PropReasonString           = 0x1F
+	PropReceiveMaximum         = 0x21
+	PropTopicAliasMaximum      = 0x22
+	PropTopicAlias             = 0x23
+	PropMaximumQOS             = 0x24
+	PropRetainAvailable        = 0x25
+	PropUser                   = 0x26
+	PropMaximumPacketSize      = 0x27
+	PropWildcardSubAvailable   = 0x28
+	PropSubIDAvailable         = 0x29
+	PropSharedSubAvailable     = 0x2A
+)
+
+// ErrInvalidProperty is returned when a property cannot be decoded
+var ErrInvalidProperty = errors.New("invalid property")
+
+// UserProperty is a name/value pair carried by the MQTT v5 User Property
+type UserProperty struct {
+	Key   string
+	Value string
+}
+
+// Properties is an internal representation of the MQTT v5 properties that
+// can be carried by a packet. Optional numeric properties are pointers so
+// that an absent property can be told apart from a zero value.
+//
+// A packet with non-nil Properties is encoded (and decoded) using the MQTT v5
+// format; a packet with nil Properties uses the MQTT 3.1/3.1.1 format.
+type Properties struct {
+	PayloadFormat          *byte
+	MessageExpiry          *uint32
+	ContentType            string
+	ResponseTopic          string
+	CorrelationData        []byte
+	SubscriptionIdentifier []int
+	SessionExpiryInterval  *uint32
+	AssignedClientID       string
+	ServerKeepAlive        *uint16
+	AuthMethod             string
+	AuthData               []byte
+	RequestProblemInfo     *byte
+	WillDelayInterval      *uint32
+	RequestResponseInfo    *byte
+	ResponseInfo           string
+	ServerReference        string
+	ReasonString           string
+	ReceiveMaximum         *uint16
+	TopicAliasMaximum      *uint16
+	TopicAlias             *uint16
+	MaximumQOS             *byte
+	RetainAvailable        *byte
+	User                   []UserProperty
+	MaximumPacketSize      *uint32
+	WildcardSubAvailable   *byte
+	SubIDAvailable         *byte
+	SharedSubAvailable     *byte
+}
+
+// Copy returns a deep copy of the properties
+func (p *Properties) Copy() *Properties {
+	if p == nil {
+		return nil
+	}
+
+	cp := *p
+	cp.CorrelationData = append([]byte(nil), p.CorrelationData...)
+	cp.SubscriptionIdentifier = append([]int(nil), p.SubscriptionIdentifier...)
+	cp.AuthData = append([]byte(nil), p.AuthData...)
+	cp.User = append([]UserProperty(nil), p.User...)
+	return &cp
+}
+
+// GetUser returns the value of the first user property with the given key
+func (p *Properties) GetUser(key string) string {
+	if p == nil {
+		return ""
+	}
+	for _, u := range p.User {
+		if u.Key == key {
+			return u.Value
+		}
+	}
+	return ""
+}
+
+func (p *Properties) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%+v", *p)
+}
+
+// Pack encodes the properties, preceded by their variable byte length
+func (p *Properties) Pack() []byte {
+	var b bytes.Buffer
+	if p != nil {
+		writeByteProp(&b, PropPayloadFormat, p.PayloadFormat)
+		writeUint32Prop(&b, PropMessageExpiry, p.MessageExpiry)
+		writeStringProp(&b, PropContentType, p.ContentType)
+		writeStringProp(&b, PropResponseTopic, p.ResponseTopic)
+		writeBytesProp(&b, PropCorrelationData, p.CorrelationData)
+		for _, id := range p.SubscriptionIdentifier {
+			b.WriteByte(PropSubscriptionIdentifier)
+			b.Write(encodeLength(id))
+		}
+		writeUint32Prop(&b, PropSessionExpiryInterval, p.SessionExpiryInterval)
+		writeStringProp(&b, PropAssignedClientID, p.AssignedClientID)
+		writeUint16Prop(&b, PropServerKeepAlive, p.ServerKeepAlive)
+		writeStringProp(&b, PropAuthMethod, p.AuthMethod)
+		writeBytesProp(&b, PropAuthData, p.AuthData)
+		writeByteProp(&b, PropRequestProblemInfo, p.RequestProblemInfo)
+		writeUint32Prop(&b, PropWillDelayInterval, p.WillDelayInterval)
+		writeByteProp(&b, PropRequestResponseInfo, p.RequestResponseInfo)
+		writeStringProp(&b, PropResponseInfo, p.ResponseInfo)
+		writeStringProp(&b, PropServerReference, p.ServerReference)
+		writeStringProp(&b, PropReasonString, p.ReasonString)
+		writeUint16Prop(&b, PropReceiveMaximum, p.ReceiveMaximum)
+		writeUint16Prop(&b, PropTopicAliasMaximum, p.TopicAliasMaximum)
+		writeUint16Prop(&b, PropTopicAlias, p.TopicAlias)
+		writeByteProp(&b, PropMaximumQOS, p.MaximumQOS)
+		writeByteProp(&b, PropRetainAvailable, p.RetainAvailable)
+		for _, u := range p.User {
+			b.WriteByte(PropUser)
+			b.Write(encodeString(u.Key))
+			b.Write(encodeString(u.Value))
+		}
+		writeUint32Prop(&b, PropMaximumPacketSize, p.MaximumPacketSize)
+		writeByteProp(&b, PropWildcardSubAvailable, p.WildcardSubAvailable)
+		writeByteProp(&b, PropSubIDAvailable, p.SubIDAvailable)
+		writeByteProp(&b, PropSharedSubAvailable, p.SharedSubAvailable)
+	}
+
+	return append(encodeLength(b.Len()), b.Bytes()...)
+}
+
+// Unpack decodes the properties (preceded by their variable byte length)
+// from the reader
+func (p *Properties) Unpack(r io.Reader) error {
+	length, err := decodeLength(r)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return err
+	}
+	b := bytes.NewBuffer(buf)
+
+	for b.Len() > 0 {
+		id, err := b.ReadByte()
+		if err != nil {
+			return err
+		}
+
+		switch id {
+		case PropPayloadFormat:
+			p.PayloadFormat, err = readByteProp(b)
+		case PropMessageExpiry:
+			p.MessageExpiry, err = readUint32Prop(b)
+		case PropContentType:
+			p.ContentType, err = decodeString(b)
+		case PropResponseTopic:
+			p.ResponseTopic, err = decodeString(b)
+		case PropCorrelationData:
+			p.CorrelationData, err = decodeBytes(b)
+		case PropSubscriptionIdentifier:
+			var v int
+			v, err = decodeLength(b)
+			p.SubscriptionIdentifier = append(p.SubscriptionIdentifier, v)
+		case PropSessionExpiryInterval:
+			p.SessionExpiryInterval, err = readUint32Prop(b)
+		case PropAssignedClientID:
+			p.AssignedClientID, err = decodeString(b)
+		case PropServerKeepAlive:
+			p.ServerKeepAlive, err = readUint16Prop(b)
+		case PropAuthMethod:
+			p.AuthMethod, err = decodeString(b)
+		case PropAuthData:
+			p.AuthData, err = decodeBytes(b)
+		case PropRequestProblemInfo:
+			p.RequestProblemInfo, err = readByteProp(b)
+		case PropWillDelayInterval:
+			p.WillDelayInterval, err = readUint32Prop(b)
+		case PropRequestResponseInfo:
+			p.RequestResponseInfo, err = readByteProp(b)
+		case PropResponseInfo:
+			p.ResponseInfo, err = decodeString(b)
+		case PropServerReference:
+			p.ServerReference, err = decodeString(b)
+		case PropReasonString:
+			p.ReasonString, err = decodeString(b)
+		case PropReceiveMaximum:
+			p.ReceiveMaximum, err = readUint16Prop(b)
+		case PropTopicAliasMaximum:
+			p.TopicAliasMaximum, err = readUint16Prop(b)
+		case PropTopicAlias:
+			p.TopicAlias, err = readUint16Prop(b)
+		case PropMaximumQOS:
+			p.MaximumQOS, err = readByteProp(b)
+		case PropRetainAvailable:
+			p.RetainAvailable, err = readByteProp(b)
+		case PropUser:
+			var u UserProperty
+			if u.Key, err = decodeString(b); err == nil {
+				u.Value, err = decodeString(b)
+			}
+			p.User = append(p.User, u)
+		case PropMaximumPacketSize:
+			p.MaximumPacketSize, err = readUint32Prop(b)
+		case PropWildcardSubAvailable:
+			p.WildcardSubAvailable, err = readByteProp(b)
+		case PropSubIDAvailable:
+			p.SubIDAvailable, err = readByteProp(b)
+		case PropSharedSubAvailable:
+			p.SharedSubAvailable, err = readByteProp(b)
+		default:
+			return fmt.Errorf("%w: unknown identifier 0x%x", ErrInvalidProperty, id)
+		}
+		if err != nil {
+			return fmt.Errorf("%w 0x%x: %v", ErrInvalidProperty, id, err)
+		}
+	}
+
+	return nil
+}
+
+func writeByteProp(b *bytes.Buffer, id byte, v *byte) {
+	if v != nil {
+		b.WriteByte(id)
+		b.WriteByte(*v)
+	}
+}
+
+func writeUint16Prop(b *bytes.Buffer, id byte, v *uint16) {
+	if v != nil {
+		b.WriteByte(id)
+		b.Write(encodeUint16(*v))
+	}
+}
+
+func writeUint32Prop(b *bytes.Buffer, id byte, v *uint32) {
+	if v != nil {
+		b.WriteByte(id)
+		b.Write(encodeUint32(*v))
+	}
+}
+
+func writeStringProp(b *bytes.Buffer, id byte, v string) {
+	if v != "" {
+		b.WriteByte(id)
+		b.Write(encodeString(v))
+	}
+}
+
+func writeBytesProp(b *bytes.Buffer, id byte, v []byte) {
+	if len(v) > 0 {
+		b.WriteByte(id)
+		b.Write(encodeBytes(v))
+	}
+}
+
+func readByteProp(b io.Reader) (*byte, error) {
+	v, err := decodeByte(b)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func readUint16Prop(b io.Reader) (*uint16, error) {
+	v, err := decodeUint16(b)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func readUint32Prop(b io.Reader) (*uint32, error) {
+	v, err := decodeUint32(b)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func decodeUint32(b io.Reader) (uint32, error) {
+	num := make([]byte, 4)
+	if _, err := io.ReadFull(b, num); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(num), nil
+}
+
+func encodeUint32(num uint32) []byte {
+	bytesResult := make([]byte, 4)
+	binary.BigEndian.PutUint32(bytesResult, num)
+	return bytesResult
+}
+
+// isEmpty returns true if no property is set
+func (p *Properties) isEmpty() bool {
+	return p == nil || len(p.Pack()) == 1
+}
+
+// packAck encodes the variable header of a PUBACK, PUBREC, PUBREL or PUBCOMP
+// packet. With nil properties (MQTT 3.1/3.1.1) only the message ID is written;
+// with MQTT v5 the reason code and properties are omitted when they can be.
+func packAck(id uint16, reasonCode byte, props *Properties) []byte {
+	body := encodeUint16(id)
+	if props == nil || (reasonCode == ReasonSuccess && props.isEmpty()) {
+		return body
+	}
+
+	body = append(body, reasonCode)
+	if !props.isEmpty() {
+		body = append(body, props.Pack()...)
+	}
+	return body
+}
+
+// unpackAck decodes the variable header written by packAck. The reason
+// code and properties are only present if the remaining length allows it.
+func unpackAck(b io.Reader, remainingLength int, props *Properties) (uint16, byte, *Properties, error) {
+	id, err := decodeUint16(b)
+	if err != nil {
+		return 0, 0, props, err
+	}
+
+	var reasonCode byte
+	if remainingLength > 2 {
+		if props == nil {
+			props = &Properties{}
+		}
+		if reasonCode, err = decodeByte(b); err != nil {
+			return id, 0, props, err
+		}
+	}
+	if remainingLength > 3 {
+		err = props.Unpack(b)
+	}
+
+	return id, reasonCode, props, err
+}
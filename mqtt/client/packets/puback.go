@@ -25,20 +25,25 @@ import (
 // Puback MQTT packet
 type PubackPacket struct {
 	FixedHeader
-	MessageID uint16
+	MessageID  uint16
+	ReasonCode byte        // MQTT v5 only
+	Properties *Properties // MQTT v5 only
 }
 
 func (pa *PubackPacket) String() string {
+	if pa.Properties != nil {
+		return fmt.Sprintf("%s MessageID: %d ReasonCode: %d Properties: %s", pa.FixedHeader, pa.MessageID, pa.ReasonCode, pa.Properties)
+	}
 	return fmt.Sprintf("%s MessageID: %d", pa.FixedHeader, pa.MessageID)
 }
 
 func (pa *PubackPacket) Write(w io.Writer) error {
 	var err error
-	pa.FixedHeader.RemainingLength = 2
+	body := packAck(pa.MessageID, pa.ReasonCode, pa.Properties)
+	pa.FixedHeader.RemainingLength = len(body)
 	packet := pa.FixedHeader.pack()
-	packet.Write(encodeUint16(pa.MessageID))
+	packet.Write(body)
 	_, err = packet.WriteTo(w)
-
 	return err
 }
 
@@ -46,8 +51,7 @@ func (pa *PubackPacket) Write(w io.Writer) error {
 // header has been read
 func (pa *PubackPacket) Unpack(b io.Reader) error {
 	var err error
-	pa.MessageID, err = decodeUint16(b)
-
+	pa.MessageID, pa.ReasonCode, pa.Properties, err = unpackAck(b, pa.RemainingLength, pa.Properties)
 	return err
 }
 
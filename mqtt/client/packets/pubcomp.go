@@ -25,20 +25,25 @@ import (
 // Pubcomp MQTT packet
 type PubcompPacket struct {
 	FixedHeader
-	MessageID uint16
+	MessageID  uint16
+	ReasonCode byte        // MQTT v5 only
+	Properties *Properties // MQTT v5 only
 }
 
 func (pc *PubcompPacket) String() string {
+	if pc.Properties != nil {
+		return fmt.Sprintf("%s MessageID: %d ReasonCode: %d Properties: %s", pc.FixedHeader, pc.MessageID, pc.ReasonCode, pc.Properties)
+	}
 	return fmt.Sprintf("%s MessageID: %d", pc.FixedHeader, pc.MessageID)
 }
 
 func (pc *PubcompPacket) Write(w io.Writer) error {
 	var err error
-	pc.FixedHeader.RemainingLength = 2
+	body := packAck(pc.MessageID, pc.ReasonCode, pc.Properties)
+	pc.FixedHeader.RemainingLength = len(body)
 	packet := pc.FixedHeader.pack()
-	packet.Write(encodeUint16(pc.MessageID))
+	packet.Write(body)
 	_, err = packet.WriteTo(w)
-
 	return err
 }
 
@@ -46,8 +51,7 @@ func (pc *PubcompPacket) Write(w io.Writer) error {
 // header has been read
 func (pc *PubcompPacket) Unpack(b io.Reader) error {
 	var err error
-	pc.MessageID, err = decodeUint16(b)
-
+	pc.MessageID, pc.ReasonCode, pc.Properties, err = unpackAck(b, pc.RemainingLength, pc.Properties)
 	return err
 }
 
@@ -26,9 +26,10 @@ import (
 // Publish MQTT packet
 type PublishPacket struct {
 	FixedHeader
-	TopicName string
-	MessageID uint16
-	Payload   []byte
+	TopicName  string
+	MessageID  uint16
+	Payload    []byte
+	Properties *Properties // MQTT v5 only
 }
 
 func (p *PublishPacket) String() string {
@@ -43,6 +44,9 @@ func (p *PublishPacket) Write(w io.Writer) error {
 	if p.Qos > 0 {
 		body.Write(encodeUint16(p.MessageID))
 	}
+	if p.Properties != nil {
+		body.Write(p.Properties.Pack())
+	}
 	p.FixedHeader.RemainingLength = body.Len() + len(p.Payload)
 	packet := p.FixedHeader.pack()
 	packet.Write(body.Bytes())
@@ -71,6 +75,13 @@ func (p *PublishPacket) Unpack(b io.Reader) error {
 	} else {
 		payloadLength -= len(p.TopicName) + 2
 	}
+	if p.Properties != nil {
+		if err = p.Properties.Unpack(b); err != nil {
+			return err
+		}
+		p.Payload, err = io.ReadAll(b)
+		return err
+	}
 	if payloadLength < 0 {
 		return fmt.Errorf("error unpacking publish, payload length < 0")
 	}
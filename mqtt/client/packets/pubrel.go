@@ -25,20 +25,25 @@ import (
 // Pubrel MQTT packet
 type PubrelPacket struct {
 	FixedHeader
-	MessageID uint16
+	MessageID  uint16
+	ReasonCode byte        // MQTT v5 only
+	Properties *Properties // MQTT v5 only
 }
 
 func (pr *PubrelPacket) String() string {
+	if pr.Properties != nil {
+		return fmt.Sprintf("%s MessageID: %d ReasonCode: %d Properties: %s", pr.FixedHeader, pr.MessageID, pr.ReasonCode, pr.Properties)
+	}
 	return fmt.Sprintf("%s MessageID: %d", pr.FixedHeader, pr.MessageID)
 }
 
 func (pr *PubrelPacket) Write(w io.Writer) error {
 	var err error
-	pr.FixedHeader.RemainingLength = 2
+	body := packAck(pr.MessageID, pr.ReasonCode, pr.Properties)
+	pr.FixedHeader.RemainingLength = len(body)
 	packet := pr.FixedHeader.pack()
-	packet.Write(encodeUint16(pr.MessageID))
+	packet.Write(body)
 	_, err = packet.WriteTo(w)
-
 	return err
 }
 
@@ -46,8 +51,7 @@ func (pr *PubrelPacket) Write(w io.Writer) error {
 // header has been read
 func (pr *PubrelPacket) Unpack(b io.Reader) error {
 	var err error
-	pr.MessageID, err = decodeUint16(b)
-
+	pr.MessageID, pr.ReasonCode, pr.Properties, err = unpackAck(b, pr.RemainingLength, pr.Properties)
 	return err
 }
 
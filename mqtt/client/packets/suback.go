@@ -27,7 +27,8 @@ import (
 type SubackPacket struct {
 	FixedHeader
 	MessageID   uint16
-	ReturnCodes []byte
+	ReturnCodes []byte      // the MQTT v5 reason codes when Properties is set
+	Properties  *Properties // MQTT v5 only
 }
 
 func (sa *SubackPacket) String() string {
@@ -38,6 +39,9 @@ func (sa *SubackPacket) Write(w io.Writer) error {
 	var body bytes.Buffer
 	var err error
 	body.Write(encodeUint16(sa.MessageID))
+	if sa.Properties != nil {
+		body.Write(sa.Properties.Pack())
+	}
 	body.Write(sa.ReturnCodes)
 	sa.FixedHeader.RemainingLength = body.Len()
 	packet := sa.FixedHeader.pack()
@@ -56,6 +60,11 @@ func (sa *SubackPacket) Unpack(b io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if sa.Properties != nil {
+		if err = sa.Properties.Unpack(b); err != nil {
+			return err
+		}
+	}
 
 	_, err = qosBuffer.ReadFrom(b)
 	if err != nil {
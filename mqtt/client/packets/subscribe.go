@@ -26,9 +26,10 @@ import (
 // Subscribe MQTT packet
 type SubscribePacket struct {
 	FixedHeader
-	MessageID uint16
-	Topics    []string
-	Qoss      []byte
+	MessageID  uint16
+	Topics     []string
+	Qoss       []byte      // the subscription options (QoS, No Local, Retain As Published, Retain Handling) with MQTT v5
+	Properties *Properties // MQTT v5 only
 }
 
 func (s *SubscribePacket) String() string {
@@ -40,6 +41,9 @@ func (s *SubscribePacket) Write(w io.Writer) error {
 	var err error
 
 	body.Write(encodeUint16(s.MessageID))
+	if s.Properties != nil {
+		body.Write(s.Properties.Pack())
+	}
 	for i, topic := range s.Topics {
 		body.Write(encodeString(topic))
 		body.WriteByte(s.Qoss[i])
@@ -60,6 +64,26 @@ func (s *SubscribePacket) Unpack(b io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if s.Properties != nil {
+		if err = s.Properties.Unpack(b); err != nil {
+			return err
+		}
+		for {
+			topic, err := decodeString(b)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			s.Topics = append(s.Topics, topic)
+			options, err := decodeByte(b)
+			if err != nil {
+				return err
+			}
+			s.Qoss = append(s.Qoss, options)
+		}
+	}
 	payloadLength := s.FixedHeader.RemainingLength - 2
 	for payloadLength > 0 {
 		topic, err := decodeString(b)
@@ -17,6 +17,7 @@
 package packets
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 )
@@ -25,7 +26,9 @@ import (
 // Unsuback MQTT packet
 type UnsubackPacket struct {
 	FixedHeader
-	MessageID uint16
+	MessageID   uint16
+	ReasonCodes []byte      // MQTT v5 only
+	Properties  *Properties // MQTT v5 only
 }
 
 func (ua *UnsubackPacket) String() string {
@@ -33,12 +36,17 @@ func (ua *UnsubackPacket) String() string {
 }
 
 func (ua *UnsubackPacket) Write(w io.Writer) error {
+	var body bytes.Buffer
 	var err error
-	ua.FixedHeader.RemainingLength = 2
+	body.Write(encodeUint16(ua.MessageID))
+	if ua.Properties != nil {
+		body.Write(ua.Properties.Pack())
+		body.Write(ua.ReasonCodes)
+	}
+	ua.FixedHeader.RemainingLength = body.Len()
 	packet := ua.FixedHeader.pack()
-	packet.Write(encodeUint16(ua.MessageID))
+	packet.Write(body.Bytes())
 	_, err = packet.WriteTo(w)
-
 	return err
 }
 
@@ -47,7 +55,14 @@ func (ua *UnsubackPacket) Write(w io.Writer) error {
 func (ua *UnsubackPacket) Unpack(b io.Reader) error {
 	var err error
 	ua.MessageID, err = decodeUint16(b)
+	if err != nil || ua.Properties == nil {
+		return err
+	}
 
+	if err = ua.Properties.Unpack(b); err != nil {
+		return err
+	}
+	ua.ReasonCodes, err = io.ReadAll(b)
 	return err
 }
 
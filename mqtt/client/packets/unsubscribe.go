@@ -26,8 +26,9 @@ import (
 // Unsubscribe MQTT packet
 type UnsubscribePacket struct {
 	FixedHeader
-	MessageID uint16
-	Topics    []string
+	MessageID  uint16
+	Topics     []string
+	Properties *Properties // MQTT v5 only
 }
 
 func (u *UnsubscribePacket) String() string {
@@ -38,6 +39,9 @@ func (u *UnsubscribePacket) Write(w io.Writer) error {
 	var body bytes.Buffer
 	var err error
 	body.Write(encodeUint16(u.MessageID))
+	if u.Properties != nil {
+		body.Write(u.Properties.Pack())
+	}
 	for _, topic := range u.Topics {
 		body.Write(encodeString(topic))
 	}
@@ -57,6 +61,11 @@ func (u *UnsubscribePacket) Unpack(b io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if u.Properties != nil {
+		if err = u.Properties.Unpack(b); err != nil {
+			return err
+		}
+	}
 
 	for topic, err := decodeString(b); err == nil && topic != ""; topic, err = decodeString(b) {
 		u.Topics = append(u.Topics, topic)
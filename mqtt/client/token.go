@@ -141,6 +141,7 @@ type ConnectToken struct {
 	baseToken
 	returnCode     byte
 	sessionPresent bool
+	properties     *packets.Properties
 }
 
 // ReturnCode returns the acknowledgement code in the connack sent
@@ -159,11 +160,22 @@ func (c *ConnectToken) SessionPresent() bool {
 	return c.sessionPresent
 }
 
+// Properties returns the MQTT v5 properties of the connack sent in
+// response to a Connect() (e.g. assigned client identifier, server keep
+// alive or topic alias maximum). It returns nil with MQTT 3.1/3.1.1.
+func (c *ConnectToken) Properties() *packets.Properties {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.properties
+}
+
 // PublishToken is an extension of Token containing the extra fields
 // required to provide information about calls to Publish()
 type PublishToken struct {
 	baseToken
-	messageID uint16
+	messageID  uint16
+	reasonCode byte
+	properties *packets.Properties
 }
 
 // MessageID returns the MQTT message ID that was assigned to the
@@ -172,13 +184,31 @@ func (p *PublishToken) MessageID() uint16 {
 	return p.messageID
 }
 
+// ReasonCode returns the MQTT v5 reason code of the acknowledgement
+// (PUBACK, PUBREC or PUBCOMP) sent by the broker. A reason code of
+// 0x80 or greater is also reported by Error().
+func (p *PublishToken) ReasonCode() byte {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.reasonCode
+}
+
+// Properties returns the MQTT v5 properties of the acknowledgement sent
+// by the broker, if any
+func (p *PublishToken) Properties() *packets.Properties {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.properties
+}
+
 // SubscribeToken is an extension of Token containing the extra fields
 // required to provide information about calls to Subscribe()
 type SubscribeToken struct {
 	baseToken
-	subs      []string
-	subResult map[string]byte
-	messageID uint16
+	subs       []string
+	subResult  map[string]byte
+	messageID  uint16
+	properties *packets.Properties
 }
 
 // Result returns a map of topics that were subscribed to along with
@@ -190,11 +220,38 @@ func (s *SubscribeToken) Result() map[string]byte {
 	return s.subResult
 }
 
+// Properties returns the MQTT v5 properties of the suback sent by
+// the broker (e.g. reason string or user properties), if any
+func (s *SubscribeToken) Properties() *packets.Properties {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	return s.properties
+}
+
 // UnsubscribeToken is an extension of Token containing the extra fields
 // required to provide information about calls to Unsubscribe()
 type UnsubscribeToken struct {
 	baseToken
-	messageID uint16
+	messageID   uint16
+	reasonCodes []byte
+	properties  *packets.Properties
+}
+
+// ReasonCodes returns the MQTT v5 reason codes sent by the broker, one
+// per topic in the order they were passed to Unsubscribe(). It returns nil
+// with MQTT 3.1/3.1.1.
+func (u *UnsubscribeToken) ReasonCodes() []byte {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.reasonCodes
+}
+
+// Properties returns the MQTT v5 properties of the unsuback sent by
+// the broker, if any
+func (u *UnsubscribeToken) Properties() *packets.Properties {
+	u.m.RLock()
+	defer u.m.RUnlock()
+	return u.properties
 }
 
 // DisconnectToken is an extension of Token containing the extra fields
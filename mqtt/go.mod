@@ -1,3 +1,13 @@
 module mqtt
 
-go 1.22.3
\ No newline at end of file
+go 1.22.3
+
+require (
+	badger v0.0.0
+	boltdb v0.0.0
+)
+
+replace (
+	badger => ../badger
+	boltdb => ../boltdb
+)
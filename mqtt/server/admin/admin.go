@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// package admin provides an HTTP handler serving a REST API for inspecting and managing
+// the clients, subscriptions and retained messages of a server on /api/, see listeners.NewHTTPHandler.
+package admin
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	mqtt "mqtt/server"
+)
+
+const (
+	// Prefix is the path prefix of the API endpoints.
+	Prefix = "/api/v1"
+
+	// DefaultLimit is the page size of list endpoints when the limit parameter is not set.
+	DefaultLimit = 100
+
+	// MaxLimit is the largest page size of list endpoints.
+	MaxLimit = 1000
+
+	// MaxPublishSize is the largest body of a publish request in bytes.
+	MaxPublishSize = 8 << 20
+)
+
+var (
+	// ErrServerRequired indicates that the API was created without a server.
+	ErrServerRequired = errors.New("admin api requires a server")
+
+	// ErrTokenRequired indicates that the API was created without any tokens.
+	ErrTokenRequired = errors.New("admin api requires at least one token")
+)
+
+//go:embed schema.json
+var schema []byte
+
+// API is an http.Handler serving the admin REST API of a server. Every request
+// must carry one of the tokens, either as a bearer token or as the password of
+// basic auth, so that the API can also be used from a browser.
+type API struct {
+	server *mqtt.Server
+	tokens [][]byte
+	mux    *http.ServeMux
+}
+
+// New returns the admin API of a server, accepting the tokens.
+func New(server *mqtt.Server, tokens []string) (*API, error) {
+	if server == nil {
+		return nil, ErrServerRequired
+	}
+
+	a := &API{
+		server: server,
+		mux:    http.NewServeMux(),
+	}
+	for _, t := range tokens {
+		if t != "" {
+			a.tokens = append(a.tokens, []byte(t))
+		}
+	}
+	if len(a.tokens) == 0 {
+		return nil, ErrTokenRequired
+	}
+
+	a.mux.HandleFunc("GET "+Prefix+"/schema", a.getSchema)
+	a.mux.HandleFunc("GET "+Prefix+"/clients", a.listClients)
+	a.mux.HandleFunc("GET "+Prefix+"/clients/{id}", a.getClient)
+	a.mux.HandleFunc("DELETE "+Prefix+"/clients/{id}", a.disconnectClient)
+	a.mux.HandleFunc("GET "+Prefix+"/clients/{id}/subscriptions", a.listSubscriptions)
+	a.mux.HandleFunc("GET "+Prefix+"/clients/{id}/inflight", a.listInflight)
+	a.mux.HandleFunc("GET "+Prefix+"/subscribers", a.listSubscribers)
+	a.mux.HandleFunc("GET "+Prefix+"/retained", a.listRetained)
+	a.mux.HandleFunc("GET "+Prefix+"/retained/message", a.getRetained)
+	a.mux.HandleFunc("DELETE "+Prefix+"/retained/message", a.clearRetained)
+	a.mux.HandleFunc("POST "+Prefix+"/publish", a.publish)
+	a.mux.HandleFunc(Prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+
+	return a, nil
+}
+
+// ServeHTTP authenticates the request and serves the endpoint.
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(r) {
+		w.Header().Add("WWW-Authenticate", `Bearer realm="mqtt"`)
+		w.Header().Add("WWW-Authenticate", `Basic realm="mqtt"`)
+		writeError(w, http.StatusUnauthorized, "a valid token is required")
+		return
+	}
+
+	a.mux.ServeHTTP(w, r)
+}
+
+// authorized returns true if the request carries one of the tokens.
+func (a *API) authorized(r *http.Request) bool {
+	var token string
+	if _, password, ok := r.BasicAuth(); ok {
+		token = password
+	} else if h := r.Header.Get("Authorization"); len(h) > 7 && strings.EqualFold(h[:7], "Bearer ") {
+		token = strings.TrimSpace(h[7:])
+	}
+	if token == "" {
+		return false
+	}
+
+	ok := 0
+	for _, t := range a.tokens {
+		ok |= subtle.ConstantTimeCompare([]byte(token), t)
+	}
+	return ok == 1
+}
+
+// getSchema writes the JSON schema of the API resources.
+func (a *API) getSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	_, _ = w.Write(schema)
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+// writeError writes an Error response.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, Error{Error: msg})
+}
+
+// pagination returns the offset and limit query parameters of a list request.
+func pagination(r *http.Request) (offset, limit int, err error) {
+	limit = DefaultLimit
+	q := r.URL.Query()
+	if v := q.Get("offset"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil || offset < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > MaxLimit {
+			return 0, 0, errors.New("limit must be an integer from 1 to " + strconv.Itoa(MaxLimit))
+		}
+	}
+	return offset, limit, nil
+}
+
+// writePage writes the page of items selected by the pagination parameters of the request.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	offset, limit, err := pagination(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page := Page[T]{
+		Total:  len(items),
+		Offset: offset,
+		Limit:  limit,
+		Items:  []T{},
+	}
+	if offset < len(items) {
+		page.Items = items[offset:min(offset+limit, len(items))]
+	}
+	writeJSON(w, http.StatusOK, page)
+}
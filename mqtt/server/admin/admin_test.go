@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/auth"
+)
+
+const testToken = "secret"
+
+// newAPI returns the admin API of a running server with the inline client.
+func newAPI(t *testing.T) (*API, *mqtt.Server) {
+	t.Helper()
+
+	s := mqtt.New(&mqtt.Options{InlineClient: true})
+	if err := s.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+
+	a, err := New(s, []string{testToken})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, s
+}
+
+// do serves a request with the token and returns the response.
+func do(a *API, method, target, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+testToken)
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	a.ServeHTTP(w, r)
+	return w
+}
+
+func TestRetainedTopics(t *testing.T) {
+	a, s := newAPI(t)
+
+	for _, topic := range []string{"/lead", "a//b", "a/b/"} {
+		if err := s.Publish(topic, []byte(topic), true, 0); err != nil {
+			t.Fatal(err)
+		}
+		target := Prefix + "/retained/message?topic=" + url.QueryEscape(topic)
+
+		w := do(a, http.MethodGet, target, "", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s: status %d, %s", topic, w.Code, w.Body)
+		}
+		var msg Message
+		if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Topic != topic || msg.Payload != topic {
+			t.Errorf("GET %s: topic %q, payload %q", topic, msg.Topic, msg.Payload)
+		}
+
+		if w := do(a, http.MethodDelete, target, "", ""); w.Code != http.StatusNoContent {
+			t.Fatalf("DELETE %s: status %d, %s", topic, w.Code, w.Body)
+		}
+		if _, ok := s.Topics.Retained.Get(topic); ok {
+			t.Errorf("DELETE %s: retained message not cleared", topic)
+		}
+		if w := do(a, http.MethodGet, target, "", ""); w.Code != http.StatusNotFound {
+			t.Errorf("GET %s after DELETE: status %d", topic, w.Code)
+		}
+	}
+
+	if w := do(a, http.MethodGet, Prefix+"/retained/message", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("GET without topic: status %d", w.Code)
+	}
+}
+
+func TestPublishContentType(t *testing.T) {
+	a, s := newAPI(t)
+	body := `{"topic": "a/b", "payload": "hello", "retain": true}`
+
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		if w := do(a, http.MethodPost, Prefix+"/publish", contentType, body); w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("content type %q: status %d", contentType, w.Code)
+		}
+	}
+	if _, ok := s.Topics.Retained.Get("a/b"); ok {
+		t.Fatal("published without application/json")
+	}
+
+	if w := do(a, http.MethodPost, Prefix+"/publish", "application/json; charset=utf-8", body); w.Code != http.StatusNoContent {
+		t.Fatalf("status %d, %s", w.Code, w.Body)
+	}
+	if pk, ok := s.Topics.Retained.Get("a/b"); !ok || string(pk.Payload) != "hello" {
+		t.Errorf("retained message %v, %v", pk, ok)
+	}
+}
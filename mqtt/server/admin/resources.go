@@ -0,0 +1,429 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package admin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"sort"
+	"unicode/utf8"
+
+	mqtt "mqtt/server"
+	"mqtt/server/packets"
+)
+
+// Payload encodings.
+const (
+	EncodingUTF8   = "utf-8"
+	EncodingBase64 = "base64"
+)
+
+// Page is a page of a list, selected by the offset and limit query parameters.
+type Page[T any] struct {
+	Total  int `json:"total"`  // the number of items in the list
+	Offset int `json:"offset"` // the index of the first item of the page
+	Limit  int `json:"limit"`  // the largest number of items of the page
+	Items  []T `json:"items"`
+}
+
+// Error is the body of an error response.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// Client describes a client known by the server.
+type Client struct {
+	ID              string `json:"id"`
+	Listener        string `json:"listener"`
+	Remote          string `json:"remote"`
+	Username        string `json:"username"`
+	ProtocolVersion byte   `json:"protocol_version"`
+	Clean           bool   `json:"clean"`
+	Keepalive       uint16 `json:"keepalive"`
+	Connected       bool   `json:"connected"` // false if the session is retained after the client disconnected
+	Inline          bool   `json:"inline"`    // the built-in inline client
+	Subscriptions   int    `json:"subscriptions"`
+	Inflight        int    `json:"inflight"`
+	WillTopic       string `json:"will_topic,omitempty"`
+}
+
+// Subscription describes a subscription of a client.
+type Subscription struct {
+	Filter            string `json:"filter"`
+	Qos               byte   `json:"qos"`
+	NoLocal           bool   `json:"no_local"`
+	RetainAsPublished bool   `json:"retain_as_published"`
+	RetainHandling    byte   `json:"retain_handling"`
+	Identifier        int    `json:"identifier,omitempty"`
+}
+
+// Subscriber is a client subscribed to a topic.
+type Subscriber struct {
+	Client string `json:"client"`
+	Subscription
+	Share string `json:"share,omitempty"` // the group of a shared subscription
+}
+
+// Message describes a retained or inflight message.
+type Message struct {
+	Type     string `json:"type"` // the packet type, e.g. publish or pubrel for inflight messages
+	Topic    string `json:"topic"`
+	PacketID uint16 `json:"packet_id,omitempty"`
+	Qos      byte   `json:"qos"`
+	Retain   bool   `json:"retain"`
+	Payload  string `json:"payload"`
+	Encoding string `json:"encoding"`         // utf-8, or base64 if the payload is not valid utf-8
+	Created  int64  `json:"created"`          // unix time the message was received
+	Expiry   int64  `json:"expiry,omitempty"` // unix time the message expires
+}
+
+// PublishRequest is the body of a publish request.
+type PublishRequest struct {
+	Topic    string `json:"topic"`
+	Payload  string `json:"payload"`
+	Encoding string `json:"encoding"` // utf-8 (default) or base64
+	Qos      byte   `json:"qos"`
+	Retain   bool   `json:"retain"`
+}
+
+// newClient returns the description of a client.
+func newClient(cl *mqtt.Client) Client {
+	return Client{
+		ID:              cl.ID,
+		Listener:        cl.Net.Listener,
+		Remote:          cl.Net.Remote,
+		Username:        string(cl.Properties.Username),
+		ProtocolVersion: cl.Properties.ProtocolVersion,
+		Clean:           cl.Properties.Clean,
+		Keepalive:       cl.State.Keepalive,
+		Connected:       !cl.Closed(),
+		Inline:          cl.Net.Inline,
+		Subscriptions:   cl.State.Subscriptions.Len(),
+		Inflight:        cl.State.Inflight.Len(),
+		WillTopic:       cl.Properties.Will.TopicName,
+	}
+}
+
+// newSubscription returns the description of a subscription.
+func newSubscription(sub packets.Subscription) Subscription {
+	return Subscription{
+		Filter:            sub.Filter,
+		Qos:               sub.Qos,
+		NoLocal:           sub.NoLocal,
+		RetainAsPublished: sub.RetainAsPublished,
+		RetainHandling:    sub.RetainHandling,
+		Identifier:        sub.Identifier,
+	}
+}
+
+// newMessage returns the description of a message packet.
+func newMessage(pk packets.Packet) Message {
+	m := Message{
+		Type:     packetType(pk.FixedHeader.Type),
+		Topic:    pk.TopicName,
+		PacketID: pk.PacketID,
+		Qos:      pk.FixedHeader.Qos,
+		Retain:   pk.FixedHeader.Retain,
+		Created:  pk.Created,
+		Encoding: EncodingUTF8,
+		Payload:  string(pk.Payload),
+	}
+	if pk.Expiry > 0 {
+		m.Expiry = pk.Expiry
+	}
+	if !utf8.Valid(pk.Payload) {
+		m.Encoding = EncodingBase64
+		m.Payload = base64.StdEncoding.EncodeToString(pk.Payload)
+	}
+	return m
+}
+
+// packetType returns the lower case name of a packet type.
+func packetType(t byte) string {
+	switch t {
+	case packets.Publish:
+		return "publish"
+	case packets.Puback:
+		return "puback"
+	case packets.Pubrec:
+		return "pubrec"
+	case packets.Pubrel:
+		return "pubrel"
+	case packets.Pubcomp:
+		return "pubcomp"
+	}
+	return packets.PacketNames[t]
+}
+
+// client returns the client of the id path value, or writes a not found error.
+func (a *API) client(w http.ResponseWriter, r *http.Request) (*mqtt.Client, bool) {
+	cl, ok := a.server.Clients.Get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "client not found")
+	}
+	return cl, ok
+}
+
+// listClients writes a page of the clients sorted by id, optionally filtered by
+// the listener and connected query parameters.
+func (a *API) listClients(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	listener := q.Get("listener")
+	connected := q.Get("connected")
+	if connected != "" && connected != "true" && connected != "false" {
+		writeError(w, http.StatusBadRequest, "connected must be true or false")
+		return
+	}
+
+	clients := []Client{}
+	for _, cl := range a.server.Clients.GetAll() {
+		if listener != "" && cl.Net.Listener != listener {
+			continue
+		}
+		c := newClient(cl)
+		if connected != "" && (connected == "true") != c.Connected {
+			continue
+		}
+		clients = append(clients, c)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+
+	writePage(w, r, clients)
+}
+
+// getClient writes a client.
+func (a *API) getClient(w http.ResponseWriter, r *http.Request) {
+	if cl, ok := a.client(w, r); ok {
+		writeJSON(w, http.StatusOK, newClient(cl))
+	}
+}
+
+// disconnectClient disconnects a connected client with the administrative action reason code.
+func (a *API) disconnectClient(w http.ResponseWriter, r *http.Request) {
+	cl, ok := a.client(w, r)
+	if !ok {
+		return
+	}
+	if cl.Net.Inline {
+		writeError(w, http.StatusBadRequest, "the inline client cannot be disconnected")
+		return
+	}
+	if cl.Closed() {
+		writeError(w, http.StatusConflict, "client is not connected")
+		return
+	}
+
+	_ = a.server.DisconnectClient(cl, packets.ErrAdministrativeAction)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listSubscriptions writes a page of the subscriptions of a client sorted by filter.
+func (a *API) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	cl, ok := a.client(w, r)
+	if !ok {
+		return
+	}
+
+	subs := []Subscription{}
+	for _, sub := range cl.State.Subscriptions.GetAll() {
+		subs = append(subs, newSubscription(sub))
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Filter < subs[j].Filter
+	})
+
+	writePage(w, r, subs)
+}
+
+// listInflight writes a page of the inflight messages of a client sorted by creation.
+func (a *API) listInflight(w http.ResponseWriter, r *http.Request) {
+	cl, ok := a.client(w, r)
+	if !ok {
+		return
+	}
+
+	msgs := []Message{}
+	for _, pk := range cl.State.Inflight.GetAll(false) {
+		msgs = append(msgs, newMessage(pk))
+	}
+	sort.SliceStable(msgs, func(i, j int) bool {
+		if msgs[i].Created != msgs[j].Created {
+			return msgs[i].Created < msgs[j].Created
+		}
+		return msgs[i].PacketID < msgs[j].PacketID
+	})
+
+	writePage(w, r, msgs)
+}
+
+// listSubscribers writes a page of the client subscriptions matching the topic
+// query parameter, sorted by client id and filter. Inline subscriptions are not included.
+func (a *API) listSubscribers(w http.ResponseWriter, r *http.Request) {
+	topic := r.URL.Query().Get("topic")
+	if topic == "" {
+		writeError(w, http.StatusBadRequest, "topic is required")
+		return
+	}
+
+	subscribers := a.server.Topics.Subscribers(topic)
+	subs := []Subscriber{}
+	for id, sub := range subscribers.Subscriptions {
+		subs = append(subs, Subscriber{Client: id, Subscription: newSubscription(sub)})
+	}
+	for group, clients := range subscribers.Shared {
+		for id, sub := range clients {
+			subs = append(subs, Subscriber{Client: id, Subscription: newSubscription(sub), Share: group})
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		if subs[i].Client != subs[j].Client {
+			return subs[i].Client < subs[j].Client
+		}
+		return subs[i].Filter < subs[j].Filter
+	})
+
+	writePage(w, r, subs)
+}
+
+// listRetained writes a page of the retained messages matching the filter query
+// parameter, # by default, sorted by topic.
+func (a *API) listRetained(w http.ResponseWriter, r *http.Request) {
+	filter := r.URL.Query().Get("filter")
+	if filter == "" {
+		filter = "#"
+	}
+	if !mqtt.IsValidFilter(filter, false) {
+		writeError(w, http.StatusBadRequest, "invalid topic filter")
+		return
+	}
+
+	msgs := []Message{}
+	for _, pk := range a.server.Topics.Messages(filter) {
+		msgs = append(msgs, newMessage(pk))
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].Topic < msgs[j].Topic
+	})
+
+	writePage(w, r, msgs)
+}
+
+// retainedTopic returns the topic query parameter of a retained message request.
+// The topic is not part of the path, which is cleaned by the mux, so that topics
+// like /a or a//b are not changed.
+func retainedTopic(w http.ResponseWriter, r *http.Request) (string, bool) {
+	topic := r.URL.Query().Get("topic")
+	if topic == "" {
+		writeError(w, http.StatusBadRequest, "topic is required")
+		return "", false
+	}
+	return topic, true
+}
+
+// getRetained writes the retained message of a topic.
+func (a *API) getRetained(w http.ResponseWriter, r *http.Request) {
+	topic, ok := retainedTopic(w, r)
+	if !ok {
+		return
+	}
+
+	pk, ok := a.server.Topics.Retained.Get(topic)
+	if !ok {
+		writeError(w, http.StatusNotFound, "no retained message")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newMessage(pk))
+}
+
+// clearRetained clears the retained message of a topic by publishing an empty
+// retained message through the inline client, so that storage hooks are updated.
+func (a *API) clearRetained(w http.ResponseWriter, r *http.Request) {
+	topic, ok := retainedTopic(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := a.server.Topics.Retained.Get(topic); !ok {
+		writeError(w, http.StatusNotFound, "no retained message")
+		return
+	}
+
+	if err := a.server.Publish(topic, nil, true, 0); err != nil {
+		writePublishError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// publish publishes a message through the inline client. The body must be sent
+// as application/json, which browsers only send cross-origin after a CORS
+// preflight, so that other sites cannot publish with the basic auth credentials
+// of a browser.
+func (a *API) publish(w http.ResponseWriter, r *http.Request) {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, "the content type must be application/json")
+		return
+	}
+
+	var req PublishRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxPublishSize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid publish request: "+err.Error())
+		return
+	}
+
+	payload, err := req.validate()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := a.server.Publish(req.Topic, payload, req.Retain, req.Qos); err != nil {
+		writePublishError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// validate checks the request and returns the decoded payload.
+func (req *PublishRequest) validate() ([]byte, error) {
+	if req.Topic == "" {
+		return nil, errors.New("topic is required")
+	}
+	if !mqtt.IsValidFilter(req.Topic, true) {
+		return nil, errors.New("invalid topic, wildcards and $SYS topics cannot be published")
+	}
+	if req.Qos > 2 {
+		return nil, errors.New("qos must be 0, 1 or 2")
+	}
+
+	switch req.Encoding {
+	case "", EncodingUTF8:
+		return []byte(req.Payload), nil
+	case EncodingBase64:
+		b, err := base64.StdEncoding.DecodeString(req.Payload)
+		if err != nil {
+			return nil, errors.New("payload is not valid base64")
+		}
+		return b, nil
+	}
+	return nil, errors.New("encoding must be utf-8 or base64")
+}
+
+// writePublishError writes an error returned by the server when publishing.
+func writePublishError(w http.ResponseWriter, err error) {
+	if errors.Is(err, mqtt.ErrInlineClientNotEnabled) {
+		writeError(w, http.StatusConflict, "the inline client is not enabled, set options.inline_client")
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
@@ -0,0 +1,72 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// Command mqtt runs a standalone broker, configured by flags or by a YAML or JSON file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"mqtt/server/config"
+)
+
+func main() {
+	tcpAddr := flag.String("tcp", ":1883", "network address for TCP listener")
+	wsAddr := flag.String("ws", ":1882", "network address for Websocket listener")
+	infoAddr := flag.String("info", ":8080", "network address for web info dashboard listener")
+	configFile := flag.String("config", "", "YAML or JSON configuration file, replaces the listener flags")
+	check := flag.Bool("check", false, "validate the configuration file and exit")
+	flag.Parse()
+
+	conf := &config.Config{
+		Listeners: []config.Listener{
+			{Type: config.TypeTCP, ID: "t1", Address: *tcpAddr},
+			{Type: config.TypeWebsocket, ID: "ws1", Address: *wsAddr},
+			{Type: config.TypeSysInfo, ID: "info", Address: *infoAddr},
+		},
+		Hooks: config.Hooks{
+			Auth: &config.Auth{AllowAll: true},
+		},
+	}
+	if *configFile != "" {
+		var err error
+		if conf, err = config.FromFile(*configFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if *check {
+		fmt.Println("configuration ok")
+		return
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+
+	server, err := conf.NewServer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if conf.Hooks.Auth == nil {
+		server.Log.Warn("no auth hook configured, all clients will be refused")
+	}
+
+	go func() {
+		err := server.Serve()
+		if err != nil {
+			server.Log.Error("failed to start server", "error", err)
+			sigs <- syscall.SIGTERM
+		}
+	}()
+
+	sig := <-sigs
+	server.Log.Warn("caught signal, stopping...", "signal", sig.String())
+	_ = server.Close()
+	server.Log.Info("main.go finished")
+}
@@ -0,0 +1,571 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// package config loads the listeners, options and hooks of a broker server from a YAML or JSON file.
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	mqtt "mqtt/server"
+	"mqtt/server/admin"
+	"mqtt/server/hooks/auth"
+	"mqtt/server/hooks/bridge"
+	"mqtt/server/hooks/metrics"
+	"mqtt/server/hooks/storage/badger"
+	"mqtt/server/hooks/storage/bolt"
+	"mqtt/server/listeners"
+	"mqtt/util/yaml"
+)
+
+// Listener types.
+const (
+	TypeTCP         = "tcp"
+	TypeWebsocket   = "ws"
+	TypeUnix        = "unix"
+	TypeHealthCheck = "healthcheck"
+	TypeSysInfo     = "sysinfo"
+	TypeMetrics     = "metrics"
+	TypeAdmin       = "admin"
+)
+
+// Config is the configuration of a broker server. JSON files are decoded
+// with the same keys, as JSON is a subset of YAML.
+type Config struct {
+	Listeners []Listener   `yaml:"listeners" json:"listeners"`
+	Hooks     Hooks        `yaml:"hooks" json:"hooks"`
+	Options   mqtt.Options `yaml:"options" json:"options"`
+	Logging   Logging      `yaml:"logging" json:"logging"`
+
+	root *yaml.Node // the decoded document, for locating keys in errors
+	dir  string     // the directory of the file, relative paths are resolved against it
+}
+
+// Listener is the configuration of a network listener.
+type Listener struct {
+	Type    string   `yaml:"type" json:"type"`       // tcp, ws, unix, healthcheck, sysinfo, metrics or admin
+	ID      string   `yaml:"id" json:"id"`           // the unique id of the listener
+	Address string   `yaml:"address" json:"address"` // the address, or socket path for unix listeners
+	TLS     *TLS     `yaml:"tls" json:"tls"`         // optional tls, not available for unix listeners
+	Tokens  []string `yaml:"tokens" json:"tokens"`   // the tokens accepted by admin listeners
+
+	tlsConfig *tls.Config
+}
+
+// TLS is the tls configuration of a listener.
+type TLS struct {
+	CertFile   string `yaml:"cert_file" json:"cert_file"`     // PEM encoded certificate chain
+	KeyFile    string `yaml:"key_file" json:"key_file"`       // PEM encoded private key
+	CAFile     string `yaml:"ca_file" json:"ca_file"`         // PEM encoded CAs which verify client certificates
+	ClientAuth bool   `yaml:"client_auth" json:"client_auth"` // require and verify client certificates against CAFile
+}
+
+// Hooks configures the hooks added to the server.
+type Hooks struct {
+	Auth    *Auth            `yaml:"auth" json:"auth"`
+	Storage *Storage         `yaml:"storage" json:"storage"`
+	Bridges []bridge.Options `yaml:"bridges" json:"bridges"`
+	Metrics *metrics.Options `yaml:"metrics" json:"metrics"` // count events for metrics listeners and time the other hooks
+}
+
+// Auth configures the authentication and ACL hook. Exactly one of the
+// fields should be set.
+type Auth struct {
+	AllowAll   bool         `yaml:"allow_all" json:"allow_all"`     // allow all clients and topics
+	Ledger     *auth.Ledger `yaml:"ledger" json:"ledger"`           // inline auth ledger
+	LedgerFile string       `yaml:"ledger_file" json:"ledger_file"` // YAML or JSON file of the auth ledger
+}
+
+// Storage configures the persistent storage hook. At most one of the
+// fields may be set.
+type Storage struct {
+	Badger *badger.Options `yaml:"badger" json:"badger"`
+	Bolt   *bolt.Options   `yaml:"bolt" json:"bolt"`
+}
+
+// Logging configures the server logger.
+type Logging struct {
+	Level  string `yaml:"level" json:"level"`   // debug, info (default), warn or error
+	Format string `yaml:"format" json:"format"` // text (default) or json
+
+	level slog.Level
+}
+
+// Error is a configuration error of a key.
+type Error struct {
+	Key  string // the path of the key, e.g. listeners[1].address
+	Line int    // the line of the key in the file, 0 if unknown
+	Err  error
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %v", e.Key, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// FromFile loads and validates the configuration in a YAML or JSON file.
+// Relative paths in the configuration are resolved against the directory of the file.
+func FromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parse(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// FromBytes loads and validates the configuration in YAML or JSON data.
+func FromBytes(data []byte) (*Config, error) {
+	return parse(data, "")
+}
+
+func parse(data []byte, dir string) (*Config, error) {
+	c := &Config{
+		Options: mqtt.Options{
+			Capabilities: mqtt.NewDefaultServerCapabilities(),
+		},
+		root: new(yaml.Node),
+		dir:  dir,
+	}
+
+	if err := yaml.Unmarshal(data, c.root); err != nil {
+		return nil, err
+	}
+	if c.root.Kind != 0 {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil {
+			return nil, c.decodeError(err)
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validate checks the values of the configuration and loads the referenced files.
+func (c *Config) validate() error {
+	ids := map[string]int{}
+	for i := range c.Listeners {
+		l := &c.Listeners[i]
+		key := func(k string) []any { return []any{"listeners", i, k} }
+		switch l.Type {
+		case TypeTCP, TypeWebsocket, TypeUnix, TypeHealthCheck, TypeSysInfo:
+		case TypeMetrics:
+			if c.Hooks.Metrics == nil {
+				return c.errorf(key("type"), "metrics listeners require the metrics hook, set hooks.metrics")
+			}
+		case TypeAdmin:
+			if len(l.Tokens) == 0 {
+				return c.errorf(key("tokens"), "admin listeners require at least one token")
+			}
+			for j, t := range l.Tokens {
+				if t == "" {
+					return c.errorf(append(key("tokens"), j), "token must not be empty")
+				}
+			}
+		case "":
+			return c.errorf(key("type"), "listener type is required")
+		default:
+			return c.errorf(key("type"), "unknown listener type %q", l.Type)
+		}
+		if len(l.Tokens) > 0 && l.Type != TypeAdmin {
+			return c.errorf(key("tokens"), "tokens are only available for admin listeners")
+		}
+		if l.ID == "" {
+			return c.errorf(key("id"), "listener id is required")
+		}
+		if j, ok := ids[l.ID]; ok {
+			return c.errorf(key("id"), "listener id %q is already used by listeners[%d]", l.ID, j)
+		}
+		ids[l.ID] = i
+		if l.Address == "" {
+			return c.errorf(key("address"), "listener address is required")
+		}
+		if l.TLS != nil {
+			if l.Type == TypeUnix {
+				return c.errorf(key("tls"), "tls is not available for unix listeners")
+			}
+			var err error
+			if l.tlsConfig, err = c.loadTLS(l.TLS, append(key("tls"), "")); err != nil {
+				return err
+			}
+		}
+	}
+
+	if a := c.Hooks.Auth; a != nil {
+		key := []any{"hooks", "auth", ""}
+		set := 0
+		for _, ok := range []bool{a.AllowAll, a.Ledger != nil, a.LedgerFile != ""} {
+			if ok {
+				set++
+			}
+		}
+		if set != 1 {
+			return c.errorf(key[:2], "exactly one of allow_all, ledger and ledger_file must be set")
+		}
+		if a.LedgerFile != "" {
+			key[2] = "ledger_file"
+			data, err := os.ReadFile(c.path(a.LedgerFile))
+			if err != nil {
+				return c.errorf(key, "%w", err)
+			}
+			a.Ledger = new(auth.Ledger)
+			if err := a.Ledger.Unmarshal(data); err != nil {
+				return c.errorf(key, "%w", err)
+			}
+		}
+	}
+
+	if s := c.Hooks.Storage; s != nil {
+		switch {
+		case s.Badger != nil && s.Bolt != nil:
+			return c.errorf([]any{"hooks", "storage"}, "only one of badger and bolt may be set")
+		case s.Badger != nil && s.Badger.Path != "":
+			s.Badger.Path = c.path(s.Badger.Path)
+		case s.Bolt != nil && s.Bolt.Path != "":
+			s.Bolt.Path = c.path(s.Bolt.Path)
+		}
+	}
+
+	for i, b := range c.Hooks.Bridges {
+		if b.Broker == "" {
+			return c.errorf([]any{"hooks", "bridges", i, "broker"}, "%w", bridge.ErrBrokerRequired)
+		}
+	}
+
+	caps := c.Options.Capabilities
+	if caps == nil {
+		caps = mqtt.NewDefaultServerCapabilities()
+		c.Options.Capabilities = caps
+	}
+	key := func(k string) []any { return []any{"options", "capabilities", k} }
+	if caps.MaximumQos > 2 {
+		return c.errorf(key("maximum_qos"), "must be 0, 1 or 2")
+	}
+	if caps.MinimumProtocolVersion < 3 || caps.MinimumProtocolVersion > 5 {
+		return c.errorf(key("minimum_protocol_version"), "must be 3, 4 or 5")
+	}
+	for k, v := range map[string]byte{
+		"shared_sub_available":   caps.SharedSubAvailable,
+		"retain_available":       caps.RetainAvailable,
+		"wildcard_sub_available": caps.WildcardSubAvailable,
+		"sub_id_available":       caps.SubIDAvailable,
+	} {
+		if v > 1 {
+			return c.errorf(key(k), "must be 0 or 1")
+		}
+	}
+
+	if c.Logging.Level != "" {
+		if err := c.Logging.level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+			return c.errorf([]any{"logging", "level"}, "unknown log level %q", c.Logging.Level)
+		}
+	}
+	switch c.Logging.Format {
+	case "", "text", "json":
+	default:
+		return c.errorf([]any{"logging", "format"}, "unknown log format %q", c.Logging.Format)
+	}
+
+	return nil
+}
+
+// loadTLS loads the certificates of the tls configuration. The last
+// element of key is replaced by the field name in errors.
+func (c *Config) loadTLS(t *TLS, key []any) (*tls.Config, error) {
+	field := func(name string) []any {
+		key[len(key)-1] = name
+		return key
+	}
+	if t.CertFile == "" {
+		return nil, c.errorf(field("cert_file"), "certificate file is required")
+	}
+	if t.KeyFile == "" {
+		return nil, c.errorf(field("key_file"), "key file is required")
+	}
+	cert, err := tls.LoadX509KeyPair(c.path(t.CertFile), c.path(t.KeyFile))
+	if err != nil {
+		return nil, c.errorf(field("cert_file"), "%w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+	if t.CAFile != "" {
+		data, err := os.ReadFile(c.path(t.CAFile))
+		if err != nil {
+			return nil, c.errorf(field("ca_file"), "%w", err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(data) {
+			return nil, c.errorf(field("ca_file"), "no certificates found")
+		}
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	if t.ClientAuth {
+		if t.CAFile == "" {
+			return nil, c.errorf(field("client_auth"), "ca_file is required to verify client certificates")
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// path resolves p against the directory of the configuration file.
+func (c *Config) path(p string) string {
+	if c.dir == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(c.dir, p)
+}
+
+// errorf returns an *Error for the key path, which holds map keys and
+// sequence indexes.
+func (c *Config) errorf(path []any, format string, a ...any) error {
+	return &Error{Key: keyString(path), Line: c.line(path), Err: fmt.Errorf(format, a...)}
+}
+
+// keyString returns the key path as a string, e.g. listeners[1].address.
+func keyString(path []any) string {
+	var key strings.Builder
+	for _, k := range path {
+		switch k := k.(type) {
+		case int:
+			key.WriteString("[" + strconv.Itoa(k) + "]")
+		case string:
+			if key.Len() > 0 {
+				key.WriteString(".")
+			}
+			key.WriteString(k)
+		}
+	}
+	return key.String()
+}
+
+// decodeError returns the first error of a *yaml.TypeError of the decoder,
+// which only knows the line, as an *Error of the key on that line.
+func (c *Config) decodeError(err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) || len(typeErr.Errors) == 0 {
+		return err
+	}
+	var line int
+	msg := typeErr.Errors[0]
+	if _, scanErr := fmt.Sscanf(msg, "line %d: ", &line); scanErr != nil {
+		return err
+	}
+	msg = msg[strings.Index(msg, ": ")+2:]
+	var field string
+	if _, scanErr := fmt.Sscanf(msg, "field %s not found in type", &field); scanErr == nil {
+		msg = "unknown field"
+	} else {
+		field = ""
+	}
+	path := nodePath(c.root, line, field, nil)
+	if path == nil {
+		return err
+	}
+	return &Error{Key: keyString(path), Line: line, Err: errors.New(msg)}
+}
+
+// nodePath returns the key path of the deepest value on line, or of the
+// mapping key named field on line if field is set, or nil if there is none.
+func nodePath(n *yaml.Node, line int, field string, path []any) []any {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) > 0 {
+			return nodePath(n.Content[0], line, field, path)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			if p := nodePath(item, line, field, append(path[:len(path):len(path)], i)); p != nil {
+				return p
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			p := append(path[:len(path):len(path)], k.Value)
+			if found := nodePath(v, line, field, p); found != nil {
+				return found
+			}
+			if field != "" && k.Line == line && k.Value == field {
+				return p
+			}
+		}
+	}
+	if field == "" && n.Line == line && len(path) > 0 {
+		return path
+	}
+	return nil
+}
+
+// line returns the line of the deepest node of the key path found in the document.
+func (c *Config) line(path []any) int {
+	n := c.root
+	if n == nil || n.Kind == 0 {
+		return 0
+	}
+	if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+		n = n.Content[0]
+	}
+	line := 0
+	for _, k := range path {
+		var next *yaml.Node
+		switch k := k.(type) {
+		case int:
+			if n.Kind == yaml.SequenceNode && k < len(n.Content) {
+				next = n.Content[k]
+				line = next.Line
+			}
+		case string:
+			if n.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(n.Content); i += 2 {
+					if n.Content[i].Value == k {
+						next = n.Content[i+1]
+						line = n.Content[i].Line
+						break
+					}
+				}
+			}
+		}
+		if next == nil {
+			return line
+		}
+		n = next
+	}
+	return line
+}
+
+// Logger returns the logger configured by the logging settings.
+func (c *Config) Logger() *slog.Logger {
+	opts := &slog.HandlerOptions{Level: c.Logging.level}
+	if c.Logging.Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stdout, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, opts))
+}
+
+// NewServer returns a server with the options, hooks and listeners of the configuration.
+// The server is not started.
+func (c *Config) NewServer() (*mqtt.Server, error) {
+	opts := c.Options
+	if opts.Logger == nil {
+		opts.Logger = c.Logger()
+	}
+	server := mqtt.New(&opts)
+	if err := c.setup(server); err != nil {
+		_ = server.Close()
+		return nil, err
+	}
+	return server, nil
+}
+
+// setup adds the hooks and listeners to the server. If the metrics hook is
+// configured, it is added first and the other hooks are instrumented by it.
+func (c *Config) setup(server *mqtt.Server) error {
+	var metricsHook *metrics.Hook
+	addHook := func(hook mqtt.Hook, config any) error {
+		if metricsHook != nil {
+			hook = metricsHook.Instrument(hook)
+		}
+		return server.AddHook(hook, config)
+	}
+
+	if m := c.Hooks.Metrics; m != nil {
+		opts := *m
+		opts.Server = server
+		hook := new(metrics.Hook)
+		if err := server.AddHook(hook, &opts); err != nil {
+			return c.errorf([]any{"hooks", "metrics"}, "%w", err)
+		}
+		metricsHook = hook
+	}
+
+	if a := c.Hooks.Auth; a != nil {
+		var err error
+		if a.AllowAll {
+			err = addHook(new(auth.AllowHook), nil)
+		} else {
+			err = addHook(new(auth.Hook), &auth.Options{Ledger: a.Ledger})
+		}
+		if err != nil {
+			return c.errorf([]any{"hooks", "auth"}, "%w", err)
+		}
+	}
+
+	if s := c.Hooks.Storage; s != nil {
+		switch {
+		case s.Badger != nil:
+			if err := addHook(new(badger.Hook), s.Badger); err != nil {
+				return c.errorf([]any{"hooks", "storage", "badger"}, "%w", err)
+			}
+		case s.Bolt != nil:
+			if err := addHook(new(bolt.Hook), s.Bolt); err != nil {
+				return c.errorf([]any{"hooks", "storage", "bolt"}, "%w", err)
+			}
+		}
+	}
+
+	for i := range c.Hooks.Bridges {
+		b := c.Hooks.Bridges[i]
+		b.Server = server
+		if err := addHook(new(bridge.Hook), &b); err != nil {
+			return c.errorf([]any{"hooks", "bridges", i}, "%w", err)
+		}
+	}
+
+	for i, l := range c.Listeners {
+		config := &listeners.Config{TLSConfig: l.tlsConfig}
+		var listener listeners.Listener
+		switch l.Type {
+		case TypeTCP:
+			listener = listeners.NewTCP(l.ID, l.Address, config)
+		case TypeWebsocket:
+			listener = listeners.NewWebsocket(l.ID, l.Address, config)
+		case TypeUnix:
+			listener = listeners.NewUnixSock(l.ID, l.Address)
+		case TypeHealthCheck:
+			listener = listeners.NewHTTPHealthCheck(l.ID, l.Address, config)
+		case TypeSysInfo:
+			listener = listeners.NewHTTPStats(l.ID, l.Address, config, server.Info)
+		case TypeMetrics:
+			listener = listeners.NewHTTPHandler(l.ID, l.Address, "/metrics", config, metricsHook)
+		case TypeAdmin:
+			api, err := admin.New(server, l.Tokens)
+			if err != nil {
+				return c.errorf([]any{"listeners", i}, "%w", err)
+			}
+			listener = listeners.NewHTTPHandler(l.ID, l.Address, "/api/", config, api)
+		}
+		if err := server.AddListener(listener); err != nil {
+			return c.errorf([]any{"listeners", i}, "%w", err)
+		}
+	}
+
+	return nil
+}
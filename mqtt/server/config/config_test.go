@@ -0,0 +1,320 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFiles writes the files to a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testCertificate returns a PEM encoded self-signed certificate and its key.
+func testCertificate(t *testing.T) (cert, key string) {
+	t.Helper()
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		DNSNames:              []string{"localhost"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	key = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return cert, key
+}
+
+// checkError checks that err is an *Error of key on line.
+func checkError(t *testing.T, name string, err error, key string, line int, contains string) {
+	t.Helper()
+
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Errorf("%s: error %v, want *Error", name, err)
+		return
+	}
+	if cfgErr.Key != key || cfgErr.Line != line || !strings.Contains(cfgErr.Err.Error(), contains) {
+		t.Errorf("%s: error %q, want %s (line %d): ...%s...", name, err, key, line, contains)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		key      string
+		line     int
+		contains string
+	}{
+		{
+			name: "missing id",
+			data: `listeners:
+  - type: tcp
+    id: t1
+    address: :1883
+  - type: ws
+    address: :1882
+`,
+			// The line is the one of the listener without the key
+			key:      "listeners[1].id",
+			line:     5,
+			contains: "listener id is required",
+		},
+		{
+			name: "unknown listener type",
+			data: `listeners:
+  - type: quic
+    id: q1
+    address: :1883
+`,
+			key:      "listeners[0].type",
+			line:     2,
+			contains: `unknown listener type "quic"`,
+		},
+		{
+			name: "duplicate id",
+			data: `listeners:
+  - type: tcp
+    id: t1
+    address: :1883
+  - type: ws
+    id: t1
+    address: :1882
+`,
+			key:      "listeners[1].id",
+			line:     6,
+			contains: `listener id "t1" is already used by listeners[0]`,
+		},
+		{
+			name: "unknown field",
+			data: `listeners:
+  - type: tcp
+    id: t1
+    address: :1883
+  - type: ws
+    id: ws1
+    adress: :1882
+`,
+			key:      "listeners[1].adress",
+			line:     7,
+			contains: "unknown field",
+		},
+		{
+			name: "unknown option",
+			data: `options:
+  capabilities:
+    maximum_qoss: 1
+`,
+			key:      "options.capabilities.maximum_qoss",
+			line:     3,
+			contains: "unknown field",
+		},
+		{
+			name: "invalid value",
+			data: `options:
+  capabilities:
+    maximum_qos: high
+`,
+			key:      "options.capabilities.maximum_qos",
+			line:     3,
+			contains: "cannot unmarshal",
+		},
+		{
+			name: "maximum qos",
+			data: `options:
+  capabilities:
+    maximum_qos: 3
+`,
+			key:      "options.capabilities.maximum_qos",
+			line:     3,
+			contains: "must be 0, 1 or 2",
+		},
+		{
+			name: "json missing id",
+			data: `{
+  "listeners": [
+    {"type": "tcp", "id": "t1", "address": ":1883"},
+    {"type": "ws", "address": ":1882"}
+  ]
+}`,
+			key:      "listeners[1].id",
+			line:     4,
+			contains: "listener id is required",
+		},
+		{
+			name: "json duplicate id",
+			data: `{
+  "listeners": [
+    {"type": "tcp", "id": "t1", "address": ":1883"},
+    {
+      "type": "ws",
+      "id": "t1",
+      "address": ":1882"
+    }
+  ]
+}`,
+			key:      "listeners[1].id",
+			line:     6,
+			contains: "already used",
+		},
+		{
+			name: "json unknown field",
+			data: `{
+  "listeners": [
+    {"type": "tcp", "id": "t1", "address": ":1883"},
+    {"type": "ws", "id": "ws1", "adress": ":1882"}
+  ]
+}`,
+			key:      "listeners[1].adress",
+			line:     4,
+			contains: "unknown field",
+		},
+		{
+			name:     "log level",
+			data:     `{"logging": {"level": "loud"}}`,
+			key:      "logging.level",
+			line:     1,
+			contains: `unknown log level "loud"`,
+		},
+	}
+	for _, test := range tests {
+		_, err := FromBytes([]byte(test.data))
+		checkError(t, test.name, err, test.key, test.line, test.contains)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"ledger.yaml": `auth:
+  - username: peach
+    password: password1
+    allow: true
+`,
+		"invalid.yaml": "auth: [",
+	})
+
+	tests := []struct {
+		name  string
+		data  string
+		error string // the expected error, empty if valid
+	}{
+		{"allow all", "hooks:\n  auth:\n    allow_all: true\n", ""},
+		{"inline ledger", "hooks:\n  auth:\n    ledger:\n      auth:\n        - username: peach\n          allow: true\n", ""},
+		// The ledger file is relative to the directory of the configuration file
+		{"ledger file", "hooks:\n  auth:\n    ledger_file: ledger.yaml\n", ""},
+		{"none", "hooks:\n  auth: {}\n", "hooks.auth (line 2): exactly one of allow_all, ledger and ledger_file must be set"},
+		{"two", "hooks:\n  auth:\n    allow_all: true\n    ledger_file: ledger.yaml\n", "hooks.auth (line 2): exactly one of"},
+		{"missing ledger file", "hooks:\n  auth:\n    ledger_file: missing.yaml\n", "hooks.auth.ledger_file (line 3): open " + filepath.Join(dir, "missing.yaml")},
+		{"invalid ledger file", "hooks:\n  auth:\n    ledger_file: invalid.yaml\n", "hooks.auth.ledger_file (line 3): "},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(path, []byte(test.data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := FromFile(path)
+		if test.error == "" {
+			if err != nil {
+				t.Errorf("%s: %v", test.name, err)
+			} else if a := c.Hooks.Auth; !a.AllowAll && (a.Ledger == nil || len(a.Ledger.Auth) != 1) {
+				t.Errorf("%s: ledger %+v", test.name, a.Ledger)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.error) {
+			t.Errorf("%s: error %v, want %q", test.name, err, test.error)
+		}
+	}
+}
+
+func TestTLS(t *testing.T) {
+	cert, key := testCertificate(t)
+	dir := writeFiles(t, map[string]string{
+		"cert.pem":  cert,
+		"key.pem":   key,
+		"empty.pem": "",
+	})
+
+	tests := []struct {
+		name     string
+		tls      string
+		key      string
+		contains string
+	}{
+		{"server certificate", "cert_file: cert.pem\n      key_file: key.pem", "", ""},
+		{"client certificates", "cert_file: cert.pem\n      key_file: key.pem\n      ca_file: cert.pem\n      client_auth: true", "", ""},
+		{"missing certificate", "key_file: key.pem", "listeners[0].tls.cert_file", "certificate file is required"},
+		{"missing key", "cert_file: cert.pem", "listeners[0].tls.key_file", "key file is required"},
+		{"unreadable certificate", "cert_file: missing.pem\n      key_file: key.pem", "listeners[0].tls.cert_file", "missing.pem"},
+		{"mismatched key", "cert_file: cert.pem\n      key_file: cert.pem", "listeners[0].tls.cert_file", "private key"},
+		{"empty ca file", "cert_file: cert.pem\n      key_file: key.pem\n      ca_file: empty.pem", "listeners[0].tls.ca_file", "no certificates found"},
+		{"client auth without ca", "cert_file: cert.pem\n      key_file: key.pem\n      client_auth: true", "listeners[0].tls.client_auth", "ca_file is required"},
+	}
+	for _, test := range tests {
+		data := "listeners:\n  - type: tcp\n    id: t1\n    address: :8883\n    tls:\n      " + test.tls + "\n"
+		path := filepath.Join(dir, "config.yaml")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		c, err := FromFile(path)
+		if test.key != "" {
+			var cfgErr *Error
+			if !errors.As(err, &cfgErr) || cfgErr.Key != test.key || !strings.Contains(err.Error(), test.contains) {
+				t.Errorf("%s: error %v, want %s: ...%s...", test.name, err, test.key, test.contains)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		config := c.Listeners[0].tlsConfig
+		if config == nil || len(config.Certificates) != 1 {
+			t.Errorf("%s: tls config %+v", test.name, config)
+		} else if strings.Contains(test.tls, "client_auth") && config.ClientAuth != tls.RequireAndVerifyClientCert {
+			t.Errorf("%s: client auth %v", test.name, config.ClientAuth)
+		}
+	}
+
+	_, err := FromBytes([]byte("listeners:\n  - type: unix\n    id: u1\n    address: /tmp/mqtt.sock\n    tls:\n      cert_file: cert.pem\n"))
+	checkError(t, "unix listener", err, "listeners[0].tls", 5, "not available for unix listeners")
+}
@@ -0,0 +1,474 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package bridge
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"mqtt/client"
+	clientpackets "mqtt/client/packets"
+	"mqtt/server/packets"
+
+	mqtt "mqtt/server"
+)
+
+const (
+	// defaultClientId is the client id used for the remote connection and the local
+	// bridge client if none is provided.
+	defaultClientId = "mochi-bridge"
+
+	// defaultSubscriptionId is the first inline subscription identifier used for the
+	// local subscriptions of the bridge.
+	defaultSubscriptionId = 1
+
+	// echoWindow is the time an outbound message is remembered to drop the echo of the
+	// remote broker when MQTT v5 no-local subscriptions are not available.
+	echoWindow = time.Minute
+
+	// originProperty is the user property key used to mark bridged messages.
+	originProperty = "bridge-origin"
+)
+
+var (
+	// ErrServerRequired indicates that the hook was configured without a server.
+	ErrServerRequired = errors.New("bridge hook requires a server")
+
+	// ErrBrokerRequired indicates that the hook was configured without a remote broker.
+	ErrBrokerRequired = errors.New("bridge hook requires a remote broker")
+
+	// ErrInvalidDirection indicates that a rule has an unknown direction.
+	ErrInvalidDirection = errors.New("invalid bridge rule direction")
+
+	// ErrInvalidRuleFilter indicates that a rule has an invalid topic filter.
+	ErrInvalidRuleFilter = errors.New("invalid bridge rule filter")
+
+	// ErrInvalidRuleQos indicates that a rule has a qos greater than 2.
+	ErrInvalidRuleQos = errors.New("invalid bridge rule qos")
+)
+
+// Direction indicates which way the messages of a rule are bridged.
+type Direction string
+
+const (
+	DirectionIn   Direction = "in"   // remote to local
+	DirectionOut  Direction = "out"  // local to remote
+	DirectionBoth Direction = "both" // both ways
+)
+
+// Rule describes a topic tree which is bridged between the local and the remote broker.
+// The filter is subscribed to as LocalPrefix+Filter on the local broker and as
+// RemotePrefix+Filter on the remote broker, and the prefixes are swapped when a
+// message is forwarded.
+type Rule struct {
+	Filter       string    `yaml:"filter" json:"filter"`               // topic filter relative to the prefixes, e.g. sensors/#
+	Direction    Direction `yaml:"direction" json:"direction"`         // in, out or both (default)
+	Qos          *byte     `yaml:"qos" json:"qos"`                     // the maximum qos of bridged messages (default 2), higher qos messages are downgraded
+	LocalPrefix  string    `yaml:"local_prefix" json:"local_prefix"`   // prefix of the topics on the local broker
+	RemotePrefix string    `yaml:"remote_prefix" json:"remote_prefix"` // prefix of the topics on the remote broker
+}
+
+// Qos returns a pointer to a qos, to set the Qos of a Rule.
+func Qos(qos byte) *byte {
+	return &qos
+}
+
+// qos returns the maximum qos of the messages of the rule.
+func (r Rule) qos() byte {
+	if r.Qos == nil {
+		return 2
+	}
+
+	return *r.Qos
+}
+
+// in returns true if the rule forwards messages from the remote broker.
+func (r Rule) in() bool {
+	return r.Direction == DirectionIn || r.Direction == DirectionBoth
+}
+
+// out returns true if the rule forwards messages to the remote broker.
+func (r Rule) out() bool {
+	return r.Direction == DirectionOut || r.Direction == DirectionBoth
+}
+
+// Options contains configuration settings for the bridge.
+type Options struct {
+	Server               *mqtt.Server  `yaml:"-" json:"-"`                                           // the local server
+	Broker               string        `yaml:"broker" json:"broker"`                                 // remote broker uri, e.g. tcp://127.0.0.1:1883
+	ClientId             string        `yaml:"client_id" json:"client_id"`                           // client id on the remote broker
+	Username             string        `yaml:"username" json:"username"`                             // username on the remote broker
+	Password             string        `yaml:"password" json:"password"`                             // password on the remote broker
+	ProtocolVersion      uint          `yaml:"protocol_version" json:"protocol_version"`             // 3, 4 or 5 (default)
+	CleanSession         bool          `yaml:"clean_session" json:"clean_session"`                   // start a clean session on the remote broker
+	KeepAlive            time.Duration `yaml:"keep_alive" json:"keep_alive"`                         // keepalive of the remote connection
+	ConnectTimeout       time.Duration `yaml:"connect_timeout" json:"connect_timeout"`               // timeout of a connection attempt
+	ConnectRetryInterval time.Duration `yaml:"connect_retry_interval" json:"connect_retry_interval"` // delay between the initial connection attempts
+	MaxReconnectInterval time.Duration `yaml:"max_reconnect_interval" json:"max_reconnect_interval"` // upper bound of the reconnection backoff
+	SubscriptionId       int           `yaml:"subscription_id" json:"subscription_id"`               // first inline subscription id, rule n uses SubscriptionId+n
+	TLSConfig            *tls.Config   `yaml:"-" json:"-"`                                           // tls configuration of the remote connection
+	Rules                []Rule        `yaml:"rules" json:"rules"`                                   // the bridged topic trees
+}
+
+// Hook is a bridge hook which forwards messages between the server and a remote broker.
+type Hook struct {
+	mqtt.HookBase
+	config *Options
+	server *mqtt.Server
+	local  *mqtt.Client   // inline client used to publish remote messages to the server
+	remote client.Client  // client connected to the remote broker
+	echoes map[uint64]int // fingerprints of outbound messages expected to be echoed back
+	expiry time.Time      // time after which the echo fingerprints are discarded
+	mu     sync.Mutex
+}
+
+// ID returns the id of the hook.
+func (h *Hook) ID() string {
+	return "bridge"
+}
+
+// Provides indicates which hook methods this hook provides.
+func (h *Hook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnStarted,
+	}, []byte{b})
+}
+
+// Init validates the bridge configuration and prepares the remote client.
+func (h *Hook) Init(config any) error {
+	if _, ok := config.(*Options); !ok && config != nil {
+		return mqtt.ErrInvalidConfigType
+	}
+
+	if config == nil {
+		return ErrServerRequired
+	}
+
+	h.config = config.(*Options)
+	if h.config.Server == nil {
+		return ErrServerRequired
+	}
+
+	if h.config.Broker == "" {
+		return ErrBrokerRequired
+	}
+
+	if h.config.ClientId == "" {
+		h.config.ClientId = defaultClientId
+	}
+
+	if h.config.ProtocolVersion == 0 {
+		h.config.ProtocolVersion = 5
+	}
+
+	if h.config.SubscriptionId == 0 {
+		h.config.SubscriptionId = defaultSubscriptionId
+	}
+
+	for i, rule := range h.config.Rules {
+		if rule.Direction == "" {
+			h.config.Rules[i].Direction = DirectionBoth
+		} else if !rule.in() && !rule.out() {
+			return ErrInvalidDirection
+		}
+
+		if rule.qos() > 2 {
+			return ErrInvalidRuleQos
+		}
+
+		if rule.Filter == "" ||
+			!mqtt.IsValidFilter(rule.LocalPrefix+rule.Filter, false) ||
+			!mqtt.IsValidFilter(rule.RemotePrefix+rule.Filter, false) {
+			return ErrInvalidRuleFilter
+		}
+	}
+
+	h.server = h.config.Server
+	h.echoes = map[uint64]int{}
+	h.local = h.server.NewClient(nil, mqtt.LocalListener, h.config.ClientId, true)
+	h.local.Properties.ProtocolVersion = 5 // keep the origin user property of bridged messages.
+
+	o := client.NewClientOptions().
+		AddBroker(h.config.Broker).
+		SetClientID(h.config.ClientId).
+		SetUsername(h.config.Username).
+		SetPassword(h.config.Password).
+		SetProtocolVersion(h.config.ProtocolVersion).
+		SetCleanSession(h.config.CleanSession).
+		SetTLSConfig(h.config.TLSConfig).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(h.onConnect).
+		SetConnectionLostHandler(h.onConnectionLost)
+
+	if h.config.KeepAlive > 0 {
+		o.SetKeepAlive(h.config.KeepAlive)
+	}
+
+	if h.config.ConnectTimeout > 0 {
+		o.SetConnectTimeout(h.config.ConnectTimeout)
+	}
+
+	if h.config.ConnectRetryInterval > 0 {
+		o.SetConnectRetryInterval(h.config.ConnectRetryInterval)
+	}
+
+	if h.config.MaxReconnectInterval > 0 {
+		o.SetMaxReconnectInterval(h.config.MaxReconnectInterval)
+	}
+
+	h.remote = client.NewClient(o)
+
+	return nil
+}
+
+// OnStarted subscribes to the local topics and connects to the remote broker. The
+// connection is retried in the background, and reconnections after a lost connection
+// back off exponentially up to MaxReconnectInterval.
+func (h *Hook) OnStarted() {
+	for i, rule := range h.config.Rules {
+		if !rule.out() {
+			continue
+		}
+
+		err := h.server.Subscribe(rule.LocalPrefix+rule.Filter, h.config.SubscriptionId+i, h.forwardOut(rule))
+		if err != nil {
+			h.Log.Error("failed to subscribe to local topic", "error", err, "filter", rule.LocalPrefix+rule.Filter)
+		}
+	}
+
+	h.remote.Connect()
+}
+
+// Stop unsubscribes from the local topics and disconnects from the remote broker.
+func (h *Hook) Stop() error {
+	if h.remote == nil {
+		return nil
+	}
+
+	for i, rule := range h.config.Rules {
+		if rule.out() {
+			_ = h.server.Unsubscribe(rule.LocalPrefix+rule.Filter, h.config.SubscriptionId+i)
+		}
+	}
+
+	h.remote.Disconnect(250)
+	return nil
+}
+
+// onConnect subscribes to the remote topics each time the remote connection is established.
+func (h *Hook) onConnect(c client.Client) {
+	h.Log.Info("connected to remote broker", "broker", h.config.Broker)
+	for _, rule := range h.config.Rules {
+		if !rule.in() {
+			continue
+		}
+
+		filter := rule.RemotePrefix + rule.Filter
+		t := c.SubscribeWithOptions(filter, client.SubscribeOptions{
+			Qos:               rule.qos(),
+			NoLocal:           true, // [MQTT-3.8.3-3] the remote broker drops our own messages.
+			RetainAsPublished: true,
+		}, h.forwardIn(rule))
+
+		t.Wait() // the handler runs in its own goroutine.
+		if err := t.Error(); err != nil {
+			h.Log.Error("failed to subscribe to remote topic", "error", err, "filter", filter)
+		}
+	}
+}
+
+// onConnectionLost logs the lost remote connection; the client reconnects by itself.
+func (h *Hook) onConnectionLost(c client.Client, err error) {
+	h.Log.Warn("lost connection to remote broker", "error", err, "broker", h.config.Broker)
+}
+
+// forwardOut returns an inline subscription handler which publishes local messages
+// to the remote broker.
+func (h *Hook) forwardOut(rule Rule) mqtt.InlineSubFn {
+	return func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		if pk.Origin == h.local.ID || hasOrigin(pk.Properties.User, h.config.ClientId) {
+			return // don't send messages back to where they came from.
+		}
+
+		topic, ok := remap(pk.TopicName, rule.LocalPrefix, rule.RemotePrefix)
+		if !ok {
+			return
+		}
+
+		var props *clientpackets.Properties
+		if h.config.ProtocolVersion == 5 {
+			props = &clientpackets.Properties{
+				User: []clientpackets.UserProperty{{Key: originProperty, Value: h.config.ClientId}},
+			}
+			for _, v := range pk.Properties.User {
+				props.User = append(props.User, clientpackets.UserProperty{Key: v.Key, Value: v.Val})
+			}
+		} else if h.expectEcho(topic) {
+			h.addEcho(topic, pk.Payload)
+		}
+
+		qos := min(pk.FixedHeader.Qos, rule.qos())
+		t := h.remote.PublishWithProperties(topic, qos, pk.FixedHeader.Retain, pk.Payload, props)
+
+		// the publish is not awaited so that the server is never blocked by the remote broker,
+		// only the errors of publishes which completed immediately (e.g. not connected) are logged.
+		select {
+		case <-t.Done():
+			if err := t.Error(); err != nil {
+				h.Log.Debug("failed to forward message to remote broker", "error", err, "topic", topic)
+			}
+		default:
+		}
+	}
+}
+
+// forwardIn returns a message handler which publishes remote messages to the server.
+func (h *Hook) forwardIn(rule Rule) client.MessageHandler {
+	return func(c client.Client, m client.Message) {
+		if props := messageProperties(m); props != nil {
+			for _, v := range props.User {
+				if v.Key == originProperty && v.Value == h.config.ClientId {
+					return // the message was forwarded by this bridge.
+				}
+			}
+		} else if h.isEcho(m.Topic(), m.Payload()) {
+			return
+		}
+
+		topic, ok := remap(m.Topic(), rule.RemotePrefix, rule.LocalPrefix)
+		if !ok {
+			return
+		}
+
+		qos := min(m.Qos(), rule.qos())
+		pk := packets.Packet{
+			FixedHeader: packets.FixedHeader{
+				Type:   packets.Publish,
+				Qos:    qos,
+				Retain: m.Retained(),
+			},
+			TopicName: topic,
+			Payload:   m.Payload(),
+			PacketID:  uint16(qos), // the inbound qos of inline clients is never processed.
+			Properties: packets.Properties{
+				User: []packets.UserProperty{{Key: originProperty, Val: h.config.ClientId}},
+			},
+		}
+
+		if props := messageProperties(m); props != nil {
+			for _, v := range props.User {
+				pk.Properties.User = append(pk.Properties.User, packets.UserProperty{Key: v.Key, Val: v.Value})
+			}
+		}
+
+		if err := h.server.InjectPacket(h.local, pk); err != nil {
+			h.Log.Error("failed to forward message to local broker", "error", err, "topic", topic)
+		}
+	}
+}
+
+// messageProperties returns the MQTT v5 properties of a remote message, or nil.
+func messageProperties(m client.Message) *clientpackets.Properties {
+	if pm, ok := m.(client.PropertiesMessage); ok {
+		return pm.Properties()
+	}
+	return nil
+}
+
+// expectEcho returns true if a message published to the remote topic will be received
+// back by one of the remote subscriptions of the bridge.
+func (h *Hook) expectEcho(topic string) bool {
+	for _, rule := range h.config.Rules {
+		if rule.in() && matches(rule.RemotePrefix+rule.Filter, topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// addEcho remembers an outbound message so that its echo can be dropped.
+func (h *Hook) addEcho(topic string, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	if now.After(h.expiry) {
+		h.echoes = map[uint64]int{} // drop the fingerprints of echoes which never arrived.
+		h.expiry = now.Add(echoWindow)
+	}
+
+	h.echoes[fingerprint(topic, payload)]++
+}
+
+// isEcho returns true and forgets the message if it was previously sent by the bridge.
+func (h *Hook) isEcho(topic string, payload []byte) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := fingerprint(topic, payload)
+	if h.echoes[key] == 0 {
+		return false
+	}
+
+	h.echoes[key]--
+	if h.echoes[key] == 0 {
+		delete(h.echoes, key)
+	}
+
+	return true
+}
+
+// fingerprint returns a hash of the topic and payload of a message.
+func fingerprint(topic string, payload []byte) uint64 {
+	f := fnv.New64a()
+	_, _ = f.Write([]byte(topic))
+	_, _ = f.Write([]byte{0})
+	_, _ = f.Write(payload)
+	return f.Sum64()
+}
+
+// hasOrigin returns true if the user properties mark the message as bridged by id.
+func hasOrigin(user []packets.UserProperty, id string) bool {
+	for _, v := range user {
+		if v.Key == originProperty && v.Val == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remap replaces the from prefix of a topic with the to prefix.
+func remap(topic, from, to string) (string, bool) {
+	if !strings.HasPrefix(topic, from) {
+		return "", false
+	}
+
+	return to + topic[len(from):], true
+}
+
+// matches returns true if the topic matches the topic filter.
+func matches(filter, topic string) bool {
+	fp := strings.Split(filter, "/")
+	tp := strings.Split(topic, "/")
+	for i, p := range fp {
+		if p == "#" {
+			return true
+		}
+
+		if i >= len(tp) || (p != "+" && p != tp[i]) {
+			return false
+		}
+	}
+
+	return len(fp) == len(tp)
+}
@@ -0,0 +1,234 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package bridge_test
+
+import (
+	"testing"
+	"time"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/auth"
+	"mqtt/server/hooks/bridge"
+	"mqtt/server/listeners"
+	"mqtt/server/packets"
+)
+
+const testTimeout = 5 * time.Second
+
+// newServer returns a running broker with the inline client, listening on a
+// loopback tcp port if listen is set.
+func newServer(t *testing.T, listen bool, hooks ...func(s *mqtt.Server)) *mqtt.Server {
+	t.Helper()
+
+	s := mqtt.New(&mqtt.Options{InlineClient: true})
+	if err := s.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if listen {
+		if err := s.AddListener(listeners.NewTCP("t1", "127.0.0.1:0", nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, hook := range hooks {
+		hook(s)
+	}
+	if err := s.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+// newBridge returns a local server bridged to the remote server with the rules.
+func newBridge(t *testing.T, remote *mqtt.Server, version uint, rules ...bridge.Rule) *mqtt.Server {
+	t.Helper()
+
+	l, _ := remote.Listeners.Get("t1")
+	return newServer(t, false, func(s *mqtt.Server) {
+		err := s.AddHook(new(bridge.Hook), &bridge.Options{
+			Server:               s,
+			Broker:               "tcp://" + l.Address(),
+			ClientId:             "bridge-1",
+			ProtocolVersion:      version,
+			ConnectRetryInterval: 10 * time.Millisecond,
+			Rules:                rules,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// waitSubscriptions waits until the bridge client of the remote server has n subscriptions.
+func waitSubscriptions(t *testing.T, remote *mqtt.Server, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+	for time.Now().Before(deadline) {
+		if cl, ok := remote.Clients.Get("bridge-1"); ok && !cl.Closed() && cl.State.Subscriptions.Len() == n {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d remote subscriptions", n)
+}
+
+// subscribe returns a channel receiving the messages of an inline subscription to the filter.
+func subscribe(t *testing.T, s *mqtt.Server, filter string) <-chan packets.Packet {
+	t.Helper()
+
+	ch := make(chan packets.Packet, 8)
+	err := s.Subscribe(filter, 100, func(cl *mqtt.Client, sub packets.Subscription, pk packets.Packet) {
+		ch <- pk
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ch
+}
+
+// receive returns the next message of a channel.
+func receive(t *testing.T, ch <-chan packets.Packet) packets.Packet {
+	t.Helper()
+
+	select {
+	case pk := <-ch:
+		return pk
+	case <-time.After(testTimeout):
+		t.Fatal("timed out waiting for message")
+		return packets.Packet{}
+	}
+}
+
+// expectNone fails the test if a message is received within a short time.
+func expectNone(t *testing.T, ch <-chan packets.Packet) {
+	t.Helper()
+
+	select {
+	case pk := <-ch:
+		t.Fatalf("unexpected message %s %q", pk.TopicName, pk.Payload)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestForwardOut(t *testing.T) {
+	remote := newServer(t, true)
+	local := newBridge(t, remote, 5, bridge.Rule{
+		Filter:       "#",
+		Direction:    bridge.DirectionOut,
+		LocalPrefix:  "site/",
+		RemotePrefix: "sites/1/",
+	})
+	received := subscribe(t, remote, "sites/#")
+	waitSubscriptions(t, remote, 0)
+
+	if err := local.Publish("site/temp", []byte("21"), false, 2); err != nil {
+		t.Fatal(err)
+	}
+	pk := receive(t, received)
+	if pk.TopicName != "sites/1/temp" || string(pk.Payload) != "21" {
+		t.Errorf("message = %s %q, want sites/1/temp \"21\"", pk.TopicName, pk.Payload)
+	}
+	if pk.FixedHeader.Qos != 2 {
+		t.Errorf("qos = %d, want 2 when the rule qos is unset", pk.FixedHeader.Qos)
+	}
+
+	if err := local.Publish("other/temp", []byte("21"), false, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectNone(t, received)
+}
+
+func TestForwardIn(t *testing.T) {
+	remote := newServer(t, true)
+	local := newBridge(t, remote, 5, bridge.Rule{
+		Filter:       "commands/#",
+		Direction:    bridge.DirectionIn,
+		Qos:          bridge.Qos(1),
+		RemotePrefix: "sites/1/",
+	})
+	received := subscribe(t, local, "#")
+	waitSubscriptions(t, remote, 1)
+
+	if err := remote.Publish("sites/1/commands/reboot", []byte("now"), false, 2); err != nil {
+		t.Fatal(err)
+	}
+	pk := receive(t, received)
+	if pk.TopicName != "commands/reboot" || string(pk.Payload) != "now" {
+		t.Errorf("message = %s %q, want commands/reboot \"now\"", pk.TopicName, pk.Payload)
+	}
+	if pk.FixedHeader.Qos != 1 {
+		t.Errorf("qos = %d, want the rule qos 1", pk.FixedHeader.Qos)
+	}
+}
+
+func TestQosZero(t *testing.T) {
+	remote := newServer(t, true)
+	local := newBridge(t, remote, 5, bridge.Rule{
+		Filter:    "a/#",
+		Direction: bridge.DirectionOut,
+		Qos:       bridge.Qos(0),
+	})
+	received := subscribe(t, remote, "a/#")
+	waitSubscriptions(t, remote, 0)
+
+	if err := local.Publish("a/b", []byte("x"), false, 2); err != nil {
+		t.Fatal(err)
+	}
+	if pk := receive(t, received); pk.FixedHeader.Qos != 0 {
+		t.Errorf("qos = %d, want the rule qos 0", pk.FixedHeader.Qos)
+	}
+}
+
+func TestNoLoop(t *testing.T) {
+	for _, version := range []uint{4, 5} {
+		remote := newServer(t, true)
+		local := newBridge(t, remote, version, bridge.Rule{
+			Filter:    "a/#",
+			Direction: bridge.DirectionBoth,
+		})
+		localReceived := subscribe(t, local, "a/#")
+		remoteReceived := subscribe(t, remote, "a/#")
+		waitSubscriptions(t, remote, 1)
+
+		if err := local.Publish("a/local", []byte("x"), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		if pk := receive(t, localReceived); pk.TopicName != "a/local" {
+			t.Errorf("v%d: local message = %s, want a/local", version, pk.TopicName)
+		}
+		if pk := receive(t, remoteReceived); pk.TopicName != "a/local" {
+			t.Errorf("v%d: remote message = %s, want a/local", version, pk.TopicName)
+		}
+
+		if err := remote.Publish("a/remote", []byte("y"), false, 1); err != nil {
+			t.Fatal(err)
+		}
+		if pk := receive(t, remoteReceived); pk.TopicName != "a/remote" {
+			t.Errorf("v%d: remote message = %s, want a/remote", version, pk.TopicName)
+		}
+		if pk := receive(t, localReceived); pk.TopicName != "a/remote" {
+			t.Errorf("v%d: local message = %s, want a/remote", version, pk.TopicName)
+		}
+
+		expectNone(t, localReceived)
+		expectNone(t, remoteReceived)
+	}
+}
+
+func TestInitInvalidRule(t *testing.T) {
+	s := mqtt.New(nil)
+	for _, rule := range []bridge.Rule{
+		{Filter: "a/#", Qos: bridge.Qos(3)},
+		{Filter: "a/#", Direction: "sideways"},
+		{Filter: ""},
+		{Filter: "a/#/b"},
+	} {
+		err := new(bridge.Hook).Init(&bridge.Options{Server: s, Broker: "tcp://127.0.0.1:1883", Rules: []bridge.Rule{rule}})
+		if err == nil {
+			t.Errorf("rule %+v: expected an error", rule)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package metrics
+
+import (
+	"time"
+
+	"mqtt/server/packets"
+
+	mqtt "mqtt/server"
+)
+
+// Instrument returns the hook wrapped to record the latency of its event methods
+// in the hook latency histogram. Add the returned hook to the server in place of
+// the hook, e.g. server.AddHook(m.Instrument(new(auth.Hook)), opts).
+func (h *Hook) Instrument(hook mqtt.Hook) mqtt.Hook {
+	return &instrumented{Hook: hook, metrics: h}
+}
+
+// instrumented wraps a hook and times its event methods. The other methods
+// are called through the embedded hook.
+type instrumented struct {
+	mqtt.Hook
+	metrics *Hook
+}
+
+// observe records the time since start of a method of the wrapped hook.
+func (i *instrumented) observe(method string, start time.Time) {
+	i.metrics.Observe(i.Hook.ID(), method, time.Since(start))
+}
+
+func (i *instrumented) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	defer i.observe("OnConnectAuthenticate", time.Now())
+	return i.Hook.OnConnectAuthenticate(cl, pk)
+}
+
+func (i *instrumented) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	defer i.observe("OnACLCheck", time.Now())
+	return i.Hook.OnACLCheck(cl, topic, write)
+}
+
+func (i *instrumented) OnConnect(cl *mqtt.Client, pk packets.Packet) error {
+	defer i.observe("OnConnect", time.Now())
+	return i.Hook.OnConnect(cl, pk)
+}
+
+func (i *instrumented) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnSessionEstablished", time.Now())
+	i.Hook.OnSessionEstablished(cl, pk)
+}
+
+func (i *instrumented) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	defer i.observe("OnDisconnect", time.Now())
+	i.Hook.OnDisconnect(cl, err, expire)
+}
+
+func (i *instrumented) OnPacketRead(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	defer i.observe("OnPacketRead", time.Now())
+	return i.Hook.OnPacketRead(cl, pk)
+}
+
+func (i *instrumented) OnSubscribe(cl *mqtt.Client, pk packets.Packet) packets.Packet {
+	defer i.observe("OnSubscribe", time.Now())
+	return i.Hook.OnSubscribe(cl, pk)
+}
+
+func (i *instrumented) OnSubscribed(cl *mqtt.Client, pk packets.Packet, reasonCodes []byte) {
+	defer i.observe("OnSubscribed", time.Now())
+	i.Hook.OnSubscribed(cl, pk, reasonCodes)
+}
+
+func (i *instrumented) OnUnsubscribed(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnUnsubscribed", time.Now())
+	i.Hook.OnUnsubscribed(cl, pk)
+}
+
+func (i *instrumented) OnPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	defer i.observe("OnPublish", time.Now())
+	return i.Hook.OnPublish(cl, pk)
+}
+
+func (i *instrumented) OnPublished(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnPublished", time.Now())
+	i.Hook.OnPublished(cl, pk)
+}
+
+func (i *instrumented) OnRetainMessage(cl *mqtt.Client, pk packets.Packet, r int64) {
+	defer i.observe("OnRetainMessage", time.Now())
+	i.Hook.OnRetainMessage(cl, pk, r)
+}
+
+func (i *instrumented) OnQosPublish(cl *mqtt.Client, pk packets.Packet, sent int64, resends int) {
+	defer i.observe("OnQosPublish", time.Now())
+	i.Hook.OnQosPublish(cl, pk, sent, resends)
+}
+
+func (i *instrumented) OnQosComplete(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnQosComplete", time.Now())
+	i.Hook.OnQosComplete(cl, pk)
+}
+
+func (i *instrumented) OnQosDropped(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnQosDropped", time.Now())
+	i.Hook.OnQosDropped(cl, pk)
+}
+
+func (i *instrumented) OnWillSent(cl *mqtt.Client, pk packets.Packet) {
+	defer i.observe("OnWillSent", time.Now())
+	i.Hook.OnWillSent(cl, pk)
+}
+
+func (i *instrumented) OnClientExpired(cl *mqtt.Client) {
+	defer i.observe("OnClientExpired", time.Now())
+	i.Hook.OnClientExpired(cl)
+}
+
+func (i *instrumented) OnRetainedExpired(filter string) {
+	defer i.observe("OnRetainedExpired", time.Now())
+	i.Hook.OnRetainedExpired(filter)
+}
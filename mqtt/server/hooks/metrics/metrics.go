@@ -0,0 +1,201 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// package metrics provides a hook which counts broker events and serves them
+// as OpenMetrics (Prometheus) text on /metrics, see listeners.NewHTTPHandler.
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mqtt/server/packets"
+
+	mqtt "mqtt/server"
+)
+
+var (
+	// ErrServerRequired indicates that the metrics hook was initialised without a server.
+	ErrServerRequired = errors.New("metrics hook requires a server")
+
+	// DefaultBuckets are the upper bounds in seconds of the hook latency histogram buckets.
+	DefaultBuckets = []float64{0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1}
+)
+
+// Options contains configuration settings for the metrics hook.
+type Options struct {
+	Server  *mqtt.Server `yaml:"-" json:"-"`             // the server, from which the gauges are read
+	Buckets []float64    `yaml:"buckets" json:"buckets"` // upper bounds in seconds of the hook latency buckets, DefaultBuckets if empty
+}
+
+// listenerStats are the connection counters of a listener.
+type listenerStats struct {
+	connections atomic.Uint64 // connection attempts
+	clients     atomic.Int64  // connected clients
+}
+
+// latencyKey identifies the latency histogram of a hook method.
+type latencyKey struct {
+	hook   string
+	method string
+}
+
+// histogram is a cumulative latency histogram.
+type histogram struct {
+	counts []atomic.Uint64 // counts per bucket, the last bucket is +Inf
+	sum    atomic.Int64    // sum of the observations in nanoseconds
+}
+
+// Hook is a hook which counts broker events for the metrics endpoint. The counters
+// are updated by the hook methods, gauges are read from the server when scraped.
+type Hook struct {
+	mqtt.HookBase
+	config *Options
+
+	received      [3]atomic.Uint64 // publish packets received by qos
+	sent          [3]atomic.Uint64 // publish packets sent by qos
+	dropped       [3]atomic.Uint64 // publishes dropped by qos
+	inflightDrops atomic.Uint64    // inflight messages dropped
+	bytesReceived atomic.Uint64
+	bytesSent     atomic.Uint64
+
+	listeners sync.Map // listener id: *listenerStats
+	clients   sync.Map // *mqtt.Client: *listenerStats, the established clients
+	latency   sync.Map // latencyKey: *histogram
+}
+
+// ID returns the ID of the hook.
+func (h *Hook) ID() string {
+	return "metrics"
+}
+
+// Provides indicates which hook methods this hook provides.
+func (h *Hook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnConnect,
+		mqtt.OnSessionEstablished,
+		mqtt.OnDisconnect,
+		mqtt.OnPacketRead,
+		mqtt.OnPacketSent,
+		mqtt.OnPublishDropped,
+		mqtt.OnQosDropped,
+	}, []byte{b})
+}
+
+// Init initialises the hook with the server whose gauges are exported.
+func (h *Hook) Init(config any) error {
+	if _, ok := config.(*Options); !ok && config != nil {
+		return mqtt.ErrInvalidConfigType
+	}
+
+	if config == nil || config.(*Options).Server == nil {
+		return ErrServerRequired
+	}
+
+	h.config = config.(*Options)
+	if len(h.config.Buckets) == 0 {
+		h.config.Buckets = DefaultBuckets
+	}
+	h.config.Buckets = append([]float64(nil), h.config.Buckets...)
+	sort.Float64s(h.config.Buckets)
+
+	return nil
+}
+
+// listener returns the counters of a listener.
+func (h *Hook) listener(id string) *listenerStats {
+	if v, ok := h.listeners.Load(id); ok {
+		return v.(*listenerStats)
+	}
+	v, _ := h.listeners.LoadOrStore(id, new(listenerStats))
+	return v.(*listenerStats)
+}
+
+// OnConnect counts a connection attempt on the listener of the client.
+func (h *Hook) OnConnect(cl *mqtt.Client, pk packets.Packet) error {
+	h.listener(cl.Net.Listener).connections.Add(1)
+	return nil
+}
+
+// OnSessionEstablished counts a connected client on its listener.
+func (h *Hook) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	l := h.listener(cl.Net.Listener)
+	if _, loaded := h.clients.LoadOrStore(cl, l); !loaded {
+		l.clients.Add(1)
+	}
+}
+
+// OnDisconnect removes a connected client from its listener.
+func (h *Hook) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	if l, ok := h.clients.LoadAndDelete(cl); ok {
+		l.(*listenerStats).clients.Add(-1)
+	}
+}
+
+// OnPacketRead counts the bytes of a received packet and received publishes.
+func (h *Hook) OnPacketRead(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	h.bytesReceived.Add(uint64(packetSize(pk)))
+	if pk.FixedHeader.Type == packets.Publish && pk.FixedHeader.Qos < 3 {
+		h.received[pk.FixedHeader.Qos].Add(1)
+	}
+	return pk, nil
+}
+
+// OnPacketSent counts the bytes of a sent packet and sent publishes.
+func (h *Hook) OnPacketSent(cl *mqtt.Client, pk packets.Packet, b []byte) {
+	// b is empty if the buffer has been written directly to the connection
+	h.bytesSent.Add(uint64(packetSize(pk)))
+	if pk.FixedHeader.Type == packets.Publish && pk.FixedHeader.Qos < 3 {
+		h.sent[pk.FixedHeader.Qos].Add(1)
+	}
+}
+
+// OnPublishDropped counts a publish which was dropped because the client was too slow.
+func (h *Hook) OnPublishDropped(cl *mqtt.Client, pk packets.Packet) {
+	if pk.FixedHeader.Qos < 3 {
+		h.dropped[pk.FixedHeader.Qos].Add(1)
+	}
+}
+
+// OnQosDropped counts an inflight message which was dropped.
+func (h *Hook) OnQosDropped(cl *mqtt.Client, pk packets.Packet) {
+	h.inflightDrops.Add(1)
+}
+
+// packetSize returns the encoded size of a packet from its fixed header.
+func packetSize(pk packets.Packet) int {
+	n := 2 // the type byte and the first remaining length byte
+	for r := pk.FixedHeader.Remaining; r > 127; r >>= 7 {
+		n++
+	}
+	return n + pk.FixedHeader.Remaining
+}
+
+// Observe records the latency of a method of a hook in the hook latency histogram.
+func (h *Hook) Observe(hook, method string, d time.Duration) {
+	key := latencyKey{hook: hook, method: method}
+	v, ok := h.latency.Load(key)
+	if !ok {
+		v, _ = h.latency.LoadOrStore(key, &histogram{
+			counts: make([]atomic.Uint64, len(h.buckets())+1),
+		})
+	}
+	hist := v.(*histogram)
+	seconds := d.Seconds()
+	i := sort.SearchFloat64s(h.buckets(), seconds)
+	hist.counts[i].Add(1)
+	hist.sum.Add(int64(d))
+}
+
+// buckets returns the latency histogram buckets.
+func (h *Hook) buckets() []float64 {
+	if h.config == nil {
+		return DefaultBuckets
+	}
+	return h.config.Buckets
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/auth"
+	"mqtt/server/listeners"
+	"mqtt/server/packets"
+)
+
+func TestBytesSent(t *testing.T) {
+	s := mqtt.New(nil)
+	h := new(Hook)
+	if err := s.AddHook(h, &Options{Server: s}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddListener(listeners.NewTCP("t1", "127.0.0.1:0", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	l, _ := s.Listeners.Get("t1")
+	conn, err := net.Dial("tcp", l.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	pk := packets.Packet{
+		FixedHeader:     packets.FixedHeader{Type: packets.Connect},
+		ProtocolVersion: 4,
+		Connect: packets.ConnectParams{
+			ProtocolName:     []byte("MQTT"),
+			ClientIdentifier: "metrics-1",
+			Keepalive:        30,
+			Clean:            true,
+		},
+	}
+	var buf bytes.Buffer
+	if err := pk.ConnectEncode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	connack := make([]byte, 4)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.ReadFull(conn, connack); err != nil {
+		t.Fatal(err)
+	}
+
+	// The hook is called after the packet is written
+	deadline := time.Now().Add(5 * time.Second)
+	for h.bytesSent.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := h.bytesSent.Load(); n != uint64(len(connack)) {
+		t.Errorf("bytes sent = %d, want %d", n, len(connack))
+	}
+	if n := h.bytesReceived.Load(); n != uint64(buf.Len()) {
+		t.Errorf("bytes received = %d, want %d", n, buf.Len())
+	}
+}
@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package metrics
+
+import (
+	"bufio"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var qosLabels = [3]string{"0", "1", "2"}
+
+// ServeHTTP writes the metrics as OpenMetrics text if the scraper accepts it,
+// or else in the Prometheus text format.
+func (h *Hook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	openMetrics := strings.Contains(req.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", contentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", contentTypeText)
+	}
+
+	e := &encoder{w: bufio.NewWriter(w), openMetrics: openMetrics}
+	h.write(e)
+	if openMetrics {
+		e.w.WriteString("# EOF\n")
+	}
+	_ = e.w.Flush()
+}
+
+// write writes all metric families.
+func (h *Hook) write(e *encoder) {
+	if h.config != nil {
+		server := h.config.Server
+		e.family("mqtt_clients_connected", "gauge", "Number of connected clients.")
+		e.sample("mqtt_clients_connected", nil, float64(atomic.LoadInt64(&server.Info.ClientsConnected)))
+		e.family("mqtt_retained_messages", "gauge", "Number of retained messages.")
+		e.sample("mqtt_retained_messages", nil, float64(server.Topics.Retained.Len()))
+		e.family("mqtt_inflight_messages", "gauge", "Number of inflight messages.")
+		e.sample("mqtt_inflight_messages", nil, float64(atomic.LoadInt64(&server.Info.Inflight)))
+	}
+
+	var ids []string
+	h.listeners.Range(func(k, _ any) bool {
+		ids = append(ids, k.(string))
+		return true
+	})
+	sort.Strings(ids)
+	e.family("mqtt_listener_clients", "gauge", "Number of connected clients by listener.")
+	for _, id := range ids {
+		e.sample("mqtt_listener_clients", []string{"listener", id}, float64(h.listener(id).clients.Load()))
+	}
+	e.counterFamily("mqtt_listener_connections", "Number of connection attempts by listener.")
+	for _, id := range ids {
+		e.sample("mqtt_listener_connections_total", []string{"listener", id}, float64(h.listener(id).connections.Load()))
+	}
+
+	e.counterFamily("mqtt_messages_received", "Number of publish packets received by qos.")
+	for i := range h.received {
+		e.sample("mqtt_messages_received_total", []string{"qos", qosLabels[i]}, float64(h.received[i].Load()))
+	}
+	e.counterFamily("mqtt_messages_sent", "Number of publish packets sent by qos.")
+	for i := range h.sent {
+		e.sample("mqtt_messages_sent_total", []string{"qos", qosLabels[i]}, float64(h.sent[i].Load()))
+	}
+	e.counterFamily("mqtt_publishes_dropped", "Number of publishes dropped for slow clients by qos.")
+	for i := range h.dropped {
+		e.sample("mqtt_publishes_dropped_total", []string{"qos", qosLabels[i]}, float64(h.dropped[i].Load()))
+	}
+	e.counterFamily("mqtt_inflight_dropped", "Number of inflight messages dropped.")
+	e.sample("mqtt_inflight_dropped_total", nil, float64(h.inflightDrops.Load()))
+	e.counterFamily("mqtt_received_bytes", "Number of bytes received.")
+	e.sample("mqtt_received_bytes_total", nil, float64(h.bytesReceived.Load()))
+	e.counterFamily("mqtt_sent_bytes", "Number of bytes sent.")
+	e.sample("mqtt_sent_bytes_total", nil, float64(h.bytesSent.Load()))
+
+	var keys []latencyKey
+	h.latency.Range(func(k, _ any) bool {
+		keys = append(keys, k.(latencyKey))
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].hook != keys[j].hook {
+			return keys[i].hook < keys[j].hook
+		}
+		return keys[i].method < keys[j].method
+	})
+	buckets := h.buckets()
+	e.family("mqtt_hook_duration_seconds", "histogram", "Latency of hook methods.")
+	for _, k := range keys {
+		v, _ := h.latency.Load(k)
+		hist := v.(*histogram)
+		var count uint64
+		for i := range hist.counts {
+			count += hist.counts[i].Load()
+			le := "+Inf"
+			if i < len(buckets) {
+				le = strconv.FormatFloat(buckets[i], 'f', -1, 64)
+			}
+			e.sample("mqtt_hook_duration_seconds_bucket", []string{"hook", k.hook, "method", k.method, "le", le}, float64(count))
+		}
+		labels := []string{"hook", k.hook, "method", k.method}
+		e.sample("mqtt_hook_duration_seconds_count", labels, float64(count))
+		e.sample("mqtt_hook_duration_seconds_sum", labels, time.Duration(hist.sum.Load()).Seconds())
+	}
+}
+
+// encoder writes metrics in the OpenMetrics or Prometheus text format.
+type encoder struct {
+	w           *bufio.Writer
+	openMetrics bool
+}
+
+// family writes the metadata of a metric family.
+func (e *encoder) family(name, typ, help string) {
+	e.w.WriteString("# HELP " + name + " " + help + "\n")
+	e.w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// counterFamily writes the metadata of a counter family, whose samples are
+// suffixed by _total. The Prometheus text format includes the suffix in the
+// family name.
+func (e *encoder) counterFamily(name, help string) {
+	if !e.openMetrics {
+		name += "_total"
+	}
+	e.family(name, "counter", help)
+}
+
+// sample writes a sample with labels of alternating names and values.
+func (e *encoder) sample(name string, labels []string, value float64) {
+	e.w.WriteString(name)
+	if len(labels) > 0 {
+		e.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteString(labels[i] + `="` + labelEscaper.Replace(labels[i+1]) + `"`)
+		}
+		e.w.WriteByte('}')
+	}
+	e.w.WriteByte(' ')
+	e.w.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	e.w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
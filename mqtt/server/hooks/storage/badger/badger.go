@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package badger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	badgerdb "badger"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/storage"
+	"mqtt/server/hooks/storage/kv"
+)
+
+const (
+	// defaultDbFile is the default file path for the badger db directory.
+	defaultDbFile = ".badger"
+
+	// defaultGcInterval is the default interval between value log garbage collections.
+	defaultGcInterval = 5 * time.Minute
+
+	// defaultGcDiscardRatio is the default ratio of stale data required in a
+	// value log file before it is rewritten.
+	defaultGcDiscardRatio = 0.5
+)
+
+// Options contains configuration settings for the badger DB.
+type Options struct {
+	Options *badgerdb.Options `yaml:"-" json:"-"` // optional badger options, overrides Path and SyncWrites
+
+	// Path is the directory of the badger db.
+	Path string `yaml:"path" json:"path"`
+
+	// SyncWrites fsyncs every write before it is acknowledged. When false,
+	// writes are flushed by badger in the background or every SyncInterval.
+	SyncWrites bool `yaml:"sync_writes" json:"sync_writes"`
+
+	// SyncInterval is the interval at which the db is synced to disk when
+	// SyncWrites is false. 0 leaves syncing to badger.
+	SyncInterval time.Duration `yaml:"sync_interval" json:"sync_interval"`
+
+	// GcInterval is the interval at which expired entries are removed and
+	// the value log is garbage collected.
+	GcInterval time.Duration `yaml:"gc_interval" json:"gc_interval"`
+
+	// GcDiscardRatio is the ratio of stale data in a value log file above
+	// which the file is rewritten. See badger's `DB.RunValueLogGC`.
+	GcDiscardRatio float64 `yaml:"gc_discard_ratio" json:"gc_discard_ratio"`
+}
+
+// Hook is a persistent storage hook which uses a badger db as the backend.
+type Hook struct {
+	kv.Hook
+	config *Options     // options for configuring the badger db.
+	db     *badgerdb.DB // the badger db instance.
+}
+
+// ID returns the id of the hook.
+func (h *Hook) ID() string {
+	return "badger-db"
+}
+
+// Init initializes and connects to the badger instance.
+func (h *Hook) Init(config any) error {
+	if _, ok := config.(*Options); !ok && config != nil {
+		return mqtt.ErrInvalidConfigType
+	}
+
+	if config == nil {
+		config = new(Options)
+	}
+
+	h.config = config.(*Options)
+	if h.config.Path == "" {
+		h.config.Path = defaultDbFile
+	}
+	if h.config.GcInterval <= 0 {
+		h.config.GcInterval = defaultGcInterval
+	}
+	if h.config.GcDiscardRatio <= 0 || h.config.GcDiscardRatio >= 1 {
+		h.config.GcDiscardRatio = defaultGcDiscardRatio
+	}
+
+	var opts badgerdb.Options
+	if h.config.Options != nil {
+		opts = *h.config.Options
+	} else {
+		opts = badgerdb.DefaultOptions(h.config.Path).WithSyncWrites(h.config.SyncWrites)
+	}
+	opts = opts.WithLogger(&logger{h})
+
+	var err error
+	h.db, err = badgerdb.Open(opts)
+	if err != nil {
+		return err
+	}
+
+	tasks := []kv.Task{{Interval: h.config.GcInterval, Run: h.Compact}}
+	if !opts.SyncWrites && h.config.SyncInterval > 0 {
+		tasks = append(tasks, kv.Task{Interval: h.config.SyncInterval, Run: h.sync})
+	}
+	h.OpenStore(&store{db: h.db}, tasks...)
+
+	return nil
+}
+
+// Stop closes the badger instance.
+func (h *Hook) Stop() error {
+	if !h.CloseStore() {
+		return nil
+	}
+
+	err := h.db.Close()
+	h.db = nil
+	return err
+}
+
+// sync syncs the db to disk.
+func (h *Hook) sync() {
+	if err := h.db.Sync(); err != nil {
+		h.Log.Error("failed to sync badger db", "error", err)
+	}
+}
+
+// Compact deletes expired retained and inflight messages and runs the badger
+// value log garbage collection until there is nothing left to rewrite.
+func (h *Hook) Compact() {
+	if h.db == nil {
+		h.Log.Error("", "error", storage.ErrDBFileNotOpen)
+		return
+	}
+
+	h.Hook.Compact()
+
+	for {
+		if err := h.db.RunValueLogGC(h.config.GcDiscardRatio); err != nil {
+			if !errors.Is(err, badgerdb.ErrNoRewrite) {
+				h.Log.Error("value log gc failed", "error", err)
+			}
+			return
+		}
+	}
+}
+
+// store is a kv.Store over a badger db.
+type store struct {
+	db *badgerdb.DB
+}
+
+// Get returns the value of a key.
+func (s *store) Get(k string) (value []byte, err error) {
+	err = s.db.View(func(txn *badgerdb.Txn) error {
+		item, err := txn.Get([]byte(k))
+		if errors.Is(err, badgerdb.ErrKeyNotFound) {
+			return kv.ErrKeyNotFound
+		} else if err != nil {
+			return err
+		}
+
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	return
+}
+
+// Set sets the value of a key.
+func (s *store) Set(k string, value []byte) error {
+	return s.db.Update(func(txn *badgerdb.Txn) error {
+		return txn.Set([]byte(k), value)
+	})
+}
+
+// Delete deletes a key.
+func (s *store) Delete(k string) error {
+	return s.db.Update(func(txn *badgerdb.Txn) error {
+		return txn.Delete([]byte(k))
+	})
+}
+
+// Iterate calls fn for each key-value pair whose key starts with prefix.
+func (s *store) Iterate(prefix string, fn func(key string, value []byte) error) error {
+	return s.db.View(func(txn *badgerdb.Txn) error {
+		opts := badgerdb.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+		iter := txn.NewIterator(opts)
+		defer iter.Close()
+
+		for iter.Rewind(); iter.Valid(); iter.Next() {
+			item := iter.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+
+			if err := fn(string(item.Key()), value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// logger adapts the hook logger to the badger Logger interface.
+type logger struct {
+	h *Hook
+}
+
+func (l *logger) Errorf(m string, v ...interface{}) {
+	l.h.Log.Error(strings.TrimSpace(fmt.Sprintf(m, v...)), "module", "badger")
+}
+
+func (l *logger) Warningf(m string, v ...interface{}) {
+	l.h.Log.Warn(strings.TrimSpace(fmt.Sprintf(m, v...)), "module", "badger")
+}
+
+func (l *logger) Infof(m string, v ...interface{}) {
+	l.h.Log.Debug(strings.TrimSpace(fmt.Sprintf(m, v...)), "module", "badger")
+}
+
+func (l *logger) Debugf(m string, v ...interface{}) {
+	l.h.Log.Debug(strings.TrimSpace(fmt.Sprintf(m, v...)), "module", "badger")
+}
@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package badger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mqtt/server/hooks/storage/kv/kvtest"
+)
+
+func newHook() kvtest.Hook {
+	return new(Hook)
+}
+
+func TestRestart(t *testing.T) {
+	for _, sync := range []bool{true, false} {
+		kvtest.TestRestart(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "badger"), SyncWrites: sync})
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	kvtest.TestDeleteClient(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "badger")})
+}
+
+func TestCompact(t *testing.T) {
+	kvtest.TestCompact(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "badger")})
+}
+
+// TestTasks runs the compaction and sync tasks at short intervals.
+func TestTasks(t *testing.T) {
+	config := &Options{
+		Path:         filepath.Join(t.TempDir(), "badger"),
+		GcInterval:   10 * time.Millisecond,
+		SyncInterval: 10 * time.Millisecond,
+	}
+	h := kvtest.Open(t, newHook, config)
+	kvtest.StoreExpired(h)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kvtest.Compacted(h) {
+		if time.Now().After(deadline) {
+			t.Fatal("the expired messages were not deleted by the compaction task")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	kvtest.CheckCompacted(t, h)
+
+	// The synced entries are read after a restart
+	if err := h.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	kvtest.CheckCompacted(t, kvtest.Open(t, newHook, config))
+}
+
+func TestDefaults(t *testing.T) {
+	h := kvtest.Open(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "badger"), GcDiscardRatio: 2}).(*Hook)
+	if h.config.GcInterval != defaultGcInterval || h.config.GcDiscardRatio != defaultGcDiscardRatio {
+		t.Errorf("options %+v", h.config)
+	}
+	if err := h.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Stop(); err != nil {
+		t.Errorf("second Stop: %v", err)
+	}
+}
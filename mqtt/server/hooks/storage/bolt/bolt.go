@@ -0,0 +1,220 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package bolt
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"boltdb/util/bbolt"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/storage/kv"
+)
+
+const (
+	// defaultDbFile is the default file path for the boltdb file.
+	defaultDbFile = ".bolt"
+
+	// defaultTimeout is the default time to hold a connection to the file.
+	defaultTimeout = 250 * time.Millisecond
+
+	// defaultBucket is the default bucket name.
+	defaultBucket = "mochi"
+
+	// defaultCompactInterval is the default interval between removals of expired entries.
+	defaultCompactInterval = 5 * time.Minute
+)
+
+var (
+	// ErrBucketNotFound indicates the bucket was not found in the db.
+	ErrBucketNotFound = errors.New("bucket not found")
+
+	// ErrKeyNotFound indicates the key was not found in the bucket.
+	ErrKeyNotFound = kv.ErrKeyNotFound
+)
+
+// Options contains configuration settings for the bolt instance.
+type Options struct {
+	Options *bbolt.Options `yaml:"-" json:"-"` // optional bbolt options, Timeout and NoSync are set from this struct
+
+	// Path is the file path of the bolt db.
+	Path string `yaml:"path" json:"path"`
+
+	// Bucket is the name of the bucket all entries are stored in.
+	Bucket string `yaml:"bucket" json:"bucket"`
+
+	// Timeout is the time to wait for the file lock on open.
+	Timeout time.Duration `yaml:"timeout" json:"timeout"`
+
+	// NoSync skips the fsync after each commit. Entries are then synced to
+	// disk every SyncInterval, if set.
+	NoSync bool `yaml:"no_sync" json:"no_sync"`
+
+	// SyncInterval is the interval at which the db is synced to disk when
+	// NoSync is set. 0 leaves syncing to the operating system.
+	SyncInterval time.Duration `yaml:"sync_interval" json:"sync_interval"`
+
+	// CompactInterval is the interval at which expired retained and inflight
+	// messages are removed from the db.
+	CompactInterval time.Duration `yaml:"compact_interval" json:"compact_interval"`
+}
+
+// Hook is a persistent storage hook using boltdb file store as a backend.
+type Hook struct {
+	kv.Hook
+	config *Options  // options for configuring the boltdb instance.
+	db     *bbolt.DB // the boltdb instance.
+}
+
+// ID returns the id of the hook.
+func (h *Hook) ID() string {
+	return "bolt-db"
+}
+
+// Init initializes and connects to the boltdb instance.
+func (h *Hook) Init(config any) error {
+	if _, ok := config.(*Options); !ok && config != nil {
+		return mqtt.ErrInvalidConfigType
+	}
+
+	if config == nil {
+		config = new(Options)
+	}
+
+	h.config = config.(*Options)
+	if h.config.Path == "" {
+		h.config.Path = defaultDbFile
+	}
+	if h.config.Bucket == "" {
+		h.config.Bucket = defaultBucket
+	}
+	if h.config.Timeout <= 0 {
+		h.config.Timeout = defaultTimeout
+	}
+	if h.config.CompactInterval <= 0 {
+		h.config.CompactInterval = defaultCompactInterval
+	}
+
+	opts := new(bbolt.Options)
+	if h.config.Options != nil {
+		*opts = *h.config.Options
+	}
+	opts.Timeout = h.config.Timeout
+	opts.NoSync = h.config.NoSync
+
+	var err error
+	h.db, err = bbolt.Open(h.config.Path, 0600, opts)
+	if err != nil {
+		return err
+	}
+
+	err = h.db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(h.config.Bucket))
+		return err
+	})
+	if err != nil {
+		_ = h.db.Close()
+		h.db = nil
+		return err
+	}
+
+	tasks := []kv.Task{{Interval: h.config.CompactInterval, Run: h.Compact}}
+	if h.config.NoSync && h.config.SyncInterval > 0 {
+		tasks = append(tasks, kv.Task{Interval: h.config.SyncInterval, Run: h.sync})
+	}
+	h.OpenStore(&store{db: h.db, bucket: []byte(h.config.Bucket)}, tasks...)
+
+	return nil
+}
+
+// Stop closes the boltdb instance.
+func (h *Hook) Stop() error {
+	if !h.CloseStore() {
+		return nil
+	}
+
+	if h.config.NoSync {
+		_ = h.db.Sync()
+	}
+	err := h.db.Close()
+	h.db = nil
+	return err
+}
+
+// sync syncs the db to disk.
+func (h *Hook) sync() {
+	if err := h.db.Sync(); err != nil {
+		h.Log.Error("failed to sync bolt db", "error", err)
+	}
+}
+
+// store is a kv.Store over a bucket of a bolt db.
+type store struct {
+	db     *bbolt.DB
+	bucket []byte
+}
+
+// Get returns the value of a key.
+func (s *store) Get(k string) (value []byte, err error) {
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+
+		v := bucket.Get([]byte(k))
+		if v == nil {
+			return ErrKeyNotFound
+		}
+
+		value = bytes.Clone(v)
+		return nil
+	})
+	return
+}
+
+// Set sets the value of a key.
+func (s *store) Set(k string, value []byte) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+		return bucket.Put([]byte(k), value)
+	})
+}
+
+// Delete deletes a key.
+func (s *store) Delete(k string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+		return bucket.Delete([]byte(k))
+	})
+}
+
+// Iterate calls fn for each key-value pair whose key starts with prefix.
+func (s *store) Iterate(prefix string, fn func(key string, value []byte) error) error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return ErrBucketNotFound
+		}
+
+		p := []byte(prefix)
+		c := bucket.Cursor()
+		for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+			if err := fn(string(k), v); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"mqtt/server/hooks/storage/kv/kvtest"
+	"mqtt/server/packets"
+)
+
+func newHook() kvtest.Hook {
+	return new(Hook)
+}
+
+func TestRestart(t *testing.T) {
+	for _, noSync := range []bool{false, true} {
+		kvtest.TestRestart(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "bolt.db"), NoSync: noSync})
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	kvtest.TestDeleteClient(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "bolt.db")})
+}
+
+func TestCompact(t *testing.T) {
+	kvtest.TestCompact(t, newHook, &Options{Path: filepath.Join(t.TempDir(), "bolt.db")})
+}
+
+// TestTasks runs the compaction and sync tasks at short intervals.
+func TestTasks(t *testing.T) {
+	config := &Options{
+		Path:            filepath.Join(t.TempDir(), "bolt.db"),
+		NoSync:          true,
+		SyncInterval:    10 * time.Millisecond,
+		CompactInterval: 10 * time.Millisecond,
+	}
+	h := kvtest.Open(t, newHook, config)
+	kvtest.StoreExpired(h)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kvtest.Compacted(h) {
+		if time.Now().After(deadline) {
+			t.Fatal("the expired messages were not deleted by the compaction task")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	kvtest.CheckCompacted(t, h)
+
+	// The synced entries are read after a restart
+	if err := h.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	kvtest.CheckCompacted(t, kvtest.Open(t, newHook, config))
+}
+
+func TestBucket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bolt.db")
+	h := kvtest.Open(t, newHook, &Options{Path: path, Bucket: "one"})
+	h.OnSessionEstablished(kvtest.Client("a"), packets.Packet{})
+	if err := h.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries of other buckets are not read
+	h = kvtest.Open(t, newHook, &Options{Path: path, Bucket: "two"})
+	if clients, err := h.StoredClients(); err != nil || len(clients) != 0 {
+		t.Errorf("clients %+v, %v, want none", clients, err)
+	}
+}
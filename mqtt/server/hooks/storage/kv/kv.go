@@ -0,0 +1,508 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// package kv provides the persistent storage hook methods over a key-value
+// store, shared by the badger and bolt hooks.
+package kv
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/storage"
+	"mqtt/server/packets"
+	"mqtt/server/system"
+)
+
+// ErrKeyNotFound indicates that a key was not found in a Store.
+var ErrKeyNotFound = errors.New("key not found")
+
+// ClientKeyOf returns the primary key of a client.
+func ClientKeyOf(id string) string {
+	return storage.ClientKey + "_" + id
+}
+
+// SubscriptionKeyOf returns the primary key of a client subscription.
+func SubscriptionKeyOf(id, filter string) string {
+	return clientPrefix(storage.SubscriptionKey, id) + filter
+}
+
+// RetainedKeyOf returns the primary key of the retained message of a topic.
+func RetainedKeyOf(topic string) string {
+	return storage.RetainedKey + "_" + topic
+}
+
+// InflightKeyOf returns the primary key of an inflight message of a client.
+func InflightKeyOf(id string, pk packets.Packet) string {
+	return clientPrefix(storage.InflightKey, id) + pk.FormatID()
+}
+
+// clientPrefix returns the prefix of the keys of a client's entries of a kind.
+// The client id is length-prefixed, so that the prefix of a client never
+// matches the keys of another client whose id it is a prefix of.
+func clientPrefix(kind, id string) string {
+	return kind + "_" + strconv.Itoa(len(id)) + ":" + id + ":"
+}
+
+// Store is a key-value store backing a Hook.
+type Store interface {
+	// Get returns the value of a key, or ErrKeyNotFound.
+	Get(key string) ([]byte, error)
+
+	// Set sets the value of a key.
+	Set(key string, value []byte) error
+
+	// Delete deletes a key.
+	Delete(key string) error
+
+	// Iterate calls fn for each key with the prefix. The value is only
+	// valid for the duration of the call.
+	Iterate(prefix string, fn func(key string, value []byte) error) error
+}
+
+// Task is a maintenance function run periodically by a Hook.
+type Task struct {
+	Interval time.Duration
+	Run      func()
+}
+
+// Hook implements the persistent storage hook methods over a Store. It is
+// embedded by the storage hooks, which open the store in Init and close it in Stop.
+type Hook struct {
+	mqtt.HookBase
+
+	mu    sync.RWMutex   // held while the store is used, and locked to close it
+	store Store          // the store, nil when closed
+	done  chan struct{}  // closed to stop the tasks
+	wg    sync.WaitGroup // the running tasks
+}
+
+// Provides indicates which hook methods this hook provides.
+func (h *Hook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnSessionEstablished,
+		mqtt.OnDisconnect,
+		mqtt.OnSubscribed,
+		mqtt.OnUnsubscribed,
+		mqtt.OnRetainMessage,
+		mqtt.OnWillSent,
+		mqtt.OnQosPublish,
+		mqtt.OnQosComplete,
+		mqtt.OnQosDropped,
+		mqtt.OnSysInfoTick,
+		mqtt.OnClientExpired,
+		mqtt.OnRetainedExpired,
+		mqtt.StoredClients,
+		mqtt.StoredInflightMessages,
+		mqtt.StoredRetainedMessages,
+		mqtt.StoredSubscriptions,
+		mqtt.StoredSysInfo,
+	}, []byte{b})
+}
+
+// OpenStore starts using the store and runs each task at its interval until
+// CloseStore is called.
+func (h *Hook) OpenStore(store Store, tasks ...Task) {
+	h.mu.Lock()
+	h.store = store
+	h.done = make(chan struct{})
+	h.mu.Unlock()
+
+	for _, t := range tasks {
+		h.wg.Add(1)
+		go h.runTask(t)
+	}
+}
+
+// CloseStore stops the tasks, waits for them to return and stops using the
+// store, which can then be closed. It returns false if the store is not open.
+func (h *Hook) CloseStore() bool {
+	h.mu.Lock()
+	if h.store == nil {
+		h.mu.Unlock()
+		return false
+	}
+	close(h.done)
+	h.mu.Unlock()
+
+	h.wg.Wait()
+
+	h.mu.Lock()
+	h.store = nil
+	h.mu.Unlock()
+	return true
+}
+
+// runTask runs a task at its interval until the store is closed.
+func (h *Hook) runTask(t Task) {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-h.done:
+			return
+		case <-ticker.C:
+			t.Run()
+		}
+	}
+}
+
+// Compact deletes expired retained and inflight messages from the store.
+func (h *Hook) Compact() {
+	now := time.Now().Unix()
+	for _, prefix := range []string{storage.RetainedKey, storage.InflightKey} {
+		var expired []string
+		err := h.iterKv(prefix+"_", func(key string, value []byte) error {
+			var m storage.Message
+			if err := m.UnmarshalBinary(value); err != nil {
+				return err
+			}
+			if messageExpired(m, now) {
+				expired = append(expired, key)
+			}
+			return nil
+		})
+		if err != nil {
+			h.Log.Error("failed to read messages", "error", err, "prefix", prefix)
+			continue
+		}
+
+		for _, key := range expired {
+			h.delKv(key)
+		}
+	}
+}
+
+// messageExpired returns true if a message has a message expiry interval
+// which has elapsed.
+func messageExpired(m storage.Message, now int64) bool {
+	expiry := int64(m.Properties.MessageExpiryInterval)
+	return expiry > 0 && m.Created > 0 && m.Created+expiry < now
+}
+
+// OnSessionEstablished adds a client to the store when their session is established.
+func (h *Hook) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	h.updateClient(cl)
+}
+
+// OnWillSent is called when a client sends a Will Message and the Will Message is removed from the client record.
+func (h *Hook) OnWillSent(cl *mqtt.Client, pk packets.Packet) {
+	h.updateClient(cl)
+}
+
+// updateClient writes the client data to the store.
+func (h *Hook) updateClient(cl *mqtt.Client) {
+	props := cl.Properties.Props.Copy(false)
+	in := &storage.Client{
+		ID:              cl.ID,
+		T:               storage.ClientKey,
+		Remote:          cl.Net.Remote,
+		Listener:        cl.Net.Listener,
+		Username:        cl.Properties.Username,
+		Clean:           cl.Properties.Clean,
+		ProtocolVersion: cl.Properties.ProtocolVersion,
+		Properties: storage.ClientProperties{
+			SessionExpiryInterval: props.SessionExpiryInterval,
+			AuthenticationMethod:  props.AuthenticationMethod,
+			AuthenticationData:    props.AuthenticationData,
+			RequestProblemInfo:    props.RequestProblemInfo,
+			RequestResponseInfo:   props.RequestResponseInfo,
+			ReceiveMaximum:        props.ReceiveMaximum,
+			TopicAliasMaximum:     props.TopicAliasMaximum,
+			User:                  props.User,
+			MaximumPacketSize:     props.MaximumPacketSize,
+		},
+		Will: storage.ClientWill(cl.Properties.Will),
+	}
+
+	h.setKv(ClientKeyOf(cl.ID), in)
+}
+
+// OnDisconnect removes a client from the store if their session has expired.
+func (h *Hook) OnDisconnect(cl *mqtt.Client, _ error, expire bool) {
+	h.updateClient(cl)
+
+	if !expire {
+		return
+	}
+
+	if errors.Is(cl.StopCause(), packets.ErrSessionTakenOver) {
+		return
+	}
+
+	h.deleteClient(cl)
+}
+
+// OnSubscribed adds one or more client subscriptions to the store.
+func (h *Hook) OnSubscribed(cl *mqtt.Client, pk packets.Packet, reasonCodes []byte) {
+	var in *storage.Subscription
+	for i := 0; i < len(pk.Filters); i++ {
+		in = &storage.Subscription{
+			ID:                SubscriptionKeyOf(cl.ID, pk.Filters[i].Filter),
+			T:                 storage.SubscriptionKey,
+			Client:            cl.ID,
+			Qos:               reasonCodes[i],
+			Filter:            pk.Filters[i].Filter,
+			Identifier:        pk.Filters[i].Identifier,
+			NoLocal:           pk.Filters[i].NoLocal,
+			RetainHandling:    pk.Filters[i].RetainHandling,
+			RetainAsPublished: pk.Filters[i].RetainAsPublished,
+		}
+		h.setKv(in.ID, in)
+	}
+}
+
+// OnUnsubscribed removes one or more client subscriptions from the store.
+func (h *Hook) OnUnsubscribed(cl *mqtt.Client, pk packets.Packet) {
+	for i := 0; i < len(pk.Filters); i++ {
+		h.delKv(SubscriptionKeyOf(cl.ID, pk.Filters[i].Filter))
+	}
+}
+
+// OnRetainMessage adds a retained message for a topic to the store.
+func (h *Hook) OnRetainMessage(cl *mqtt.Client, pk packets.Packet, r int64) {
+	if r == -1 {
+		h.delKv(RetainedKeyOf(pk.TopicName))
+		return
+	}
+
+	props := pk.Properties.Copy(false)
+	in := &storage.Message{
+		ID:          RetainedKeyOf(pk.TopicName),
+		T:           storage.RetainedKey,
+		FixedHeader: pk.FixedHeader,
+		TopicName:   pk.TopicName,
+		Payload:     pk.Payload,
+		Created:     pk.Created,
+		Origin:      pk.Origin,
+		Properties: storage.MessageProperties{
+			PayloadFormat:          props.PayloadFormat,
+			PayloadFormatFlag:      props.PayloadFormatFlag,
+			MessageExpiryInterval:  props.MessageExpiryInterval,
+			ContentType:            props.ContentType,
+			ResponseTopic:          props.ResponseTopic,
+			CorrelationData:        props.CorrelationData,
+			SubscriptionIdentifier: props.SubscriptionIdentifier,
+			TopicAlias:             props.TopicAlias,
+			User:                   props.User,
+		},
+	}
+
+	h.setKv(in.ID, in)
+}
+
+// OnQosPublish adds or updates an inflight message in the store.
+func (h *Hook) OnQosPublish(cl *mqtt.Client, pk packets.Packet, sent int64, resends int) {
+	props := pk.Properties.Copy(false)
+	in := &storage.Message{
+		ID:          InflightKeyOf(cl.ID, pk),
+		T:           storage.InflightKey,
+		Origin:      pk.Origin,
+		PacketID:    pk.PacketID,
+		FixedHeader: pk.FixedHeader,
+		TopicName:   pk.TopicName,
+		Payload:     pk.Payload,
+		Sent:        sent,
+		Created:     pk.Created,
+		Properties: storage.MessageProperties{
+			PayloadFormat:          props.PayloadFormat,
+			PayloadFormatFlag:      props.PayloadFormatFlag,
+			MessageExpiryInterval:  props.MessageExpiryInterval,
+			ContentType:            props.ContentType,
+			ResponseTopic:          props.ResponseTopic,
+			CorrelationData:        props.CorrelationData,
+			SubscriptionIdentifier: props.SubscriptionIdentifier,
+			TopicAlias:             props.TopicAlias,
+			User:                   props.User,
+		},
+	}
+
+	h.setKv(in.ID, in)
+}
+
+// OnQosComplete removes a resolved inflight message from the store.
+func (h *Hook) OnQosComplete(cl *mqtt.Client, pk packets.Packet) {
+	h.delKv(InflightKeyOf(cl.ID, pk))
+}
+
+// OnQosDropped removes a dropped inflight message from the store.
+func (h *Hook) OnQosDropped(cl *mqtt.Client, pk packets.Packet) {
+	h.OnQosComplete(cl, pk)
+}
+
+// OnSysInfoTick stores the latest system info in the store.
+func (h *Hook) OnSysInfoTick(sys *system.Info) {
+	in := &storage.SystemInfo{
+		ID:   storage.SysInfoKey,
+		T:    storage.SysInfoKey,
+		Info: *sys.Clone(),
+	}
+
+	h.setKv(in.ID, in)
+}
+
+// OnRetainedExpired deletes expired retained messages from the store.
+func (h *Hook) OnRetainedExpired(filter string) {
+	h.delKv(RetainedKeyOf(filter))
+}
+
+// OnClientExpired deleted expired clients from the store.
+func (h *Hook) OnClientExpired(cl *mqtt.Client) {
+	h.deleteClient(cl)
+}
+
+// deleteClient removes a client together with its subscriptions and
+// inflight messages from the store.
+func (h *Hook) deleteClient(cl *mqtt.Client) {
+	h.delKv(ClientKeyOf(cl.ID))
+
+	for _, prefix := range []string{clientPrefix(storage.SubscriptionKey, cl.ID), clientPrefix(storage.InflightKey, cl.ID)} {
+		var keys []string
+		err := h.iterKv(prefix, func(key string, _ []byte) error {
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil {
+			h.Log.Error("failed to read client data", "error", err, "id", cl.ID)
+			continue
+		}
+
+		for _, key := range keys {
+			h.delKv(key)
+		}
+	}
+}
+
+// StoredClients returns all stored clients from the store.
+func (h *Hook) StoredClients() (v []storage.Client, err error) {
+	err = h.iterKv(storage.ClientKey+"_", func(_ string, value []byte) error {
+		obj := storage.Client{}
+		if err := obj.UnmarshalBinary(value); err != nil {
+			return err
+		}
+		v = append(v, obj)
+		return nil
+	})
+	return
+}
+
+// StoredSubscriptions returns all stored subscriptions from the store.
+func (h *Hook) StoredSubscriptions() (v []storage.Subscription, err error) {
+	err = h.iterKv(storage.SubscriptionKey+"_", func(_ string, value []byte) error {
+		obj := storage.Subscription{}
+		if err := obj.UnmarshalBinary(value); err != nil {
+			return err
+		}
+		v = append(v, obj)
+		return nil
+	})
+	return
+}
+
+// StoredRetainedMessages returns all stored retained messages from the store.
+func (h *Hook) StoredRetainedMessages() (v []storage.Message, err error) {
+	err = h.iterKv(storage.RetainedKey+"_", func(_ string, value []byte) error {
+		obj := storage.Message{}
+		if err := obj.UnmarshalBinary(value); err != nil {
+			return err
+		}
+		v = append(v, obj)
+		return nil
+	})
+	return
+}
+
+// StoredInflightMessages returns all stored inflight messages from the store.
+func (h *Hook) StoredInflightMessages() (v []storage.Message, err error) {
+	err = h.iterKv(storage.InflightKey+"_", func(_ string, value []byte) error {
+		obj := storage.Message{}
+		if err := obj.UnmarshalBinary(value); err != nil {
+			return err
+		}
+		v = append(v, obj)
+		return nil
+	})
+	return
+}
+
+// StoredSysInfo returns the system info from the store.
+func (h *Hook) StoredSysInfo() (v storage.SystemInfo, err error) {
+	err = h.getKv(storage.SysInfoKey, &v)
+	if errors.Is(err, ErrKeyNotFound) {
+		return v, nil
+	}
+
+	return
+}
+
+// setKv stores a key-value pair in the store.
+func (h *Hook) setKv(k string, v encoding.BinaryMarshaler) {
+	data, err := v.MarshalBinary()
+	if err != nil {
+		h.Log.Error("failed to marshal data", "error", err, "key", k)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.store == nil {
+		h.Log.Error("", "error", storage.ErrDBFileNotOpen)
+		return
+	}
+
+	if err := h.store.Set(k, data); err != nil {
+		h.Log.Error("failed to upsert data", "error", err, "key", k)
+	}
+}
+
+// delKv deletes a key-value pair from the store.
+func (h *Hook) delKv(k string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.store == nil {
+		h.Log.Error("", "error", storage.ErrDBFileNotOpen)
+		return
+	}
+
+	if err := h.store.Delete(k); err != nil {
+		h.Log.Error("failed to delete data", "error", err, "key", k)
+	}
+}
+
+// getKv retrieves the value of a key from the store.
+func (h *Hook) getKv(k string, v encoding.BinaryUnmarshaler) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.store == nil {
+		h.Log.Error("", "error", storage.ErrDBFileNotOpen)
+		return nil
+	}
+
+	value, err := h.store.Get(k)
+	if err != nil {
+		return err
+	}
+
+	return v.UnmarshalBinary(value)
+}
+
+// iterKv calls fn for each key-value pair whose key starts with prefix.
+func (h *Hook) iterKv(prefix string, fn func(key string, value []byte) error) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.store == nil {
+		h.Log.Error("", "error", storage.ErrDBFileNotOpen)
+		return nil
+	}
+
+	return h.store.Iterate(prefix, fn)
+}
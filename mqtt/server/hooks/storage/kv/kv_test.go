@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package kv_test
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mqtt/server/hooks/storage/kv"
+	"mqtt/server/hooks/storage/kv/kvtest"
+	"mqtt/server/packets"
+)
+
+// memStore is a kv.Store in memory.
+type memStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (s *memStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.data[key]
+	if !ok {
+		return nil, kv.ErrKeyNotFound
+	}
+	return value, nil
+}
+
+func (s *memStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, key)
+	return nil
+}
+
+func (s *memStore) Iterate(prefix string, fn func(key string, value []byte) error) error {
+	s.mu.Lock()
+	var keys []string
+	for key := range s.data {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value, err := s.Get(key)
+		if err != nil {
+			continue
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memHook is a storage hook over a memStore, which is its config and is kept
+// when the hook is stopped.
+type memHook struct {
+	kv.Hook
+}
+
+func (h *memHook) ID() string {
+	return "memory"
+}
+
+func (h *memHook) Init(config any) error {
+	h.OpenStore(config.(*memStore))
+	return nil
+}
+
+func (h *memHook) Stop() error {
+	h.CloseStore()
+	return nil
+}
+
+func newMemHook() kvtest.Hook {
+	return new(memHook)
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: map[string][]byte{}}
+}
+
+func TestRestart(t *testing.T) {
+	kvtest.TestRestart(t, newMemHook, newMemStore())
+}
+
+func TestDeleteClient(t *testing.T) {
+	kvtest.TestDeleteClient(t, newMemHook, newMemStore())
+}
+
+func TestCompact(t *testing.T) {
+	kvtest.TestCompact(t, newMemHook, newMemStore())
+}
+
+func TestKeys(t *testing.T) {
+	pk := packets.Packet{PacketID: 42}
+	tests := []struct {
+		got, want string
+	}{
+		{kv.ClientKeyOf("a:b"), "CL_a:b"},
+		{kv.RetainedKeyOf("a/b/c"), "RET_a/b/c"},
+		{kv.SubscriptionKeyOf("a", "t/#"), "SUB_1:a:t/#"},
+		{kv.SubscriptionKeyOf("a:1", "t"), "SUB_3:a:1:t"},
+		{kv.SubscriptionKeyOf("", "t"), "SUB_0::t"},
+		{kv.InflightKeyOf("ab", pk), "IFM_2:ab:42"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("key %q, want %q", test.got, test.want)
+		}
+	}
+
+	// The keys of a client never start with the prefix of another client
+	ids := []string{"a", "ab", "a:", "a:b", "1:a", "a:1:b", ""}
+	for _, id := range ids {
+		prefix := kv.SubscriptionKeyOf(id, "")
+		for _, other := range ids {
+			if other != id && strings.HasPrefix(kv.SubscriptionKeyOf(other, "x"), prefix) {
+				t.Errorf("subscription key of %q starts with the prefix of %q", other, id)
+			}
+		}
+	}
+}
+
+func TestTasks(t *testing.T) {
+	var h memHook
+	var runs atomic.Int32
+	ran := make(chan struct{}, 1)
+	h.OpenStore(newMemStore(), kv.Task{Interval: time.Millisecond, Run: func() {
+		runs.Add(1)
+		select {
+		case ran <- struct{}{}:
+		default:
+		}
+	}})
+
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the task did not run")
+	}
+	if !h.CloseStore() {
+		t.Fatal("CloseStore of an open store returned false")
+	}
+	n := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	if runs.Load() != n {
+		t.Error("the task ran after CloseStore returned")
+	}
+	if h.CloseStore() {
+		t.Error("CloseStore of a closed store returned true")
+	}
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+// package kvtest provides the tests shared by the storage hooks built on kv.Hook.
+package kvtest
+
+import (
+	"io"
+	"log/slog"
+	"sort"
+	"testing"
+	"time"
+
+	mqtt "mqtt/server"
+	"mqtt/server/hooks/storage"
+	"mqtt/server/packets"
+	"mqtt/server/system"
+)
+
+// Hook is a storage hook built on kv.Hook.
+type Hook interface {
+	mqtt.Hook
+	Compact()
+}
+
+// server creates the clients passed to the hooks.
+var server = mqtt.New(nil)
+
+// Client returns a client with the id, which isn't connected.
+func Client(id string) *mqtt.Client {
+	cl := server.NewClient(nil, "t1", id, false)
+	cl.Net.Remote = "127.0.0.1:1883"
+	cl.Properties.Username = []byte("user-" + id)
+	cl.Properties.ProtocolVersion = 5
+	return cl
+}
+
+// Open initializes a new hook with config and stops it when the test ends.
+func Open(t *testing.T, newHook func() Hook, config any) Hook {
+	t.Helper()
+
+	h := newHook()
+	h.SetOpts(slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	if err := h.Init(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = h.Stop() })
+	return h
+}
+
+// message returns a qos 1 publish packet.
+func message(id uint16, topic, payload string, created int64, expiry uint32) packets.Packet {
+	return packets.Packet{
+		FixedHeader: packets.FixedHeader{Type: packets.Publish, Qos: 1, Retain: true},
+		PacketID:    id,
+		TopicName:   topic,
+		Payload:     []byte(payload),
+		Created:     created,
+		Properties:  packets.Properties{MessageExpiryInterval: expiry},
+	}
+}
+
+// subscribe returns a subscribe packet of the filters.
+func subscribe(filters ...string) packets.Packet {
+	pk := packets.Packet{FixedHeader: packets.FixedHeader{Type: packets.Subscribe}}
+	for _, f := range filters {
+		pk.Filters = append(pk.Filters, packets.Subscription{Filter: f, Qos: 1})
+	}
+	return pk
+}
+
+// TestRestart stores clients, subscriptions, inflight and retained messages and
+// the system info, and checks that they are read back after the hook was stopped
+// and initialized again with the same config.
+func TestRestart(t *testing.T, newHook func() Hook, config any) {
+	h := Open(t, newHook, config)
+	now := time.Now().Unix()
+
+	a, b := Client("a"), Client("b")
+	a.Properties.Props.SessionExpiryInterval = 3600
+	h.OnSessionEstablished(a, packets.Packet{})
+	h.OnSessionEstablished(b, packets.Packet{})
+	h.OnSubscribed(a, subscribe("a/+", "a/#"), []byte{1, 2})
+	h.OnSubscribed(b, subscribe("b/1"), []byte{0})
+	h.OnUnsubscribed(a, subscribe("a/#"))
+	h.OnQosPublish(a, message(1, "a/1", "one", now, 0), now, 0)
+	h.OnQosPublish(a, message(2, "a/2", "two", now, 0), now, 0)
+	h.OnQosComplete(a, message(2, "a/2", "two", now, 0))
+	h.OnRetainMessage(a, message(0, "r/1", "retained", now, 0), 1)
+	h.OnRetainMessage(a, message(0, "r/2", "cleared", now, 0), 1)
+	h.OnRetainMessage(a, message(0, "r/2", "", now, 0), -1)
+	h.OnSysInfoTick(&system.Info{Version: "test", Started: now, BytesReceived: 123})
+
+	if err := h.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	h = Open(t, newHook, config)
+
+	clients, err := h.StoredClients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(clients, func(i, j int) bool { return clients[i].ID < clients[j].ID })
+	if len(clients) != 2 || clients[0].ID != "a" || clients[1].ID != "b" {
+		t.Fatalf("clients %+v, want a and b", clients)
+	}
+	if c := clients[0]; string(c.Username) != "user-a" || c.Remote != "127.0.0.1:1883" || c.Listener != "t1" ||
+		c.ProtocolVersion != 5 || c.Properties.SessionExpiryInterval != 3600 || c.T != storage.ClientKey {
+		t.Errorf("client a %+v", c)
+	}
+
+	subs, err := h.StoredSubscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Filter < subs[j].Filter })
+	if len(subs) != 2 || subs[0].Client != "a" || subs[0].Filter != "a/+" || subs[0].Qos != 1 ||
+		subs[1].Client != "b" || subs[1].Filter != "b/1" {
+		t.Errorf("subscriptions %+v, want a/+ of a and b/1 of b", subs)
+	}
+
+	inflight, err := h.StoredInflightMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inflight) != 1 || inflight[0].PacketID != 1 || inflight[0].TopicName != "a/1" ||
+		string(inflight[0].Payload) != "one" || inflight[0].Sent != now {
+		t.Errorf("inflight messages %+v, want packet 1", inflight)
+	}
+
+	retained, err := h.StoredRetainedMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(retained) != 1 || retained[0].TopicName != "r/1" || string(retained[0].Payload) != "retained" {
+		t.Errorf("retained messages %+v, want r/1", retained)
+	}
+
+	info, err := h.StoredSysInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "test" || info.Started != now || info.BytesReceived != 123 {
+		t.Errorf("system info %+v", info.Info)
+	}
+}
+
+// TestDeleteClient deletes clients whose ids are prefixes of the ids of other
+// clients, whose subscriptions and inflight messages must remain.
+func TestDeleteClient(t *testing.T, newHook func() Hook, config any) {
+	h := Open(t, newHook, config)
+	now := time.Now().Unix()
+
+	ids := []string{"a", "ab", "a:b", "a_b", "1:a"}
+	for _, id := range ids {
+		cl := Client(id)
+		h.OnSessionEstablished(cl, packets.Packet{})
+		h.OnSubscribed(cl, subscribe("t/"+id), []byte{1})
+		h.OnQosPublish(cl, message(7, "t/"+id, id, now, 0), now, 0)
+	}
+	h.OnClientExpired(Client("a"))
+	h.OnDisconnect(Client("1:a"), nil, true)
+
+	want := map[string]bool{"ab": true, "a:b": true, "a_b": true}
+	clients, err := h.StoredClients()
+	if err != nil {
+		t.Fatal(err)
+	}
+	subs, err := h.StoredSubscriptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflight, err := h.StoredInflightMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != len(want) || len(subs) != len(want) || len(inflight) != len(want) {
+		t.Errorf("%d clients, %d subscriptions and %d inflight messages, want %d each", len(clients), len(subs), len(inflight), len(want))
+	}
+	for _, c := range clients {
+		if !want[c.ID] {
+			t.Errorf("client %q was not deleted", c.ID)
+		}
+	}
+	for _, s := range subs {
+		if !want[s.Client] || s.Filter != "t/"+s.Client {
+			t.Errorf("subscription %+v", s)
+		}
+	}
+	for _, m := range inflight {
+		if !want[string(m.Payload)] {
+			t.Errorf("inflight message of %q was not deleted", m.Payload)
+		}
+	}
+}
+
+// TestCompact checks that Compact deletes the expired retained and inflight
+// messages only.
+func TestCompact(t *testing.T, newHook func() Hook, config any) {
+	h := Open(t, newHook, config)
+	StoreExpired(h)
+
+	h.Compact()
+	CheckCompacted(t, h)
+}
+
+// StoreExpired stores expired and current retained and inflight messages.
+func StoreExpired(h Hook) {
+	now := time.Now().Unix()
+	cl := Client("c")
+	h.OnRetainMessage(cl, message(0, "r/expired", "expired", now-100, 10), 1)
+	h.OnRetainMessage(cl, message(0, "r/current", "current", now-100, 1000), 1)
+	h.OnRetainMessage(cl, message(0, "r/forever", "forever", now-100, 0), 1)
+	h.OnQosPublish(cl, message(1, "i/expired", "expired", now-100, 10), now, 0)
+	h.OnQosPublish(cl, message(2, "i/current", "current", now-100, 1000), now, 0)
+}
+
+// CheckCompacted checks that the expired messages of StoreExpired were deleted.
+func CheckCompacted(t *testing.T, h Hook) {
+	t.Helper()
+
+	retained, err := h.StoredRetainedMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflight, err := h.StoredInflightMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range append(retained, inflight...) {
+		if string(m.Payload) == "expired" {
+			t.Errorf("expired message %s was not deleted", m.TopicName)
+		}
+	}
+	if len(retained) != 2 || len(inflight) != 1 {
+		t.Errorf("%d retained and %d inflight messages, want 2 and 1", len(retained), len(inflight))
+	}
+}
+
+// Compacted returns true if the expired messages of StoreExpired were deleted.
+func Compacted(h Hook) bool {
+	retained, err := h.StoredRetainedMessages()
+	if err != nil {
+		return false
+	}
+	inflight, err := h.StoredInflightMessages()
+	return err == nil && len(retained) == 2 && len(inflight) == 1
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2022 mochi-mqtt, mochi-co
+// SPDX-FileContributor: mochi-co
+
+package listeners
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HTTPHandler is a listener for serving an http.Handler on the http endpoints matching
+// a pattern, e.g. the OpenMetrics handler of the metrics hook on /metrics or the REST
+// api of the admin package on /api/.
+type HTTPHandler struct {
+	sync.RWMutex
+	id      string       // the internal id of the listener
+	address string       // the network address to bind to
+	pattern string       // the http.ServeMux pattern of the endpoints
+	config  *Config      // configuration values for the listener
+	listen  *http.Server // the http server
+	handler http.Handler // the handler serving the endpoints
+	log     *slog.Logger // server logger
+	end     uint32       // ensure the close methods are only called once
+}
+
+// NewHTTPHandler initialises and returns a new HTTP listener, listening on an address
+// and serving handler on the endpoints matching pattern.
+func NewHTTPHandler(id, address, pattern string, config *Config, handler http.Handler) *HTTPHandler {
+	if config == nil {
+		config = new(Config)
+	}
+	return &HTTPHandler{
+		id:      id,
+		address: address,
+		pattern: pattern,
+		handler: handler,
+		config:  config,
+	}
+}
+
+// ID returns the id of the listener.
+func (l *HTTPHandler) ID() string {
+	return l.id
+}
+
+// Address returns the address of the listener.
+func (l *HTTPHandler) Address() string {
+	return l.address
+}
+
+// Protocol returns the address of the listener.
+func (l *HTTPHandler) Protocol() string {
+	if l.listen != nil && l.listen.TLSConfig != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// Init initializes the listener.
+func (l *HTTPHandler) Init(log *slog.Logger) error {
+	l.log = log
+	mux := http.NewServeMux()
+	mux.Handle(l.pattern, l.handler)
+	l.listen = &http.Server{
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		Addr:         l.address,
+		Handler:      mux,
+	}
+
+	if l.config.TLSConfig != nil {
+		l.listen.TLSConfig = l.config.TLSConfig
+	}
+
+	return nil
+}
+
+// Serve starts listening for new connections and serving responses.
+func (l *HTTPHandler) Serve(establish EstablishFn) {
+	var err error
+	if l.listen.TLSConfig != nil {
+		err = l.listen.ListenAndServeTLS("", "")
+	} else {
+		err = l.listen.ListenAndServe()
+	}
+
+	// After the listener has been shutdown, no need to print the http.ErrServerClosed error.
+	if err != nil && atomic.LoadUint32(&l.end) == 0 {
+		l.log.Error("failed to serve.", "error", err, "listener", l.id)
+	}
+}
+
+// Close closes the listener and any client connections.
+func (l *HTTPHandler) Close(closeClients CloseFn) {
+	l.Lock()
+	defer l.Unlock()
+
+	if atomic.CompareAndSwapUint32(&l.end, 0, 1) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = l.listen.Shutdown(ctx)
+	}
+
+	closeClients(l.id)
+}
@@ -0,0 +1,42 @@
+//go:build !ppc64 && !ppc64le
+
+package serial
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+const (
+	cbaud  = 0x100F
+	bother = 0x1000
+)
+
+// termios2 is struct termios2, which has the speeds in baud.
+type termios2 struct {
+	Iflag  uint32
+	Oflag  uint32
+	Cflag  uint32
+	Lflag  uint32
+	Line   uint8
+	Cc     [nccs]uint8
+	Ispeed uint32
+	Ospeed uint32
+}
+
+// setCustomBaudRate sets a baud rate without B constant with BOTHER.
+// The input speed follows the output speed.
+func setCustomBaudRate(fd int, rate int) (err error) {
+	var termios termios2
+	if err = ioctl(fd, tcgets2, uintptr(unsafe.Pointer(&termios))); err != nil {
+		return fmt.Errorf("serial: could not get setting: %v", err)
+	}
+	termios.Cflag &^= cbaud
+	termios.Cflag |= bother
+	termios.Ispeed = uint32(rate)
+	termios.Ospeed = uint32(rate)
+	if err = ioctl(fd, tcsets2, uintptr(unsafe.Pointer(&termios))); err != nil {
+		return fmt.Errorf("serial: could not set baud rate %v: %v", rate, err)
+	}
+	return
+}
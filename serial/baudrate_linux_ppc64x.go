@@ -0,0 +1,30 @@
+//go:build linux && (ppc64 || ppc64le)
+
+package serial
+
+import (
+	"fmt"
+	"syscall"
+)
+
+const (
+	cbaud  = 0xFF
+	bother = 0x1F
+)
+
+// setCustomBaudRate sets a baud rate without B constant with BOTHER. struct
+// termios of powerpc has the speeds in baud, so there is no termios2.
+func setCustomBaudRate(fd int, rate int) (err error) {
+	var termios syscall.Termios
+	if err = tcgetattr(fd, &termios); err != nil {
+		return fmt.Errorf("serial: could not get setting: %v", err)
+	}
+	termios.Cflag &^= cbaud
+	termios.Cflag |= bother
+	termios.Ispeed = uint32(rate)
+	termios.Ospeed = uint32(rate)
+	if err = tcsetattr(fd, &termios); err != nil {
+		return fmt.Errorf("serial: could not set baud rate %v: %v", rate, err)
+	}
+	return
+}
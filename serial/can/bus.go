@@ -1,7 +1,10 @@
 package can
 
 import (
+	"errors"
 	"io"
+	"os"
+	"sync"
 )
 
 // Bus represents the CAN bus.
@@ -10,10 +13,9 @@ import (
 type Bus struct {
 	rwc     ReadWriteCloser
 	handler []Handler
+	mu      sync.RWMutex
 }
 
-// NewBusForInterfaceWithName returns a bus from the network interface with name ifaceName.
-
 // NewBus returns a new CAN bus.
 func NewBus(rwc ReadWriteCloser) *Bus {
 	return &Bus{
@@ -27,6 +29,10 @@ func (b *Bus) ConnectAndPublish() error {
 	for {
 		err := b.publishNextFrame()
 		if err != nil {
+			// EOF is not an error, it happens when calling rwc.Close()
+			if err == io.EOF || errors.Is(err, os.ErrClosed) {
+				return nil
+			}
 			return err
 		}
 	}
@@ -39,6 +45,8 @@ func (b *Bus) Disconnect() error {
 
 // Subscribe adds a handler to the bus.
 func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.handler = append(b.handler, handler)
 }
 
@@ -50,9 +58,12 @@ func (b *Bus) SubscribeFunc(fn HandlerFunc) {
 
 // Unsubscribe removes a handler.
 func (b *Bus) Unsubscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	for i, h := range b.handler {
 		if h == handler {
-			b.handler = append(b.handler[:i], b.handler[i+1:]...)
+			// Copy, publish may be iterating over the current slice.
+			b.handler = append(b.handler[:i:i], b.handler[i+1:]...)
 			return
 		}
 	}
@@ -66,6 +77,8 @@ func (b *Bus) Publish(frame Frame) error {
 }
 
 func (b *Bus) Contains(handler Handler) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
 	for _, h := range b.handler {
 		if h == handler {
 			return true
@@ -79,12 +92,7 @@ func (b *Bus) publishNextFrame() error {
 	err := b.rwc.ReadFrame(&frame)
 	if err != nil {
 		b.rwc.Close()
-
-		if err != io.EOF { // EOF is not an error, it happens when calling rwc.Close()
-			return err
-		}
-
-		return nil
+		return err
 	}
 
 	b.publish(frame)
@@ -93,7 +101,11 @@ func (b *Bus) publishNextFrame() error {
 }
 
 func (b *Bus) publish(frame Frame) {
-	for _, h := range b.handler {
+	// Handlers may subscribe or unsubscribe while handling the frame.
+	b.mu.RLock()
+	handler := b.handler
+	b.mu.RUnlock()
+	for _, h := range handler {
 		h.Handle(frame)
 	}
 }
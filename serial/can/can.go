@@ -20,3 +20,19 @@ const (
 	// MaxExtFrameDataLength defines the max length of an CAN extended data frame defined in ISO ISO 11898-7.
 	MaxExtFrameDataLength = 64
 )
+
+const (
+	// MTU is the size of the byte encoding of a classic CAN frame.
+	MTU = 16
+	// FDMTU is the size of the byte encoding of a CAN-FD frame.
+	FDMTU = 72
+)
+
+const (
+	// FlagBRS is the bit rate switch flag of a CAN-FD frame (second bitrate for the payload).
+	FlagBRS = 0x01
+	// FlagESI is the error state indicator flag of a CAN-FD frame.
+	FlagESI = 0x02
+	// FlagFDF marks a frame as CAN-FD frame.
+	FlagFDF = 0x04
+)
@@ -0,0 +1,60 @@
+package canopen
+
+import "fmt"
+
+// AbortCode is the reason of an aborted SDO transfer. It is returned as error by
+// the SDO client, and an error of this type returned by the object dictionary is
+// sent to the client by the SDO server.
+type AbortCode uint32
+
+// SDO abort codes of CiA 301.
+const (
+	AbortToggleBit            AbortCode = 0x05030000
+	AbortTimeout              AbortCode = 0x05040000
+	AbortCommandSpecifier     AbortCode = 0x05040001
+	AbortOutOfMemory          AbortCode = 0x05040005
+	AbortUnsupportedAccess    AbortCode = 0x06010000
+	AbortWriteOnly            AbortCode = 0x06010001
+	AbortReadOnly             AbortCode = 0x06010002
+	AbortObjectDoesNotExist   AbortCode = 0x06020000
+	AbortCannotBeMapped       AbortCode = 0x06040041
+	AbortPDOLengthExceeded    AbortCode = 0x06040042
+	AbortHardwareError        AbortCode = 0x06060000
+	AbortLengthMismatch       AbortCode = 0x06070010
+	AbortLengthTooHigh        AbortCode = 0x06070012
+	AbortLengthTooLow         AbortCode = 0x06070013
+	AbortSubIndexDoesNotExist AbortCode = 0x06090011
+	AbortInvalidValue         AbortCode = 0x06090030
+	AbortGeneralError         AbortCode = 0x08000000
+	AbortDataTransfer         AbortCode = 0x08000020
+	AbortDeviceState          AbortCode = 0x08000022
+)
+
+var abortMessages = map[AbortCode]string{
+	AbortToggleBit:            "toggle bit not alternated",
+	AbortTimeout:              "SDO protocol timed out",
+	AbortCommandSpecifier:     "client/server command specifier not valid or unknown",
+	AbortOutOfMemory:          "out of memory",
+	AbortUnsupportedAccess:    "unsupported access to an object",
+	AbortWriteOnly:            "attempt to read a write only object",
+	AbortReadOnly:             "attempt to write a read only object",
+	AbortObjectDoesNotExist:   "object does not exist in the object dictionary",
+	AbortCannotBeMapped:       "object cannot be mapped to the PDO",
+	AbortPDOLengthExceeded:    "number and length of objects to be mapped exceeds PDO length",
+	AbortHardwareError:        "access failed due to a hardware error",
+	AbortLengthMismatch:       "data type does not match, length of service parameter does not match",
+	AbortLengthTooHigh:        "data type does not match, length of service parameter too high",
+	AbortLengthTooLow:         "data type does not match, length of service parameter too low",
+	AbortSubIndexDoesNotExist: "sub-index does not exist",
+	AbortInvalidValue:         "invalid value for parameter",
+	AbortGeneralError:         "general error",
+	AbortDataTransfer:         "data cannot be transferred or stored to the application",
+	AbortDeviceState:          "data cannot be transferred or stored because of the present device state",
+}
+
+func (c AbortCode) Error() string {
+	if msg, ok := abortMessages[c]; ok {
+		return fmt.Sprintf("canopen: SDO abort %08X: %s", uint32(c), msg)
+	}
+	return fmt.Sprintf("canopen: SDO abort %08X", uint32(c))
+}
@@ -0,0 +1,30 @@
+// Package canopen implements the CANopen application layer (CiA 301) on top of a
+// CAN bus: an object dictionary which can be loaded from EDS files, SDO client and
+// server, process data objects, NMT master commands and heartbeat and node
+// guarding monitoring.
+//
+// The protocol objects implement can.Handler and subscribe to the bus when they
+// are created; Close unsubscribes them again.
+package canopen
+
+// Function codes of the predefined connection set, the COB-ID of an object is
+// the function code plus the node ID.
+const (
+	FunctionNMT       = 0x000
+	FunctionSYNC      = 0x080
+	FunctionEMCY      = 0x080
+	FunctionTPDO1     = 0x180
+	FunctionRPDO1     = 0x200
+	FunctionTPDO2     = 0x280
+	FunctionRPDO2     = 0x300
+	FunctionTPDO3     = 0x380
+	FunctionRPDO3     = 0x400
+	FunctionTPDO4     = 0x480
+	FunctionRPDO4     = 0x500
+	FunctionSDOTx     = 0x580
+	FunctionSDORx     = 0x600
+	FunctionHeartbeat = 0x700
+)
+
+// MaxNodeID is the largest CANopen node ID.
+const MaxNodeID = 127
@@ -0,0 +1,550 @@
+//go:build linux
+
+package canopen_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"serial/can"
+	"serial/can/canopen"
+	"serial/can/internal/cantest"
+)
+
+const testEDS = `
+[1018]
+ParameterName=Identity
+ObjectType=0x9
+SubNumber=2
+
+[1018sub0]
+ParameterName=Highest sub-index
+DataType=0x0005
+AccessType=ro
+DefaultValue=1
+
+[1018sub1]
+ParameterName=Vendor-ID
+DataType=0x0007
+AccessType=ro
+DefaultValue=0x1234
+
+[1400]
+ParameterName=RPDO1 communication
+ObjectType=0x9
+SubNumber=3
+
+[1400sub0]
+ParameterName=Highest sub-index
+DataType=0x0005
+AccessType=ro
+DefaultValue=2
+
+[1400sub1]
+ParameterName=COB-ID
+DataType=0x0007
+AccessType=rw
+DefaultValue=$NODEID+0x200
+
+[1400sub2]
+ParameterName=Transmission type
+DataType=0x0005
+AccessType=rw
+DefaultValue=0xFF
+
+[1600]
+ParameterName=RPDO1 mapping
+ObjectType=0x9
+SubNumber=3
+
+[1600sub0]
+ParameterName=Number of entries
+DataType=0x0005
+AccessType=rw
+DefaultValue=2
+
+[1600sub1]
+ParameterName=Entry 1
+DataType=0x0007
+AccessType=rw
+DefaultValue=0x20000010
+
+[1600sub2]
+ParameterName=Entry 2
+DataType=0x0007
+AccessType=rw
+DefaultValue=0x20020008
+
+[1800]
+ParameterName=TPDO1 communication
+ObjectType=0x9
+SubNumber=3
+
+[1800sub0]
+ParameterName=Highest sub-index
+DataType=0x0005
+AccessType=ro
+DefaultValue=2
+
+[1800sub1]
+ParameterName=COB-ID
+DataType=0x0007
+AccessType=rw
+DefaultValue=$NODEID+0x180
+
+[1800sub2]
+ParameterName=Transmission type
+DataType=0x0005
+AccessType=rw
+DefaultValue=0
+
+[1801]
+ParameterName=TPDO2 communication
+ObjectType=0x9
+SubNumber=2
+
+[1801sub0]
+ParameterName=Highest sub-index
+DataType=0x0005
+AccessType=ro
+DefaultValue=1
+
+[1801sub1]
+ParameterName=COB-ID
+DataType=0x0007
+AccessType=rw
+DefaultValue=0x280 + $NODEID
+
+[1A00]
+ParameterName=TPDO1 mapping
+ObjectType=0x9
+SubNumber=2
+
+[1A00sub0]
+ParameterName=Number of entries
+DataType=0x0005
+AccessType=rw
+DefaultValue=1
+
+[1A00sub1]
+ParameterName=Entry 1
+DataType=0x0007
+AccessType=rw
+DefaultValue=0x20000010
+
+[2000]
+ParameterName=Counter
+ObjectType=0x7
+DataType=0x0006
+AccessType=rw
+DefaultValue=7
+
+[2001]
+ParameterName=Name
+ObjectType=0x7
+DataType=0x0009
+AccessType=rw
+DefaultValue=
+
+[2002]
+ParameterName=Flags
+ObjectType=0x7
+DataType=0x0005
+AccessType=rw
+DefaultValue=0
+`
+
+func loadEDS(t *testing.T, nodeID uint8) *canopen.ObjectDictionary {
+	t.Helper()
+
+	od, err := canopen.LoadEDS(strings.NewReader(testEDS), nodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return od
+}
+
+func TestLoadEDSNodeID(t *testing.T) {
+	od := loadEDS(t, 5)
+	for _, tc := range []struct {
+		index uint16
+		want  uint64
+	}{
+		{0x1800, 0x185},
+		{0x1801, 0x285},
+	} {
+		v, err := od.Variable(tc.index, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := v.Uint(); got != tc.want {
+			t.Errorf("%04X COB-ID = %#x, want %#x", tc.index, got, tc.want)
+		}
+	}
+}
+
+func TestSDO(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+	server := canopen.NewSDOServer(b, 5, loadEDS(t, 5))
+	defer server.Close()
+	client := canopen.NewSDOClient(a, 5)
+	defer client.Close()
+
+	data, err := client.Upload(0x2000, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{7, 0}) {
+		t.Errorf("Upload(2000) = % x, want 07 00", data)
+	}
+
+	for _, value := range [][]byte{[]byte("a longer name"), []byte("abc"), {}} {
+		if err := client.Download(0x2001, 0, value); err != nil {
+			t.Fatalf("Download(%q): %v", value, err)
+		}
+		data, err := client.Upload(0x2001, 0)
+		if err != nil {
+			t.Fatalf("Upload after Download(%q): %v", value, err)
+		}
+		if !bytes.Equal(data, value) {
+			t.Errorf("Upload = %q, want %q", data, value)
+		}
+	}
+
+	if err := client.Download(0x1018, 1, []byte{1, 2, 3, 4}); err != canopen.AbortReadOnly {
+		t.Errorf("Download of a read-only variable = %v, want %v", err, canopen.AbortReadOnly)
+	}
+}
+
+func TestTPDOSyncAcyclic(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+	od := loadEDS(t, 5)
+	pdo, err := od.TPDO(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdo.TransmissionType != canopen.TransmissionSyncAcyclic {
+		t.Fatalf("transmission type = %d, want %d", pdo.TransmissionType, canopen.TransmissionSyncAcyclic)
+	}
+	tpdo, err := canopen.NewTPDO(b, pdo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpdo.Close()
+
+	frames := make(chan can.Frame, 4)
+	a.SubscribeFunc(func(frame can.Frame) {
+		if frame.ID == 0x185 {
+			frames <- frame
+		}
+	})
+
+	// Without an event the SYNC does not send the TPDO.
+	if err := canopen.SendSYNC(a); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected TPDO % x without event", frame.Data[:frame.Length])
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The event alone does not send the TPDO either.
+	if err := tpdo.Trigger(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected TPDO % x before SYNC", frame.Data[:frame.Length])
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The next SYNC sends it once.
+	for range 2 {
+		if err := canopen.SendSYNC(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case frame := <-frames:
+		if frame.Length != 2 || frame.Data[0] != 7 {
+			t.Errorf("TPDO = % x, want 07 00", frame.Data[:frame.Length])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for TPDO")
+	}
+	select {
+	case frame := <-frames:
+		t.Fatalf("unexpected second TPDO % x", frame.Data[:frame.Length])
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRPDO(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+	od := loadEDS(t, 5)
+	pdo, err := od.RPDO(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pdo.ID != 0x205 || pdo.TransmissionType != canopen.TransmissionEvent || len(pdo.Entries) != 2 {
+		t.Fatalf("RPDO(1) = %+v, want ID 0x205, event-driven with 2 entries", pdo)
+	}
+	for i, want := range []struct {
+		index uint16
+		bits  int
+	}{{0x2000, 16}, {0x2002, 8}} {
+		if e := pdo.Entries[i]; e.Variable.Index != want.index || e.Bits != want.bits {
+			t.Errorf("entry %d = %04X/%d bits, want %04X/%d bits", i, e.Variable.Index, e.Bits, want.index, want.bits)
+		}
+	}
+
+	written := make(chan uint16, 4)
+	od.OnWrite = func(v *canopen.Variable) { written <- v.Index }
+	rpdo, err := canopen.NewRPDO(b, od, pdo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpdo.Close()
+
+	// Frames of other PDOs and frames which are too short are ignored.
+	for _, frame := range []can.Frame{
+		{ID: 0x206, Length: 3, Data: [can.MaxFrameDataLength]uint8{1, 2, 3}},
+		{ID: 0x205, Length: 2, Data: [can.MaxFrameDataLength]uint8{1, 2}},
+		{ID: 0x205, Length: 3, Data: [can.MaxFrameDataLength]uint8{0x34, 0x12, 0xAB}},
+	} {
+		if err := a.Publish(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []uint16{0x2000, 0x2002} {
+		select {
+		case index := <-written:
+			if index != want {
+				t.Errorf("OnWrite(%04X), want %04X", index, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for OnWrite")
+		}
+	}
+	select {
+	case index := <-written:
+		t.Errorf("unexpected OnWrite(%04X)", index)
+	case <-time.After(100 * time.Millisecond):
+	}
+	for _, want := range []struct {
+		index uint16
+		value uint64
+	}{{0x2000, 0x1234}, {0x2002, 0xAB}} {
+		v, _ := od.Variable(want.index, 0)
+		if got := v.Uint(); got != want.value {
+			t.Errorf("%04X = %#x, want %#x", want.index, got, want.value)
+		}
+	}
+
+	counter, _ := od.Variable(0x2000, 0)
+	if _, err := canopen.NewPDO(0x210, counter, counter, counter, counter, counter); err != canopen.AbortPDOLengthExceeded {
+		t.Errorf("NewPDO of 80 bits = %v, want %v", err, canopen.AbortPDOLengthExceeded)
+	}
+	if _, err := canopen.NewRPDO(b, od, canopen.PDO{ID: 0x210, Entries: []canopen.PDOEntry{{counter, 17}}}); err != canopen.AbortCannotBeMapped {
+		t.Errorf("NewRPDO mapping 17 bits of UNSIGNED16 = %v, want %v", err, canopen.AbortCannotBeMapped)
+	}
+}
+
+func TestNMTMaster(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+	frames := make(chan can.Frame, 8)
+	b.SubscribeFunc(func(frame can.Frame) { frames <- frame })
+
+	master := canopen.NewNMTMaster(a)
+	for _, tc := range []struct {
+		send    func(uint8) error
+		command canopen.NMTCommand
+		nodeID  uint8
+	}{
+		{master.Start, canopen.NMTStart, 5},
+		{master.Stop, canopen.NMTStop, 6},
+		{master.EnterPreOperational, canopen.NMTEnterPreOperational, 0},
+		{master.ResetNode, canopen.NMTResetNode, 127},
+		{master.ResetCommunication, canopen.NMTResetCommunication, 1},
+	} {
+		if err := tc.send(tc.nodeID); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case frame := <-frames:
+			if frame.ID != canopen.FunctionNMT || frame.Length != 2 ||
+				frame.Data[0] != byte(tc.command) || frame.Data[1] != tc.nodeID {
+				t.Errorf("NMT %#x to %d sent %#x % x", tc.command, tc.nodeID, frame.ID, frame.Data[:frame.Length])
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for NMT command")
+		}
+	}
+}
+
+// monitorEvents records the callbacks of a monitor.
+type monitorEvents struct {
+	states   chan canopen.NMTState
+	timeouts chan uint8
+}
+
+func newMonitor(t *testing.T, bus *can.Bus) (*canopen.Monitor, monitorEvents) {
+	t.Helper()
+
+	events := monitorEvents{make(chan canopen.NMTState, 16), make(chan uint8, 16)}
+	m := canopen.NewMonitor(bus)
+	m.OnStateChange = func(nodeID uint8, state canopen.NMTState) { events.states <- state }
+	m.OnTimeout = func(nodeID uint8) { events.timeouts <- nodeID }
+	t.Cleanup(m.Close)
+	return m, events
+}
+
+func (e monitorEvents) wantState(t *testing.T, want canopen.NMTState) {
+	t.Helper()
+
+	select {
+	case state := <-e.states:
+		if state != want {
+			t.Errorf("state changed to %v, want %v", state, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for state %v", want)
+	}
+}
+
+func (e monitorEvents) wantTimeout(t *testing.T, nodeID uint8) {
+	t.Helper()
+
+	select {
+	case id := <-e.timeouts:
+		if id != nodeID {
+			t.Errorf("timeout of node %d, want %d", id, nodeID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the timeout of node %d", nodeID)
+	}
+}
+
+func (e monitorEvents) wantNone(t *testing.T, d time.Duration) {
+	t.Helper()
+
+	select {
+	case state := <-e.states:
+		t.Errorf("unexpected state change to %v", state)
+	case id := <-e.timeouts:
+		t.Errorf("unexpected timeout of node %d", id)
+	case <-time.After(d):
+	}
+}
+
+func TestMonitorHeartbeat(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+	m, events := newMonitor(t, a)
+	m.WatchHeartbeat(5, 200*time.Millisecond)
+	heartbeat := func(nodeID uint8, state canopen.NMTState) {
+		t.Helper()
+		frame := can.Frame{ID: canopen.FunctionHeartbeat + uint32(nodeID), Length: 1}
+		frame.Data[0] = byte(state)
+		if err := b.Publish(frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, _, ok := m.State(5); ok {
+		t.Error("State before the first heartbeat is known")
+	}
+	heartbeat(5, canopen.StateBootUp)
+	events.wantState(t, canopen.StateBootUp)
+	heartbeat(5, canopen.StatePreOperational)
+	events.wantState(t, canopen.StatePreOperational)
+
+	// Heartbeats of an unchanged state and of unwatched nodes are no changes,
+	// and heartbeats within the timeout keep the node alive.
+	for range 4 {
+		heartbeat(5, canopen.StatePreOperational)
+		heartbeat(6, canopen.StateOperational)
+		events.wantNone(t, 100*time.Millisecond)
+	}
+	if state, lastSeen, ok := m.State(5); !ok || state != canopen.StatePreOperational || time.Since(lastSeen) > time.Second {
+		t.Errorf("State = %v, %v, %v, want pre-operational", state, lastSeen, ok)
+	}
+	if _, _, ok := m.State(6); ok {
+		t.Error("State of an unwatched node is known")
+	}
+
+	events.wantTimeout(t, 5)
+	if _, _, ok := m.State(5); ok {
+		t.Error("State after the timeout is known")
+	}
+	// The next heartbeat is a change again.
+	heartbeat(5, canopen.StatePreOperational)
+	events.wantState(t, canopen.StatePreOperational)
+
+	m.Unwatch(5)
+	heartbeat(5, canopen.StateOperational)
+	events.wantNone(t, 300*time.Millisecond)
+}
+
+func TestMonitorGuard(t *testing.T) {
+	a, b := cantest.NewBusPair(t)
+
+	// The node answers the remote requests with its state and the toggle bit,
+	// which alternates unless repeat is set.
+	var (
+		mu      sync.Mutex
+		toggle  byte
+		repeat  bool
+		state   = canopen.StatePreOperational
+		request = make(chan struct{}, 64)
+	)
+	b.SubscribeFunc(func(frame can.Frame) {
+		if frame.ID != canopen.FunctionHeartbeat+5|can.MaskRtr {
+			return
+		}
+		mu.Lock()
+		response := can.Frame{ID: canopen.FunctionHeartbeat + 5, Length: 1}
+		response.Data[0] = toggle<<7 | byte(state)
+		if !repeat {
+			toggle ^= 1
+		}
+		mu.Unlock()
+		b.Publish(response)
+		select {
+		case request <- struct{}{}:
+		default:
+		}
+	})
+
+	m, events := newMonitor(t, a)
+	m.Guard(5, 30*time.Millisecond, 4)
+	events.wantState(t, canopen.StatePreOperational)
+	for range 5 {
+		<-request
+	}
+	mu.Lock()
+	state = canopen.StateOperational
+	mu.Unlock()
+	events.wantState(t, canopen.StateOperational)
+	events.wantNone(t, 300*time.Millisecond)
+
+	// Responses with the wrong toggle bit are ignored, so the node is lost.
+	mu.Lock()
+	repeat = true
+	mu.Unlock()
+	events.wantTimeout(t, 5)
+	if _, _, ok := m.State(5); ok {
+		t.Error("State after the timeout is known")
+	}
+
+	// After the timeout the toggle bit starts with 0 again.
+	mu.Lock()
+	repeat = false
+	toggle = 0
+	mu.Unlock()
+	events.wantState(t, canopen.StateOperational)
+	events.wantNone(t, 300*time.Millisecond)
+}
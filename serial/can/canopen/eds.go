@@ -0,0 +1,218 @@
+package canopen
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadEDSFile loads the object dictionary from the EDS file at path. See LoadEDS.
+func LoadEDSFile(path string, nodeID uint8) (*ObjectDictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadEDS(f, nodeID)
+}
+
+// LoadEDS loads the object dictionary from an electronic data sheet (CiA 306).
+// The variables are initialized with their default values, in which $NODEID is
+// replaced by nodeID.
+func LoadEDS(r io.Reader, nodeID uint8) (*ObjectDictionary, error) {
+	sections, err := parseINI(r)
+	if err != nil {
+		return nil, err
+	}
+
+	od := NewObjectDictionary()
+	for name, keys := range sections {
+		index, ok := parseIndex(name)
+		if !ok {
+			continue
+		}
+		objectType := ObjectVar
+		if s, ok := keys["objecttype"]; ok {
+			t, err := parseUint(s, 8)
+			if err != nil {
+				return nil, fmt.Errorf("canopen: EDS [%s] ObjectType: %v", name, err)
+			}
+			objectType = ObjectType(t)
+		}
+
+		obj := &Object{
+			Index:      index,
+			Name:       keys["parametername"],
+			ObjectType: objectType,
+			Variables:  map[uint8]*Variable{},
+		}
+		if objectType == ObjectVar || objectType == ObjectDomain {
+			v, err := edsVariable(name, keys, index, 0, nodeID)
+			if err != nil {
+				return nil, err
+			}
+			obj.Variables[0] = v
+		} else {
+			prefix := strings.ToLower(name) + "sub"
+			for subName, subKeys := range sections {
+				if !strings.HasPrefix(subName, prefix) {
+					continue
+				}
+				subIndex, err := strconv.ParseUint(subName[len(prefix):], 16, 8)
+				if err != nil {
+					continue
+				}
+				v, err := edsVariable(subName, subKeys, index, uint8(subIndex), nodeID)
+				if err != nil {
+					return nil, err
+				}
+				obj.Variables[uint8(subIndex)] = v
+			}
+		}
+		od.AddObject(obj)
+	}
+	return od, nil
+}
+
+func edsVariable(section string, keys map[string]string, index uint16, subIndex uint8, nodeID uint8) (*Variable, error) {
+	v := &Variable{
+		Index:      index,
+		SubIndex:   subIndex,
+		Name:       keys["parametername"],
+		PDOMapping: keys["pdomapping"] == "1",
+	}
+
+	dataType, err := parseUint(keys["datatype"], 16)
+	if err != nil {
+		return nil, fmt.Errorf("canopen: EDS [%s] DataType: %v", section, err)
+	}
+	v.DataType = DataType(dataType)
+
+	switch strings.ToLower(keys["accesstype"]) {
+	case "ro", "const":
+		v.Access = AccessRead
+	case "wo":
+		v.Access = AccessWrite
+	default:
+		v.Access = AccessReadWrite
+	}
+
+	value, err := parseValue(v.DataType, keys["defaultvalue"], nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("canopen: EDS [%s] DefaultValue: %v", section, err)
+	}
+	v.value = value
+	return v, nil
+}
+
+// parseValue encodes the EDS value s of type t.
+func parseValue(t DataType, s string, nodeID uint8) ([]byte, error) {
+	switch t {
+	case VisibleString, UnicodeString:
+		return []byte(s), nil
+	case OctetString, Domain:
+		s = strings.ReplaceAll(s, " ", "")
+		b := make([]byte, len(s)/2)
+		for i := range b {
+			n, err := strconv.ParseUint(s[2*i:2*i+2], 16, 8)
+			if err != nil {
+				return nil, err
+			}
+			b[i] = byte(n)
+		}
+		return b, nil
+	}
+
+	size := t.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported data type %#x", uint16(t))
+	}
+	var bits uint64
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+	case t == Real32:
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, err
+		}
+		bits = uint64(math.Float32bits(float32(f)))
+	case t == Real64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, err
+		}
+		bits = math.Float64bits(f)
+	default:
+		n, err := parseInteger(s, nodeID, t.signed())
+		if err != nil {
+			return nil, err
+		}
+		bits = n
+	}
+	b := binary.LittleEndian.AppendUint64(nil, bits)
+	return b[:size], nil
+}
+
+// parseInteger parses a decimal, hexadecimal (0x) or octal (0) integer, which may
+// be an expression $NODEID+n or n+$NODEID.
+func parseInteger(s string, nodeID uint8, signed bool) (uint64, error) {
+	var offset uint64
+	if upper := strings.ToUpper(s); strings.Contains(upper, "$NODEID") {
+		offset = uint64(nodeID)
+		s = strings.TrimSpace(strings.Replace(upper, "$NODEID", "", 1))
+		s = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(s, "+"), "+"))
+		if s == "" {
+			return offset, nil
+		}
+		s = strings.Replace(s, "0X", "0x", 1)
+	}
+	if signed {
+		n, err := strconv.ParseInt(s, 0, 64)
+		return uint64(n) + offset, err
+	}
+	n, err := strconv.ParseUint(s, 0, 64)
+	return n + offset, err
+}
+
+func parseUint(s string, bitSize int) (uint64, error) {
+	return strconv.ParseUint(strings.TrimSpace(s), 0, bitSize)
+}
+
+// parseIndex parses the name of an object section, which is the index in hex.
+func parseIndex(name string) (uint16, bool) {
+	if len(name) != 4 {
+		return 0, false
+	}
+	index, err := strconv.ParseUint(name, 16, 16)
+	return uint16(index), err == nil
+}
+
+// parseINI returns the keys of the sections of an INI file. Section names and
+// keys are lower case.
+func parseINI(r io.Reader) (map[string]map[string]string, error) {
+	sections := map[string]map[string]string{}
+	var section map[string]string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			name := strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			section = map[string]string{}
+			sections[name] = section
+		case section != nil:
+			key, value, ok := strings.Cut(line, "=")
+			if ok {
+				section[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return sections, scanner.Err()
+}
@@ -0,0 +1,181 @@
+package canopen
+
+import (
+	"sync"
+	"time"
+
+	"serial/can"
+)
+
+// Monitor watches the NMT state of nodes by their heartbeat or by node guarding.
+type Monitor struct {
+	// OnStateChange is called when the state of a watched node changes, including
+	// the boot-up of a node.
+	OnStateChange func(nodeID uint8, state NMTState)
+	// OnTimeout is called when a watched node missed its heartbeat or did not
+	// answer the node guarding in time.
+	OnTimeout func(nodeID uint8)
+
+	bus     *can.Bus
+	handler can.Handler
+	mu      sync.Mutex
+	nodes   map[uint8]*watchedNode
+}
+
+type watchedNode struct {
+	state    NMTState
+	known    bool
+	lastSeen time.Time
+	timeout  time.Duration
+	timer    *time.Timer
+	// node guarding
+	guard  *time.Ticker
+	done   chan struct{}
+	toggle byte
+}
+
+// NewMonitor returns a monitor for the nodes of bus.
+func NewMonitor(bus *can.Bus) *Monitor {
+	m := &Monitor{
+		bus:   bus,
+		nodes: map[uint8]*watchedNode{},
+	}
+	m.handler = can.NewHandler(m.handle)
+	bus.Subscribe(m.handler)
+	return m
+}
+
+// Close stops watching all nodes and unsubscribes the monitor from the bus.
+func (m *Monitor) Close() {
+	m.bus.Unsubscribe(m.handler)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for nodeID := range m.nodes {
+		m.unwatch(nodeID)
+	}
+}
+
+// WatchHeartbeat watches the heartbeat of the node nodeID, which is missed if
+// no heartbeat arrived within timeout. The timeout should be somewhat longer than
+// the heartbeat producer time of the node (object 1017).
+func (m *Monitor) WatchHeartbeat(nodeID uint8, timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unwatch(nodeID)
+	n := &watchedNode{timeout: timeout}
+	n.timer = time.AfterFunc(timeout, func() { m.expired(nodeID, n) })
+	m.nodes[nodeID] = n
+}
+
+// Guard watches the node nodeID by node guarding: a remote request is sent every
+// guardTime, and the node is lost if it did not answer within guardTime times
+// lifeTimeFactor.
+func (m *Monitor) Guard(nodeID uint8, guardTime time.Duration, lifeTimeFactor int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unwatch(nodeID)
+	n := &watchedNode{
+		timeout: guardTime * time.Duration(max(lifeTimeFactor, 1)),
+		guard:   time.NewTicker(guardTime),
+		done:    make(chan struct{}),
+	}
+	n.timer = time.AfterFunc(n.timeout, func() { m.expired(nodeID, n) })
+	m.nodes[nodeID] = n
+
+	request := can.Frame{ID: FunctionHeartbeat + uint32(nodeID) | can.MaskRtr, Length: 1}
+	go func() {
+		m.bus.Publish(request)
+		for {
+			select {
+			case <-n.guard.C:
+				m.bus.Publish(request)
+			case <-n.done:
+				return
+			}
+		}
+	}()
+}
+
+// Unwatch stops watching the node nodeID.
+func (m *Monitor) Unwatch(nodeID uint8) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unwatch(nodeID)
+}
+
+// State returns the last state of the node nodeID and when it was received. ok is
+// false if nothing was received from the node yet.
+func (m *Monitor) State(nodeID uint8) (state NMTState, lastSeen time.Time, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := m.nodes[nodeID]
+	if n == nil || !n.known {
+		return 0, time.Time{}, false
+	}
+	return n.state, n.lastSeen, true
+}
+
+func (m *Monitor) unwatch(nodeID uint8) {
+	n := m.nodes[nodeID]
+	if n == nil {
+		return
+	}
+	n.timer.Stop()
+	if n.guard != nil {
+		n.guard.Stop()
+		close(n.done)
+	}
+	delete(m.nodes, nodeID)
+}
+
+func (m *Monitor) handle(frame can.Frame) {
+	if frame.ID&^MaxNodeID != FunctionHeartbeat || frame.Length < 1 {
+		return
+	}
+	nodeID := uint8(frame.ID & MaxNodeID)
+
+	m.mu.Lock()
+	n := m.nodes[nodeID]
+	if n == nil {
+		m.mu.Unlock()
+		return
+	}
+	b := frame.Data[0]
+	if n.guard != nil {
+		// The toggle bit alternates with every response, starting with 0.
+		// A response with the wrong toggle bit is ignored.
+		if b>>7 != n.toggle {
+			m.mu.Unlock()
+			return
+		}
+		n.toggle ^= 1
+	}
+	state := NMTState(b & 0x7F)
+	if state == StateBootUp {
+		n.toggle = 0
+	}
+	changed := !n.known || n.state != state
+	n.state = state
+	n.known = true
+	n.lastSeen = time.Now()
+	n.timer.Reset(n.timeout)
+	m.mu.Unlock()
+
+	if changed && m.OnStateChange != nil {
+		m.OnStateChange(nodeID, state)
+	}
+}
+
+func (m *Monitor) expired(nodeID uint8, n *watchedNode) {
+	m.mu.Lock()
+	watched := m.nodes[nodeID] == n
+	if watched {
+		n.known = false
+		n.toggle = 0
+	}
+	m.mu.Unlock()
+
+	if watched && m.OnTimeout != nil {
+		m.OnTimeout(nodeID)
+	}
+}
@@ -0,0 +1,88 @@
+package canopen
+
+import (
+	"fmt"
+
+	"serial/can"
+)
+
+// NMTCommand is a command of the NMT master.
+type NMTCommand uint8
+
+// NMT commands.
+const (
+	NMTStart               NMTCommand = 0x01
+	NMTStop                NMTCommand = 0x02
+	NMTEnterPreOperational NMTCommand = 0x80
+	NMTResetNode           NMTCommand = 0x81
+	NMTResetCommunication  NMTCommand = 0x82
+)
+
+// NMTState is the NMT state of a node as reported by heartbeat and node guarding.
+type NMTState uint8
+
+// NMT states.
+const (
+	StateBootUp         NMTState = 0x00
+	StateStopped        NMTState = 0x04
+	StateOperational    NMTState = 0x05
+	StatePreOperational NMTState = 0x7F
+)
+
+func (s NMTState) String() string {
+	switch s {
+	case StateBootUp:
+		return "boot-up"
+	case StateStopped:
+		return "stopped"
+	case StateOperational:
+		return "operational"
+	case StatePreOperational:
+		return "pre-operational"
+	}
+	return fmt.Sprintf("state %#x", uint8(s))
+}
+
+// NMTMaster sends NMT commands to the nodes of a bus.
+type NMTMaster struct {
+	bus *can.Bus
+}
+
+// NewNMTMaster returns an NMT master for bus.
+func NewNMTMaster(bus *can.Bus) *NMTMaster {
+	return &NMTMaster{bus}
+}
+
+// Send sends command to the node nodeID, or to all nodes if nodeID is 0.
+func (m *NMTMaster) Send(command NMTCommand, nodeID uint8) error {
+	return m.bus.Publish(can.Frame{
+		ID:     FunctionNMT,
+		Length: 2,
+		Data:   [can.MaxFrameDataLength]uint8{byte(command), nodeID},
+	})
+}
+
+// Start switches the node to operational.
+func (m *NMTMaster) Start(nodeID uint8) error {
+	return m.Send(NMTStart, nodeID)
+}
+
+// Stop switches the node to stopped.
+func (m *NMTMaster) Stop(nodeID uint8) error {
+	return m.Send(NMTStop, nodeID)
+}
+
+// EnterPreOperational switches the node to pre-operational.
+func (m *NMTMaster) EnterPreOperational(nodeID uint8) error {
+	return m.Send(NMTEnterPreOperational, nodeID)
+}
+
+// ResetNode resets the application of the node.
+func (m *NMTMaster) ResetNode(nodeID uint8) error {
+	return m.Send(NMTResetNode, nodeID)
+}
+
+// ResetCommunication resets the communication of the node.
+func (m *NMTMaster) ResetCommunication(nodeID uint8) error {
+	return m.Send(NMTResetCommunication, nodeID)
+}
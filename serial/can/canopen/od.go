@@ -0,0 +1,246 @@
+package canopen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// DataType is the type of a variable of the object dictionary.
+type DataType uint16
+
+// Data types of CiA 301.
+const (
+	Boolean       DataType = 0x01
+	Integer8      DataType = 0x02
+	Integer16     DataType = 0x03
+	Integer32     DataType = 0x04
+	Unsigned8     DataType = 0x05
+	Unsigned16    DataType = 0x06
+	Unsigned32    DataType = 0x07
+	Real32        DataType = 0x08
+	VisibleString DataType = 0x09
+	OctetString   DataType = 0x0A
+	UnicodeString DataType = 0x0B
+	Domain        DataType = 0x0F
+	Real64        DataType = 0x11
+	Integer64     DataType = 0x15
+	Unsigned64    DataType = 0x1B
+)
+
+// Size returns the size in bytes of a value of type t, or 0 if values of t have
+// a variable length.
+func (t DataType) Size() int {
+	switch t {
+	case Boolean, Integer8, Unsigned8:
+		return 1
+	case Integer16, Unsigned16:
+		return 2
+	case Integer32, Unsigned32, Real32:
+		return 4
+	case Integer64, Unsigned64, Real64:
+		return 8
+	}
+	return 0
+}
+
+func (t DataType) signed() bool {
+	return t == Integer8 || t == Integer16 || t == Integer32 || t == Integer64
+}
+
+// ObjectType is the type of an object of the object dictionary.
+type ObjectType uint8
+
+// Object types of CiA 301.
+const (
+	ObjectDomain ObjectType = 0x2
+	ObjectVar    ObjectType = 0x7
+	ObjectArray  ObjectType = 0x8
+	ObjectRecord ObjectType = 0x9
+)
+
+// Access is the access type of a variable.
+type Access uint8
+
+// Access types.
+const (
+	AccessRead Access = 1 << iota
+	AccessWrite
+
+	AccessReadWrite = AccessRead | AccessWrite
+)
+
+// Variable is a value of the object dictionary at an index and sub-index. The
+// value is stored in the little endian encoding of the CAN bus.
+type Variable struct {
+	Index      uint16
+	SubIndex   uint8
+	Name       string
+	DataType   DataType
+	Access     Access
+	PDOMapping bool
+
+	mu    sync.RWMutex
+	value []byte
+}
+
+// Object is an entry of the object dictionary. A VAR object has a single variable
+// at sub-index 0, ARRAY and RECORD objects have one variable per sub-index.
+type Object struct {
+	Index      uint16
+	Name       string
+	ObjectType ObjectType
+	Variables  map[uint8]*Variable
+}
+
+// ObjectDictionary is the object dictionary of a CANopen node. It is safe for
+// concurrent use.
+type ObjectDictionary struct {
+	// OnWrite is called after a variable was written by an SDO download or an RPDO.
+	OnWrite func(v *Variable)
+
+	mu      sync.RWMutex
+	objects map[uint16]*Object
+}
+
+// NewObjectDictionary returns an empty object dictionary.
+func NewObjectDictionary() *ObjectDictionary {
+	return &ObjectDictionary{objects: map[uint16]*Object{}}
+}
+
+// AddObject adds obj to the dictionary, replacing an object with the same index.
+func (od *ObjectDictionary) AddObject(obj *Object) {
+	od.mu.Lock()
+	defer od.mu.Unlock()
+	if obj.Variables == nil {
+		obj.Variables = map[uint8]*Variable{}
+	}
+	od.objects[obj.Index] = obj
+}
+
+// AddVariable adds a VAR object with the variable v and the initial value.
+func (od *ObjectDictionary) AddVariable(v *Variable, value []byte) {
+	v.SubIndex = 0
+	v.SetBytes(value)
+	od.AddObject(&Object{
+		Index:      v.Index,
+		Name:       v.Name,
+		ObjectType: ObjectVar,
+		Variables:  map[uint8]*Variable{0: v},
+	})
+}
+
+// Object returns the object at index, or nil if it does not exist.
+func (od *ObjectDictionary) Object(index uint16) *Object {
+	od.mu.RLock()
+	defer od.mu.RUnlock()
+	return od.objects[index]
+}
+
+// Indexes returns the indexes of all objects in ascending order.
+func (od *ObjectDictionary) Indexes() []uint16 {
+	od.mu.RLock()
+	defer od.mu.RUnlock()
+	indexes := make([]uint16, 0, len(od.objects))
+	for index := range od.objects {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// Variable returns the variable at index and subIndex. The error is an SDO abort
+// code if the object or the sub-index does not exist.
+func (od *ObjectDictionary) Variable(index uint16, subIndex uint8) (*Variable, error) {
+	obj := od.Object(index)
+	if obj == nil {
+		return nil, AbortObjectDoesNotExist
+	}
+	od.mu.RLock()
+	defer od.mu.RUnlock()
+	v, ok := obj.Variables[subIndex]
+	if !ok {
+		return nil, AbortSubIndexDoesNotExist
+	}
+	return v, nil
+}
+
+func (od *ObjectDictionary) written(v *Variable) {
+	if od.OnWrite != nil {
+		od.OnWrite(v)
+	}
+}
+
+// Bytes returns a copy of the encoded value.
+func (v *Variable) Bytes() []byte {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]byte(nil), v.value...)
+}
+
+// SetBytes sets the encoded value. Values of fixed size types must have the size
+// of the type.
+func (v *Variable) SetBytes(b []byte) error {
+	if size := v.DataType.Size(); size > 0 && len(b) != size {
+		return AbortLengthMismatch
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.value = append(v.value[:0], b...)
+	return nil
+}
+
+// Uint returns the value of an unsigned integer or boolean variable.
+func (v *Variable) Uint() uint64 {
+	var b [8]byte
+	copy(b[:], v.Bytes())
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Int returns the value of a signed integer variable.
+func (v *Variable) Int() int64 {
+	size := v.DataType.Size()
+	if size == 0 {
+		return 0
+	}
+	shift := 64 - 8*size
+	return int64(v.Uint()<<shift) >> shift
+}
+
+// Float returns the value of a REAL32 or REAL64 variable.
+func (v *Variable) Float() float64 {
+	if v.DataType == Real32 {
+		return float64(math.Float32frombits(uint32(v.Uint())))
+	}
+	return math.Float64frombits(v.Uint())
+}
+
+// String returns the value of a string variable.
+func (v *Variable) String() string {
+	return string(v.Bytes())
+}
+
+// SetUint sets the value of an integer or boolean variable.
+func (v *Variable) SetUint(value uint64) error {
+	size := v.DataType.Size()
+	if size == 0 {
+		return fmt.Errorf("canopen: variable %04Xsub%d has no fixed size", v.Index, v.SubIndex)
+	}
+	b := binary.LittleEndian.AppendUint64(nil, value)
+	return v.SetBytes(b[:size])
+}
+
+// SetInt sets the value of a signed integer variable.
+func (v *Variable) SetInt(value int64) error {
+	return v.SetUint(uint64(value))
+}
+
+// SetFloat sets the value of a REAL32 or REAL64 variable.
+func (v *Variable) SetFloat(value float64) error {
+	if v.DataType == Real32 {
+		return v.SetUint(uint64(math.Float32bits(float32(value))))
+	}
+	return v.SetUint(math.Float64bits(value))
+}
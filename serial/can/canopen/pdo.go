@@ -0,0 +1,296 @@
+package canopen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"serial/can"
+)
+
+// Indexes of the PDO parameters in the object dictionary.
+const (
+	indexRPDOCommunication = 0x1400
+	indexRPDOMapping       = 0x1600
+	indexTPDOCommunication = 0x1800
+	indexTPDOMapping       = 0x1A00
+)
+
+// Transmission types of PDOs.
+const (
+	// TransmissionSyncAcyclic is synchronous, the TPDO is sent with the next SYNC
+	// after Trigger was called. With 1 to 240 the TPDO is sent after every n-th SYNC.
+	TransmissionSyncAcyclic = 0x00
+	TransmissionSyncMax     = 0xF0
+	// TransmissionRTR sends the TPDO on remote request.
+	TransmissionRTR = 0xFD
+	// TransmissionEvent is event-driven, the TPDO is sent by Trigger, Transmit and the event timer.
+	TransmissionEvent = 0xFF
+)
+
+// cobIDInvalid marks a PDO as not valid, cobIDExtended as using a 29-bit CAN identifier.
+const (
+	cobIDInvalid  = 0x80000000
+	cobIDExtended = 0x20000000
+)
+
+// PDOEntry is a variable mapped into a PDO with a length in bits.
+type PDOEntry struct {
+	Variable *Variable
+	Bits     int
+}
+
+// PDO is the configuration of a process data object: the CAN identifier and
+// the variables which are mapped in order into the frame data.
+type PDO struct {
+	// ID is the CAN identifier, extended identifiers include can.MaskEff.
+	ID               uint32
+	TransmissionType uint8
+	// EventTimer is the period of an event-driven TPDO.
+	EventTimer time.Duration
+	Entries    []PDOEntry
+}
+
+// NewPDO returns an event-driven PDO which maps the whole value of each variable.
+func NewPDO(id uint32, variables ...*Variable) (PDO, error) {
+	pdo := PDO{ID: id, TransmissionType: TransmissionEvent}
+	for _, v := range variables {
+		pdo.Entries = append(pdo.Entries, PDOEntry{v, 8 * v.DataType.Size()})
+	}
+	return pdo, pdo.validate()
+}
+
+// RPDO returns the configuration of the n-th RPDO (starting with 1) from the
+// communication and mapping parameters in od.
+func (od *ObjectDictionary) RPDO(n int) (PDO, error) {
+	return od.pdo(indexRPDOCommunication, indexRPDOMapping, n)
+}
+
+// TPDO returns the configuration of the n-th TPDO (starting with 1) from the
+// communication and mapping parameters in od.
+func (od *ObjectDictionary) TPDO(n int) (PDO, error) {
+	return od.pdo(indexTPDOCommunication, indexTPDOMapping, n)
+}
+
+func (od *ObjectDictionary) pdo(communication, mapping uint16, n int) (PDO, error) {
+	if n < 1 || n > 512 {
+		return PDO{}, fmt.Errorf("canopen: invalid PDO number %d", n)
+	}
+	communication += uint16(n - 1)
+	mapping += uint16(n - 1)
+
+	var pdo PDO
+	v, err := od.Variable(communication, 1)
+	if err != nil {
+		return pdo, err
+	}
+	cobID := uint32(v.Uint())
+	if cobID&cobIDInvalid != 0 {
+		return pdo, fmt.Errorf("canopen: PDO %04X is not valid", communication)
+	}
+	if cobID&cobIDExtended != 0 {
+		pdo.ID = cobID&can.MaskIDEff | can.MaskEff
+	} else {
+		pdo.ID = cobID & can.MaskIDSff
+	}
+	pdo.TransmissionType = TransmissionEvent
+	if v, err := od.Variable(communication, 2); err == nil {
+		pdo.TransmissionType = uint8(v.Uint())
+	}
+	if v, err := od.Variable(communication, 5); err == nil && communication >= indexTPDOCommunication {
+		pdo.EventTimer = time.Duration(v.Uint()) * time.Millisecond
+	}
+
+	v, err = od.Variable(mapping, 0)
+	if err != nil {
+		return pdo, err
+	}
+	for i := 1; i <= int(v.Uint()); i++ {
+		entry, err := od.Variable(mapping, uint8(i))
+		if err != nil {
+			return pdo, err
+		}
+		value := uint32(entry.Uint())
+		mapped, err := od.Variable(uint16(value>>16), uint8(value>>8))
+		if err != nil {
+			return pdo, err
+		}
+		pdo.Entries = append(pdo.Entries, PDOEntry{mapped, int(value & 0xFF)})
+	}
+	return pdo, pdo.validate()
+}
+
+func (pdo PDO) validate() error {
+	bits := 0
+	for _, e := range pdo.Entries {
+		if e.Bits <= 0 || e.Bits > 8*e.Variable.DataType.Size() {
+			return AbortCannotBeMapped
+		}
+		bits += e.Bits
+	}
+	if bits > 64 {
+		return AbortPDOLengthExceeded
+	}
+	return nil
+}
+
+// length returns the number of data bytes of the PDO.
+func (pdo PDO) length() int {
+	bits := 0
+	for _, e := range pdo.Entries {
+		bits += e.Bits
+	}
+	return (bits + 7) / 8
+}
+
+// RPDO receives a PDO and writes the mapped variables.
+type RPDO struct {
+	PDO
+
+	bus     *can.Bus
+	od      *ObjectDictionary
+	handler can.Handler
+}
+
+// NewRPDO returns a receiver of pdo. The OnWrite function of od is called for the
+// written variables, od may be nil.
+func NewRPDO(bus *can.Bus, od *ObjectDictionary, pdo PDO) (*RPDO, error) {
+	if err := pdo.validate(); err != nil {
+		return nil, err
+	}
+	r := &RPDO{PDO: pdo, bus: bus, od: od}
+	r.handler = can.NewHandler(r.handle)
+	bus.Subscribe(r.handler)
+	return r, nil
+}
+
+// Close unsubscribes the receiver from the bus.
+func (r *RPDO) Close() {
+	r.bus.Unsubscribe(r.handler)
+}
+
+func (r *RPDO) handle(frame can.Frame) {
+	// Frames which are too short are ignored.
+	if frame.ID != r.ID || int(frame.Length) < r.length() {
+		return
+	}
+
+	data := binary.LittleEndian.Uint64(frame.Data[:8])
+	for _, e := range r.Entries {
+		value := data & (1<<e.Bits - 1)
+		data >>= e.Bits
+		b := binary.LittleEndian.AppendUint64(nil, value)
+		e.Variable.SetBytes(b[:e.Variable.DataType.Size()])
+		if r.od != nil {
+			r.od.written(e.Variable)
+		}
+	}
+}
+
+// TPDO transmits a PDO with the current values of the mapped variables.
+type TPDO struct {
+	PDO
+
+	bus     *can.Bus
+	handler can.Handler
+	done    chan struct{}
+	mu      sync.Mutex
+	syncs   int
+	// triggered is set by Trigger for an acyclic synchronous TPDO.
+	triggered bool
+}
+
+// NewTPDO returns a transmitter of pdo. An event-driven TPDO with an event timer
+// is sent periodically, a cyclic synchronous TPDO after the configured number of
+// SYNC frames, an acyclic synchronous TPDO with the first SYNC after Trigger and
+// an RTR TPDO on remote request. Transmit sends it at any time.
+func NewTPDO(bus *can.Bus, pdo PDO) (*TPDO, error) {
+	if err := pdo.validate(); err != nil {
+		return nil, err
+	}
+	t := &TPDO{PDO: pdo, bus: bus, done: make(chan struct{})}
+	t.handler = can.NewHandler(t.handle)
+	bus.Subscribe(t.handler)
+
+	if pdo.EventTimer > 0 && pdo.TransmissionType > TransmissionSyncMax {
+		go t.run()
+	}
+	return t, nil
+}
+
+// Close stops the transmitter and unsubscribes it from the bus.
+func (t *TPDO) Close() {
+	t.bus.Unsubscribe(t.handler)
+	close(t.done)
+}
+
+// Trigger signals an application event. An acyclic synchronous TPDO is sent with
+// the next SYNC, an event-driven or RTR TPDO is sent immediately and a cyclic
+// synchronous TPDO is not affected.
+func (t *TPDO) Trigger() error {
+	switch {
+	case t.TransmissionType == TransmissionSyncAcyclic:
+		t.mu.Lock()
+		t.triggered = true
+		t.mu.Unlock()
+		return nil
+	case t.TransmissionType <= TransmissionSyncMax:
+		return nil
+	}
+	return t.Transmit()
+}
+
+// Transmit sends the PDO.
+func (t *TPDO) Transmit() error {
+	var data uint64
+	shift := 0
+	for _, e := range t.Entries {
+		data |= (e.Variable.Uint() & (1<<e.Bits - 1)) << shift
+		shift += e.Bits
+	}
+	frame := can.Frame{ID: t.ID, Length: uint8(t.length())}
+	binary.LittleEndian.PutUint64(frame.Data[:8], data)
+	return t.bus.Publish(frame)
+}
+
+func (t *TPDO) run() {
+	ticker := time.NewTicker(t.EventTimer)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.Transmit()
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *TPDO) handle(frame can.Frame) {
+	switch {
+	case frame.ID == FunctionSYNC && t.TransmissionType <= TransmissionSyncMax:
+		t.mu.Lock()
+		var send bool
+		if t.TransmissionType == TransmissionSyncAcyclic {
+			send, t.triggered = t.triggered, false
+		} else {
+			t.syncs++
+			send = t.syncs >= int(t.TransmissionType)
+			if send {
+				t.syncs = 0
+			}
+		}
+		t.mu.Unlock()
+		if send {
+			t.Transmit()
+		}
+	case frame.ID == t.ID|can.MaskRtr:
+		t.Transmit()
+	}
+}
+
+// SendSYNC sends a SYNC frame, which triggers the synchronous PDOs.
+func SendSYNC(bus *can.Bus) error {
+	return bus.Publish(can.Frame{ID: FunctionSYNC})
+}
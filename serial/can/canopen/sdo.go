@@ -0,0 +1,425 @@
+package canopen
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"serial/can"
+)
+
+// Command specifiers of SDO frames.
+const (
+	sdoDownloadSegment  = 0
+	sdoInitiateDownload = 1
+	sdoInitiateUpload   = 2
+	sdoUploadSegment    = 3
+	sdoAbort            = 4
+)
+
+// Response command specifiers of SDO frames.
+const (
+	sdoUploadSegmentResponse    = 0
+	sdoDownloadSegmentResponse  = 1
+	sdoInitiateUploadResponse   = 2
+	sdoInitiateDownloadResponse = 3
+)
+
+// DefaultSDOTimeout is the default time the SDO client waits for a response.
+const DefaultSDOTimeout = time.Second
+
+type sdoFrame [8]byte
+
+func newSDOFrame(command byte, index uint16, subIndex uint8) sdoFrame {
+	var f sdoFrame
+	f[0] = command
+	binary.LittleEndian.PutUint16(f[1:], index)
+	f[3] = subIndex
+	return f
+}
+
+func abortFrame(index uint16, subIndex uint8, code AbortCode) sdoFrame {
+	f := newSDOFrame(sdoAbort<<5, index, subIndex)
+	binary.LittleEndian.PutUint32(f[4:], uint32(code))
+	return f
+}
+
+func (f sdoFrame) command() byte       { return f[0] >> 5 }
+func (f sdoFrame) toggle() byte        { return f[0] >> 4 & 1 }
+func (f sdoFrame) index() uint16       { return binary.LittleEndian.Uint16(f[1:]) }
+func (f sdoFrame) subIndex() uint8     { return f[3] }
+func (f sdoFrame) abort() AbortCode    { return AbortCode(binary.LittleEndian.Uint32(f[4:])) }
+func (f sdoFrame) expedited() bool     { return f[0]&0x02 != 0 }
+func (f sdoFrame) sizeIndicated() bool { return f[0]&0x01 != 0 }
+
+// expeditedData returns the data of an expedited initiate frame.
+func (f sdoFrame) expeditedData() []byte {
+	if !f.sizeIndicated() {
+		return f[4:8]
+	}
+	return f[4 : 8-int(f[0]>>2&3)]
+}
+
+// segmentData returns the data of a segment frame and true if it is the last segment.
+func (f sdoFrame) segmentData() ([]byte, bool) {
+	return f[1 : 8-int(f[0]>>1&7)], f[0]&0x01 != 0
+}
+
+func publishSDO(bus *can.Bus, id uint32, f sdoFrame) error {
+	frame := can.Frame{ID: id, Length: 8}
+	copy(frame.Data[:], f[:])
+	return bus.Publish(frame)
+}
+
+// SDOClient reads and writes the object dictionary of a node with SDO transfers.
+// Transfers are sequential, concurrent calls wait for the running transfer.
+type SDOClient struct {
+	// Timeout is the time to wait for a response of the server.
+	Timeout time.Duration
+
+	bus       *can.Bus
+	nodeID    uint8
+	handler   can.Handler
+	responses chan sdoFrame
+	mu        sync.Mutex
+}
+
+// NewSDOClient returns a client for the default SDO of the node nodeID.
+func NewSDOClient(bus *can.Bus, nodeID uint8) *SDOClient {
+	c := &SDOClient{
+		Timeout:   DefaultSDOTimeout,
+		bus:       bus,
+		nodeID:    nodeID,
+		responses: make(chan sdoFrame, 1),
+	}
+	c.handler = can.NewHandler(c.handle)
+	bus.Subscribe(c.handler)
+	return c
+}
+
+// Close unsubscribes the client from the bus.
+func (c *SDOClient) Close() {
+	c.bus.Unsubscribe(c.handler)
+}
+
+func (c *SDOClient) handle(frame can.Frame) {
+	if frame.ID != FunctionSDOTx+uint32(c.nodeID) || frame.Length != 8 {
+		return
+	}
+	var f sdoFrame
+	copy(f[:], frame.Data[:8])
+	select {
+	case c.responses <- f:
+	default:
+	}
+}
+
+// Upload reads the variable at index and subIndex from the node. Values of up to
+// 4 bytes are transferred expedited, longer values in segments.
+func (c *SDOClient) Upload(index uint16, subIndex uint8) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resp, err := c.request(newSDOFrame(sdoInitiateUpload<<5, index, subIndex), sdoInitiateUploadResponse)
+	if err != nil {
+		return nil, err
+	}
+	if resp.index() != index || resp.subIndex() != subIndex {
+		return nil, c.abort(index, subIndex, AbortGeneralError)
+	}
+	if resp.expedited() {
+		return append([]byte(nil), resp.expeditedData()...), nil
+	}
+
+	var data []byte
+	size := -1
+	if resp.sizeIndicated() {
+		size = int(binary.LittleEndian.Uint32(resp[4:]))
+		data = make([]byte, 0, min(size, 1<<16))
+	}
+	for toggle := byte(0); ; toggle ^= 1 {
+		resp, err := c.request(sdoFrame{sdoUploadSegment<<5 | toggle<<4}, sdoUploadSegmentResponse)
+		if err != nil {
+			return nil, err
+		}
+		if resp.toggle() != toggle {
+			return nil, c.abort(index, subIndex, AbortToggleBit)
+		}
+		segment, last := resp.segmentData()
+		data = append(data, segment...)
+		if last {
+			break
+		}
+	}
+	if size >= 0 && len(data) != size {
+		return nil, AbortLengthMismatch
+	}
+	return data, nil
+}
+
+// Download writes data to the variable at index and subIndex of the node.
+func (c *SDOClient) Download(index uint16, subIndex uint8, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// An empty value is downloaded as segmented transfer with a single empty
+	// segment, the expedited transfer cannot indicate a size of 0.
+	if len(data) > 0 && len(data) <= 4 {
+		req := newSDOFrame(sdoInitiateDownload<<5|byte(4-len(data))<<2|0x03, index, subIndex)
+		copy(req[4:], data)
+		_, err := c.request(req, sdoInitiateDownloadResponse)
+		return err
+	}
+
+	req := newSDOFrame(sdoInitiateDownload<<5|0x01, index, subIndex)
+	binary.LittleEndian.PutUint32(req[4:], uint32(len(data)))
+	if _, err := c.request(req, sdoInitiateDownloadResponse); err != nil {
+		return err
+	}
+	for toggle, first := byte(0), true; first || len(data) > 0; toggle, first = toggle^1, false {
+		n := min(len(data), 7)
+		req := sdoFrame{sdoDownloadSegment<<5 | toggle<<4 | byte(7-n)<<1}
+		if n == len(data) {
+			req[0] |= 0x01
+		}
+		copy(req[1:], data[:n])
+		resp, err := c.request(req, sdoDownloadSegmentResponse)
+		if err != nil {
+			return err
+		}
+		if resp.toggle() != toggle {
+			return c.abort(index, subIndex, AbortToggleBit)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// request sends req and waits for a response with the command specifier command.
+func (c *SDOClient) request(req sdoFrame, command byte) (sdoFrame, error) {
+	// Drop a stale response.
+	select {
+	case <-c.responses:
+	default:
+	}
+
+	if err := publishSDO(c.bus, FunctionSDORx+uint32(c.nodeID), req); err != nil {
+		return sdoFrame{}, err
+	}
+
+	timer := time.NewTimer(c.Timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-c.responses:
+		if resp.command() == sdoAbort {
+			return resp, resp.abort()
+		}
+		if resp.command() != command {
+			return resp, c.abort(req.index(), req.subIndex(), AbortCommandSpecifier)
+		}
+		return resp, nil
+	case <-timer.C:
+		return sdoFrame{}, c.abort(req.index(), req.subIndex(), AbortTimeout)
+	}
+}
+
+// abort aborts the transfer and returns code.
+func (c *SDOClient) abort(index uint16, subIndex uint8, code AbortCode) error {
+	publishSDO(c.bus, FunctionSDORx+uint32(c.nodeID), abortFrame(index, subIndex, code))
+	return code
+}
+
+// SDOServer serves the object dictionary of a node with the default SDO.
+type SDOServer struct {
+	bus     *can.Bus
+	nodeID  uint8
+	od      *ObjectDictionary
+	handler can.Handler
+
+	// state of the segmented transfer in progress
+	mu       sync.Mutex
+	command  byte
+	variable *Variable
+	toggle   byte
+	size     int
+	buf      []byte
+}
+
+// NewSDOServer returns a server for the object dictionary od of the node nodeID.
+func NewSDOServer(bus *can.Bus, nodeID uint8, od *ObjectDictionary) *SDOServer {
+	s := &SDOServer{
+		bus:    bus,
+		nodeID: nodeID,
+		od:     od,
+	}
+	s.handler = can.NewHandler(s.handle)
+	bus.Subscribe(s.handler)
+	return s
+}
+
+// Close unsubscribes the server from the bus.
+func (s *SDOServer) Close() {
+	s.bus.Unsubscribe(s.handler)
+}
+
+func (s *SDOServer) handle(frame can.Frame) {
+	if frame.ID != FunctionSDORx+uint32(s.nodeID) || frame.Length != 8 {
+		return
+	}
+	var req sdoFrame
+	copy(req[:], frame.Data[:8])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var resp sdoFrame
+	var err error
+	switch req.command() {
+	case sdoInitiateDownload:
+		resp, err = s.initiateDownload(req)
+	case sdoDownloadSegment:
+		resp, err = s.downloadSegment(req)
+	case sdoInitiateUpload:
+		resp, err = s.initiateUpload(req)
+	case sdoUploadSegment:
+		resp, err = s.uploadSegment(req)
+	case sdoAbort:
+		s.reset()
+		return
+	default:
+		err = AbortCommandSpecifier
+	}
+
+	if err != nil {
+		index, subIndex := req.index(), req.subIndex()
+		if s.variable != nil {
+			index, subIndex = s.variable.Index, s.variable.SubIndex
+		}
+		s.reset()
+		var code AbortCode
+		if !errors.As(err, &code) {
+			code = AbortGeneralError
+		}
+		resp = abortFrame(index, subIndex, code)
+	}
+	publishSDO(s.bus, FunctionSDOTx+uint32(s.nodeID), resp)
+}
+
+func (s *SDOServer) initiateDownload(req sdoFrame) (sdoFrame, error) {
+	s.reset()
+	v, err := s.od.Variable(req.index(), req.subIndex())
+	if err != nil {
+		return req, err
+	}
+	if v.Access&AccessWrite == 0 {
+		return req, AbortReadOnly
+	}
+
+	if req.expedited() {
+		data := req.expeditedData()
+		if size := v.DataType.Size(); !req.sizeIndicated() && size > 0 && size < len(data) {
+			data = data[:size]
+		}
+		if err := s.write(v, data); err != nil {
+			return req, err
+		}
+	} else {
+		s.command = sdoInitiateDownload
+		s.variable = v
+		s.size = -1
+		if req.sizeIndicated() {
+			s.size = int(binary.LittleEndian.Uint32(req[4:]))
+			if size := v.DataType.Size(); size > 0 && s.size != size {
+				return req, AbortLengthMismatch
+			}
+		}
+	}
+	return newSDOFrame(sdoInitiateDownloadResponse<<5, req.index(), req.subIndex()), nil
+}
+
+func (s *SDOServer) downloadSegment(req sdoFrame) (sdoFrame, error) {
+	if s.command != sdoInitiateDownload {
+		return req, AbortCommandSpecifier
+	}
+	if req.toggle() != s.toggle {
+		return req, AbortToggleBit
+	}
+
+	data, last := req.segmentData()
+	s.buf = append(s.buf, data...)
+	resp := sdoFrame{sdoDownloadSegmentResponse<<5 | s.toggle<<4}
+	s.toggle ^= 1
+	if last {
+		v := s.variable
+		if s.size >= 0 && len(s.buf) != s.size {
+			return req, AbortLengthMismatch
+		}
+		if err := s.write(v, s.buf); err != nil {
+			return req, err
+		}
+		s.reset()
+	}
+	return resp, nil
+}
+
+func (s *SDOServer) initiateUpload(req sdoFrame) (sdoFrame, error) {
+	s.reset()
+	v, err := s.od.Variable(req.index(), req.subIndex())
+	if err != nil {
+		return req, err
+	}
+	if v.Access&AccessRead == 0 {
+		return req, AbortWriteOnly
+	}
+
+	data := v.Bytes()
+	if len(data) <= 4 && len(data) > 0 {
+		resp := newSDOFrame(sdoInitiateUploadResponse<<5|byte(4-len(data))<<2|0x03, req.index(), req.subIndex())
+		copy(resp[4:], data)
+		return resp, nil
+	}
+
+	s.command = sdoInitiateUpload
+	s.variable = v
+	s.buf = data
+	resp := newSDOFrame(sdoInitiateUploadResponse<<5|0x01, req.index(), req.subIndex())
+	binary.LittleEndian.PutUint32(resp[4:], uint32(len(data)))
+	return resp, nil
+}
+
+func (s *SDOServer) uploadSegment(req sdoFrame) (sdoFrame, error) {
+	if s.command != sdoInitiateUpload {
+		return req, AbortCommandSpecifier
+	}
+	if req.toggle() != s.toggle {
+		return req, AbortToggleBit
+	}
+
+	n := min(len(s.buf), 7)
+	resp := sdoFrame{sdoUploadSegmentResponse<<5 | s.toggle<<4 | byte(7-n)<<1}
+	copy(resp[1:], s.buf[:n])
+	s.buf = s.buf[n:]
+	s.toggle ^= 1
+	if len(s.buf) == 0 {
+		resp[0] |= 0x01
+		s.reset()
+	}
+	return resp, nil
+}
+
+func (s *SDOServer) write(v *Variable, data []byte) error {
+	if err := v.SetBytes(data); err != nil {
+		return err
+	}
+	s.od.written(v)
+	return nil
+}
+
+func (s *SDOServer) reset() {
+	s.command = 0
+	s.variable = nil
+	s.toggle = 0
+	s.size = 0
+	s.buf = nil
+}
@@ -0,0 +1,95 @@
+package can
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error classes of error frames, which are set in the CAN identifier bits.
+// See linux/can/error.h.
+const (
+	ErrorClassTxTimeout       = 0x00000001
+	ErrorClassLostArbitration = 0x00000002
+	ErrorClassController      = 0x00000004
+	ErrorClassProtocol        = 0x00000008
+	ErrorClassTransceiver     = 0x00000010
+	ErrorClassNoAck           = 0x00000020
+	ErrorClassBusOff          = 0x00000040
+	ErrorClassBusError        = 0x00000080
+	ErrorClassRestarted       = 0x00000100
+	ErrorClassCounters        = 0x00000200
+	// ErrorClassAll selects all error classes in SocketCAN.SetErrorFilter.
+	ErrorClassAll = MaskIDEff
+)
+
+// Controller status of ErrorClassController errors.
+const (
+	ControllerRxOverflow = 0x01
+	ControllerTxOverflow = 0x02
+	ControllerRxWarning  = 0x04
+	ControllerTxWarning  = 0x08
+	ControllerRxPassive  = 0x10
+	ControllerTxPassive  = 0x20
+	ControllerActive     = 0x40
+)
+
+var errorClassNames = []string{
+	"tx timeout",
+	"lost arbitration",
+	"controller problem",
+	"protocol violation",
+	"transceiver status",
+	"no ack",
+	"bus off",
+	"bus error",
+	"controller restarted",
+	"error counters",
+}
+
+// ErrorFrame is a decoded error frame of a SocketCAN interface.
+type ErrorFrame struct {
+	// Class is a set of the ErrorClass flags.
+	Class uint32
+	// LostArbitrationBit is the bit number in the bitstream at which the arbitration was lost.
+	LostArbitrationBit uint8
+	// Controller is a set of the Controller status flags.
+	Controller uint8
+	// Protocol is the type and ProtocolLocation the location of a protocol violation.
+	Protocol         uint8
+	ProtocolLocation uint8
+	// Transceiver is the transceiver status.
+	Transceiver uint8
+	// TxErrorCounter and RxErrorCounter are the error counters of the controller.
+	TxErrorCounter uint8
+	RxErrorCounter uint8
+}
+
+// Err returns the decoded ErrorFrame if frm is an error frame, and nil otherwise.
+func (frm Frame) Err() error {
+	if !frm.IsError() {
+		return nil
+	}
+	return ErrorFrame{
+		Class:              frm.ID & MaskIDEff,
+		LostArbitrationBit: frm.Data[0],
+		Controller:         frm.Data[1],
+		Protocol:           frm.Data[2],
+		ProtocolLocation:   frm.Data[3],
+		Transceiver:        frm.Data[4],
+		TxErrorCounter:     frm.Data[6],
+		RxErrorCounter:     frm.Data[7],
+	}
+}
+
+func (e ErrorFrame) Error() string {
+	var classes []string
+	for i, name := range errorClassNames {
+		if e.Class&(1<<i) != 0 {
+			classes = append(classes, name)
+		}
+	}
+	if len(classes) == 0 {
+		classes = append(classes, fmt.Sprintf("class %#x", e.Class))
+	}
+	return "can: " + strings.Join(classes, ", ")
+}
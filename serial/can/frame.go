@@ -3,9 +3,10 @@ package can
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 )
 
-// Frame represents a standard CAN data frame
+// Frame represents a classic CAN or CAN-FD data frame.
 type Frame struct {
 	// bit 0-28: CAN identifier (11/29 bit)
 	// bit 29: error message flag (ERR)
@@ -13,30 +14,80 @@ type Frame struct {
 	// bit 31: extended frame format (EFF)
 	ID     uint32
 	Length uint8
-	Flags  uint8
-	Res0   uint8
-	Res1   uint8
-	Data   [MaxFrameDataLength]uint8
+	// CAN-FD flags FlagBRS, FlagESI and FlagFDF
+	Flags uint8
+	Res0  uint8
+	Res1  uint8
+	// Data is the payload of a classic frame.
+	Data [MaxFrameDataLength]uint8
+	// FDData is the payload of a CAN-FD frame, which has the flag FlagFDF set.
+	FDData [MaxExtFrameDataLength]uint8
 }
 
-// Marshal returns the byte encoding of frm.
+// IsFD returns true if frm is a CAN-FD frame.
+func (frm Frame) IsFD() bool {
+	return frm.Flags&FlagFDF != 0
+}
+
+// IsError returns true if frm is an error frame. Use Err to decode it.
+func (frm Frame) IsError() bool {
+	return frm.ID&MaskErr != 0
+}
+
+// Payload returns the first Length bytes of Data, or of FDData for CAN-FD frames.
+func (frm *Frame) Payload() []byte {
+	if frm.IsFD() {
+		return frm.FDData[:min(int(frm.Length), MaxExtFrameDataLength)]
+	}
+	return frm.Data[:min(int(frm.Length), MaxFrameDataLength)]
+}
+
+// ValidFDLength returns true if n is a data length of a CAN-FD frame,
+// which are 0-8, 12, 16, 20, 24, 32, 48 and 64.
+func ValidFDLength(n int) bool {
+	switch n {
+	case 12, 16, 20, 24, 32, 48, 64:
+		return true
+	}
+	return n >= 0 && n <= MaxFrameDataLength
+}
+
+// Marshal returns the byte encoding of frm, which is the struct can_frame (MTU bytes)
+// for classic frames and struct canfd_frame (FDMTU bytes) for CAN-FD frames.
 func Marshal(frm Frame) (b []byte, err error) {
+	var data []byte
+	if frm.IsFD() {
+		if !ValidFDLength(int(frm.Length)) {
+			return nil, fmt.Errorf("can: invalid CAN-FD data length %d", frm.Length)
+		}
+		data = frm.FDData[:]
+	} else {
+		if frm.Length > MaxFrameDataLength {
+			return nil, fmt.Errorf("can: invalid data length %d", frm.Length)
+		}
+		data = frm.Data[:]
+	}
+
 	wr := errWriter{
-		buf: bytes.NewBuffer([]byte{}),
+		buf: bytes.NewBuffer(make([]byte, 0, FDMTU)),
 	}
 	wr.write(&frm.ID)
 	wr.write(&frm.Length)
 	wr.write(&frm.Flags)
 	wr.write(&frm.Res0)
 	wr.write(&frm.Res1)
-	wr.write(&frm.Data)
+	wr.write(data)
 
 	return wr.buf.Bytes(), wr.err
 }
 
 // Unmarshal parses the bytes b and stores the result in the value
-// pointed to by frm.
+// pointed to by frm. b holds a CAN-FD frame if it has exactly FDMTU bytes,
+// or if it is longer and the FlagFDF flag is set. CAN-FD frames are stored
+// in FDData with the flag FlagFDF set, classic frames in Data.
 func Unmarshal(b []byte, frm *Frame) (err error) {
+	fd := len(b) == FDMTU || (len(b) > FDMTU && b[5]&FlagFDF != 0)
+
 	cr := &errReader{
 		buf: bytes.NewBuffer(b),
 	}
@@ -45,7 +96,21 @@ func Unmarshal(b []byte, frm *Frame) (err error) {
 	cr.read(&frm.Flags)
 	cr.read(&frm.Res0)
 	cr.read(&frm.Res1)
-	cr.read(&frm.Data)
+	if fd {
+		frm.Flags |= FlagFDF
+		frm.Data = [MaxFrameDataLength]uint8{}
+		cr.read(frm.FDData[:])
+		if cr.err == nil && !ValidFDLength(int(frm.Length)) {
+			cr.err = fmt.Errorf("can: invalid CAN-FD data length %d", frm.Length)
+		}
+	} else {
+		frm.Flags &^= FlagFDF
+		frm.FDData = [MaxExtFrameDataLength]uint8{}
+		cr.read(frm.Data[:])
+		if cr.err == nil && frm.Length > MaxFrameDataLength {
+			cr.err = fmt.Errorf("can: invalid data length %d", frm.Length)
+		}
+	}
 
 	return cr.err
 }
@@ -0,0 +1,125 @@
+package can_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"serial/can"
+)
+
+func TestMarshalClassic(t *testing.T) {
+	frm := can.Frame{ID: 0x123, Length: 3, Data: [can.MaxFrameDataLength]uint8{1, 2, 3}}
+	b, err := can.Marshal(frm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{0x23, 0x01, 0, 0, 3, 0, 0, 0, 1, 2, 3, 0, 0, 0, 0, 0}
+	if !bytes.Equal(b, want) {
+		t.Fatalf("Marshal = % x, want % x", b, want)
+	}
+
+	var got can.Frame
+	if err := can.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != frm {
+		t.Errorf("Unmarshal = %+v, want %+v", got, frm)
+	}
+}
+
+func TestMarshalFD(t *testing.T) {
+	frm := can.Frame{ID: 0x123 | can.MaskEff, Length: 12, Flags: can.FlagFDF | can.FlagBRS}
+	for i := range frm.FDData[:12] {
+		frm.FDData[i] = byte(i + 1)
+	}
+	b, err := can.Marshal(frm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != can.FDMTU {
+		t.Fatalf("len(Marshal) = %d, want %d", len(b), can.FDMTU)
+	}
+	if b[5] != can.FlagFDF|can.FlagBRS {
+		t.Errorf("flags = %#x, want %#x", b[5], can.FlagFDF|can.FlagBRS)
+	}
+
+	var got can.Frame
+	if err := can.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got != frm {
+		t.Errorf("Unmarshal = %+v, want %+v", got, frm)
+	}
+	if p := got.Payload(); !bytes.Equal(p, frm.FDData[:12]) {
+		t.Errorf("Payload = % x, want % x", p, frm.FDData[:12])
+	}
+}
+
+func TestMarshalInvalidLength(t *testing.T) {
+	for _, frm := range []can.Frame{
+		{Length: 9},
+		{Length: 64},
+		{Length: 9, Flags: can.FlagFDF},
+		{Length: 33, Flags: can.FlagFDF},
+		{Length: 65, Flags: can.FlagFDF},
+	} {
+		if b, err := can.Marshal(frm); err == nil {
+			t.Errorf("Marshal(length %d, flags %#x) = %d bytes, want an error", frm.Length, frm.Flags, len(b))
+		}
+	}
+}
+
+func TestUnmarshalInvalidLength(t *testing.T) {
+	b := make([]byte, can.MTU)
+	b[4] = 9
+	if err := can.Unmarshal(b, new(can.Frame)); err == nil {
+		t.Error("Unmarshal of a classic frame with length 9: expected an error")
+	}
+
+	b = make([]byte, can.FDMTU)
+	b[4] = 9
+	if err := can.Unmarshal(b, new(can.Frame)); err == nil {
+		t.Error("Unmarshal of a CAN-FD frame with length 9: expected an error")
+	}
+}
+
+func TestValidFDLength(t *testing.T) {
+	var valid []int
+	for n := -1; n <= 65; n++ {
+		if can.ValidFDLength(n) {
+			valid = append(valid, n)
+		}
+	}
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 20, 24, 32, 48, 64}
+	if len(valid) != len(want) {
+		t.Fatalf("valid lengths = %v, want %v", valid, want)
+	}
+	for i := range want {
+		if valid[i] != want[i] {
+			t.Fatalf("valid lengths = %v, want %v", valid, want)
+		}
+	}
+}
+
+func TestErrorFrame(t *testing.T) {
+	frm := can.Frame{
+		ID:     can.MaskErr | can.ErrorClassController | can.ErrorClassCounters,
+		Length: can.MaxFrameDataLength,
+		Data:   [can.MaxFrameDataLength]uint8{1: can.ControllerRxPassive, 6: 12, 7: 130},
+	}
+	if !frm.IsError() {
+		t.Fatal("IsError = false, want true")
+	}
+
+	var e can.ErrorFrame
+	if !errors.As(frm.Err(), &e) {
+		t.Fatalf("Err = %v, want an ErrorFrame", frm.Err())
+	}
+	if e.Controller != can.ControllerRxPassive || e.TxErrorCounter != 12 || e.RxErrorCounter != 130 {
+		t.Errorf("Err = %+v", e)
+	}
+	if (can.Frame{ID: 0x123}).Err() != nil {
+		t.Error("Err of a data frame != nil")
+	}
+}
@@ -0,0 +1,34 @@
+//go:build linux
+
+// Package cantest provides connected CAN buses for the tests of the protocols
+// on top of package can.
+package cantest
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"serial/can"
+)
+
+// NewBusPair returns two connected buses over a socketpair standing in for a CAN interface.
+func NewBusPair(t *testing.T) (*can.Bus, *can.Bus) {
+	t.Helper()
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buses [2]*can.Bus
+	for i, fd := range fds {
+		if err := syscall.SetNonblock(fd, true); err != nil {
+			t.Fatal(err)
+		}
+		bus := can.NewBus(can.NewReadWriteCloser(os.NewFile(uintptr(fd), "can")))
+		go bus.ConnectAndPublish()
+		t.Cleanup(func() { bus.Disconnect() })
+		buses[i] = bus
+	}
+	return buses[0], buses[1]
+}
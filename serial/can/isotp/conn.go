@@ -0,0 +1,409 @@
+package isotp
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"serial/can"
+)
+
+// Default values of Config.
+const (
+	DefaultTimeout   = time.Second
+	DefaultMaxLength = maxShortLength
+)
+
+// ErrTimeout is returned by Receive if no message arrived in time.
+var ErrTimeout = errors.New("isotp: timeout waiting for message")
+
+// ErrClosed is returned by Receive after the connection was closed.
+var ErrClosed = errors.New("isotp: connection closed")
+
+// Config configures an ISO-TP connection with normal addressing.
+type Config struct {
+	// TxID is the CAN identifier of the sent frames and RxID the identifier of the
+	// received frames. Extended identifiers must include the flag can.MaskEff.
+	TxID uint32
+	RxID uint32
+	// FD sends CAN-FD frames with up to 64 bytes. BitRateSwitch sets the flag
+	// can.FlagBRS in the sent frames.
+	FD            bool
+	BitRateSwitch bool
+	// Padding pads the sent frames to 8 bytes with PaddingByte. CAN-FD frames
+	// longer than 8 bytes are always padded to the next valid length.
+	Padding     bool
+	PaddingByte byte
+	// BlockSize is the number of consecutive frames the sender may send before
+	// waiting for the next flow control frame. Zero means no limit.
+	BlockSize uint8
+	// STmin is the minimum time between consecutive frames the receiver asks for.
+	STmin time.Duration
+	// Timeout is the time to wait for a flow control frame (N_Bs) and for the next
+	// consecutive frame (N_Cr). Defaults to DefaultTimeout.
+	Timeout time.Duration
+	// MaxWaitFrames is the number of flow control wait frames in a row the
+	// sender accepts (N_WFTmax).
+	MaxWaitFrames int
+	// MaxLength is the largest payload which is received. Longer payloads are
+	// rejected with a flow control overflow frame. Defaults to DefaultMaxLength.
+	MaxLength int
+}
+
+type message struct {
+	data []byte
+	err  error
+}
+
+// Conn sends and receives ISO-TP messages on a CAN bus.
+type Conn struct {
+	bus    *can.Bus
+	config Config
+	// txDL is the data length of the sent frames.
+	txDL int
+
+	handler  can.Handler
+	messages chan message
+	flow     chan can.Frame
+	done     chan struct{}
+	closeMu  sync.Once
+	sendMu   sync.Mutex
+
+	// state of the reception in progress
+	mu         sync.Mutex
+	rxBuf      []byte
+	rxLength   int
+	rxSN       uint8
+	rxBlock    int
+	rxTimer    *time.Timer
+	generation int
+}
+
+// NewConn returns a connection which subscribes to the frames of bus with the
+// identifier config.RxID. Messages are received in the background and buffered
+// until they are read with Receive.
+func NewConn(bus *can.Bus, config *Config) *Conn {
+	c := &Conn{
+		bus:      bus,
+		config:   *config,
+		txDL:     can.MaxFrameDataLength,
+		messages: make(chan message, 16),
+		flow:     make(chan can.Frame, 1),
+		done:     make(chan struct{}),
+	}
+	if c.config.FD {
+		c.txDL = can.MaxExtFrameDataLength
+	}
+	if c.config.Timeout <= 0 {
+		c.config.Timeout = DefaultTimeout
+	}
+	if c.config.MaxLength <= 0 {
+		c.config.MaxLength = DefaultMaxLength
+	}
+	c.handler = can.NewHandler(c.handle)
+	bus.Subscribe(c.handler)
+	return c
+}
+
+// Close unsubscribes the connection from the bus. A pending Receive returns ErrClosed.
+func (c *Conn) Close() error {
+	c.closeMu.Do(func() {
+		c.bus.Unsubscribe(c.handler)
+		c.mu.Lock()
+		c.resetReception()
+		c.mu.Unlock()
+		close(c.done)
+	})
+	return nil
+}
+
+// Receive returns the next received message. A reception which failed is
+// returned as Result error. A timeout of zero waits forever.
+func (c *Conn) Receive(timeout time.Duration) ([]byte, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case msg := <-c.messages:
+		return msg.data, msg.err
+	case <-expired:
+		return nil, ErrTimeout
+	case <-c.done:
+		return nil, ErrClosed
+	}
+}
+
+// Send sends payload as a single frame, or segmented into a first frame and
+// consecutive frames as requested by the flow control frames of the receiver.
+func (c *Conn) Send(payload []byte) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+
+	if len(payload) == 0 {
+		return errors.New("isotp: empty payload")
+	}
+
+	// Single frame
+	if len(payload) <= 7 {
+		return c.publish(append([]byte{byte(len(payload))}, payload...))
+	}
+	if len(payload) <= c.txDL-2 {
+		return c.publish(append([]byte{0, byte(len(payload))}, payload...))
+	}
+
+	// Drop a stale flow control frame.
+	select {
+	case <-c.flow:
+	default:
+	}
+
+	// First frame
+	var data []byte
+	if len(payload) <= maxShortLength {
+		data = []byte{pciFirstFrame<<4 | byte(len(payload)>>8), byte(len(payload))}
+	} else {
+		data = binary.BigEndian.AppendUint32([]byte{pciFirstFrame << 4, 0}, uint32(len(payload)))
+	}
+	sent := c.txDL - len(data)
+	if err := c.publish(append(data, payload[:sent]...)); err != nil {
+		return err
+	}
+
+	// Consecutive frames
+	sn := uint8(1)
+	for sent < len(payload) {
+		blockSize, stmin, err := c.waitFlowControl()
+		if err != nil {
+			return err
+		}
+		for block := 0; sent < len(payload) && (blockSize == 0 || block < blockSize); block++ {
+			if block > 0 && stmin > 0 {
+				time.Sleep(stmin)
+			}
+			n := min(len(payload)-sent, c.txDL-1)
+			if err := c.publish(append([]byte{pciConsecutiveFrame<<4 | sn}, payload[sent:sent+n]...)); err != nil {
+				return err
+			}
+			sent += n
+			sn = (sn + 1) & 0x0F
+		}
+	}
+	return nil
+}
+
+// waitFlowControl waits for a flow control frame which allows to continue and
+// returns its block size and separation time.
+func (c *Conn) waitFlowControl() (blockSize int, stmin time.Duration, err error) {
+	timer := time.NewTimer(c.config.Timeout)
+	defer timer.Stop()
+
+	waits := 0
+	for {
+		var frame can.Frame
+		select {
+		case frame = <-c.flow:
+		case <-timer.C:
+			return 0, 0, ResultTimeoutBs
+		case <-c.done:
+			return 0, 0, ErrClosed
+		}
+		data := frame.Payload()
+		if len(data) < 3 {
+			return 0, 0, ResultInvalidFS
+		}
+
+		switch data[0] & 0x0F {
+		case flowStatusContinue:
+			return int(data[1]), decodeSTmin(data[2]), nil
+		case flowStatusWait:
+			waits++
+			if waits > c.config.MaxWaitFrames {
+				return 0, 0, ResultWaitOverrun
+			}
+			timer.Reset(c.config.Timeout)
+		case flowStatusOverflow:
+			return 0, 0, ResultBufferOverflow
+		default:
+			return 0, 0, ResultInvalidFS
+		}
+	}
+}
+
+// publish sends data as one frame, padded as configured.
+func (c *Conn) publish(data []byte) error {
+	frame := can.Frame{ID: c.config.TxID}
+	if c.config.FD {
+		frame.Flags = can.FlagFDF
+		if c.config.BitRateSwitch {
+			frame.Flags |= can.FlagBRS
+		}
+	}
+
+	length := len(data)
+	if c.config.Padding && length < can.MaxFrameDataLength {
+		length = can.MaxFrameDataLength
+	}
+	if length > can.MaxFrameDataLength {
+		for _, l := range fdLengths {
+			if l >= length {
+				length = l
+				break
+			}
+		}
+	}
+	frame.Length = uint8(length)
+	payload := frame.Payload()
+	n := copy(payload, data)
+	for i := n; i < length; i++ {
+		payload[i] = c.config.PaddingByte
+	}
+	return c.bus.Publish(frame)
+}
+
+// handle is called by the bus for every received frame.
+func (c *Conn) handle(frame can.Frame) {
+	data := frame.Payload()
+	if frame.ID != c.config.RxID || len(data) == 0 {
+		return
+	}
+
+	switch data[0] >> 4 {
+	case pciSingleFrame:
+		c.receiveSingleFrame(data)
+	case pciFirstFrame:
+		c.receiveFirstFrame(data)
+	case pciConsecutiveFrame:
+		c.receiveConsecutiveFrame(data)
+	case pciFlowControl:
+		select {
+		case c.flow <- frame:
+		default:
+		}
+	}
+}
+
+func (c *Conn) receiveSingleFrame(data []byte) {
+	length, offset := int(data[0]&0x0F), 1
+	if length == 0 && len(data) > can.MaxFrameDataLength {
+		length, offset = int(data[1]), 2
+	}
+	if length == 0 || offset+length > len(data) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interrupt()
+	c.deliver(message{data: append([]byte(nil), data[offset:offset+length]...)})
+}
+
+func (c *Conn) receiveFirstFrame(data []byte) {
+	if len(data) < can.MaxFrameDataLength {
+		return
+	}
+	length, offset := int(data[0]&0x0F)<<8|int(data[1]), 2
+	if length == 0 {
+		length, offset = int(binary.BigEndian.Uint32(data[2:])), 6
+	}
+	// The payload must not fit into a single frame.
+	if length <= len(data)-offset {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interrupt()
+	if length > c.config.MaxLength {
+		c.sendFlowControl(flowStatusOverflow)
+		return
+	}
+
+	c.rxBuf = append(make([]byte, 0, length), data[offset:]...)
+	c.rxLength = length
+	c.rxSN = 1
+	c.rxBlock = 0
+	c.startTimer()
+	c.sendFlowControl(flowStatusContinue)
+}
+
+func (c *Conn) receiveConsecutiveFrame(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rxBuf == nil {
+		return
+	}
+	if data[0]&0x0F != c.rxSN {
+		c.resetReception()
+		c.deliver(message{err: ResultWrongSN})
+		return
+	}
+
+	n := min(c.rxLength-len(c.rxBuf), len(data)-1)
+	c.rxBuf = append(c.rxBuf, data[1:1+n]...)
+	if len(c.rxBuf) == c.rxLength {
+		msg := message{data: c.rxBuf}
+		c.resetReception()
+		c.deliver(msg)
+		return
+	}
+
+	c.rxSN = (c.rxSN + 1) & 0x0F
+	c.startTimer()
+	c.rxBlock++
+	if c.config.BlockSize > 0 && c.rxBlock == int(c.config.BlockSize) {
+		c.rxBlock = 0
+		c.sendFlowControl(flowStatusContinue)
+	}
+}
+
+// interrupt aborts a reception in progress because a new message started.
+func (c *Conn) interrupt() {
+	if c.rxBuf != nil {
+		c.resetReception()
+		c.deliver(message{err: ResultUnexpectedPDU})
+	}
+}
+
+func (c *Conn) sendFlowControl(status byte) {
+	c.publish([]byte{pciFlowControl<<4 | status, c.config.BlockSize, encodeSTmin(c.config.STmin)})
+}
+
+// startTimer (re)starts the N_Cr timeout of the reception in progress.
+func (c *Conn) startTimer() {
+	if c.rxTimer != nil {
+		c.rxTimer.Stop()
+	}
+	c.generation++
+	generation := c.generation
+	c.rxTimer = time.AfterFunc(c.config.Timeout, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.generation == generation && c.rxBuf != nil {
+			c.resetReception()
+			c.deliver(message{err: ResultTimeoutCr})
+		}
+	})
+}
+
+func (c *Conn) resetReception() {
+	if c.rxTimer != nil {
+		c.rxTimer.Stop()
+		c.rxTimer = nil
+	}
+	c.generation++
+	c.rxBuf = nil
+}
+
+// deliver queues msg for Receive. Like a full CAN receive buffer, the message
+// is dropped if Receive is not called in time.
+func (c *Conn) deliver(msg message) {
+	select {
+	case c.messages <- msg:
+	default:
+	}
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+package isotp_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"serial/can/internal/cantest"
+	"serial/can/isotp"
+)
+
+// newConnPair returns two connections sending to each other.
+func newConnPair(t *testing.T, config isotp.Config) (*isotp.Conn, *isotp.Conn) {
+	t.Helper()
+
+	a, b := cantest.NewBusPair(t)
+	configA, configB := config, config
+	configA.TxID, configA.RxID = 0x7E0, 0x7E8
+	configB.TxID, configB.RxID = 0x7E8, 0x7E0
+	connA, connB := isotp.NewConn(a, &configA), isotp.NewConn(b, &configB)
+	t.Cleanup(func() {
+		connA.Close()
+		connB.Close()
+	})
+	return connA, connB
+}
+
+func testSendReceive(t *testing.T, config isotp.Config, payload []byte) {
+	t.Helper()
+
+	a, b := newConnPair(t, config)
+	if err := a.Send(payload); err != nil {
+		t.Fatal(err)
+	}
+	got, err := b.Receive(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("Receive = % x, want % x", got, payload)
+	}
+}
+
+func payload(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+func TestSingleFrame(t *testing.T) {
+	testSendReceive(t, isotp.Config{Padding: true, PaddingByte: 0xCC}, payload(7))
+}
+
+func TestSegmented(t *testing.T) {
+	testSendReceive(t, isotp.Config{BlockSize: 4, STmin: time.Millisecond}, payload(300))
+}
+
+func TestFD(t *testing.T) {
+	config := isotp.Config{FD: true, BitRateSwitch: true}
+	testSendReceive(t, config, payload(40))
+	testSendReceive(t, config, payload(1000))
+}
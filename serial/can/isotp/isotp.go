@@ -0,0 +1,97 @@
+// Package isotp implements the ISO-TP transport protocol (ISO 15765-2) on top of a
+// CAN bus. It segments payloads into a first frame and consecutive frames, which
+// are paced by the flow control frames of the receiver, and reassembles them on the
+// receiving side. Payloads of up to 4095 bytes are supported with classic CAN
+// frames; CAN-FD and the escape sequence of ISO 15765-2:2016 allow larger payloads.
+package isotp
+
+import (
+	"fmt"
+	"time"
+)
+
+// Protocol control information types in the upper nibble of the first byte.
+const (
+	pciSingleFrame      = 0x0
+	pciFirstFrame       = 0x1
+	pciConsecutiveFrame = 0x2
+	pciFlowControl      = 0x3
+)
+
+// Flow status of a flow control frame.
+const (
+	flowStatusContinue = 0x0
+	flowStatusWait     = 0x1
+	flowStatusOverflow = 0x2
+)
+
+// maxShortLength is the largest payload length of a first frame without escape sequence.
+const maxShortLength = 0xFFF
+
+// Result is an error of the ISO-TP protocol. The values correspond to the
+// N_Result codes of ISO 15765-2.
+type Result int
+
+// ISO-TP errors.
+const (
+	// ResultTimeoutBs (N_TIMEOUT_Bs) is returned if the sender does not receive a flow control frame in time.
+	ResultTimeoutBs Result = iota + 1
+	// ResultTimeoutCr (N_TIMEOUT_Cr) is returned if the receiver does not receive a consecutive frame in time.
+	ResultTimeoutCr
+	// ResultWrongSN (N_WRONG_SN) is returned if a consecutive frame has an unexpected sequence number.
+	ResultWrongSN
+	// ResultInvalidFS (N_INVALID_FS) is returned if a flow control frame has an invalid flow status.
+	ResultInvalidFS
+	// ResultUnexpectedPDU (N_UNEXP_PDU) is returned if a reception is interrupted by a new first or single frame.
+	ResultUnexpectedPDU
+	// ResultWaitOverrun (N_WFT_OVRN) is returned if the receiver sends more wait frames than allowed.
+	ResultWaitOverrun
+	// ResultBufferOverflow (N_BUFFER_OVFLW) is returned if the receiver cannot take the payload.
+	ResultBufferOverflow
+)
+
+var resultNames = map[Result]string{
+	ResultTimeoutBs:      "N_TIMEOUT_Bs",
+	ResultTimeoutCr:      "N_TIMEOUT_Cr",
+	ResultWrongSN:        "N_WRONG_SN",
+	ResultInvalidFS:      "N_INVALID_FS",
+	ResultUnexpectedPDU:  "N_UNEXP_PDU",
+	ResultWaitOverrun:    "N_WFT_OVRN",
+	ResultBufferOverflow: "N_BUFFER_OVFLW",
+}
+
+func (r Result) Error() string {
+	if name, ok := resultNames[r]; ok {
+		return "isotp: " + name
+	}
+	return fmt.Sprintf("isotp: unknown error %d", int(r))
+}
+
+// fdLengths are the valid data lengths of CAN-FD frames.
+var fdLengths = []int{8, 12, 16, 20, 24, 32, 48, 64}
+
+// encodeSTmin returns the STmin byte of a flow control frame for d.
+func encodeSTmin(d time.Duration) byte {
+	switch {
+	case d <= 0:
+		return 0
+	case d <= 900*time.Microsecond:
+		us := (d + 99*time.Microsecond) / (100 * time.Microsecond)
+		return 0xF0 + byte(us)
+	case d > 127*time.Millisecond:
+		return 0x7F
+	}
+	return byte((d + time.Millisecond - 1) / time.Millisecond)
+}
+
+// decodeSTmin returns the separation time of the STmin byte b. Reserved values
+// are interpreted as 127 ms as required by the standard.
+func decodeSTmin(b byte) time.Duration {
+	switch {
+	case b <= 0x7F:
+		return time.Duration(b) * time.Millisecond
+	case b >= 0xF1 && b <= 0xF9:
+		return time.Duration(b-0xF0) * 100 * time.Microsecond
+	}
+	return 127 * time.Millisecond
+}
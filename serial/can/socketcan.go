@@ -0,0 +1,135 @@
+package can
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+)
+
+// SocketFilter is a filter of a SocketCAN raw socket which is applied by the
+// kernel. A frame passes the filter if frame.ID & Mask == ID & Mask. ID and Mask
+// may include the flags MaskEff and MaskRtr to match the frame format.
+type SocketFilter struct {
+	ID   uint32
+	Mask uint32
+	// Invert passes the frames which do not match instead.
+	Invert bool
+}
+
+const canInvFilter = 0x20000000
+
+// SocketCAN is a ReadWriteCloser for a SocketCAN raw socket.
+type SocketCAN struct {
+	f *os.File
+}
+
+// NewBusForInterfaceWithName returns a bus from the network interface with name ifaceName.
+func NewBusForInterfaceWithName(ifaceName string) (*Bus, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := NewSocketCAN(iface)
+	if err != nil {
+		return nil, err
+	}
+	return NewBus(conn), nil
+}
+
+// NewSocketCAN returns a raw socket bound to the CAN interface i. The socket
+// receives classic and CAN-FD frames; writing CAN-FD frames requires a CAN-FD
+// capable interface.
+func NewSocketCAN(i *net.Interface) (*SocketCAN, error) {
+	f, err := openSocket(i.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SocketCAN{f}
+	// Older kernels do not support CAN-FD, classic frames still work there.
+	s.setsockoptInt(canRawFDFrames, 1)
+	return s, nil
+}
+
+// SetFilters replaces the filters of the socket. A frame is received if it passes
+// any of the filters. Without filters no data frames are received. A new socket
+// receives all data frames.
+func (s *SocketCAN) SetFilters(filters ...SocketFilter) error {
+	b := make([]byte, 0, 8*len(filters))
+	for _, filter := range filters {
+		id := filter.ID
+		if filter.Invert {
+			id |= canInvFilter
+		}
+		b = binary.NativeEndian.AppendUint32(b, id)
+		b = binary.NativeEndian.AppendUint32(b, filter.Mask)
+	}
+	return s.setsockopt(canRawFilter, b)
+}
+
+// SetErrorFilter sets the error classes of the error frames which are received.
+// Error frames are not received by default, use ErrorClassAll to receive all.
+func (s *SocketCAN) SetErrorFilter(mask uint32) error {
+	return s.setsockoptInt(canRawErrFilter, int(mask))
+}
+
+// SetLoopback enables or disables the local loopback of sent frames to other
+// sockets on the same interface. It is enabled by default.
+func (s *SocketCAN) SetLoopback(enable bool) error {
+	return s.setsockoptInt(canRawLoopback, boolToInt(enable))
+}
+
+// SetReceiveOwnMessages enables or disables receiving the frames sent by this
+// socket. It is disabled by default.
+func (s *SocketCAN) SetReceiveOwnMessages(enable bool) error {
+	return s.setsockoptInt(canRawRecvOwnMsgs, boolToInt(enable))
+}
+
+// ReadFrame reads the next classic or CAN-FD frame.
+func (s *SocketCAN) ReadFrame(frame *Frame) error {
+	var b [FDMTU]byte
+	n, err := s.f.Read(b[:])
+	if err != nil {
+		return err
+	}
+	if n != MTU && n != FDMTU {
+		return errors.New("can: invalid frame size")
+	}
+	return Unmarshal(b[:n], frame)
+}
+
+// WriteFrame writes frame.
+func (s *SocketCAN) WriteFrame(frame Frame) error {
+	b, err := Marshal(frame)
+	if err != nil {
+		return err
+	}
+	_, err = s.f.Write(b)
+	return err
+}
+
+func (s *SocketCAN) Read(b []byte) (n int, err error) {
+	return s.f.Read(b)
+}
+
+func (s *SocketCAN) Write(b []byte) (n int, err error) {
+	return s.f.Write(b)
+}
+
+// Close closes the socket. A pending ReadFrame returns an error.
+func (s *SocketCAN) Close() error {
+	return s.f.Close()
+}
+
+func (s *SocketCAN) setsockoptInt(opt int, value int) error {
+	return s.setsockopt(opt, binary.NativeEndian.AppendUint32(nil, uint32(value)))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
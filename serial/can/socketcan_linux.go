@@ -0,0 +1,72 @@
+//go:build linux && !386
+
+package can
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Constants of linux/can.h and linux/can/raw.h.
+const (
+	afCAN  = 29
+	canRaw = 1
+
+	solCANRaw         = 100 + canRaw
+	canRawFilter      = 1
+	canRawErrFilter   = 2
+	canRawLoopback    = 3
+	canRawRecvOwnMsgs = 4
+	canRawFDFrames    = 5
+)
+
+// rawSockaddrCAN is struct sockaddr_can.
+type rawSockaddrCAN struct {
+	Family  uint16
+	_       uint16
+	Ifindex int32
+	Addr    [16]byte
+}
+
+// openSocket opens a raw CAN socket bound to the interface with index ifindex.
+func openSocket(ifindex int) (*os.File, error) {
+	fd, err := syscall.Socket(afCAN, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, canRaw)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+
+	addr := rawSockaddrCAN{Family: afCAN, Ifindex: int32(ifindex)}
+	_, _, errno := syscall.Syscall(syscall.SYS_BIND, uintptr(fd), uintptr(unsafe.Pointer(&addr)), unsafe.Sizeof(addr))
+	if errno != 0 {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", errno)
+	}
+	return newSocketFile(fd)
+}
+
+// newSocketFile returns a file for the socket fd, which uses the runtime poller so
+// that Close interrupts a pending Read. A socketpair(AF_UNIX, SOCK_SEQPACKET) can
+// stand in for a CAN socket.
+func newSocketFile(fd int) (*os.File, error) {
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("setnonblock", err)
+	}
+	return os.NewFile(uintptr(fd), "can"), nil
+}
+
+func (s *SocketCAN) setsockopt(opt int, value []byte) error {
+	conn, err := s.f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	err = conn.Control(func(fd uintptr) {
+		serr = syscall.SetsockoptString(int(fd), solCANRaw, opt, string(value))
+	})
+	if err != nil {
+		return err
+	}
+	return os.NewSyscallError("setsockopt", serr)
+}
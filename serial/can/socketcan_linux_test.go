@@ -0,0 +1,109 @@
+//go:build linux && !386
+
+package can
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+// newSocketPair returns two connected sockets standing in for two CAN sockets
+// on the same interface.
+func newSocketPair(t *testing.T) (*SocketCAN, *SocketCAN) {
+	t.Helper()
+
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := newSocketFile(fds[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := newSocketFile(fds[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		a.Close()
+		b.Close()
+	})
+	return &SocketCAN{a}, &SocketCAN{b}
+}
+
+func TestSocketCANReadWriteFrame(t *testing.T) {
+	a, b := newSocketPair(t)
+
+	classic := Frame{ID: 0x7FF, Length: 2, Data: [MaxFrameDataLength]uint8{0xCA, 0xFE}}
+	fd := Frame{ID: 0x100 | MaskEff, Length: 64, Flags: FlagFDF | FlagBRS}
+	for i := range fd.FDData {
+		fd.FDData[i] = byte(i)
+	}
+
+	for _, frame := range []Frame{classic, fd} {
+		if err := a.WriteFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+		var got Frame
+		if err := b.ReadFrame(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != frame {
+			t.Errorf("ReadFrame = %+v, want %+v", got, frame)
+		}
+	}
+
+	if err := a.WriteFrame(Frame{Length: 9, Flags: FlagFDF}); err == nil {
+		t.Error("WriteFrame of a CAN-FD frame with length 9: expected an error")
+	}
+}
+
+func TestSocketCANInvalidFrameSize(t *testing.T) {
+	a, b := newSocketPair(t)
+
+	if _, err := a.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.ReadFrame(new(Frame)); err == nil {
+		t.Error("ReadFrame of 10 bytes: expected an error")
+	}
+}
+
+func TestSocketCANBus(t *testing.T) {
+	a, b := newSocketPair(t)
+
+	frames := make(chan Frame, 1)
+	bus := NewBus(b)
+	bus.SubscribeFunc(func(frame Frame) {
+		frames <- frame
+	})
+	done := make(chan error, 1)
+	go func() { done <- bus.ConnectAndPublish() }()
+
+	sent := Frame{ID: 0x181, Length: 12, Flags: FlagFDF}
+	sent.FDData[11] = 0xFF
+	if err := NewBus(a).Publish(sent); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-frames:
+		if got != sent {
+			t.Errorf("frame = %+v, want %+v", got, sent)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for frame")
+	}
+
+	if err := bus.Disconnect(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ConnectAndPublish = %v, want nil after Disconnect", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ConnectAndPublish did not return after Disconnect")
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !linux || 386
+
+package can
+
+import (
+	"errors"
+	"os"
+)
+
+const (
+	canRawFilter = iota + 1
+	canRawErrFilter
+	canRawLoopback
+	canRawRecvOwnMsgs
+	canRawFDFrames
+)
+
+var errSocketCANUnsupported = errors.New("can: SocketCAN is not supported on this platform")
+
+func openSocket(ifindex int) (*os.File, error) {
+	return nil, errSocketCANUnsupported
+}
+
+func (s *SocketCAN) setsockopt(opt int, value []byte) error {
+	return errSocketCANUnsupported
+}
@@ -0,0 +1,16 @@
+//go:build !mips && !mipsle && !mips64 && !mips64le && !ppc64 && !ppc64le
+
+package serial
+
+// ioctl requests which are missing in package syscall on some architectures.
+const (
+	tcsbrk = 0x5409
+	tcflsh = 0x540B
+)
+
+// ioctl requests and size of struct termios2.
+const (
+	tcgets2 = 0x802C542A
+	tcsets2 = 0x402C542B
+	nccs    = 19
+)
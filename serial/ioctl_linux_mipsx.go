@@ -0,0 +1,16 @@
+//go:build linux && (mips || mipsle || mips64 || mips64le)
+
+package serial
+
+// ioctl requests which are missing in package syscall on some architectures.
+const (
+	tcsbrk = 0x5405
+	tcflsh = 0x5407
+)
+
+// ioctl requests and size of struct termios2.
+const (
+	tcgets2 = 0x402C542A
+	tcsets2 = 0x802C542B
+	nccs    = 23
+)
@@ -0,0 +1,9 @@
+//go:build linux && (ppc64 || ppc64le)
+
+package serial
+
+// ioctl requests which are missing in package syscall on some architectures.
+const (
+	tcsbrk = 0x2000741D
+	tcflsh = 0x2000741F
+)
@@ -4,6 +4,10 @@
 
 package modbus
 
+import (
+	"context"
+)
+
 type Client interface {
 	// Bit access
 
@@ -46,4 +50,69 @@ type Client interface {
 	//ReadFIFOQueue reads the contents of a First-In-First-Out (FIFO) queue
 	// of register in a remote device and returns FIFO value register.
 	ReadFIFOQueue(address uint16) (results []byte, err error)
+
+	// File record access
+
+	// ReadFileRecord reads the Length registers of each file record in a
+	// remote device and returns the records with their register values in Data.
+	ReadFileRecord(records []FileRecord) (results []FileRecord, err error)
+	// WriteFileRecord writes the register values in Data of each file record
+	// in a remote device and returns the echoed request.
+	WriteFileRecord(records []FileRecord) (results []byte, err error)
+
+	// Diagnostics
+
+	// ReadExceptionStatus reads the contents of the eight exception status
+	// outputs in a remote device.
+	ReadExceptionStatus() (results []byte, err error)
+	// Diagnostics performs the diagnostics sub-function on a remote device
+	// and returns its data. Force listen only mode is not answered.
+	Diagnostics(subFunction uint16, data []byte) (results []byte, err error)
+	// GetCommEventCounter returns the status word and the event count of
+	// the communication event counter of a remote device.
+	GetCommEventCounter() (results []byte, err error)
+	// GetCommEventLog returns the status word, event count, message count
+	// and the event bytes (most recent first) of a remote device.
+	GetCommEventLog() (results []byte, err error)
+	// ReportServerID returns the server ID, run indicator status and
+	// additional data of a remote device.
+	ReportServerID() (results []byte, err error)
+	// ReadDeviceIdentification reads the identification objects of a remote
+	// device, starting with objectID, and returns them keyed by object ID.
+	// All objects of the readDeviceIDCode category are read, following
+	// "more follows" responses; ReadDeviceIDSpecific reads only objectID.
+	ReadDeviceIdentification(readDeviceIDCode, objectID byte) (results map[byte][]byte, err error)
+
+	// Context variants
+
+	// The methods below do the same as the ones above. They return ctx.Err()
+	// when ctx is done before the response arrived, and send the request again
+	// after a failure as the RetryPolicy added by WithRetryPolicy allows.
+	ReadCoilsContext(ctx context.Context, address, quantity uint16) (results []byte, err error)
+	ReadDiscreteInputsContext(ctx context.Context, address, quantity uint16) (results []byte, err error)
+	WriteSingleCoilContext(ctx context.Context, address, value uint16) (results []byte, err error)
+	WriteMultipleCoilsContext(ctx context.Context, address, quantity uint16, value []byte) (results []byte, err error)
+	ReadInputRegistersContext(ctx context.Context, address, quantity uint16) (results []byte, err error)
+	ReadHoldingRegistersContext(ctx context.Context, address, quantity uint16) (results []byte, err error)
+	WriteSingleRegisterContext(ctx context.Context, address, value uint16) (results []byte, err error)
+	WriteMultipleRegistersContext(ctx context.Context, address, quantity uint16, value []byte) (results []byte, err error)
+	ReadWriteMultipleRegistersContext(ctx context.Context, readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) (results []byte, err error)
+	MaskWriteRegisterContext(ctx context.Context, address, andMask, orMask uint16) (results []byte, err error)
+	ReadFIFOQueueContext(ctx context.Context, address uint16) (results []byte, err error)
+	ReadFileRecordContext(ctx context.Context, records []FileRecord) (results []FileRecord, err error)
+	WriteFileRecordContext(ctx context.Context, records []FileRecord) (results []byte, err error)
+	ReadExceptionStatusContext(ctx context.Context) (results []byte, err error)
+	DiagnosticsContext(ctx context.Context, subFunction uint16, data []byte) (results []byte, err error)
+	GetCommEventCounterContext(ctx context.Context) (results []byte, err error)
+	GetCommEventLogContext(ctx context.Context) (results []byte, err error)
+	ReportServerIDContext(ctx context.Context) (results []byte, err error)
+	ReadDeviceIdentificationContext(ctx context.Context, readDeviceIDCode, objectID byte) (results map[byte][]byte, err error)
+}
+
+// FileRecord references a record of a file for the file record functions.
+type FileRecord struct {
+	File   uint16
+	Record uint16
+	Length uint16 // number of registers to read
+	Data   []byte // register values
 }
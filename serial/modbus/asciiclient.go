@@ -6,8 +6,10 @@ package modbus
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -161,6 +163,10 @@ type asciiSerialTransporter struct {
 }
 
 func (mb *asciiSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+func (mb *asciiSerialTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
 	mb.serialPort.mu.Lock()
 	defer mb.serialPort.mu.Unlock()
 
@@ -178,11 +184,21 @@ func (mb *asciiSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, e
 		return
 	}
 	// Get the response
+	if aduResponse, err = readASCIIResponse(contextReader{ctx, mb.port}); err != nil {
+		mb.serialPort.flushIfDone(ctx)
+		return
+	}
+	mb.serialPort.logf("modbus: received %q\n", aduResponse)
+	return
+}
+
+// readASCIIResponse reads an ASCII response from r until the end of the frame.
+func readASCIIResponse(r io.Reader) (aduResponse []byte, err error) {
 	var n int
 	var data [asciiMaxSize]byte
 	length := 0
 	for {
-		if n, err = mb.port.Read(data[length:]); err != nil {
+		if n, err = r.Read(data[length:]); err != nil {
 			return
 		}
 		length += n
@@ -197,7 +213,6 @@ func (mb *asciiSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, e
 		}
 	}
 	aduResponse = data[:length]
-	mb.serialPort.logf("modbus: received %q\n", aduResponse)
 	return
 }
 
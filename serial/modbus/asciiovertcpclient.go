@@ -0,0 +1,46 @@
+package modbus
+
+import (
+	"context"
+)
+
+// ASCIIOverTCPClientHandler implements Packager and Transporter interface for
+// ASCII frames tunneled over TCP.
+type ASCIIOverTCPClientHandler struct {
+	asciiPackager
+	asciiTCPTransporter
+}
+
+// NewASCIIOverTCPClientHandler allocates a new ASCIIOverTCPClientHandler.
+func NewASCIIOverTCPClientHandler(address string) *ASCIIOverTCPClientHandler {
+	h := &ASCIIOverTCPClientHandler{}
+	h.Address = address
+	h.Timeout = tcpTimeout
+	h.IdleTimeout = tcpIdleTimeout
+	h.ReconnectBackoff = tcpReconnectBackoff
+	h.MaxReconnectBackoff = tcpMaxReconnectBackoff
+	return h
+}
+
+// ASCIIOverTCPClient creates ASCII over TCP client with default handler and given connect string.
+func ASCIIOverTCPClient(address string) Client {
+	handler := NewASCIIOverTCPClientHandler(address)
+	return NewClient(handler)
+}
+
+// asciiTCPTransporter implements Transporter interface.
+type asciiTCPTransporter struct {
+	tcpTransporter
+}
+
+// Send sends the ASCII frame and reads the response.
+func (mb *asciiTCPTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+// SendContext sends the ASCII frame and reads the response until the end of the frame.
+func (mb *asciiTCPTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.send(ctx, aduRequest, func(aduRequest []byte) ([]byte, error) {
+		return readASCIIResponse(mb.conn)
+	})
+}
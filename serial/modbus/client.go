@@ -5,10 +5,15 @@
 package modbus
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
 )
 
+// fileRecordReferenceType is the reference type of the file record functions.
+const fileRecordReferenceType = 6
+
 // ClientHandler is the interface that groups the Packager and Transporter methods.
 type ClientHandler interface {
 	Packager
@@ -30,6 +35,84 @@ func NewClient2(packager Packager, transporter Transporter) Client {
 	return &client{packager: packager, transporter: transporter}
 }
 
+// The methods without context use the background context.
+
+func (mb *client) ReadCoils(address, quantity uint16) (results []byte, err error) {
+	return mb.ReadCoilsContext(context.Background(), address, quantity)
+}
+
+func (mb *client) ReadDiscreteInputs(address, quantity uint16) (results []byte, err error) {
+	return mb.ReadDiscreteInputsContext(context.Background(), address, quantity)
+}
+
+func (mb *client) ReadHoldingRegisters(address, quantity uint16) (results []byte, err error) {
+	return mb.ReadHoldingRegistersContext(context.Background(), address, quantity)
+}
+
+func (mb *client) ReadInputRegisters(address, quantity uint16) (results []byte, err error) {
+	return mb.ReadInputRegistersContext(context.Background(), address, quantity)
+}
+
+func (mb *client) WriteSingleCoil(address, value uint16) (results []byte, err error) {
+	return mb.WriteSingleCoilContext(context.Background(), address, value)
+}
+
+func (mb *client) WriteSingleRegister(address, value uint16) (results []byte, err error) {
+	return mb.WriteSingleRegisterContext(context.Background(), address, value)
+}
+
+func (mb *client) WriteMultipleCoils(address, quantity uint16, value []byte) (results []byte, err error) {
+	return mb.WriteMultipleCoilsContext(context.Background(), address, quantity, value)
+}
+
+func (mb *client) WriteMultipleRegisters(address, quantity uint16, value []byte) (results []byte, err error) {
+	return mb.WriteMultipleRegistersContext(context.Background(), address, quantity, value)
+}
+
+func (mb *client) MaskWriteRegister(address, andMask, orMask uint16) (results []byte, err error) {
+	return mb.MaskWriteRegisterContext(context.Background(), address, andMask, orMask)
+}
+
+func (mb *client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) (results []byte, err error) {
+	return mb.ReadWriteMultipleRegistersContext(context.Background(), readAddress, readQuantity, writeAddress, writeQuantity, value)
+}
+
+func (mb *client) ReadFIFOQueue(address uint16) (results []byte, err error) {
+	return mb.ReadFIFOQueueContext(context.Background(), address)
+}
+
+func (mb *client) ReadFileRecord(records []FileRecord) (results []FileRecord, err error) {
+	return mb.ReadFileRecordContext(context.Background(), records)
+}
+
+func (mb *client) WriteFileRecord(records []FileRecord) (results []byte, err error) {
+	return mb.WriteFileRecordContext(context.Background(), records)
+}
+
+func (mb *client) ReadExceptionStatus() (results []byte, err error) {
+	return mb.ReadExceptionStatusContext(context.Background())
+}
+
+func (mb *client) Diagnostics(subFunction uint16, data []byte) (results []byte, err error) {
+	return mb.DiagnosticsContext(context.Background(), subFunction, data)
+}
+
+func (mb *client) GetCommEventCounter() (results []byte, err error) {
+	return mb.GetCommEventCounterContext(context.Background())
+}
+
+func (mb *client) GetCommEventLog() (results []byte, err error) {
+	return mb.GetCommEventLogContext(context.Background())
+}
+
+func (mb *client) ReportServerID() (results []byte, err error) {
+	return mb.ReportServerIDContext(context.Background())
+}
+
+func (mb *client) ReadDeviceIdentification(readDeviceIDCode, objectID byte) (results map[byte][]byte, err error) {
+	return mb.ReadDeviceIdentificationContext(context.Background(), readDeviceIDCode, objectID)
+}
+
 // Request:
 //  Function code         : 1 byte (0x01)
 //  Starting address      : 2 bytes
@@ -38,7 +121,7 @@ func NewClient2(packager Packager, transporter Transporter) Client {
 //  Function code         : 1 byte (0x01)
 //  Byte count            : 1 byte
 //  Coil status           : N* bytes (=N or N+1)
-func (mb *client) ReadCoils(address, quantity uint16) (results []byte, err error) {
+func (mb *client) ReadCoilsContext(ctx context.Context, address, quantity uint16) (results []byte, err error) {
 	if quantity < 1 || quantity > 2000 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 2000)
 		return
@@ -47,7 +130,7 @@ func (mb *client) ReadCoils(address, quantity uint16) (results []byte, err error
 		FunctionCode: FuncCodeReadCoils,
 		Data:         dataBlock(address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -69,7 +152,7 @@ func (mb *client) ReadCoils(address, quantity uint16) (results []byte, err error
 //  Function code         : 1 byte (0x02)
 //  Byte count            : 1 byte
 //  Input status          : N* bytes (=N or N+1)
-func (mb *client) ReadDiscreteInputs(address, quantity uint16) (results []byte, err error) {
+func (mb *client) ReadDiscreteInputsContext(ctx context.Context, address, quantity uint16) (results []byte, err error) {
 	if quantity < 1 || quantity > 2000 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 2000)
 		return
@@ -78,7 +161,7 @@ func (mb *client) ReadDiscreteInputs(address, quantity uint16) (results []byte,
 		FunctionCode: FuncCodeReadDiscreteInputs,
 		Data:         dataBlock(address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -100,7 +183,7 @@ func (mb *client) ReadDiscreteInputs(address, quantity uint16) (results []byte,
 //  Function code         : 1 byte (0x03)
 //  Byte count            : 1 byte
 //  Register value        : Nx2 bytes
-func (mb *client) ReadHoldingRegisters(address, quantity uint16) (results []byte, err error) {
+func (mb *client) ReadHoldingRegistersContext(ctx context.Context, address, quantity uint16) (results []byte, err error) {
 	if quantity < 1 || quantity > 125 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 125)
 		return
@@ -109,7 +192,7 @@ func (mb *client) ReadHoldingRegisters(address, quantity uint16) (results []byte
 		FunctionCode: FuncCodeReadHoldingRegisters,
 		Data:         dataBlock(address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -131,7 +214,7 @@ func (mb *client) ReadHoldingRegisters(address, quantity uint16) (results []byte
 //  Function code         : 1 byte (0x04)
 //  Byte count            : 1 byte
 //  Input registers       : N bytes
-func (mb *client) ReadInputRegisters(address, quantity uint16) (results []byte, err error) {
+func (mb *client) ReadInputRegistersContext(ctx context.Context, address, quantity uint16) (results []byte, err error) {
 	if quantity < 1 || quantity > 125 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 125)
 		return
@@ -140,7 +223,7 @@ func (mb *client) ReadInputRegisters(address, quantity uint16) (results []byte,
 		FunctionCode: FuncCodeReadInputRegisters,
 		Data:         dataBlock(address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -162,7 +245,7 @@ func (mb *client) ReadInputRegisters(address, quantity uint16) (results []byte,
 //  Function code         : 1 byte (0x05)
 //  Output address        : 2 bytes
 //  Output value          : 2 bytes
-func (mb *client) WriteSingleCoil(address, value uint16) (results []byte, err error) {
+func (mb *client) WriteSingleCoilContext(ctx context.Context, address, value uint16) (results []byte, err error) {
 	// The requested ON/OFF state can only be 0xFF00 and 0x0000
 	if value != 0xFF00 && value != 0x0000 {
 		err = fmt.Errorf("modbus: state '%v' must be either 0xFF00 (ON) or 0x0000 (OFF)", value)
@@ -172,7 +255,7 @@ func (mb *client) WriteSingleCoil(address, value uint16) (results []byte, err er
 		FunctionCode: FuncCodeWriteSingleCoil,
 		Data:         dataBlock(address, value),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -203,12 +286,12 @@ func (mb *client) WriteSingleCoil(address, value uint16) (results []byte, err er
 //  Function code         : 1 byte (0x06)
 //  Register address      : 2 bytes
 //  Register value        : 2 bytes
-func (mb *client) WriteSingleRegister(address, value uint16) (results []byte, err error) {
+func (mb *client) WriteSingleRegisterContext(ctx context.Context, address, value uint16) (results []byte, err error) {
 	request := ProtocolDataUnit{
 		FunctionCode: FuncCodeWriteSingleRegister,
 		Data:         dataBlock(address, value),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -241,7 +324,7 @@ func (mb *client) WriteSingleRegister(address, value uint16) (results []byte, er
 //  Function code         : 1 byte (0x0F)
 //  Starting address      : 2 bytes
 //  Quantity of outputs   : 2 bytes
-func (mb *client) WriteMultipleCoils(address, quantity uint16, value []byte) (results []byte, err error) {
+func (mb *client) WriteMultipleCoilsContext(ctx context.Context, address, quantity uint16, value []byte) (results []byte, err error) {
 	if quantity < 1 || quantity > 1968 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 1968)
 		return
@@ -250,7 +333,7 @@ func (mb *client) WriteMultipleCoils(address, quantity uint16, value []byte) (re
 		FunctionCode: FuncCodeWriteMultipleCoils,
 		Data:         dataBlockSuffix(value, address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -283,7 +366,7 @@ func (mb *client) WriteMultipleCoils(address, quantity uint16, value []byte) (re
 //  Function code         : 1 byte (0x10)
 //  Starting address      : 2 bytes
 //  Quantity of registers : 2 bytes
-func (mb *client) WriteMultipleRegisters(address, quantity uint16, value []byte) (results []byte, err error) {
+func (mb *client) WriteMultipleRegistersContext(ctx context.Context, address, quantity uint16, value []byte) (results []byte, err error) {
 	if quantity < 1 || quantity > 123 {
 		err = fmt.Errorf("modbus: quantity '%v' must be between '%v' and '%v',", quantity, 1, 123)
 		return
@@ -292,7 +375,7 @@ func (mb *client) WriteMultipleRegisters(address, quantity uint16, value []byte)
 		FunctionCode: FuncCodeWriteMultipleRegisters,
 		Data:         dataBlockSuffix(value, address, quantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -325,12 +408,12 @@ func (mb *client) WriteMultipleRegisters(address, quantity uint16, value []byte)
 //  Reference address     : 2 bytes
 //  AND-mask              : 2 bytes
 //  OR-mask               : 2 bytes
-func (mb *client) MaskWriteRegister(address, andMask, orMask uint16) (results []byte, err error) {
+func (mb *client) MaskWriteRegisterContext(ctx context.Context, address, andMask, orMask uint16) (results []byte, err error) {
 	request := ProtocolDataUnit{
 		FunctionCode: FuncCodeMaskWriteRegister,
 		Data:         dataBlock(address, andMask, orMask),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -370,7 +453,7 @@ func (mb *client) MaskWriteRegister(address, andMask, orMask uint16) (results []
 //  Function code         : 1 byte (0x17)
 //  Byte count            : 1 byte
 //  Read registers value  : Nx2 bytes
-func (mb *client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) (results []byte, err error) {
+func (mb *client) ReadWriteMultipleRegistersContext(ctx context.Context, readAddress, readQuantity, writeAddress, writeQuantity uint16, value []byte) (results []byte, err error) {
 	if readQuantity < 1 || readQuantity > 125 {
 		err = fmt.Errorf("modbus: quantity to read '%v' must be between '%v' and '%v',", readQuantity, 1, 125)
 		return
@@ -383,7 +466,7 @@ func (mb *client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAdd
 		FunctionCode: FuncCodeReadWriteMultipleRegisters,
 		Data:         dataBlockSuffix(value, readAddress, readQuantity, writeAddress, writeQuantity),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -405,12 +488,12 @@ func (mb *client) ReadWriteMultipleRegisters(readAddress, readQuantity, writeAdd
 //  FIFO count            : 2 bytes
 //  FIFO count            : 2 bytes (<=31)
 //  FIFO value register   : Nx2 bytes
-func (mb *client) ReadFIFOQueue(address uint16) (results []byte, err error) {
+func (mb *client) ReadFIFOQueueContext(ctx context.Context, address uint16) (results []byte, err error) {
 	request := ProtocolDataUnit{
 		FunctionCode: FuncCodeReadFIFOQueue,
 		Data:         dataBlock(address),
 	}
-	response, err := mb.send(&request)
+	response, err := mb.send(ctx, &request)
 	if err != nil {
 		return
 	}
@@ -419,8 +502,8 @@ func (mb *client) ReadFIFOQueue(address uint16) (results []byte, err error) {
 		return
 	}
 	count := int(binary.BigEndian.Uint16(response.Data))
-	if count != (len(response.Data) - 1) {
-		err = fmt.Errorf("modbus: response data size '%v' does not match count '%v'", len(response.Data)-1, count)
+	if count != (len(response.Data) - 2) {
+		err = fmt.Errorf("modbus: response data size '%v' does not match count '%v'", len(response.Data)-2, count)
 		return
 	}
 	count = int(binary.BigEndian.Uint16(response.Data[2:]))
@@ -432,15 +515,320 @@ func (mb *client) ReadFIFOQueue(address uint16) (results []byte, err error) {
 	return
 }
 
+// Request:
+//  Function code         : 1 byte (0x14)
+//  Byte count            : 1 byte
+//  Sub-requests          : Nx7 bytes
+//   Reference type       : 1 byte (0x06)
+//   File number          : 2 bytes
+//   Record number        : 2 bytes
+//   Record length        : 2 bytes
+// Response:
+//  Function code         : 1 byte (0x14)
+//  Response data length  : 1 byte
+//  Sub-responses         : N* bytes
+//   File response length : 1 byte
+//   Reference type       : 1 byte (0x06)
+//   Record data          : Nx2 bytes
+func (mb *client) ReadFileRecordContext(ctx context.Context, records []FileRecord) (results []FileRecord, err error) {
+	if len(records) < 1 || len(records) > 35 {
+		err = fmt.Errorf("modbus: number of records '%v' must be between '%v' and '%v',", len(records), 1, 35)
+		return
+	}
+	data := []byte{byte(len(records) * 7)}
+	for _, record := range records {
+		if record.Record > 9999 {
+			err = fmt.Errorf("modbus: record number '%v' must not be greater than '%v'", record.Record, 9999)
+			return
+		}
+		data = append(data, fileRecordReferenceType)
+		data = append(data, dataBlock(record.File, record.Record, record.Length)...)
+	}
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeReadFileRecord,
+		Data:         data,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	count := int(response.Data[0])
+	if count != len(response.Data)-1 {
+		err = fmt.Errorf("modbus: response data size '%v' does not match count '%v'", len(response.Data)-1, count)
+		return
+	}
+	data = response.Data[1:]
+	for _, record := range records {
+		if len(data) < 2 || int(data[0]) < 1 || int(data[0]) >= len(data) {
+			err = fmt.Errorf("modbus: response data size '%v' is less than expected", len(data))
+			return
+		}
+		length := int(data[0])
+		if length != 1+2*int(record.Length) || data[1] != fileRecordReferenceType {
+			err = fmt.Errorf("modbus: file response length '%v' does not match expected '%v'", length, 1+2*int(record.Length))
+			return
+		}
+		record.Data = data[2 : 1+length]
+		results = append(results, record)
+		data = data[1+length:]
+	}
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x15)
+//  Request data length   : 1 byte
+//  Sub-requests          : N* bytes
+//   Reference type       : 1 byte (0x06)
+//   File number          : 2 bytes
+//   Record number        : 2 bytes
+//   Record length        : 2 bytes
+//   Record data          : Nx2 bytes
+// Response:
+//  Function code         : 1 byte (0x15)
+//  Echo of the request data
+func (mb *client) WriteFileRecordContext(ctx context.Context, records []FileRecord) (results []byte, err error) {
+	data := []byte{0}
+	for _, record := range records {
+		if record.Record > 9999 {
+			err = fmt.Errorf("modbus: record number '%v' must not be greater than '%v'", record.Record, 9999)
+			return
+		}
+		if len(record.Data) == 0 || len(record.Data)%2 != 0 {
+			err = fmt.Errorf("modbus: record data size '%v' must be a positive multiple of 2", len(record.Data))
+			return
+		}
+		data = append(data, fileRecordReferenceType)
+		data = append(data, dataBlock(record.File, record.Record, uint16(len(record.Data)/2))...)
+		data = append(data, record.Data...)
+	}
+	if len(data) < 2 || len(data)-1 > 251 {
+		err = fmt.Errorf("modbus: request data length '%v' must be between '%v' and '%v',", len(data)-1, 9, 251)
+		return
+	}
+	data[0] = byte(len(data) - 1)
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeWriteFileRecord,
+		Data:         data,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	if !bytes.Equal(response.Data, request.Data) {
+		err = fmt.Errorf("modbus: response data '%v' does not match request '%v'", response.Data, request.Data)
+		return
+	}
+	results = response.Data[1:]
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x07)
+// Response:
+//  Function code         : 1 byte (0x07)
+//  Output data           : 1 byte
+func (mb *client) ReadExceptionStatusContext(ctx context.Context) (results []byte, err error) {
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeReadExceptionStatus,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	// Fixed response length
+	if len(response.Data) != 1 {
+		err = fmt.Errorf("modbus: response data size '%v' does not match expected '%v'", len(response.Data), 1)
+		return
+	}
+	results = response.Data
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x08)
+//  Sub-function          : 2 bytes
+//  Data                  : N* bytes
+// Response:
+//  Function code         : 1 byte (0x08)
+//  Sub-function          : 2 bytes
+//  Data                  : N* bytes
+func (mb *client) DiagnosticsContext(ctx context.Context, subFunction uint16, data []byte) (results []byte, err error) {
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeDiagnostics,
+		Data:         append(dataBlock(subFunction), data...),
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	if len(response.Data) < 2 {
+		err = fmt.Errorf("modbus: response data size '%v' is less than expected '%v'", len(response.Data), 2)
+		return
+	}
+	respValue := binary.BigEndian.Uint16(response.Data)
+	if subFunction != respValue {
+		err = fmt.Errorf("modbus: response sub-function '%v' does not match request '%v'", respValue, subFunction)
+		return
+	}
+	results = response.Data[2:]
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x0B)
+// Response:
+//  Function code         : 1 byte (0x0B)
+//  Status                : 2 bytes
+//  Event count           : 2 bytes
+func (mb *client) GetCommEventCounterContext(ctx context.Context) (results []byte, err error) {
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeGetCommEventCounter,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	// Fixed response length
+	if len(response.Data) != 4 {
+		err = fmt.Errorf("modbus: response data size '%v' does not match expected '%v'", len(response.Data), 4)
+		return
+	}
+	results = response.Data
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x0C)
+// Response:
+//  Function code         : 1 byte (0x0C)
+//  Byte count            : 1 byte
+//  Status                : 2 bytes
+//  Event count           : 2 bytes
+//  Message count         : 2 bytes
+//  Events                : (N-6) bytes
+func (mb *client) GetCommEventLogContext(ctx context.Context) (results []byte, err error) {
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeGetCommEventLog,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	count := int(response.Data[0])
+	if count != len(response.Data)-1 || count < 6 {
+		err = fmt.Errorf("modbus: response data size '%v' does not match count '%v'", len(response.Data)-1, count)
+		return
+	}
+	results = response.Data[1:]
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x11)
+// Response:
+//  Function code         : 1 byte (0x11)
+//  Byte count            : 1 byte
+//  Server ID             : device specific
+//  Run indicator status  : 1 byte
+//  Additional data       : device specific
+func (mb *client) ReportServerIDContext(ctx context.Context) (results []byte, err error) {
+	request := ProtocolDataUnit{
+		FunctionCode: FuncCodeReportServerID,
+	}
+	response, err := mb.send(ctx, &request)
+	if err != nil {
+		return
+	}
+	count := int(response.Data[0])
+	if count != len(response.Data)-1 {
+		err = fmt.Errorf("modbus: response data size '%v' does not match count '%v'", len(response.Data)-1, count)
+		return
+	}
+	results = response.Data[1:]
+	return
+}
+
+// Request:
+//  Function code         : 1 byte (0x2B)
+//  MEI type              : 1 byte (0x0E)
+//  Read device ID code   : 1 byte
+//  Object ID             : 1 byte
+// Response:
+//  Function code         : 1 byte (0x2B)
+//  MEI type              : 1 byte (0x0E)
+//  Read device ID code   : 1 byte
+//  Conformity level      : 1 byte
+//  More follows          : 1 byte
+//  Next object ID        : 1 byte
+//  Number of objects     : 1 byte
+//  Objects               : N* (object ID, object length, object value)
+func (mb *client) ReadDeviceIdentificationContext(ctx context.Context, readDeviceIDCode, objectID byte) (results map[byte][]byte, err error) {
+	if readDeviceIDCode < ReadDeviceIDBasic || readDeviceIDCode > ReadDeviceIDSpecific {
+		err = fmt.Errorf("modbus: read device id code '%v' must be between '%v' and '%v',", readDeviceIDCode, ReadDeviceIDBasic, ReadDeviceIDSpecific)
+		return
+	}
+	results = map[byte][]byte{}
+	for {
+		request := ProtocolDataUnit{
+			FunctionCode: FuncCodeEncapsulatedInterface,
+			Data:         []byte{MEITypeReadDeviceIdentification, readDeviceIDCode, objectID},
+		}
+		var response *ProtocolDataUnit
+		if response, err = mb.send(ctx, &request); err != nil {
+			return nil, err
+		}
+		data := response.Data
+		if len(data) < 6 || data[0] != MEITypeReadDeviceIdentification {
+			return nil, fmt.Errorf("modbus: response data size '%v' is less than expected '%v'", len(data), 6)
+		}
+		moreFollows, nextObjectID, count := data[3], data[4], int(data[5])
+		data = data[6:]
+		for i := 0; i < count; i++ {
+			if len(data) < 2 || len(data) < 2+int(data[1]) {
+				return nil, fmt.Errorf("modbus: response object '%v' is truncated", i)
+			}
+			results[data[0]] = data[2 : 2+int(data[1])]
+			data = data[2+int(data[1]):]
+		}
+		if readDeviceIDCode == ReadDeviceIDSpecific || moreFollows == 0 {
+			return
+		}
+		if nextObjectID <= objectID {
+			return nil, fmt.Errorf("modbus: next object id '%v' does not follow '%v'", nextObjectID, objectID)
+		}
+		objectID = nextObjectID
+	}
+}
+
 // Helpers
 
-// send sends request and checks possible exception in the response.
-func (mb *client) send(request *ProtocolDataUnit) (response *ProtocolDataUnit, err error) {
+// send sends request, again as often as the RetryPolicy of ctx allows if it fails.
+func (mb *client) send(ctx context.Context, request *ProtocolDataUnit) (response *ProtocolDataUnit, err error) {
+	policy := retryPolicyFromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		response, err = mb.sendOnce(ctx, request)
+		if err == nil || ctx.Err() != nil || !policy.retry(attempt, request.FunctionCode, err) {
+			return
+		}
+		if err = sleepContext(ctx, policy.backoff(attempt)); err != nil {
+			return
+		}
+	}
+}
+
+// sendOnce sends request and checks possible exception in the response.
+func (mb *client) sendOnce(ctx context.Context, request *ProtocolDataUnit) (response *ProtocolDataUnit, err error) {
 	aduRequest, err := mb.packager.Encode(request)
 	if err != nil {
 		return
 	}
-	aduResponse, err := mb.transporter.Send(aduRequest)
+	var aduResponse []byte
+	if transporter, ok := mb.transporter.(ContextTransporter); ok {
+		aduResponse, err = transporter.SendContext(ctx, aduRequest)
+	} else if err = ctx.Err(); err == nil {
+		aduResponse, err = mb.transporter.Send(aduRequest)
+	}
 	if err != nil {
 		return
 	}
@@ -0,0 +1,138 @@
+package modbus
+
+import "sync"
+
+// Communication event log bytes of function 12.
+const (
+	eventReceive                 = 0x80
+	eventReceiveCommError        = 0x02
+	eventReceiveListenOnly       = 0x20
+	eventReceiveBroadcast        = 0x40
+	eventSend                    = 0x40
+	eventSendReadException       = 0x01
+	eventSendAbortException      = 0x02
+	eventSendBusyException       = 0x04
+	eventSendNAKException        = 0x08
+	eventSendListenOnly          = 0x20
+	eventEnteredListenOnly       = 0x04
+	eventCommunicationsRestarted = 0x00
+
+	eventLogSize = 64
+)
+
+// diagnostics holds the counters and the event log of the serial line
+// diagnostics functions 8, 11 and 12.
+type diagnostics struct {
+	mu               sync.Mutex
+	listenOnly       bool
+	register         uint16
+	eventCount       uint16
+	busMessages      uint16
+	busCommErrors    uint16
+	busExceptions    uint16
+	serverMessages   uint16
+	serverNoResponse uint16
+	serverNAKs       uint16
+	serverBusy       uint16
+	busOverruns      uint16
+	events           []byte // most recent first
+}
+
+// clear resets the counters and the diagnostic register.
+func (d *diagnostics) clear() {
+	d.register = 0
+	d.busMessages = 0
+	d.busCommErrors = 0
+	d.busExceptions = 0
+	d.serverMessages = 0
+	d.serverNoResponse = 0
+	d.serverNAKs = 0
+	d.serverBusy = 0
+	d.busOverruns = 0
+}
+
+// restart clears the counters and the event log and leaves the listen only mode.
+func (d *diagnostics) restart(clearLog bool) {
+	d.clear()
+	d.listenOnly = false
+	d.eventCount = 0
+	if clearLog {
+		d.events = nil
+	}
+	d.addEvent(eventCommunicationsRestarted)
+}
+
+func (d *diagnostics) addEvent(event byte) {
+	d.events = append([]byte{event}, d.events...)
+	if len(d.events) > eventLogSize {
+		d.events = d.events[:eventLogSize]
+	}
+}
+
+// commError counts a frame which was discarded because of a CRC or LRC error.
+func (d *diagnostics) commError() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.busMessages++
+	d.busCommErrors++
+	d.addEvent(eventReceive | eventReceiveCommError)
+}
+
+// received counts a request and returns true if the server is in listen only mode.
+func (d *diagnostics) received(served, broadcast bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.busMessages++
+	if !served {
+		return d.listenOnly
+	}
+	d.serverMessages++
+	event := byte(eventReceive)
+	if d.listenOnly {
+		event |= eventReceiveListenOnly
+	}
+	if broadcast {
+		event |= eventReceiveBroadcast
+	}
+	d.addEvent(event)
+	return d.listenOnly
+}
+
+// sent counts the response to a request. A nil exception means no response was sent.
+func (d *diagnostics) sent(function uint8, exception *Exception) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if exception == nil {
+		d.serverNoResponse++
+		return
+	}
+
+	event := byte(eventSend)
+	switch *exception {
+	case Success:
+		if function != FuncCodeGetCommEventCounter && function != FuncCodeGetCommEventLog {
+			d.eventCount++
+		}
+	case IllegalFunction, IllegalDataAddress, IllegalDataValue:
+		event |= eventSendReadException
+	case SlaveDeviceFailure:
+		event |= eventSendAbortException
+	case AcknowledgeSlave, SlaveDeviceBusy:
+		event |= eventSendBusyException
+	case NegativeAcknowledge:
+		event |= eventSendNAKException
+	}
+	if *exception != Success {
+		d.busExceptions++
+	}
+	if *exception == NegativeAcknowledge {
+		d.serverNAKs++
+	}
+	if *exception == SlaveDeviceBusy {
+		d.serverBusy++
+	}
+	if d.listenOnly {
+		event |= eventSendListenOnly
+	}
+	d.addEvent(event)
+}
@@ -1,6 +1,13 @@
 package modbus
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"serial"
+)
 
 // Framer is the interface that wraps Modbus frames.
 type Framer interface {
@@ -8,10 +15,43 @@ type Framer interface {
 	Copy() Framer
 	GetData() []byte
 	GetFunction() uint8
+	GetUnitID() uint8
 	SetException(exception *Exception)
 	SetData(data []byte)
 }
 
+// frameReader reads request frames from a connection or serial port.
+type frameReader interface {
+	ReadFrame() (Framer, error)
+}
+
+// errChecksum indicates that a frame was discarded because of a CRC or LRC error.
+// The reader resynchronizes with the following frame.
+var errChecksum = errors.New("modbus: frame checksum error")
+
+// readDeadliner is implemented by connections with read deadlines.
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// setFrameDeadline limits the time until a partial frame is completed. Readers
+// without deadlines (serial ports) time out after their configured timeout.
+func setFrameDeadline(r interface{}, partial bool, timeout time.Duration) {
+	if d, ok := r.(readDeadliner); ok {
+		deadline := time.Time{}
+		if partial {
+			deadline = time.Now().Add(timeout)
+		}
+		d.SetReadDeadline(deadline)
+	}
+}
+
+// isTimeout returns true if err is a read timeout of a connection or serial port.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, serial.ErrTimeout) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
 // GetException retunrns the Modbus exception or Success (indicating not exception).
 func GetException(frame Framer) (exception Exception) {
 	function := frame.GetFunction()
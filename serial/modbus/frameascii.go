@@ -0,0 +1,145 @@
+package modbus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// asciiFrameTimeout is the time after which a partial ASCII frame is discarded.
+const asciiFrameTimeout = time.Second
+
+// ASCIIFrame is the Modbus ASCII frame.
+type ASCIIFrame struct {
+	Address  uint8
+	Function uint8
+	Data     []byte
+	LRC      uint8
+}
+
+// NewASCIIFrame converts a packet (including the colon and CRLF) to a Modbus ASCII frame.
+func NewASCIIFrame(packet []byte) (*ASCIIFrame, error) {
+	// Check the packet length and boundaries.
+	pLen := len(packet)
+	if pLen < asciiMinSize+6 || pLen > asciiMaxSize || pLen%2 != 1 {
+		return nil, fmt.Errorf("ASCII Frame error: invalid packet length %v", pLen)
+	}
+	if string(packet[:len(asciiStart)]) != asciiStart || string(packet[pLen-len(asciiEnd):]) != asciiEnd {
+		return nil, fmt.Errorf("ASCII Frame error: packet is not delimited by '%v' and '%q'", asciiStart, asciiEnd)
+	}
+
+	decoded := make([]byte, hex.DecodedLen(pLen-len(asciiStart)-len(asciiEnd)))
+	if _, err := hex.Decode(decoded, packet[len(asciiStart):pLen-len(asciiEnd)]); err != nil {
+		return nil, fmt.Errorf("ASCII Frame error: %v", err)
+	}
+
+	// Check the LRC.
+	dLen := len(decoded)
+	var lrc lrc
+	lrc.reset().pushBytes(decoded[0 : dLen-1])
+	if lrc.value() != decoded[dLen-1] {
+		return nil, fmt.Errorf("ASCII Frame error: LRC (expected 0x%x, got 0x%x)", decoded[dLen-1], lrc.value())
+	}
+
+	frame := &ASCIIFrame{
+		Address:  decoded[0],
+		Function: decoded[1],
+		Data:     decoded[2 : dLen-1],
+		LRC:      decoded[dLen-1],
+	}
+
+	return frame, nil
+}
+
+// Copy the ASCIIFrame.
+func (frame *ASCIIFrame) Copy() Framer {
+	copy := *frame
+	return &copy
+}
+
+// Bytes returns the Modbus byte stream based on the ASCIIFrame fields
+func (frame *ASCIIFrame) Bytes() []byte {
+	var buf bytes.Buffer
+
+	// Calculate the LRC.
+	var lrc lrc
+	lrc.reset().pushByte(frame.Address).pushByte(frame.Function).pushBytes(frame.Data)
+
+	buf.WriteString(asciiStart)
+	writeHex(&buf, []byte{frame.Address, frame.Function})
+	writeHex(&buf, frame.Data)
+	writeHex(&buf, []byte{lrc.value()})
+	buf.WriteString(asciiEnd)
+
+	return buf.Bytes()
+}
+
+// GetFunction returns the Modbus function code.
+func (frame *ASCIIFrame) GetFunction() uint8 {
+	return frame.Function
+}
+
+// GetUnitID returns the address of the addressed unit (slave).
+func (frame *ASCIIFrame) GetUnitID() uint8 {
+	return frame.Address
+}
+
+// GetData returns the ASCIIFrame Data byte field.
+func (frame *ASCIIFrame) GetData() []byte {
+	return frame.Data
+}
+
+// SetData sets the ASCIIFrame Data byte field.
+func (frame *ASCIIFrame) SetData(data []byte) {
+	frame.Data = data
+}
+
+// SetException sets the Modbus exception code in the frame.
+func (frame *ASCIIFrame) SetException(exception *Exception) {
+	frame.Function = frame.Function | 0x80
+	frame.Data = []byte{byte(*exception)}
+}
+
+// asciiFrameReader reads ASCII request frames from a stream. Characters before the
+// colon are skipped, and a colon restarts the frame.
+type asciiFrameReader struct {
+	r   io.Reader
+	buf *bufio.Reader
+}
+
+func newASCIIFrameReader(r io.Reader) *asciiFrameReader {
+	return &asciiFrameReader{r: r, buf: bufio.NewReaderSize(r, asciiMaxSize)}
+}
+
+// ReadFrame reads the next frame. Frames with an LRC error are reported with errChecksum.
+func (f *asciiFrameReader) ReadFrame() (Framer, error) {
+	var packet []byte
+	for {
+		setFrameDeadline(f.r, len(packet) > 0, asciiFrameTimeout)
+		c, err := f.buf.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case c == asciiStart[0]:
+			packet = append(packet[:0], c)
+		case len(packet) == 0:
+			// Skip characters between frames.
+		case len(packet) >= asciiMaxSize:
+			packet = packet[:0]
+		default:
+			packet = append(packet, c)
+			if bytes.HasSuffix(packet, []byte(asciiEnd)) {
+				frame, err := NewASCIIFrame(packet)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %v", errChecksum, err)
+				}
+				return frame, nil
+			}
+		}
+	}
+}
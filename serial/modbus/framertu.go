@@ -3,8 +3,27 @@ package modbus
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
+	"time"
 )
 
+// rtuOverTCPFrameTimeout is the time after which a partial RTU frame on a TCP
+// connection is discarded.
+const rtuOverTCPFrameTimeout = 500 * time.Millisecond
+
+// rtuFrameGap returns the silent interval of 3.5 characters of 11 bits which
+// ends an RTU frame on a serial line, fixed at 1750us above 19200 baud.
+// See MODBUS over Serial Line - Specification and Implementation Guide (page 13).
+func rtuFrameGap(baudRate int) time.Duration {
+	if baudRate <= 0 {
+		baudRate = 19200 // the default of serial.Config
+	}
+	if baudRate > 19200 {
+		return 1750 * time.Microsecond
+	}
+	return time.Duration(38500000/baudRate) * time.Microsecond
+}
+
 // RTUFrame is the Modbus TCP frame.
 type RTUFrame struct {
 	Address  uint8
@@ -16,8 +35,8 @@ type RTUFrame struct {
 // NewRTUFrame converts a packet to a Modbus TCP frame.
 func NewRTUFrame(packet []byte) (*RTUFrame, error) {
 	// Check the that the packet length.
-	if len(packet) < 5 {
-		return nil, fmt.Errorf("RTU Frame error: packet less than 5 bytes: %v", packet)
+	if len(packet) < 4 {
+		return nil, fmt.Errorf("RTU Frame error: packet less than 4 bytes: %v", packet)
 	}
 
 	// Check the CRC.
@@ -67,6 +86,11 @@ func (frame *RTUFrame) GetFunction() uint8 {
 	return frame.Function
 }
 
+// GetUnitID returns the address of the addressed unit (slave).
+func (frame *RTUFrame) GetUnitID() uint8 {
+	return frame.Address
+}
+
 // GetData returns the RTUFrame Data byte field.
 func (frame *RTUFrame) GetData() []byte {
 	return frame.Data
@@ -83,3 +107,104 @@ func (frame *RTUFrame) SetException(exception *Exception) {
 	frame.Function = frame.Function | 0x80
 	frame.Data = []byte{byte(*exception)}
 }
+
+// rtuFrameReader reads RTU request frames from a stream. The length of a frame is
+// determined from its function code, so frames may be split across or coalesced in
+// reads. A silent interval (read timeout) discards a partial frame.
+type rtuFrameReader struct {
+	r       io.Reader
+	gap     time.Duration           // the silent interval which discards a partial frame
+	lengths *[256]requestLengthFunc // the request lengths of custom functions
+	buf     []byte
+	data    [rtuMaxSize]byte
+}
+
+func newRTUFrameReader(r io.Reader, gap time.Duration, lengths *[256]requestLengthFunc) *rtuFrameReader {
+	return &rtuFrameReader{r: r, gap: gap, lengths: lengths}
+}
+
+// ReadFrame reads the next frame. Frames with a CRC error are discarded byte by
+// byte to resynchronize, and reported with errChecksum.
+func (f *rtuFrameReader) ReadFrame() (Framer, error) {
+	for {
+		if length := requestLength(f.buf, f.lengths); len(f.buf) >= length {
+			frame, err := NewRTUFrame(f.buf[:length])
+			if err != nil {
+				f.buf = f.buf[1:]
+				return nil, fmt.Errorf("%w: %v", errChecksum, err)
+			}
+			f.buf = f.buf[length:]
+			return frame, nil
+		}
+
+		setFrameDeadline(f.r, len(f.buf) > 0, f.gap)
+		n, err := f.r.Read(f.data[:])
+		f.buf = append(f.buf, f.data[:n]...)
+		if err != nil {
+			if isTimeout(err) {
+				f.buf = nil
+			}
+			return nil, err
+		}
+	}
+}
+
+// requestLengthFunc returns the length of the data of a request, or the length
+// needed to determine it, given the data received so far.
+type requestLengthFunc func(data []byte) int
+
+// requestLength returns the length of the RTU request frame, or the length needed
+// to determine it, given its first bytes. The length of unknown functions is the
+// length of the bytes received so far.
+func requestLength(adu []byte, lengths *[256]requestLengthFunc) int {
+	if len(adu) < 2 {
+		return rtuMinSize
+	}
+	length := rtuMinSize
+	if lengths != nil && lengths[adu[1]] != nil {
+		return length + lengths[adu[1]](adu[2:])
+	}
+	switch adu[1] {
+	case FuncCodeReadCoils,
+		FuncCodeReadDiscreteInputs,
+		FuncCodeReadHoldingRegisters,
+		FuncCodeReadInputRegisters,
+		FuncCodeWriteSingleCoil,
+		FuncCodeWriteSingleRegister,
+		FuncCodeDiagnostics:
+		length += 4
+	case FuncCodeReadExceptionStatus,
+		FuncCodeGetCommEventCounter,
+		FuncCodeGetCommEventLog,
+		FuncCodeReportServerID:
+	case FuncCodeReadFIFOQueue:
+		length += 2
+	case FuncCodeEncapsulatedInterface:
+		length += 3
+	case FuncCodeMaskWriteRegister:
+		length += 6
+	case FuncCodeWriteMultipleCoils,
+		FuncCodeWriteMultipleRegisters:
+		// Address, quantity and byte count
+		if len(adu) < 7 {
+			return 7 + 2
+		}
+		length += 5 + int(adu[6])
+	case FuncCodeReadWriteMultipleRegisters:
+		if len(adu) < 11 {
+			return 11 + 2
+		}
+		length += 9 + int(adu[10])
+	case FuncCodeReadFileRecord,
+		FuncCodeWriteFileRecord:
+		if len(adu) < 3 {
+			return 3 + 2
+		}
+		length += 1 + int(adu[2])
+	default:
+		if len(adu) > length {
+			length = len(adu)
+		}
+	}
+	return length
+}
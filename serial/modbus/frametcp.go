@@ -3,6 +3,7 @@ package modbus
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 )
 
 // TCPFrame is the Modbus TCP frame.
@@ -18,8 +19,8 @@ type TCPFrame struct {
 // NewTCPFrame converts a packet to a Modbus TCP frame.
 func NewTCPFrame(packet []byte) (*TCPFrame, error) {
 	// Check if the packet is too short.
-	if len(packet) < 9 {
-		return nil, fmt.Errorf("TCP Frame error: packet less than 9 bytes")
+	if len(packet) < 8 {
+		return nil, fmt.Errorf("TCP Frame error: packet less than 8 bytes")
 	}
 
 	frame := &TCPFrame{
@@ -64,6 +65,11 @@ func (frame *TCPFrame) GetFunction() uint8 {
 	return frame.Function
 }
 
+// GetUnitID returns the unit identifier of the addressed unit (slave).
+func (frame *TCPFrame) GetUnitID() uint8 {
+	return frame.Device
+}
+
 // GetData returns the TCPFrame Data byte field.
 func (frame *TCPFrame) GetData() []byte {
 	return frame.Data
@@ -86,3 +92,25 @@ func (frame *TCPFrame) SetException(exception *Exception) {
 func (frame *TCPFrame) setLength() {
 	frame.Length = uint16(len(frame.Data) + 2)
 }
+
+// tcpFrameReader reads MBAP request frames from a stream.
+type tcpFrameReader struct {
+	r io.Reader
+}
+
+// ReadFrame reads the header and then the number of bytes given by its length field.
+func (f *tcpFrameReader) ReadFrame() (Framer, error) {
+	packet := make([]byte, 6, tcpMaxLength)
+	if _, err := io.ReadFull(f.r, packet); err != nil {
+		return nil, err
+	}
+	length := int(binary.BigEndian.Uint16(packet[4:6]))
+	if length < 2 || length > tcpMaxLength-6 {
+		return nil, fmt.Errorf("TCP Frame error: invalid length %v", length)
+	}
+	packet = packet[:6+length]
+	if _, err := io.ReadFull(f.r, packet[6:]); err != nil {
+		return nil, err
+	}
+	return NewTCPFrame(packet)
+}
@@ -2,139 +2,487 @@ package modbus
 
 import (
 	"encoding/binary"
+	"sort"
 )
 
-// ReadCoils function 1, reads coils from internal memory.
+// ReadCoils function 1, reads coils from the store of the unit.
 func ReadCoils(s *Server, frame Framer) ([]byte, *Exception) {
-	register, numRegs, endRegister := registerAddressAndNumber(frame)
-	if endRegister > 65535 {
-		return []byte{}, &IllegalDataAddress
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
 	}
-	dataSize := numRegs / 8
-	if (numRegs % 8) != 0 {
-		dataSize++
+	register, numRegs, _ := registerAddressAndNumber(frame)
+	if numRegs < 1 || numRegs > 2000 {
+		return []byte{}, &IllegalDataValue
 	}
-	data := make([]byte, 1+dataSize)
-	data[0] = byte(dataSize)
-	for i, value := range s.Coils[register:endRegister] {
-		if value != 0 {
-			shift := uint(i) % 8
-			data[1+i/8] |= byte(1 << shift)
-		}
+	values, err := s.Store(frame.GetUnitID()).ReadCoils(uint16(register), uint16(numRegs))
+	if err != nil {
+		return []byte{}, toException(err)
 	}
-	return data, &Success
+	return packBits(values), &Success
 }
 
-// ReadDiscreteInputs function 2, reads discrete inputs from internal memory.
+// ReadDiscreteInputs function 2, reads discrete inputs from the store of the unit.
 func ReadDiscreteInputs(s *Server, frame Framer) ([]byte, *Exception) {
-	register, numRegs, endRegister := registerAddressAndNumber(frame)
-	if endRegister > 65535 {
-		return []byte{}, &IllegalDataAddress
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
 	}
-	dataSize := numRegs / 8
-	if (numRegs % 8) != 0 {
-		dataSize++
+	register, numRegs, _ := registerAddressAndNumber(frame)
+	if numRegs < 1 || numRegs > 2000 {
+		return []byte{}, &IllegalDataValue
 	}
-	data := make([]byte, 1+dataSize)
-	data[0] = byte(dataSize)
-	for i, value := range s.DiscreteInputs[register:endRegister] {
-		if value != 0 {
-			shift := uint(i) % 8
-			data[1+i/8] |= byte(1 << shift)
-		}
+	values, err := s.Store(frame.GetUnitID()).ReadDiscreteInputs(uint16(register), uint16(numRegs))
+	if err != nil {
+		return []byte{}, toException(err)
 	}
-	return data, &Success
+	return packBits(values), &Success
 }
 
-// ReadHoldingRegisters function 3, reads holding registers from internal memory.
+// ReadHoldingRegisters function 3, reads holding registers from the store of the unit.
 func ReadHoldingRegisters(s *Server, frame Framer) ([]byte, *Exception) {
-	register, numRegs, endRegister := registerAddressAndNumber(frame)
-	if endRegister > 65536 {
-		return []byte{}, &IllegalDataAddress
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
+	}
+	register, numRegs, _ := registerAddressAndNumber(frame)
+	if numRegs < 1 || numRegs > 125 {
+		return []byte{}, &IllegalDataValue
+	}
+	values, err := s.Store(frame.GetUnitID()).ReadHoldingRegisters(uint16(register), uint16(numRegs))
+	if err != nil {
+		return []byte{}, toException(err)
 	}
-	return append([]byte{byte(numRegs * 2)}, Uint16ToBytes(s.HoldingRegisters[register:endRegister])...), &Success
+	return append([]byte{byte(numRegs * 2)}, Uint16ToBytes(values)...), &Success
 }
 
-// ReadInputRegisters function 4, reads input registers from internal memory.
+// ReadInputRegisters function 4, reads input registers from the store of the unit.
 func ReadInputRegisters(s *Server, frame Framer) ([]byte, *Exception) {
-	register, numRegs, endRegister := registerAddressAndNumber(frame)
-	if endRegister > 65536 {
-		return []byte{}, &IllegalDataAddress
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
+	}
+	register, numRegs, _ := registerAddressAndNumber(frame)
+	if numRegs < 1 || numRegs > 125 {
+		return []byte{}, &IllegalDataValue
 	}
-	return append([]byte{byte(numRegs * 2)}, Uint16ToBytes(s.InputRegisters[register:endRegister])...), &Success
+	values, err := s.Store(frame.GetUnitID()).ReadInputRegisters(uint16(register), uint16(numRegs))
+	if err != nil {
+		return []byte{}, toException(err)
+	}
+	return append([]byte{byte(numRegs * 2)}, Uint16ToBytes(values)...), &Success
 }
 
-// WriteSingleCoil function 5, write a coil to internal memory.
+// WriteSingleCoil function 5, writes a coil to the store of the unit.
 func WriteSingleCoil(s *Server, frame Framer) ([]byte, *Exception) {
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
+	}
 	register, value := registerAddressAndValue(frame)
 	// TODO Should we use 0 for off and 65,280 (FF00 in hexadecimal) for on?
+	coil := byte(0)
 	if value != 0 {
-		value = 1
+		coil = 1
+	}
+	if err := s.Store(frame.GetUnitID()).WriteCoils(uint16(register), []byte{coil}); err != nil {
+		return []byte{}, toException(err)
 	}
-	s.Coils[register] = byte(value)
 	return frame.GetData()[0:4], &Success
 }
 
-// WriteHoldingRegister function 6, write a holding register to internal memory.
+// WriteHoldingRegister function 6, writes a holding register to the store of the unit.
 func WriteHoldingRegister(s *Server, frame Framer) ([]byte, *Exception) {
+	if len(frame.GetData()) < 4 {
+		return []byte{}, &IllegalDataValue
+	}
 	register, value := registerAddressAndValue(frame)
-	s.HoldingRegisters[register] = value
+	if err := s.Store(frame.GetUnitID()).WriteHoldingRegisters(uint16(register), []uint16{value}); err != nil {
+		return []byte{}, toException(err)
+	}
 	return frame.GetData()[0:4], &Success
 }
 
-// WriteMultipleCoils function 15, writes holding registers to internal memory.
+// WriteMultipleCoils function 15, writes coils to the store of the unit.
 func WriteMultipleCoils(s *Server, frame Framer) ([]byte, *Exception) {
-	register, numRegs, endRegister := registerAddressAndNumber(frame)
+	if len(frame.GetData()) < 5 {
+		return []byte{}, &IllegalDataValue
+	}
+	register, numRegs, _ := registerAddressAndNumber(frame)
 	valueBytes := frame.GetData()[5:]
 
-	if endRegister > 65536 {
-		return []byte{}, &IllegalDataAddress
+	if numRegs < 1 || numRegs > 1968 || len(valueBytes) != (numRegs+7)/8 {
+		return []byte{}, &IllegalDataValue
 	}
 
-	// TODO This is not correct, bits and bytes do not always align
-	//if len(valueBytes)/2 != numRegs {
-	//	return []byte{}, &IllegalDataAddress
-	//}
-
-	bitCount := 0
-	for i, value := range valueBytes {
-		for bitPos := uint(0); bitPos < 8; bitPos++ {
-			s.Coils[register+(i*8)+int(bitPos)] = bitAtPosition(value, bitPos)
-			bitCount++
-			if bitCount >= numRegs {
-				break
-			}
-		}
-		if bitCount >= numRegs {
-			break
-		}
+	values := make([]byte, numRegs)
+	for i := range values {
+		values[i] = bitAtPosition(valueBytes[i/8], uint(i%8))
+	}
+	if err := s.Store(frame.GetUnitID()).WriteCoils(uint16(register), values); err != nil {
+		return []byte{}, toException(err)
 	}
 
 	return frame.GetData()[0:4], &Success
 }
 
-// WriteHoldingRegisters function 16, writes holding registers to internal memory.
+// WriteHoldingRegisters function 16, writes holding registers to the store of the unit.
 func WriteHoldingRegisters(s *Server, frame Framer) ([]byte, *Exception) {
+	if len(frame.GetData()) < 5 {
+		return []byte{}, &IllegalDataValue
+	}
 	register, numRegs, _ := registerAddressAndNumber(frame)
 	valueBytes := frame.GetData()[5:]
-	var exception *Exception
-	var data []byte
 
-	if len(valueBytes)/2 != numRegs {
-		exception = &IllegalDataAddress
+	if numRegs < 1 || numRegs > 123 || len(valueBytes)/2 != numRegs {
+		return []byte{}, &IllegalDataValue
 	}
 
-	// Copy data to memroy
 	values := BytesToUint16(valueBytes)
-	valuesUpdated := copy(s.HoldingRegisters[register:], values)
-	if valuesUpdated == numRegs {
-		exception = &Success
-		data = frame.GetData()[0:4]
-	} else {
-		exception = &IllegalDataAddress
+	if err := s.Store(frame.GetUnitID()).WriteHoldingRegisters(uint16(register), values); err != nil {
+		return []byte{}, toException(err)
+	}
+
+	return frame.GetData()[0:4], &Success
+}
+
+// ReadExceptionStatus function 7, reads the exception status outputs of the server.
+func ReadExceptionStatus(s *Server, frame Framer) ([]byte, *Exception) {
+	if s.ExceptionStatus == nil {
+		return []byte{}, &IllegalFunction
+	}
+	return []byte{s.ExceptionStatus()}, &Success
+}
+
+// Diagnostics function 8, performs the serial line diagnostics sub-functions.
+func Diagnostics(s *Server, frame Framer) ([]byte, *Exception) {
+	data := frame.GetData()
+	if len(data) < 2 {
+		return []byte{}, &IllegalDataValue
+	}
+	subFunction := binary.BigEndian.Uint16(data[0:2])
+
+	d := &s.diagnostics
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var counter uint16
+	switch subFunction {
+	case DiagReturnQueryData:
+		return data, &Success
+	case DiagRestartCommunications:
+		if len(data) != 4 || (data[2] != 0x00 && data[2] != 0xFF) || data[3] != 0x00 {
+			return []byte{}, &IllegalDataValue
+		}
+		d.restart(data[2] == 0xFF)
+		return data, &Success
+	case DiagForceListenOnlyMode:
+		d.listenOnly = true
+		d.addEvent(eventEnteredListenOnly)
+		return data, &Success
+	case DiagClearCounters:
+		d.clear()
+		return data, &Success
+	case DiagClearOverrunCounter:
+		d.busOverruns = 0
+		return data, &Success
+	case DiagReturnDiagnosticRegister:
+		counter = d.register
+	case DiagReturnBusMessageCount:
+		counter = d.busMessages
+	case DiagReturnBusCommErrorCount:
+		counter = d.busCommErrors
+	case DiagReturnBusExceptionErrorCount:
+		counter = d.busExceptions
+	case DiagReturnServerMessageCount:
+		counter = d.serverMessages
+	case DiagReturnServerNoResponseCount:
+		counter = d.serverNoResponse
+	case DiagReturnServerNAKCount:
+		counter = d.serverNAKs
+	case DiagReturnServerBusyCount:
+		counter = d.serverBusy
+	case DiagReturnBusCharacterOverrunCount:
+		counter = d.busOverruns
+	default:
+		return []byte{}, &IllegalFunction
+	}
+	return Uint16ToBytes([]uint16{subFunction, counter}), &Success
+}
+
+// GetCommEventCounter function 11, returns the status word and the event count.
+func GetCommEventCounter(s *Server, frame Framer) ([]byte, *Exception) {
+	s.diagnostics.mu.Lock()
+	defer s.diagnostics.mu.Unlock()
+	return Uint16ToBytes([]uint16{0, s.diagnostics.eventCount}), &Success
+}
+
+// GetCommEventLog function 12, returns the status word, the event and message
+// counts and the communication event log.
+func GetCommEventLog(s *Server, frame Framer) ([]byte, *Exception) {
+	d := &s.diagnostics
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	data := append([]byte{byte(6 + len(d.events))}, Uint16ToBytes([]uint16{0, d.eventCount, d.busMessages})...)
+	return append(data, d.events...), &Success
+}
+
+// ReportServerID function 17, returns the server ID and the run indicator status.
+func ReportServerID(s *Server, frame Framer) ([]byte, *Exception) {
+	data := append([]byte{byte(len(s.ServerID) + 1)}, s.ServerID...)
+	return append(data, 0xFF), &Success
+}
+
+// ReadFileRecord function 20, reads file records from the store of the unit.
+func ReadFileRecord(s *Server, frame Framer) ([]byte, *Exception) {
+	store, ok := s.Store(frame.GetUnitID()).(FileRecordStore)
+	if !ok {
+		return []byte{}, &IllegalFunction
+	}
+	data := frame.GetData()
+	if len(data) < 8 || int(data[0]) != len(data)-1 || data[0]%7 != 0 || data[0] > 0xF5 {
+		return []byte{}, &IllegalDataValue
+	}
+
+	response := []byte{0}
+	for data = data[1:]; len(data) > 0; data = data[7:] {
+		if data[0] != fileRecordReferenceType {
+			return []byte{}, &IllegalDataValue
+		}
+		file := binary.BigEndian.Uint16(data[1:3])
+		record := binary.BigEndian.Uint16(data[3:5])
+		length := binary.BigEndian.Uint16(data[5:7])
+		if file == 0 || record > 9999 || int(record)+int(length) > 10000 {
+			return []byte{}, &IllegalDataAddress
+		}
+		if len(response)+2+2*int(length) > 252 {
+			return []byte{}, &IllegalDataValue
+		}
+		values, err := store.ReadFileRecord(file, record, length)
+		if err != nil {
+			return []byte{}, toException(err)
+		}
+		response = append(response, byte(1+2*len(values)), fileRecordReferenceType)
+		response = append(response, Uint16ToBytes(values)...)
+	}
+	response[0] = byte(len(response) - 1)
+	return response, &Success
+}
+
+// WriteFileRecord function 21, writes file records to the store of the unit.
+func WriteFileRecord(s *Server, frame Framer) ([]byte, *Exception) {
+	store, ok := s.Store(frame.GetUnitID()).(FileRecordStore)
+	if !ok {
+		return []byte{}, &IllegalFunction
+	}
+	data := frame.GetData()
+	if len(data) < 10 || int(data[0]) != len(data)-1 || data[0] > 0xFB {
+		return []byte{}, &IllegalDataValue
+	}
+
+	for request := data[1:]; len(request) > 0; {
+		if len(request) < 9 || request[0] != fileRecordReferenceType {
+			return []byte{}, &IllegalDataValue
+		}
+		file := binary.BigEndian.Uint16(request[1:3])
+		record := binary.BigEndian.Uint16(request[3:5])
+		length := int(binary.BigEndian.Uint16(request[5:7]))
+		if length < 1 || len(request) < 7+2*length {
+			return []byte{}, &IllegalDataValue
+		}
+		if file == 0 || record > 9999 || int(record)+length > 10000 {
+			return []byte{}, &IllegalDataAddress
+		}
+		if err := store.WriteFileRecord(file, record, BytesToUint16(request[7:7+2*length])); err != nil {
+			return []byte{}, toException(err)
+		}
+		request = request[7+2*length:]
+	}
+	return data, &Success
+}
+
+// MaskWriteRegister function 22, modifies a holding register in the store of the
+// unit with an AND mask and an OR mask.
+func MaskWriteRegister(s *Server, frame Framer) ([]byte, *Exception) {
+	data := frame.GetData()
+	if len(data) < 6 {
+		return []byte{}, &IllegalDataValue
+	}
+	register := binary.BigEndian.Uint16(data[0:2])
+	andMask := binary.BigEndian.Uint16(data[2:4])
+	orMask := binary.BigEndian.Uint16(data[4:6])
+
+	store := s.Store(frame.GetUnitID())
+	if masker, ok := store.(MaskWriter); ok {
+		if err := masker.MaskWriteHoldingRegister(register, andMask, orMask); err != nil {
+			return []byte{}, toException(err)
+		}
+		return data[0:6], &Success
+	}
+
+	values, err := store.ReadHoldingRegisters(register, 1)
+	if err != nil {
+		return []byte{}, toException(err)
+	}
+	if err := store.WriteHoldingRegisters(register, []uint16{maskRegister(values[0], andMask, orMask)}); err != nil {
+		return []byte{}, toException(err)
+	}
+	return data[0:6], &Success
+}
+
+// maskRegister applies the masks of function 22 to the value of a register.
+func maskRegister(value, andMask, orMask uint16) uint16 {
+	return (value & andMask) | (orMask &^ andMask)
+}
+
+// ReadWriteMultipleRegisters function 23, writes and then reads holding registers
+// in the store of the unit.
+func ReadWriteMultipleRegisters(s *Server, frame Framer) ([]byte, *Exception) {
+	data := frame.GetData()
+	if len(data) < 9 {
+		return []byte{}, &IllegalDataValue
 	}
+	readRegister := binary.BigEndian.Uint16(data[0:2])
+	readNumRegs := binary.BigEndian.Uint16(data[2:4])
+	writeRegister := binary.BigEndian.Uint16(data[4:6])
+	writeNumRegs := binary.BigEndian.Uint16(data[6:8])
+	valueBytes := data[9:]
 
-	return data, exception
+	if readNumRegs < 1 || readNumRegs > 125 || writeNumRegs < 1 || writeNumRegs > 121 ||
+		int(data[8]) != 2*int(writeNumRegs) || len(valueBytes) != int(data[8]) {
+		return []byte{}, &IllegalDataValue
+	}
+
+	store := s.Store(frame.GetUnitID())
+	if err := store.WriteHoldingRegisters(writeRegister, BytesToUint16(valueBytes)); err != nil {
+		return []byte{}, toException(err)
+	}
+	values, err := store.ReadHoldingRegisters(readRegister, readNumRegs)
+	if err != nil {
+		return []byte{}, toException(err)
+	}
+	return append([]byte{byte(readNumRegs * 2)}, Uint16ToBytes(values)...), &Success
+}
+
+// ReadFIFOQueue function 24, reads a FIFO queue of holding registers from the store
+// of the unit. The register at the pointer address holds the number of queued
+// registers, which follow it.
+func ReadFIFOQueue(s *Server, frame Framer) ([]byte, *Exception) {
+	data := frame.GetData()
+	if len(data) < 2 {
+		return []byte{}, &IllegalDataValue
+	}
+	register := binary.BigEndian.Uint16(data[0:2])
+
+	store := s.Store(frame.GetUnitID())
+	count, err := store.ReadHoldingRegisters(register, 1)
+	if err != nil {
+		return []byte{}, toException(err)
+	}
+	if count[0] > 31 {
+		return []byte{}, &IllegalDataValue
+	}
+	values := []uint16{}
+	if count[0] > 0 {
+		if register == 0xFFFF {
+			return []byte{}, &IllegalDataAddress
+		}
+		if values, err = store.ReadHoldingRegisters(register+1, count[0]); err != nil {
+			return []byte{}, toException(err)
+		}
+	}
+	return Uint16ToBytes(append([]uint16{2 + 2*count[0], count[0]}, values...)), &Success
+}
+
+// EncapsulatedInterface function 43, dispatches the MEI types. Only read device
+// identification (MEI type 14) is supported.
+func EncapsulatedInterface(s *Server, frame Framer) ([]byte, *Exception) {
+	data := frame.GetData()
+	if len(data) < 1 || data[0] != MEITypeReadDeviceIdentification {
+		return []byte{}, &IllegalFunction
+	}
+	return ReadDeviceIdentification(s, frame)
+}
+
+// ReadDeviceIdentification function 43/14, reads the device identification objects
+// of the server.
+func ReadDeviceIdentification(s *Server, frame Framer) ([]byte, *Exception) {
+	if len(s.DeviceIdentification) == 0 {
+		return []byte{}, &IllegalFunction
+	}
+	data := frame.GetData()
+	if len(data) < 3 {
+		return []byte{}, &IllegalDataValue
+	}
+	code, objectID := data[1], data[2]
+
+	ids := make([]int, 0, len(s.DeviceIdentification))
+	conformity := byte(ReadDeviceIDBasic)
+	for id := range s.DeviceIdentification {
+		ids = append(ids, int(id))
+		if level := deviceIDCategory(id); level > conformity {
+			conformity = level
+		}
+	}
+	sort.Ints(ids)
+
+	response := []byte{MEITypeReadDeviceIdentification, code, conformity | 0x80, 0x00, 0x00, 0x00}
+	switch code {
+	case ReadDeviceIDSpecific:
+		value, ok := s.DeviceIdentification[objectID]
+		if !ok {
+			return []byte{}, &IllegalDataAddress
+		}
+		response[5] = 1
+		return append(response, appendDeviceObject(nil, objectID, value)...), &Success
+	case ReadDeviceIDBasic, ReadDeviceIDRegular, ReadDeviceIDExtended:
+	default:
+		return []byte{}, &IllegalDataValue
+	}
+
+	if _, ok := s.DeviceIdentification[objectID]; !ok || deviceIDCategory(objectID) > code {
+		objectID = 0 // Restart at the first object.
+	}
+	for _, id := range ids {
+		if id < int(objectID) || deviceIDCategory(uint8(id)) > code {
+			continue
+		}
+		object := appendDeviceObject(nil, uint8(id), s.DeviceIdentification[uint8(id)])
+		if len(response)+len(object) > 252 {
+			response[3] = 0xFF // More follows.
+			response[4] = uint8(id)
+			break
+		}
+		response = append(response, object...)
+		response[5]++
+	}
+	return response, &Success
+}
+
+// deviceIDCategory returns the read device ID code of the category of an object.
+func deviceIDCategory(id uint8) byte {
+	switch {
+	case id <= 0x02:
+		return ReadDeviceIDBasic
+	case id <= 0x7F:
+		return ReadDeviceIDRegular
+	}
+	return ReadDeviceIDExtended
+}
+
+func appendDeviceObject(data []byte, id uint8, value string) []byte {
+	if len(value) > 245 {
+		value = value[:245]
+	}
+	data = append(data, id, byte(len(value)))
+	return append(data, value...)
+}
+
+func isRestartCommunications(frame Framer) bool {
+	data := frame.GetData()
+	return frame.GetFunction() == FuncCodeDiagnostics && len(data) >= 2 &&
+		binary.BigEndian.Uint16(data[0:2]) == DiagRestartCommunications
+}
+
+func isForceListenOnly(frame Framer) bool {
+	data := frame.GetData()
+	return frame.GetFunction() == FuncCodeDiagnostics && len(data) >= 2 &&
+		binary.BigEndian.Uint16(data[0:2]) == DiagForceListenOnlyMode
 }
 
 // BytesToUint16 converts a big endian array of bytes to an array of unit16s
@@ -157,6 +505,19 @@ func Uint16ToBytes(values []uint16) []byte {
 	return bytes
 }
 
+// packBits packs one byte per bit into the byte count and bytes of a response.
+func packBits(values []byte) []byte {
+	dataSize := (len(values) + 7) / 8
+	data := make([]byte, 1+dataSize)
+	data[0] = byte(dataSize)
+	for i, value := range values {
+		if value != 0 {
+			data[1+i/8] |= byte(1 << (uint(i) % 8))
+		}
+	}
+	return data
+}
+
 func bitAtPosition(value uint8, pos uint) uint8 {
 	return (value >> pos) & 0x01
 }
@@ -0,0 +1,350 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// registerOnlyStore hides the optional interfaces of the store it wraps.
+type registerOnlyStore struct {
+	RegisterStore
+}
+
+func TestReadExceptionStatus(t *testing.T) {
+	s, address := newTCPServer(t)
+	client, _ := newTCPClient(t, address, 1)
+
+	if _, err := client.ReadExceptionStatus(); exceptionCode(err) != ExceptionCodeIllegalFunction {
+		t.Errorf("without ExceptionStatus: %v, want illegal function", err)
+	}
+	s.ExceptionStatus = func() byte { return 0x5A }
+	results, err := client.ReadExceptionStatus()
+	if err != nil || !bytes.Equal(results, []byte{0x5A}) {
+		t.Errorf("ReadExceptionStatus = %x, %v, want 5a", results, err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	_, address := newTCPServer(t)
+	client, _ := newTCPClient(t, address, 1)
+
+	tests := []struct {
+		name      string
+		request   func() ([]byte, error)
+		want      []byte
+		exception byte
+	}{
+		{"return query data", func() ([]byte, error) { return client.Diagnostics(DiagReturnQueryData, []byte{0xA5, 0x5A}) }, []byte{0xA5, 0x5A}, 0},
+		{"clear counters", func() ([]byte, error) { return client.Diagnostics(DiagClearCounters, []byte{0, 0}) }, []byte{0, 0}, 0},
+		{"read", func() ([]byte, error) { return client.ReadHoldingRegisters(0, 1) }, []byte{0, 0}, 0},
+		{"read beyond the table", func() ([]byte, error) { return client.ReadHoldingRegisters(65535, 2) }, nil, ExceptionCodeIllegalDataAddress},
+		// The counters include the request which reads them.
+		{"server message count", func() ([]byte, error) { return client.Diagnostics(DiagReturnServerMessageCount, []byte{0, 0}) }, []byte{0, 3}, 0},
+		{"bus message count", func() ([]byte, error) { return client.Diagnostics(DiagReturnBusMessageCount, []byte{0, 0}) }, []byte{0, 4}, 0},
+		{"bus exception count", func() ([]byte, error) { return client.Diagnostics(DiagReturnBusExceptionErrorCount, []byte{0, 0}) }, []byte{0, 1}, 0},
+		{"no response count", func() ([]byte, error) { return client.Diagnostics(DiagReturnServerNoResponseCount, []byte{0, 0}) }, []byte{0, 0}, 0},
+		{"restart with invalid data", func() ([]byte, error) { return client.Diagnostics(DiagRestartCommunications, []byte{0x12, 0}) }, nil, ExceptionCodeIllegalDataValue},
+		{"unknown sub-function", func() ([]byte, error) { return client.Diagnostics(0x55, []byte{0, 0}) }, nil, ExceptionCodeIllegalFunction},
+	}
+	for _, test := range tests {
+		results, err := test.request()
+		if code := exceptionCode(err); code != test.exception || code == 0 && err != nil {
+			t.Errorf("%s: error %v, want exception %d", test.name, err, test.exception)
+			continue
+		}
+		if err == nil && !bytes.Equal(results, test.want) {
+			t.Errorf("%s: results %x, want %x", test.name, results, test.want)
+		}
+	}
+}
+
+func TestDiagnosticsListenOnly(t *testing.T) {
+	_, address := newTCPServer(t)
+	client, handler := newTCPClient(t, address, 1)
+	handler.Timeout = 200 * time.Millisecond
+
+	// Force listen only mode is not answered, and neither are the requests
+	// which follow it until communications are restarted.
+	if _, err := client.Diagnostics(DiagForceListenOnlyMode, []byte{0, 0}); err == nil {
+		t.Fatal("force listen only mode was answered")
+	}
+	if _, err := client.ReadHoldingRegisters(0, 1); err == nil {
+		t.Fatal("request in listen only mode was answered")
+	}
+	handler.Timeout = 5 * time.Second
+	if _, err := client.Diagnostics(DiagRestartCommunications, []byte{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReadHoldingRegisters(0, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommEventLog(t *testing.T) {
+	_, address := newTCPServer(t)
+	client, _ := newTCPClient(t, address, 1)
+
+	results, err := client.GetCommEventCounter()
+	if err != nil || !bytes.Equal(results, []byte{0, 0, 0, 0}) {
+		t.Fatalf("GetCommEventCounter = %x, %v, want 00000000", results, err)
+	}
+	if _, err := client.WriteSingleRegister(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReadHoldingRegisters(65535, 2); exceptionCode(err) != ExceptionCodeIllegalDataAddress {
+		t.Fatalf("read beyond the table: %v", err)
+	}
+	// Only successful requests other than functions 11 and 12 are counted.
+	results, err = client.GetCommEventCounter()
+	if err != nil || !bytes.Equal(results, []byte{0, 0, 0, 1}) {
+		t.Fatalf("GetCommEventCounter = %x, %v, want 00000001", results, err)
+	}
+
+	results, err = client.GetCommEventLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		0, 0, // status
+		0, 1, // event count
+		0, 5, // message count
+		eventReceive,
+		eventSend, eventReceive,
+		eventSend | eventSendReadException, eventReceive,
+		eventSend, eventReceive,
+		eventSend, eventReceive,
+	}
+	if !bytes.Equal(results, want) {
+		t.Errorf("GetCommEventLog = %x, want %x", results, want)
+	}
+}
+
+func TestReportServerID(t *testing.T) {
+	s, address := newTCPServer(t)
+	s.ServerID = []byte("meter")
+	client, _ := newTCPClient(t, address, 1)
+
+	results, err := client.ReportServerID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte("meter\xFF"); !bytes.Equal(results, want) {
+		t.Errorf("ReportServerID = %q, want %q", results, want)
+	}
+}
+
+func TestFileRecords(t *testing.T) {
+	s, address := newTCPServer(t)
+	s.SetStore(2, registerOnlyStore{NewMemoryStore()})
+	client, handler := newTCPClient(t, address, 1)
+
+	written := []FileRecord{
+		{File: 1, Record: 2, Data: []byte{0x00, 0x01, 0x00, 0x02}},
+		{File: 3, Record: 9998, Data: []byte{0xAB, 0xCD, 0xEF, 0x01}},
+	}
+	if _, err := client.WriteFileRecord(written); err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.ReadFileRecord([]FileRecord{
+		{File: 1, Record: 2, Length: 2},
+		{File: 3, Record: 9999, Length: 1},
+		{File: 1, Record: 0, Length: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{{0x00, 0x01, 0x00, 0x02}, {0xEF, 0x01}, {0x00, 0x00}}
+	for i, record := range results {
+		if !bytes.Equal(record.Data, want[i]) {
+			t.Errorf("record %d: %x, want %x", i, record.Data, want[i])
+		}
+	}
+
+	tests := []struct {
+		name      string
+		unit      byte
+		records   []FileRecord
+		exception byte
+	}{
+		{"file which was never written", 1, []FileRecord{{File: 9, Record: 0, Length: 1}}, ExceptionCodeIllegalDataAddress},
+		{"file 0", 1, []FileRecord{{File: 0, Record: 0, Length: 1}}, ExceptionCodeIllegalDataAddress},
+		{"beyond the last record", 1, []FileRecord{{File: 1, Record: 9999, Length: 2}}, ExceptionCodeIllegalDataAddress},
+		{"store without files", 2, []FileRecord{{File: 1, Record: 0, Length: 1}}, ExceptionCodeIllegalFunction},
+	}
+	for _, test := range tests {
+		handler.SlaveId = test.unit
+		if _, err := client.ReadFileRecord(test.records); exceptionCode(err) != test.exception {
+			t.Errorf("%s: %v, want exception %d", test.name, err, test.exception)
+		}
+	}
+	handler.SlaveId = 2
+	if _, err := client.WriteFileRecord(written); exceptionCode(err) != ExceptionCodeIllegalFunction {
+		t.Errorf("write to store without files: %v, want illegal function", err)
+	}
+}
+
+func TestMaskWriteRegister(t *testing.T) {
+	s, address := newTCPServer(t)
+	masker := NewMemoryStore()
+	plain := NewMemoryStore()
+	s.SetStore(1, masker)
+	s.SetStore(2, registerOnlyStore{plain})
+	client, handler := newTCPClient(t, address, 1)
+
+	// The example of the specification: 0x12 AND 0xF2 OR 0x25 is 0x17.
+	for _, test := range []struct {
+		unit  byte
+		store *MemoryStore
+	}{{1, masker}, {2, plain}} {
+		test.store.SetHoldingRegisters(4, 0x12)
+		handler.SlaveId = test.unit
+		results, err := client.MaskWriteRegister(4, 0xF2, 0x25)
+		if err != nil {
+			t.Fatalf("unit %d: %v", test.unit, err)
+		}
+		if want := []byte{0x00, 0xF2, 0x00, 0x25}; !bytes.Equal(results, want) {
+			t.Errorf("unit %d: results %x, want %x", test.unit, results, want)
+		}
+		if got := test.store.HoldingRegisters[4]; got != 0x17 {
+			t.Errorf("unit %d: register = %#x, want 0x17", test.unit, got)
+		}
+	}
+}
+
+func TestReadWriteMultipleRegisters(t *testing.T) {
+	s, address := newTCPServer(t)
+	s.HoldingRegisters[9] = 0x0909
+	client, _ := newTCPClient(t, address, 1)
+
+	// The registers are written before they are read.
+	results, err := client.ReadWriteMultipleRegisters(9, 4, 10, 3, []byte{0, 1, 0, 2, 0, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{0x09, 0x09, 0, 1, 0, 2, 0, 3}; !bytes.Equal(results, want) {
+		t.Errorf("results %x, want %x", results, want)
+	}
+	if _, err := client.ReadWriteMultipleRegisters(65535, 2, 0, 1, []byte{0, 1}); exceptionCode(err) != ExceptionCodeIllegalDataAddress {
+		t.Errorf("read beyond the table: %v", err)
+	}
+}
+
+func TestReadFIFOQueue(t *testing.T) {
+	s, address := newTCPServer(t)
+	copy(s.HoldingRegisters[100:], []uint16{3, 0x1111, 0x2222, 0x3333})
+	s.HoldingRegisters[200] = 32
+	client, _ := newTCPClient(t, address, 1)
+
+	tests := []struct {
+		name      string
+		address   uint16
+		want      []byte
+		exception byte
+	}{
+		{"queue", 100, []byte{0x11, 0x11, 0x22, 0x22, 0x33, 0x33}, 0},
+		{"empty queue", 300, []byte{}, 0},
+		{"too many registers", 200, nil, ExceptionCodeIllegalDataValue},
+	}
+	for _, test := range tests {
+		results, err := client.ReadFIFOQueue(test.address)
+		if code := exceptionCode(err); code != test.exception || code == 0 && err != nil {
+			t.Errorf("%s: error %v, want exception %d", test.name, err, test.exception)
+			continue
+		}
+		if err == nil && !bytes.Equal(results, test.want) {
+			t.Errorf("%s: results %x, want %x", test.name, results, test.want)
+		}
+	}
+}
+
+func TestReadDeviceIdentification(t *testing.T) {
+	s, address := newTCPServer(t)
+	client, _ := newTCPClient(t, address, 1)
+
+	if _, err := client.ReadDeviceIdentification(ReadDeviceIDBasic, 0); exceptionCode(err) != ExceptionCodeIllegalFunction {
+		t.Errorf("without objects: %v, want illegal function", err)
+	}
+
+	// The extended objects do not fit in one response.
+	s.DeviceIdentification = map[uint8]string{0x00: "Vendor", 0x01: "Product", 0x02: "1.0", 0x05: "Model"}
+	for id := uint8(0x80); id < 0x84; id++ {
+		s.DeviceIdentification[id] = strings.Repeat(string(rune('a'+id-0x80)), 100)
+	}
+
+	tests := []struct {
+		name      string
+		code      byte
+		objectID  byte
+		want      []uint8
+		exception byte
+	}{
+		{"basic", ReadDeviceIDBasic, 0, []uint8{0x00, 0x01, 0x02}, 0},
+		{"regular", ReadDeviceIDRegular, 0, []uint8{0x00, 0x01, 0x02, 0x05}, 0},
+		{"extended", ReadDeviceIDExtended, 0, []uint8{0x00, 0x01, 0x02, 0x05, 0x80, 0x81, 0x82, 0x83}, 0},
+		{"from an object", ReadDeviceIDExtended, 0x81, []uint8{0x81, 0x82, 0x83}, 0},
+		{"specific", ReadDeviceIDSpecific, 0x05, []uint8{0x05}, 0},
+		{"missing specific", ReadDeviceIDSpecific, 0x06, nil, ExceptionCodeIllegalDataAddress},
+	}
+	for _, test := range tests {
+		results, err := client.ReadDeviceIdentification(test.code, test.objectID)
+		if code := exceptionCode(err); code != test.exception || code == 0 && err != nil {
+			t.Errorf("%s: error %v, want exception %d", test.name, err, test.exception)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(results) != len(test.want) {
+			t.Errorf("%s: %d objects, want %d", test.name, len(results), len(test.want))
+		}
+		for _, id := range test.want {
+			if string(results[id]) != s.DeviceIdentification[id] {
+				t.Errorf("%s: object %#x = %q, want %q", test.name, id, results[id], s.DeviceIdentification[id])
+			}
+		}
+	}
+}
+
+// TestServeBroadcast writes with the broadcast address over RTU, which is
+// executed but not answered.
+func TestServeBroadcast(t *testing.T) {
+	s := NewServer()
+	if err := s.ListenRTUOverTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	handler := NewRTUOverTCPClientHandler(s.listeners[0].Addr().String())
+	t.Cleanup(func() { handler.Close() })
+	client := NewClient(handler)
+
+	handler.Timeout = 200 * time.Millisecond
+	handler.SlaveId = 0
+	if _, err := client.WriteSingleRegister(1, 7); err == nil {
+		t.Fatal("broadcast request was answered")
+	}
+
+	handler.Timeout = 5 * time.Second
+	handler.SlaveId = 1
+	results, err := client.ReadHoldingRegisters(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.BigEndian.Uint16(results); got != 7 {
+		t.Errorf("register = %d, want 7", got)
+	}
+
+	results, err = client.GetCommEventLog()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := results[len(results)-1]; got != eventReceive|eventReceiveBroadcast {
+		t.Errorf("first event = %#x, want %#x", got, eventReceive|eventReceiveBroadcast)
+	}
+	results, err = client.Diagnostics(DiagReturnServerNoResponseCount, []byte{0, 0})
+	if err != nil || !bytes.Equal(results, []byte{0, 1}) {
+		t.Errorf("no response count = %x, %v, want 0001", results, err)
+	}
+}
@@ -0,0 +1,519 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Limits of the quantity of one request.
+const (
+	maxReadBits       = 2000
+	maxReadRegisters  = 125
+	maxWriteBits      = 1968
+	maxWriteRegisters = 123
+)
+
+// registerType is the encoding of a field in the registers.
+type registerType uint8
+
+const (
+	typeBool registerType = iota
+	typeUint16
+	typeInt16
+	typeUint32
+	typeInt32
+	typeUint64
+	typeInt64
+	typeFloat32
+	typeFloat64
+	typeString
+)
+
+var registerTypes = map[string]registerType{
+	"bool":    typeBool,
+	"uint16":  typeUint16,
+	"int16":   typeInt16,
+	"uint32":  typeUint32,
+	"int32":   typeInt32,
+	"uint64":  typeUint64,
+	"int64":   typeInt64,
+	"float32": typeFloat32,
+	"float64": typeFloat64,
+	"string":  typeString,
+}
+
+var tables = map[string]Table{
+	"coil":     CoilTable,
+	"discrete": DiscreteInputTable,
+	"holding":  HoldingRegisterTable,
+	"input":    InputRegisterTable,
+}
+
+// mappedField is a struct field with a modbus tag.
+type mappedField struct {
+	name     string
+	index    int
+	table    Table
+	address  uint16
+	quantity uint16 // registers, or bits of coils and discrete inputs
+	typ      registerType
+	scale    float64
+	byteSwap bool
+	wordSwap bool
+	readOnly bool
+}
+
+// structLayout is the mapping of a struct type.
+type structLayout struct {
+	fields []*mappedField // sorted by table and address
+}
+
+var layouts sync.Map // reflect.Type => *structLayout
+
+// layoutOf returns the layout of the struct type t.
+func layoutOf(t reflect.Type) (*structLayout, error) {
+	if l, ok := layouts.Load(t); ok {
+		return l.(*structLayout), nil
+	}
+	l := &structLayout{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("modbus")
+		if !ok || tag == "-" {
+			continue
+		}
+		if !sf.IsExported() {
+			return nil, fmt.Errorf("modbus: field '%v' with tag is not exported", sf.Name)
+		}
+		f, err := parseField(sf, tag)
+		if err != nil {
+			return nil, err
+		}
+		f.index = i
+		l.fields = append(l.fields, f)
+	}
+	sort.Slice(l.fields, func(i, j int) bool {
+		a, b := l.fields[i], l.fields[j]
+		return a.table < b.table || a.table == b.table && a.address < b.address
+	})
+	for i := 1; i < len(l.fields); i++ {
+		prev, f := l.fields[i-1], l.fields[i]
+		if prev.table == f.table && prev.end() > int(f.address) {
+			return nil, fmt.Errorf("modbus: field '%v' overlaps field '%v'", f.name, prev.name)
+		}
+	}
+	layouts.Store(t, l)
+	return l, nil
+}
+
+// parseField parses a tag of the form
+//
+//	address[,table][,type=T][,scale=S][,len=N][,byteswap][,wordswap][,readonly]
+func parseField(sf reflect.StructField, tag string) (*mappedField, error) {
+	options := strings.Split(tag, ",")
+	address, err := strconv.ParseUint(options[0], 0, 16)
+	if err != nil {
+		return nil, fmt.Errorf("modbus: address '%v' of field '%v' is invalid", options[0], sf.Name)
+	}
+	f := &mappedField{name: sf.Name, address: uint16(address), scale: 1}
+	f.table, f.typ = defaultMapping(sf.Type.Kind())
+	length := 0
+	for _, option := range options[1:] {
+		key, value, _ := strings.Cut(option, "=")
+		switch key {
+		case "coil", "discrete", "holding", "input":
+			f.table = tables[key]
+		case "type":
+			typ, ok := registerTypes[value]
+			if !ok {
+				return nil, fmt.Errorf("modbus: type '%v' of field '%v' is not supported", value, sf.Name)
+			}
+			f.typ = typ
+		case "scale":
+			if f.scale, err = strconv.ParseFloat(value, 64); err != nil || f.scale == 0 {
+				return nil, fmt.Errorf("modbus: scale '%v' of field '%v' is invalid", value, sf.Name)
+			}
+		case "len":
+			if length, err = strconv.Atoi(value); err != nil || length <= 0 {
+				return nil, fmt.Errorf("modbus: length '%v' of field '%v' is invalid", value, sf.Name)
+			}
+		case "byteswap":
+			f.byteSwap = true
+		case "wordswap":
+			f.wordSwap = true
+		case "readonly":
+			f.readOnly = true
+		default:
+			return nil, fmt.Errorf("modbus: option '%v' of field '%v' is not supported", option, sf.Name)
+		}
+	}
+
+	kind := sf.Type.Kind()
+	switch {
+	case f.table == CoilTable || f.table == DiscreteInputTable:
+		if kind != reflect.Bool || f.typ != typeBool {
+			return nil, fmt.Errorf("modbus: field '%v' in %v must be a bool", sf.Name, f.table)
+		}
+	case f.typ == typeString:
+		if kind != reflect.String || length == 0 {
+			return nil, fmt.Errorf("modbus: field '%v' of type string must be a string with a length", sf.Name)
+		}
+	case f.typ == typeBool:
+		if kind != reflect.Bool {
+			return nil, fmt.Errorf("modbus: field '%v' of type bool must be a bool", sf.Name)
+		}
+	default:
+		if !isNumber(kind) {
+			return nil, fmt.Errorf("modbus: field '%v' of kind '%v' is not supported", sf.Name, kind)
+		}
+	}
+	f.quantity = f.typ.size(length)
+	if f.quantity > maxWriteRegisters {
+		return nil, fmt.Errorf("modbus: length '%v' of field '%v' must not be greater than '%v'", length, sf.Name, 2*maxWriteRegisters)
+	}
+	if f.end() > 65536 {
+		return nil, fmt.Errorf("modbus: field '%v' exceeds the address space", sf.Name)
+	}
+	if f.table == DiscreteInputTable || f.table == InputRegisterTable {
+		f.readOnly = true
+	}
+	return f, nil
+}
+
+// defaultMapping returns the table and register type of a field of kind.
+func defaultMapping(kind reflect.Kind) (Table, registerType) {
+	switch kind {
+	case reflect.Bool:
+		return CoilTable, typeBool
+	case reflect.Int8, reflect.Int16:
+		return HoldingRegisterTable, typeInt16
+	case reflect.Uint8, reflect.Uint16:
+		return HoldingRegisterTable, typeUint16
+	case reflect.Int32:
+		return HoldingRegisterTable, typeInt32
+	case reflect.Uint32:
+		return HoldingRegisterTable, typeUint32
+	case reflect.Int, reflect.Int64:
+		return HoldingRegisterTable, typeInt64
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return HoldingRegisterTable, typeUint64
+	case reflect.Float32:
+		return HoldingRegisterTable, typeFloat32
+	case reflect.Float64:
+		return HoldingRegisterTable, typeFloat64
+	case reflect.String:
+		return HoldingRegisterTable, typeString
+	}
+	return HoldingRegisterTable, typeUint16
+}
+
+func isNumber(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+// size returns the number of registers or bits of the type, length is the number
+// of characters of strings.
+func (t registerType) size(length int) uint16 {
+	switch t {
+	case typeUint32, typeInt32, typeFloat32:
+		return 2
+	case typeUint64, typeInt64, typeFloat64:
+		return 4
+	case typeString:
+		return uint16((length + 1) / 2)
+	}
+	return 1
+}
+
+func (t registerType) signed() bool {
+	return t == typeInt16 || t == typeInt32 || t == typeInt64
+}
+
+// end returns the address after the field.
+func (f *mappedField) end() int {
+	return int(f.address) + int(f.quantity)
+}
+
+// swap converts the registers between the order of the field and big endian.
+func (f *mappedField) swap(registers []uint16) {
+	if f.byteSwap {
+		for i, r := range registers {
+			registers[i] = r<<8 | r>>8
+		}
+	}
+	if f.wordSwap && f.typ != typeString {
+		for i, j := 0, len(registers)-1; i < j; i, j = i+1, j-1 {
+			registers[i], registers[j] = registers[j], registers[i]
+		}
+	}
+}
+
+// encode returns the registers (or bits) of the field value v.
+func (f *mappedField) encode(v reflect.Value) []uint16 {
+	registers := make([]uint16, f.quantity)
+	var raw uint64
+	switch f.typ {
+	case typeBool:
+		if v.Bool() {
+			raw = 1
+		}
+	case typeString:
+		b := make([]byte, 2*len(registers))
+		copy(b, v.String())
+		for i := range registers {
+			registers[i] = binary.BigEndian.Uint16(b[2*i:])
+		}
+		f.swap(registers)
+		return registers
+	case typeFloat32:
+		raw = uint64(math.Float32bits(float32(f.float(v))))
+	case typeFloat64:
+		raw = math.Float64bits(f.float(v))
+	default:
+		raw = f.integer(v)
+	}
+	for i := range registers {
+		registers[i] = uint16(raw >> (16 * (len(registers) - 1 - i)))
+	}
+	f.swap(registers)
+	return registers
+}
+
+// float returns the field value v divided by the scale.
+func (f *mappedField) float(v reflect.Value) float64 {
+	switch {
+	case v.CanInt():
+		return float64(v.Int()) / f.scale
+	case v.CanUint():
+		return float64(v.Uint()) / f.scale
+	}
+	return v.Float() / f.scale
+}
+
+// integer returns the field value v divided by the scale, rounded to an integer
+// in two's complement.
+func (f *mappedField) integer(v reflect.Value) uint64 {
+	if f.scale == 1 {
+		switch {
+		case v.CanInt():
+			return uint64(v.Int())
+		case v.CanUint():
+			return v.Uint()
+		}
+	}
+	x := math.Round(f.float(v))
+	if f.typ.signed() || x < 0 {
+		return uint64(int64(x))
+	}
+	return uint64(x)
+}
+
+// decode sets the field value v from its registers (or bits).
+func (f *mappedField) decode(v reflect.Value, registers []uint16) {
+	registers = append([]uint16(nil), registers...)
+	f.swap(registers)
+	if f.typ == typeString {
+		b := make([]byte, 2*len(registers))
+		for i, r := range registers {
+			binary.BigEndian.PutUint16(b[2*i:], r)
+		}
+		if i := strings.IndexByte(string(b), 0); i >= 0 {
+			b = b[:i]
+		}
+		v.SetString(string(b))
+		return
+	}
+	var raw uint64
+	for _, r := range registers {
+		raw = raw<<16 | uint64(r)
+	}
+	switch f.typ {
+	case typeBool:
+		v.SetBool(raw != 0)
+	case typeFloat32:
+		f.setFloat(v, float64(math.Float32frombits(uint32(raw))))
+	case typeFloat64:
+		f.setFloat(v, math.Float64frombits(raw))
+	default:
+		bits := 16 * len(registers)
+		if f.typ.signed() {
+			n := int64(raw<<(64-bits)) >> (64 - bits)
+			if f.scale != 1 || !(v.CanInt() || v.CanUint()) {
+				f.setFloat(v, float64(n))
+			} else if v.CanInt() {
+				v.SetInt(n)
+			} else {
+				v.SetUint(uint64(n))
+			}
+		} else {
+			if f.scale != 1 || !(v.CanInt() || v.CanUint()) {
+				f.setFloat(v, float64(raw))
+			} else if v.CanInt() {
+				v.SetInt(int64(raw))
+			} else {
+				v.SetUint(raw)
+			}
+		}
+	}
+}
+
+// setFloat sets the field value v to x multiplied by the scale.
+func (f *mappedField) setFloat(v reflect.Value, x float64) {
+	x *= f.scale
+	switch {
+	case v.CanInt():
+		v.SetInt(int64(math.Round(x)))
+	case v.CanUint():
+		v.SetUint(uint64(math.Round(x)))
+	default:
+		v.SetFloat(x)
+	}
+}
+
+// block is a range of a table which is read or written in one request.
+type block struct {
+	table    Table
+	address  uint16
+	quantity uint16
+	fields   []*mappedField
+}
+
+// blocks merges the fields into as few blocks as possible, only writable ones if
+// write is true.
+func (l *structLayout) blocks(write bool) []*block {
+	var blocks []*block
+	var b *block
+	for _, f := range l.fields {
+		if write && f.readOnly {
+			continue
+		}
+		limit := maxRequestQuantity(f.table, write)
+		if b == nil || b.table != f.table || int(b.address)+int(b.quantity) != int(f.address) || int(b.quantity)+int(f.quantity) > limit {
+			b = &block{table: f.table, address: f.address}
+			blocks = append(blocks, b)
+		}
+		b.quantity += f.quantity
+		b.fields = append(b.fields, f)
+	}
+	return blocks
+}
+
+// maxRequestQuantity returns the maximum quantity of a read or write request of table.
+func maxRequestQuantity(table Table, write bool) int {
+	switch {
+	case table == CoilTable || table == DiscreteInputTable:
+		if write {
+			return maxWriteBits
+		}
+		return maxReadBits
+	case write:
+		return maxWriteRegisters
+	}
+	return maxReadRegisters
+}
+
+// structValue returns the struct v points to and its layout.
+func structValue(v any) (reflect.Value, *structLayout, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, fmt.Errorf("modbus: '%T' is not a pointer to a struct", v)
+	}
+	rv = rv.Elem()
+	l, err := layoutOf(rv.Type())
+	return rv, l, err
+}
+
+// ReadStruct reads the fields of the struct v points to from the device of c. The
+// fields are mapped by tags of the form
+//
+//	modbus:"address[,table][,type=T][,scale=S][,len=N][,byteswap][,wordswap][,readonly]"
+//
+// where table is one of holding (default), input, coil (default of bool fields) or
+// discrete. The register type T is one of bool, uint16, int16, uint32, int32,
+// uint64, int64, float32, float64 or string and defaults to the one matching the
+// kind of the field. The field value is the register value multiplied by the
+// scale S. Strings have a length of N characters, two per register, and are
+// truncated at the first NUL. Values of several registers are big endian
+// (ABCD) unless the bytes in each register (BADC) and/or the order of the
+// registers (CDAB, DCBA) are swapped.
+//
+// Adjacent fields of a table are read in one request.
+func ReadStruct(ctx context.Context, c Client, v any) error {
+	rv, l, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	for _, b := range l.blocks(false) {
+		var results []byte
+		switch b.table {
+		case CoilTable:
+			results, err = c.ReadCoilsContext(ctx, b.address, b.quantity)
+		case DiscreteInputTable:
+			results, err = c.ReadDiscreteInputsContext(ctx, b.address, b.quantity)
+		case HoldingRegisterTable:
+			results, err = c.ReadHoldingRegistersContext(ctx, b.address, b.quantity)
+		case InputRegisterTable:
+			results, err = c.ReadInputRegistersContext(ctx, b.address, b.quantity)
+		}
+		if err != nil {
+			return err
+		}
+		var values []uint16
+		if b.table == CoilTable || b.table == DiscreteInputTable {
+			if len(results) < (int(b.quantity)+7)/8 {
+				return fmt.Errorf("modbus: response data size '%v' does not match quantity '%v'", len(results), b.quantity)
+			}
+			values = make([]uint16, b.quantity)
+			for i := range values {
+				values[i] = uint16(bitAtPosition(results[i/8], uint(i%8)))
+			}
+		} else {
+			if len(results) != 2*int(b.quantity) {
+				return fmt.Errorf("modbus: response data size '%v' does not match quantity '%v'", len(results), b.quantity)
+			}
+			values = BytesToUint16(results)
+		}
+		for _, f := range b.fields {
+			offset := f.address - b.address
+			f.decode(rv.Field(f.index), values[offset:offset+f.quantity])
+		}
+	}
+	return nil
+}
+
+// WriteStruct writes the fields of the struct v points to, except read-only ones
+// and the ones of discrete inputs and input registers, to the device of c. The
+// fields are mapped as described for ReadStruct. Adjacent fields of a table are
+// written in one request.
+func WriteStruct(ctx context.Context, c Client, v any) error {
+	rv, l, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	for _, b := range l.blocks(true) {
+		values := make([]uint16, 0, b.quantity)
+		for _, f := range b.fields {
+			values = append(values, f.encode(rv.Field(f.index))...)
+		}
+		if b.table == CoilTable {
+			bits := make([]byte, len(values))
+			for i, value := range values {
+				bits[i] = byte(value)
+			}
+			_, err = c.WriteMultipleCoilsContext(ctx, b.address, b.quantity, packBits(bits)[1:])
+		} else {
+			_, err = c.WriteMultipleRegistersContext(ctx, b.address, b.quantity, Uint16ToBytes(values))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
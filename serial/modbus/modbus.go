@@ -8,6 +8,7 @@ Package modbus provides a client for MODBUS TCP and RTU/ASCII.
 package modbus
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -26,6 +27,50 @@ const (
 	FuncCodeReadWriteMultipleRegisters = 23
 	FuncCodeMaskWriteRegister          = 22
 	FuncCodeReadFIFOQueue              = 24
+
+	// File record access
+	FuncCodeReadFileRecord  = 20
+	FuncCodeWriteFileRecord = 21
+
+	// Diagnostics
+	FuncCodeReadExceptionStatus = 7
+	FuncCodeDiagnostics         = 8
+	FuncCodeGetCommEventCounter = 11
+	FuncCodeGetCommEventLog     = 12
+	FuncCodeReportServerID      = 17
+
+	// Encapsulated interface transport
+	FuncCodeEncapsulatedInterface = 43
+)
+
+const (
+	// MEITypeReadDeviceIdentification is the MEI type of Read Device Identification.
+	MEITypeReadDeviceIdentification = 14
+
+	// Read device ID codes
+	ReadDeviceIDBasic    = 1
+	ReadDeviceIDRegular  = 2
+	ReadDeviceIDExtended = 3
+	ReadDeviceIDSpecific = 4
+)
+
+const (
+	// Diagnostics sub-function codes
+	DiagReturnQueryData                = 0x00
+	DiagRestartCommunications          = 0x01
+	DiagReturnDiagnosticRegister       = 0x02
+	DiagChangeASCIIInputDelimiter      = 0x03
+	DiagForceListenOnlyMode            = 0x04
+	DiagClearCounters                  = 0x0A
+	DiagReturnBusMessageCount          = 0x0B
+	DiagReturnBusCommErrorCount        = 0x0C
+	DiagReturnBusExceptionErrorCount   = 0x0D
+	DiagReturnServerMessageCount       = 0x0E
+	DiagReturnServerNoResponseCount    = 0x0F
+	DiagReturnServerNAKCount           = 0x10
+	DiagReturnServerBusyCount          = 0x11
+	DiagReturnBusCharacterOverrunCount = 0x12
+	DiagClearOverrunCounter            = 0x14
 )
 
 const (
@@ -35,6 +80,7 @@ const (
 	ExceptionCodeServerDeviceFailure                = 4
 	ExceptionCodeAcknowledge                        = 5
 	ExceptionCodeServerDeviceBusy                   = 6
+	ExceptionCodeNegativeAcknowledge                = 7
 	ExceptionCodeMemoryParityError                  = 8
 	ExceptionCodeGatewayPathUnavailable             = 10
 	ExceptionCodeGatewayTargetDeviceFailedToRespond = 11
@@ -62,6 +108,8 @@ func (e *ModbusError) Error() string {
 		name = "acknowledge"
 	case ExceptionCodeServerDeviceBusy:
 		name = "server device busy"
+	case ExceptionCodeNegativeAcknowledge:
+		name = "negative acknowledge"
 	case ExceptionCodeMemoryParityError:
 		name = "memory parity error"
 	case ExceptionCodeGatewayPathUnavailable:
@@ -91,3 +139,10 @@ type Packager interface {
 type Transporter interface {
 	Send(aduRequest []byte) (aduResponse []byte, err error)
 }
+
+// ContextTransporter is a Transporter which stops waiting for the response when
+// the context is done.
+type ContextTransporter interface {
+	Transporter
+	SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error)
+}
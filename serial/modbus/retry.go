@@ -0,0 +1,101 @@
+package modbus
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryPolicy specifies how often and when a failed request is sent again.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a request is sent at most, requests are
+	// not retried if it is less than 2.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, which doubles with every
+	// further retry up to MaxBackoff (unlimited if zero).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable reports whether a request which failed with err is sent again.
+	// If it is nil, requests are retried after transport errors and the
+	// exceptions 'acknowledge' and 'server device busy', but not after other
+	// exceptions.
+	Retryable func(err error) bool
+	// RetryNonIdempotent allows to retry requests which change the state of the
+	// server when sent twice: all writes, Read FIFO Queue and Diagnostics.
+	// A request may have been executed although its response was lost.
+	RetryNonIdempotent bool
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a copy of ctx which makes the context variants of the
+// Client methods retry failed requests as p specifies.
+func WithRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// retryPolicyFromContext returns the RetryPolicy of ctx, or nil if none is set.
+func retryPolicyFromContext(ctx context.Context) *RetryPolicy {
+	p, _ := ctx.Value(retryPolicyKey{}).(*RetryPolicy)
+	return p
+}
+
+// retry reports whether the request with functionCode is sent again after
+// attempt failed with err.
+func (p *RetryPolicy) retry(attempt int, functionCode byte, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if !p.RetryNonIdempotent && !idempotent(functionCode) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	var mbError *ModbusError
+	if errors.As(err, &mbError) {
+		return mbError.ExceptionCode == ExceptionCodeAcknowledge || mbError.ExceptionCode == ExceptionCodeServerDeviceBusy
+	}
+	return true
+}
+
+// idempotent reports whether a request with functionCode can be sent twice
+// without changing the state of the server.
+func idempotent(functionCode byte) bool {
+	switch functionCode {
+	case FuncCodeReadCoils, FuncCodeReadDiscreteInputs,
+		FuncCodeReadHoldingRegisters, FuncCodeReadInputRegisters,
+		FuncCodeReadFileRecord, FuncCodeReadExceptionStatus,
+		FuncCodeGetCommEventCounter, FuncCodeGetCommEventLog,
+		FuncCodeReportServerID, FuncCodeEncapsulatedInterface:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay after attempt before the next one.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// sleepContext waits for the duration d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,59 @@
+package modbus
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"serial"
+)
+
+// openPTY opens a pseudo-terminal and returns its master and the path of the
+// slave, which stands in for a serial port.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	ioctl := func(req uint, arg unsafe.Pointer) {
+		t.Helper()
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), uintptr(req), uintptr(arg)); errno != 0 {
+			t.Fatal(errno)
+		}
+	}
+	var unlock int32
+	ioctl(syscall.TIOCSPTLCK, unsafe.Pointer(&unlock))
+	var n uint32
+	ioctl(syscall.TIOCGPTN, unsafe.Pointer(&n))
+	return master, "/dev/pts/" + strconv.Itoa(int(n))
+}
+
+// TestRTU serves RTU requests on a serial port, which is connected to the serial
+// port of the client by two pseudo-terminals.
+func TestRTU(t *testing.T) {
+	serverMaster, serverPort := openPTY(t)
+	clientMaster, clientPort := openPTY(t)
+	go io.Copy(serverMaster, clientMaster)
+	go io.Copy(clientMaster, serverMaster)
+
+	s := NewServer()
+	if err := s.ListenRTU(&serial.Config{Address: serverPort, BaudRate: 19200, Timeout: 100 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+
+	handler := NewRTUClientHandler(clientPort)
+	handler.BaudRate = 19200
+	handler.SlaveId = 1
+	handler.Timeout = 5 * time.Second
+	t.Cleanup(func() { handler.Close() })
+	testRoundTrip(t, NewClient(handler))
+}
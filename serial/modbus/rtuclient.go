@@ -5,6 +5,7 @@
 package modbus
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -111,6 +112,13 @@ type rtuSerialTransporter struct {
 }
 
 func (mb *rtuSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+func (mb *rtuSerialTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
+	mb.serialPort.mu.Lock()
+	defer mb.serialPort.mu.Unlock()
+
 	// Make sure port is connected
 	if err = mb.serialPort.connect(); err != nil {
 		return
@@ -124,35 +132,53 @@ func (mb *rtuSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, err
 	if _, err = mb.port.Write(aduRequest); err != nil {
 		return
 	}
-	function := aduRequest[1]
-	functionFail := aduRequest[1] & 0x80
 	bytesToRead := calculateResponseLength(aduRequest)
-	time.Sleep(mb.calculateDelay(len(aduRequest) + bytesToRead))
+	if err = sleepContext(ctx, mb.calculateDelay(len(aduRequest)+bytesToRead)); err != nil {
+		mb.serialPort.flushIfDone(ctx)
+		return
+	}
+
+	if aduResponse, err = readRTUResponse(contextReader{ctx, mb.port}, aduRequest); err != nil {
+		mb.serialPort.flushIfDone(ctx)
+		return
+	}
+	mb.serialPort.logf("modbus: received % x\n", aduResponse)
+	return
+}
+
+// readRTUResponse reads the RTU response to the request adu from r.
+func readRTUResponse(r io.Reader, aduRequest []byte) (aduResponse []byte, err error) {
+	function := aduRequest[1]
+	functionFail := aduRequest[1] | 0x80
 
 	var n int
 	var n1 int
 	var data [rtuMaxSize]byte
 	//We first read the minimum length and then read either the full package
 	//or the error package, depending on the error status (byte 2 of the response)
-	n, err = io.ReadAtLeast(mb.port, data[:], rtuMinSize)
+	n, err = io.ReadAtLeast(r, data[:], rtuMinSize)
 	if err != nil {
 		return
 	}
 	//if the function is correct
 	if data[1] == function {
-		//we read the rest of the bytes
-		if n < bytesToRead {
-			if bytesToRead > rtuMinSize && bytesToRead <= rtuMaxSize {
-				if bytesToRead > n {
-					n1, err = io.ReadFull(mb.port, data[n:bytesToRead])
-					n += n1
-				}
+		//we read the rest of the bytes, the length of variable sized responses
+		//is known once their byte count has been read
+		for length := responseLength(aduRequest, data[:n]); n < length; length = responseLength(aduRequest, data[:n]) {
+			if length > rtuMaxSize {
+				err = fmt.Errorf("modbus: response length '%v' must not be bigger than '%v'", length, rtuMaxSize)
+				return
+			}
+			n1, err = io.ReadFull(r, data[n:length])
+			n += n1
+			if err != nil {
+				return
 			}
 		}
 	} else if data[1] == functionFail {
 		//for error we need to read 5 bytes
 		if n < rtuExceptionSize {
-			n1, err = io.ReadFull(mb.port, data[n:rtuExceptionSize])
+			n1, err = io.ReadFull(r, data[n:rtuExceptionSize])
 		}
 		n += n1
 	}
@@ -161,7 +187,6 @@ func (mb *rtuSerialTransporter) Send(aduRequest []byte) (aduResponse []byte, err
 		return
 	}
 	aduResponse = data[:n]
-	mb.serialPort.logf("modbus: received % x\n", aduResponse)
 	return
 }
 
@@ -202,9 +227,53 @@ func calculateResponseLength(adu []byte) int {
 		length += 4
 	case FuncCodeMaskWriteRegister:
 		length += 6
-	case FuncCodeReadFIFOQueue:
+	case FuncCodeReadExceptionStatus:
+		length += 1
+	case FuncCodeGetCommEventCounter:
+		length += 4
+	case FuncCodeDiagnostics,
+		FuncCodeWriteFileRecord:
+		length = len(adu)
+	case FuncCodeReadFIFOQueue,
+		FuncCodeReadFileRecord,
+		FuncCodeGetCommEventLog,
+		FuncCodeReportServerID,
+		FuncCodeEncapsulatedInterface:
 		// undetermined
 	default:
 	}
 	return length
 }
+
+// responseLength returns the length of the response to the request adu, or the
+// length needed to determine it, given the first bytes of the response.
+func responseLength(request, response []byte) int {
+	switch request[1] {
+	case FuncCodeReadFIFOQueue:
+		// Byte count : 2 bytes
+		if len(response) < 4 {
+			return 4
+		}
+		return 4 + int(binary.BigEndian.Uint16(response[2:])) + 2
+	case FuncCodeReadFileRecord,
+		FuncCodeGetCommEventLog,
+		FuncCodeReportServerID:
+		// Byte count : 1 byte
+		return 3 + int(response[2]) + 2
+	case FuncCodeEncapsulatedInterface:
+		// MEI type, read device ID code, conformity level, more follows,
+		// next object ID and number of objects, followed by the objects
+		length := 8
+		if len(response) < length {
+			return length + 2
+		}
+		for i := 0; i < int(response[7]); i++ {
+			if len(response) < length+2 {
+				return length + 2 + 2
+			}
+			length += 2 + int(response[length+1])
+		}
+		return length + 2
+	}
+	return calculateResponseLength(request)
+}
@@ -0,0 +1,54 @@
+package modbus
+
+import (
+	"context"
+	"io"
+)
+
+// RTUOverTCPClientHandler implements Packager and Transporter interface for RTU
+// frames tunneled over TCP.
+type RTUOverTCPClientHandler struct {
+	rtuPackager
+	rtuTCPTransporter
+}
+
+// NewRTUOverTCPClientHandler allocates a new RTUOverTCPClientHandler.
+func NewRTUOverTCPClientHandler(address string) *RTUOverTCPClientHandler {
+	h := &RTUOverTCPClientHandler{}
+	h.Address = address
+	h.Timeout = tcpTimeout
+	h.IdleTimeout = tcpIdleTimeout
+	h.ReconnectBackoff = tcpReconnectBackoff
+	h.MaxReconnectBackoff = tcpMaxReconnectBackoff
+	return h
+}
+
+// RTUOverTCPClient creates RTU over TCP client with default handler and given connect string.
+func RTUOverTCPClient(address string) Client {
+	handler := NewRTUOverTCPClientHandler(address)
+	return NewClient(handler)
+}
+
+// rtuTCPTransporter implements Transporter interface.
+type rtuTCPTransporter struct {
+	tcpTransporter
+}
+
+// Send sends the RTU frame and reads the response.
+func (mb *rtuTCPTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+// SendContext sends the RTU frame and reads the response, which is delimited by its
+// length rather than by a silent interval. Pending data is flushed after an
+// incomplete response so that the next response starts at a frame boundary.
+func (mb *rtuTCPTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.send(ctx, aduRequest, func(aduRequest []byte) ([]byte, error) {
+		aduResponse, err := readRTUResponse(mb.conn, aduRequest)
+		if err != nil && err != io.EOF {
+			var data [rtuMaxSize]byte
+			mb.flush(data[:])
+		}
+		return aduResponse, err
+	})
+}
@@ -5,7 +5,7 @@
 package modbus
 
 import (
-	"io"
+	"context"
 	"log"
 	"sync"
 	"time"
@@ -29,7 +29,7 @@ type serialPort struct {
 
 	mu sync.Mutex
 	// port is platform-dependent data structure for serial port.
-	port         io.ReadWriteCloser
+	port         serial.Port
 	lastActivity time.Time
 	closeTimer   *time.Timer
 }
@@ -69,6 +69,14 @@ func (mb *serialPort) close() (err error) {
 	return
 }
 
+// flushIfDone discards the rest of a response after ctx is done, which would be
+// taken for the response to the next request otherwise. Caller must hold the mutex.
+func (mb *serialPort) flushIfDone(ctx context.Context) {
+	if ctx.Err() != nil && mb.port != nil {
+		mb.port.Flush()
+	}
+}
+
 func (mb *serialPort) logf(format string, v ...interface{}) {
 	if mb.Logger != nil {
 		mb.Logger.Printf(format, v...)
@@ -100,3 +108,13 @@ func (mb *serialPort) closeIdle() {
 		mb.close()
 	}
 }
+
+// contextReader reads from a serial port until ctx is done.
+type contextReader struct {
+	ctx  context.Context
+	port serial.Port
+}
+
+func (r contextReader) Read(b []byte) (int, error) {
+	return r.port.ReadContext(r.ctx, b)
+}
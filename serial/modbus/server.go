@@ -9,16 +9,36 @@ import (
 	"serial"
 )
 
-// Server is a Modbus slave with allocated memory for discrete inputs, coils, etc.
+// Server is a Modbus slave which answers requests from the RegisterStore of the
+// addressed unit.
 type Server struct {
 	// Debug enables more verbose messaging.
-	Debug            bool
-	listeners        []net.Listener
-	ports            []serial.Port
-	portsWG          sync.WaitGroup
-	portsCloseChan   chan struct{}
-	requestChan      chan *Request
-	function         [256](func(*Server, Framer) ([]byte, *Exception))
+	Debug          bool
+	listeners      []net.Listener
+	packetConns    []net.PacketConn
+	ports          []serial.Port
+	portsWG        sync.WaitGroup
+	portsCloseChan chan struct{}
+	requestChan    chan *Request
+	function       [256](func(*Server, Framer) ([]byte, *Exception))
+	requestLengths [256]requestLengthFunc
+	storesMu       sync.RWMutex
+	stores         map[uint8]RegisterStore
+	defaultStore   RegisterStore
+	diagnostics    diagnostics
+	// ServerID is returned by function 17 (report server ID), followed by the
+	// run indicator status.
+	ServerID []byte
+	// ExceptionStatus returns the eight exception status outputs of function 7
+	// (read exception status). Function 7 is not supported if it is nil.
+	ExceptionStatus func() byte
+	// DeviceIdentification holds the objects returned by function 43/14 (read
+	// device identification) keyed by object ID, e.g. 0 (vendor name), 1 (product
+	// code) and 2 (major minor revision). Function 43/14 is not supported if it is empty.
+	DeviceIdentification map[uint8]string
+	// DiscreteInputs, Coils, HoldingRegisters and InputRegisters are the tables of
+	// the default store created by NewServer. Requests are served one at a time by
+	// a single goroutine, use the store methods to access them while serving.
 	DiscreteInputs   []byte
 	Coils            []byte
 	HoldingRegisters []uint16
@@ -31,15 +51,18 @@ type Request struct {
 	frame Framer
 }
 
-// NewServer creates a new Modbus server (slave).
+// NewServer creates a new Modbus server (slave). All unit IDs are answered from a
+// default MemoryStore until SetStore or SetDefaultStore are used.
 func NewServer() *Server {
-	s := &Server{}
+	s := &Server{stores: map[uint8]RegisterStore{}}
 
 	// Allocate Modbus memory maps.
-	s.DiscreteInputs = make([]byte, 65536)
-	s.Coils = make([]byte, 65536)
-	s.HoldingRegisters = make([]uint16, 65536)
-	s.InputRegisters = make([]uint16, 65536)
+	store := NewMemoryStore()
+	s.defaultStore = store
+	s.DiscreteInputs = store.DiscreteInputs
+	s.Coils = store.Coils
+	s.HoldingRegisters = store.HoldingRegisters
+	s.InputRegisters = store.InputRegisters
 
 	// Add default functions.
 	s.function[1] = ReadCoils
@@ -48,8 +71,19 @@ func NewServer() *Server {
 	s.function[4] = ReadInputRegisters
 	s.function[5] = WriteSingleCoil
 	s.function[6] = WriteHoldingRegister
+	s.function[7] = ReadExceptionStatus
+	s.function[8] = Diagnostics
+	s.function[11] = GetCommEventCounter
+	s.function[12] = GetCommEventLog
 	s.function[15] = WriteMultipleCoils
 	s.function[16] = WriteHoldingRegisters
+	s.function[17] = ReportServerID
+	s.function[20] = ReadFileRecord
+	s.function[21] = WriteFileRecord
+	s.function[22] = MaskWriteRegister
+	s.function[23] = ReadWriteMultipleRegisters
+	s.function[24] = ReadFIFOQueue
+	s.function[43] = EncapsulatedInterface
 
 	s.requestChan = make(chan *Request)
 	s.portsCloseChan = make(chan struct{})
@@ -59,17 +93,63 @@ func NewServer() *Server {
 	return s
 }
 
+// SetStore sets the store of a unit ID (slave address). A nil store removes it.
+func (s *Server) SetStore(unitID uint8, store RegisterStore) {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+	if store == nil {
+		delete(s.stores, unitID)
+		return
+	}
+	s.stores[unitID] = store
+}
+
+// SetDefaultStore sets the store of the unit IDs without their own store. With a
+// nil store, requests to those units are not answered.
+func (s *Server) SetDefaultStore(store RegisterStore) {
+	s.storesMu.Lock()
+	defer s.storesMu.Unlock()
+	s.defaultStore = store
+}
+
+// Store returns the store of a unit ID, or nil if the unit is not served.
+func (s *Server) Store(unitID uint8) RegisterStore {
+	s.storesMu.RLock()
+	defer s.storesMu.RUnlock()
+	if store, ok := s.stores[unitID]; ok {
+		return store
+	}
+	return s.defaultStore
+}
+
 // RegisterFunctionHandler override the default behavior for a given Modbus function.
 func (s *Server) RegisterFunctionHandler(funcCode uint8, function func(*Server, Framer) ([]byte, *Exception)) {
 	s.function[funcCode] = function
 }
 
+// RegisterRequestLength sets the length of the RTU requests of a function, which is
+// needed to find the end of the frames of functions the server doesn't know. Given
+// the data received so far (after the function code), length returns the length
+// of the request data, or the length needed to determine it. It must be set before
+// the server starts listening.
+func (s *Server) RegisterRequestLength(funcCode uint8, length func(data []byte) int) {
+	s.requestLengths[funcCode] = length
+}
+
 func (s *Server) handle(request *Request) Framer {
 	var exception *Exception
 	var data []byte
 
 	response := request.frame.Copy()
 
+	if s.Store(request.frame.GetUnitID()) == nil {
+		if _, ok := request.frame.(*TCPFrame); !ok {
+			return nil // Serial units which don't exist don't answer.
+		}
+		response.SetException(&GatewayTargetDeviceFailedtoRespond)
+		return response
+	}
+
 	function := request.frame.GetFunction()
 	if s.function[function] != nil {
 		data, exception = s.function[function](s, request.frame)
@@ -89,17 +169,48 @@ func (s *Server) handle(request *Request) Framer {
 func (s *Server) handler() {
 	for {
 		request := <-s.requestChan
-		response := s.handle(request)
-		request.conn.Write(response.Bytes())
+		s.serve(request)
 	}
 }
 
+// serve handles a request and writes the response.
+func (s *Server) serve(request *Request) {
+	function := request.frame.GetFunction()
+	broadcast := false
+	switch frame := request.frame.(type) {
+	case *RTUFrame:
+		broadcast = frame.Address == 0 // Broadcast requests are not answered.
+	case *ASCIIFrame:
+		broadcast = frame.Address == 0
+	}
+
+	served := broadcast || s.Store(request.frame.GetUnitID()) != nil
+	if listenOnly := s.diagnostics.received(served, broadcast); listenOnly && !isRestartCommunications(request.frame) {
+		s.diagnostics.sent(function, nil)
+		return
+	}
+
+	response := s.handle(request)
+	if response == nil || broadcast || isForceListenOnly(request.frame) {
+		s.diagnostics.sent(function, nil)
+		return
+	}
+
+	exception := GetException(response)
+	s.diagnostics.sent(function, &exception)
+	request.conn.Write(response.Bytes())
+}
+
 // Close stops listening to TCP/IP ports and closes serial ports.
 func (s *Server) Close() {
 	for _, listen := range s.listeners {
 		listen.Close()
 	}
 
+	for _, conn := range s.packetConns {
+		conn.Close()
+	}
+
 	close(s.portsCloseChan)
 	s.portsWG.Wait()
 
@@ -1,7 +1,6 @@
 package modbus
 
 import (
-	"io"
 	"log"
 
 	"serial"
@@ -19,49 +18,14 @@ func (s *Server) ListenRTU(serialConfig *serial.Config) (err error) {
 	s.portsWG.Add(1)
 	go func() {
 		defer s.portsWG.Done()
-		s.acceptSerialRequests(port)
+		s.acceptSerialRequests(port, serialConfig.BaudRate)
 	}()
 
 	return err
 }
 
-func (s *Server) acceptSerialRequests(port serial.Port) {
-SkipFrameError:
-	for {
-		select {
-		case <-s.portsCloseChan:
-			return
-		default:
-		}
-
-		buffer := make([]byte, 512)
-
-		bytesRead, err := port.Read(buffer)
-		if err != nil {
-			if err != io.EOF {
-				log.Printf("serial read error %v\n", err)
-			}
-			return
-		}
-
-		if bytesRead != 0 {
-
-			// Set the length of the packet to the number of read bytes.
-			packet := buffer[:bytesRead]
-
-			frame, err := NewRTUFrame(packet)
-			if err != nil {
-				log.Printf("bad serial frame error %v\n", err)
-				//The next line prevents RTU server from exiting when it receives a bad frame. Simply discard the erroneous
-				//frame and wait for next frame by jumping back to the beginning of the 'for' loop.
-				log.Printf("Keep the RTU server running!!\n")
-				continue SkipFrameError
-				//return
-			}
-
-			request := &Request{port, frame}
-
-			s.requestChan <- request
-		}
-	}
+func (s *Server) acceptSerialRequests(port serial.Port, baudRate int) {
+	// Bad frames are discarded to keep the RTU server running, and a silent
+	// interval of 3.5 characters discards a partial frame.
+	s.serveFrames(port, newRTUFrameReader(port, rtuFrameGap(baudRate), &s.requestLengths))
 }
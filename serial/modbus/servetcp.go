@@ -2,13 +2,14 @@ package modbus
 
 import (
 	"crypto/tls"
+	"errors"
 	"io"
 	"log"
 	"net"
 	"strings"
 )
 
-func (s *Server) accept(listen net.Listener) error {
+func (s *Server) accept(listen net.Listener, newReader func(net.Conn) frameReader) error {
 	for {
 		conn, err := listen.Accept()
 		if err != nil {
@@ -21,30 +22,37 @@ func (s *Server) accept(listen net.Listener) error {
 
 		go func(conn net.Conn) {
 			defer conn.Close()
+			s.serveFrames(conn, newReader(conn))
+		}(conn)
+	}
+}
 
-			for {
-				packet := make([]byte, 512)
-				bytesRead, err := conn.Read(packet)
-				if err != nil {
-					if err != io.EOF {
-						log.Printf("read error %v\n", err)
-					}
-					return
-				}
-				// Set the length of the packet to the number of read bytes.
-				packet = packet[:bytesRead]
-
-				frame, err := NewTCPFrame(packet)
-				if err != nil {
-					log.Printf("bad packet error %v\n", err)
+// serveFrames serves the requests read from a connection or serial port until it
+// is closed. Frames with checksum errors and partial frames are discarded.
+func (s *Server) serveFrames(conn io.ReadWriteCloser, reader frameReader) {
+	for {
+		frame, err := reader.ReadFrame()
+		if err != nil {
+			if errors.Is(err, errChecksum) {
+				s.diagnostics.commError()
+				log.Printf("bad frame error %v\n", err)
+				continue
+			}
+			if isTimeout(err) {
+				select {
+				case <-s.portsCloseChan:
 					return
+				default:
+					continue
 				}
-
-				request := &Request{conn, frame}
-
-				s.requestChan <- request
 			}
-		}(conn)
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				log.Printf("read error %v\n", err)
+			}
+			return
+		}
+
+		s.requestChan <- &Request{conn, frame}
 	}
 }
 
@@ -56,7 +64,7 @@ func (s *Server) ListenTCP(addressPort string) (err error) {
 		return err
 	}
 	s.listeners = append(s.listeners, listen)
-	go s.accept(listen)
+	go s.accept(listen, newTCPFrameReader)
 	return err
 }
 
@@ -68,6 +76,38 @@ func (s *Server) ListenTLS(addressPort string, config *tls.Config) (err error) {
 		return err
 	}
 	s.listeners = append(s.listeners, listen)
-	go s.accept(listen)
+	go s.accept(listen, newTCPFrameReader)
 	return err
 }
+
+// ListenRTUOverTCP starts the Modbus server listening on "address:port" for RTU
+// frames tunneled over TCP.
+func (s *Server) ListenRTUOverTCP(addressPort string) (err error) {
+	listen, err := net.Listen("tcp", addressPort)
+	if err != nil {
+		log.Printf("Failed to Listen: %v\n", err)
+		return err
+	}
+	s.listeners = append(s.listeners, listen)
+	go s.accept(listen, func(conn net.Conn) frameReader {
+		return newRTUFrameReader(conn, rtuOverTCPFrameTimeout, &s.requestLengths)
+	})
+	return err
+}
+
+// ListenASCIIOverTCP starts the Modbus server listening on "address:port" for
+// ASCII frames tunneled over TCP.
+func (s *Server) ListenASCIIOverTCP(addressPort string) (err error) {
+	listen, err := net.Listen("tcp", addressPort)
+	if err != nil {
+		log.Printf("Failed to Listen: %v\n", err)
+		return err
+	}
+	s.listeners = append(s.listeners, listen)
+	go s.accept(listen, func(conn net.Conn) frameReader { return newASCIIFrameReader(conn) })
+	return err
+}
+
+func newTCPFrameReader(conn net.Conn) frameReader {
+	return &tcpFrameReader{conn}
+}
@@ -0,0 +1,59 @@
+package modbus
+
+import (
+	"errors"
+	"log"
+	"net"
+)
+
+// udpConn writes the response to a UDP request back to its sender.
+type udpConn struct {
+	conn net.PacketConn
+	addr net.Addr
+}
+
+func (c *udpConn) Read(b []byte) (int, error) {
+	return 0, errors.New("modbus: udp responses can not be read")
+}
+
+func (c *udpConn) Write(b []byte) (int, error) {
+	return c.conn.WriteTo(b, c.addr)
+}
+
+func (c *udpConn) Close() error {
+	return nil
+}
+
+// ListenUDP starts the Modbus server listening on "address:port" for MBAP frames
+// over UDP. Each datagram holds one request.
+func (s *Server) ListenUDP(addressPort string) (err error) {
+	conn, err := net.ListenPacket("udp", addressPort)
+	if err != nil {
+		log.Printf("Failed to Listen on UDP: %v\n", err)
+		return err
+	}
+	s.packetConns = append(s.packetConns, conn)
+	go s.acceptPackets(conn)
+	return err
+}
+
+func (s *Server) acceptPackets(conn net.PacketConn) {
+	for {
+		packet := make([]byte, tcpMaxLength)
+		bytesRead, addr, err := conn.ReadFrom(packet)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("udp read error %v\n", err)
+			}
+			return
+		}
+
+		frame, err := NewTCPFrame(packet[:bytesRead])
+		if err != nil {
+			log.Printf("bad packet error %v\n", err)
+			continue
+		}
+
+		s.requestChan <- &Request{&udpConn{conn, addr}, frame}
+	}
+}
@@ -0,0 +1,410 @@
+package modbus
+
+import (
+	"errors"
+	"sync"
+)
+
+// Table identifies one of the four Modbus data tables.
+type Table uint8
+
+// Modbus data tables.
+const (
+	CoilTable Table = iota
+	DiscreteInputTable
+	HoldingRegisterTable
+	InputRegisterTable
+)
+
+func (t Table) String() string {
+	switch t {
+	case CoilTable:
+		return "coils"
+	case DiscreteInputTable:
+		return "discrete inputs"
+	case HoldingRegisterTable:
+		return "holding registers"
+	case InputRegisterTable:
+		return "input registers"
+	}
+	return "unknown"
+}
+
+// RegisterStore is the data model of a Modbus unit (slave). The default function
+// handlers read and write the store of the addressed unit, so an implementation can
+// back the registers with live device values. Coils and discrete inputs are passed
+// as one byte (0 or 1) per bit. A store returns an Exception as error to answer
+// with that exception, any other error is answered with SlaveDeviceFailure.
+type RegisterStore interface {
+	ReadCoils(address, quantity uint16) ([]byte, error)
+	ReadDiscreteInputs(address, quantity uint16) ([]byte, error)
+	ReadHoldingRegisters(address, quantity uint16) ([]uint16, error)
+	ReadInputRegisters(address, quantity uint16) ([]uint16, error)
+	WriteCoils(address uint16, values []byte) error
+	WriteHoldingRegisters(address uint16, values []uint16) error
+}
+
+// FileRecordStore is implemented by stores which support the file record functions
+// 20 and 21. Files are numbered from 1 and have 10000 records of one register each.
+type FileRecordStore interface {
+	ReadFileRecord(file, record, length uint16) ([]uint16, error)
+	WriteFileRecord(file, record uint16, values []uint16) error
+}
+
+// MaskWriter is implemented by stores which apply the mask write of function 22
+// atomically. The register of other stores is read and written by two calls.
+type MaskWriter interface {
+	MaskWriteHoldingRegister(address, andMask, orMask uint16) error
+}
+
+// Access is a set of permissions for a range of a data table.
+type Access uint8
+
+// Access permissions.
+const (
+	AccessNone  Access = 0
+	AccessRead  Access = 1 << 0
+	AccessWrite Access = 1 << 1
+
+	AccessReadWrite = AccessRead | AccessWrite
+)
+
+type accessRange struct {
+	table  Table
+	start  int
+	end    int
+	access Access
+}
+
+// Change describes a write of a Modbus master to the store. Coil values are 0 or 1.
+type Change struct {
+	Table   Table
+	Address uint16
+	Values  []uint16
+}
+
+// MemoryStore is a RegisterStore which keeps the four data tables in memory. It is
+// safe for concurrent use; use the Set methods to update values from the application.
+type MemoryStore struct {
+	// OnRead is called before values are read, e.g. to refresh them from a device.
+	// Returning an error rejects the request.
+	OnRead func(table Table, address, quantity uint16) error
+	// OnWrite is called before values written by a master are stored. Returning an
+	// error rejects the request. It is called without the store locked, so it may
+	// use the store; for a mask write it is called again if the register changed
+	// in the meantime.
+	OnWrite func(table Table, address uint16, values []uint16) error
+
+	DiscreteInputs   []byte
+	Coils            []byte
+	HoldingRegisters []uint16
+	InputRegisters   []uint16
+
+	mu      sync.RWMutex
+	files   map[uint16][]uint16
+	ranges  []accessRange
+	notify  []chan<- Change
+	notifMu sync.Mutex
+}
+
+// NewMemoryStore creates a store with 65536 elements in each table.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		DiscreteInputs:   make([]byte, 65536),
+		Coils:            make([]byte, 65536),
+		HoldingRegisters: make([]uint16, 65536),
+		InputRegisters:   make([]uint16, 65536),
+	}
+}
+
+// SetAccess restricts the access of masters to quantity elements of table starting
+// at address. A request is rejected with IllegalDataAddress if any of its elements
+// lies in a range which does not grant the access. Everything is accessible by default.
+func (m *MemoryStore) SetAccess(table Table, address, quantity uint16, access Access) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ranges = append(m.ranges, accessRange{table, int(address), int(address) + int(quantity), access})
+}
+
+// Notify relays the changes written by masters to c. Like signal.Notify, the store
+// does not block sending to c: the caller must ensure that c has sufficient buffer
+// space to keep up with the expected write rate.
+func (m *MemoryStore) Notify(c chan<- Change) {
+	m.notifMu.Lock()
+	defer m.notifMu.Unlock()
+	m.notify = append(m.notify, c)
+}
+
+// Stop stops relaying changes to c.
+func (m *MemoryStore) Stop(c chan<- Change) {
+	m.notifMu.Lock()
+	defer m.notifMu.Unlock()
+	for i, n := range m.notify {
+		if n == c {
+			m.notify = append(m.notify[:i], m.notify[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetCoils sets coils from the application.
+func (m *MemoryStore) SetCoils(address uint16, values ...byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return setBits(m.Coils, address, values)
+}
+
+// SetDiscreteInputs sets discrete inputs from the application.
+func (m *MemoryStore) SetDiscreteInputs(address uint16, values ...byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return setBits(m.DiscreteInputs, address, values)
+}
+
+// SetHoldingRegisters sets holding registers from the application.
+func (m *MemoryStore) SetHoldingRegisters(address uint16, values ...uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return setRegisters(m.HoldingRegisters, address, values)
+}
+
+// SetInputRegisters sets input registers from the application.
+func (m *MemoryStore) SetInputRegisters(address uint16, values ...uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return setRegisters(m.InputRegisters, address, values)
+}
+
+// ReadCoils implements RegisterStore.
+func (m *MemoryStore) ReadCoils(address, quantity uint16) ([]byte, error) {
+	return m.readBits(CoilTable, m.Coils, address, quantity)
+}
+
+// ReadDiscreteInputs implements RegisterStore.
+func (m *MemoryStore) ReadDiscreteInputs(address, quantity uint16) ([]byte, error) {
+	return m.readBits(DiscreteInputTable, m.DiscreteInputs, address, quantity)
+}
+
+// ReadHoldingRegisters implements RegisterStore.
+func (m *MemoryStore) ReadHoldingRegisters(address, quantity uint16) ([]uint16, error) {
+	return m.readRegisters(HoldingRegisterTable, m.HoldingRegisters, address, quantity)
+}
+
+// ReadInputRegisters implements RegisterStore.
+func (m *MemoryStore) ReadInputRegisters(address, quantity uint16) ([]uint16, error) {
+	return m.readRegisters(InputRegisterTable, m.InputRegisters, address, quantity)
+}
+
+// WriteCoils implements RegisterStore.
+func (m *MemoryStore) WriteCoils(address uint16, values []byte) error {
+	changed := make([]uint16, len(values))
+	for i, value := range values {
+		if value != 0 {
+			changed[i] = 1
+		}
+	}
+	if err := m.checkWrite(CoilTable, len(m.Coils), address, changed); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	for i, value := range changed {
+		m.Coils[int(address)+i] = byte(value)
+	}
+	m.mu.Unlock()
+
+	m.changed(Change{CoilTable, address, changed})
+	return nil
+}
+
+// WriteHoldingRegisters implements RegisterStore.
+func (m *MemoryStore) WriteHoldingRegisters(address uint16, values []uint16) error {
+	changed := append([]uint16(nil), values...)
+	if err := m.checkWrite(HoldingRegisterTable, len(m.HoldingRegisters), address, changed); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	copy(m.HoldingRegisters[address:], changed)
+	m.mu.Unlock()
+
+	m.changed(Change{HoldingRegisterTable, address, changed})
+	return nil
+}
+
+// MaskWriteHoldingRegister implements MaskWriter. The register is masked and
+// written only if it was not changed since it was read, otherwise the mask write
+// is repeated with the new value.
+func (m *MemoryStore) MaskWriteHoldingRegister(address, andMask, orMask uint16) error {
+	if err := m.checkRead(HoldingRegisterTable, len(m.HoldingRegisters), address, 1); err != nil {
+		return err
+	}
+
+	for {
+		m.mu.RLock()
+		value := m.HoldingRegisters[address]
+		m.mu.RUnlock()
+
+		changed := []uint16{maskRegister(value, andMask, orMask)}
+		if err := m.checkWrite(HoldingRegisterTable, len(m.HoldingRegisters), address, changed); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		if m.HoldingRegisters[address] != value {
+			m.mu.Unlock()
+			continue
+		}
+		m.HoldingRegisters[address] = changed[0]
+		m.mu.Unlock()
+
+		m.changed(Change{HoldingRegisterTable, address, changed})
+		return nil
+	}
+}
+
+// SetFileRecords sets records of a file from the application, creating the file
+// if it does not exist.
+func (m *MemoryStore) SetFileRecords(file, record uint16, values ...uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setFileRecords(file, record, values)
+}
+
+// ReadFileRecord implements FileRecordStore. Files which were never written do not exist.
+func (m *MemoryStore) ReadFileRecord(file, record, length uint16) ([]uint16, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	records, ok := m.files[file]
+	if !ok || int(record)+int(length) > len(records) {
+		return nil, IllegalDataAddress
+	}
+	return append([]uint16(nil), records[record:int(record)+int(length)]...), nil
+}
+
+// WriteFileRecord implements FileRecordStore.
+func (m *MemoryStore) WriteFileRecord(file, record uint16, values []uint16) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.setFileRecords(file, record, values)
+}
+
+func (m *MemoryStore) setFileRecords(file, record uint16, values []uint16) error {
+	if file == 0 || int(record)+len(values) > 10000 {
+		return IllegalDataAddress
+	}
+	if m.files == nil {
+		m.files = map[uint16][]uint16{}
+	}
+	if m.files[file] == nil {
+		m.files[file] = make([]uint16, 10000)
+	}
+	copy(m.files[file][record:], values)
+	return nil
+}
+
+func (m *MemoryStore) readBits(table Table, bits []byte, address, quantity uint16) ([]byte, error) {
+	if err := m.checkRead(table, len(bits), address, quantity); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]byte(nil), bits[address:int(address)+int(quantity)]...), nil
+}
+
+func (m *MemoryStore) readRegisters(table Table, registers []uint16, address, quantity uint16) ([]uint16, error) {
+	if err := m.checkRead(table, len(registers), address, quantity); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]uint16(nil), registers[address:int(address)+int(quantity)]...), nil
+}
+
+func (m *MemoryStore) checkRead(table Table, size int, address, quantity uint16) error {
+	if !m.allowed(table, size, int(address), int(quantity), AccessRead) {
+		return IllegalDataAddress
+	}
+	if m.OnRead != nil {
+		return m.OnRead(table, address, quantity)
+	}
+	return nil
+}
+
+func (m *MemoryStore) checkWrite(table Table, size int, address uint16, values []uint16) error {
+	if !m.allowed(table, size, int(address), len(values), AccessWrite) {
+		return IllegalDataAddress
+	}
+	if m.OnWrite != nil {
+		return m.OnWrite(table, address, values)
+	}
+	return nil
+}
+
+func (m *MemoryStore) allowed(table Table, size, address, quantity int, access Access) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.allowedLocked(table, size, address, quantity, access)
+}
+
+func (m *MemoryStore) allowedLocked(table Table, size, address, quantity int, access Access) bool {
+	if address+quantity > size {
+		return false
+	}
+
+	for _, r := range m.ranges {
+		if r.table == table && r.start < address+quantity && address < r.end && r.access&access == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *MemoryStore) changed(change Change) {
+	m.notifMu.Lock()
+	defer m.notifMu.Unlock()
+	for _, c := range m.notify {
+		select {
+		case c <- change:
+		default:
+		}
+	}
+}
+
+func setBits(bits []byte, address uint16, values []byte) error {
+	if int(address)+len(values) > len(bits) {
+		return IllegalDataAddress
+	}
+	for i, value := range values {
+		if value != 0 {
+			value = 1
+		}
+		bits[int(address)+i] = value
+	}
+	return nil
+}
+
+func setRegisters(registers []uint16, address uint16, values []uint16) error {
+	if int(address)+len(values) > len(registers) {
+		return IllegalDataAddress
+	}
+	copy(registers[address:], values)
+	return nil
+}
+
+// toException converts an error of a RegisterStore to the exception of the response.
+func toException(err error) *Exception {
+	if err == nil {
+		return &Success
+	}
+	var exception Exception
+	if errors.As(err, &exception) {
+		if exception == Success {
+			return &Success
+		}
+		return &exception
+	}
+	return &SlaveDeviceFailure
+}
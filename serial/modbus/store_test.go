@@ -0,0 +1,219 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// exceptionCode returns the exception code of a client error, or 0.
+func exceptionCode(err error) byte {
+	var mbErr *ModbusError
+	if errors.As(err, &mbErr) {
+		return mbErr.ExceptionCode
+	}
+	return 0
+}
+
+func TestServerUnitRouting(t *testing.T) {
+	s, address := newTCPServer(t)
+	unit1, unit2 := NewMemoryStore(), NewMemoryStore()
+	unit1.SetHoldingRegisters(0, 101)
+	unit2.SetHoldingRegisters(0, 202)
+	s.SetStore(1, unit1)
+	s.SetStore(2, unit2)
+	s.SetDefaultStore(nil)
+	client, handler := newTCPClient(t, address, 1)
+
+	tests := []struct {
+		unit      byte
+		want      uint16
+		exception byte
+	}{
+		{1, 101, 0},
+		{2, 202, 0},
+		{3, 0, ExceptionCodeGatewayTargetDeviceFailedToRespond},
+	}
+	for _, test := range tests {
+		handler.SlaveId = test.unit
+		results, err := client.ReadHoldingRegisters(0, 1)
+		if code := exceptionCode(err); code != test.exception {
+			t.Errorf("unit %d: exception %d (%v), want %d", test.unit, code, err, test.exception)
+			continue
+		}
+		if err == nil && binary.BigEndian.Uint16(results) != test.want {
+			t.Errorf("unit %d: register = %d, want %d", test.unit, binary.BigEndian.Uint16(results), test.want)
+		}
+	}
+
+	// Units without a store are answered from the default store
+	s.SetDefaultStore(unit1)
+	handler.SlaveId = 3
+	if results, err := client.ReadHoldingRegisters(0, 1); err != nil || binary.BigEndian.Uint16(results) != 101 {
+		t.Errorf("default store: %v, %v", results, err)
+	}
+}
+
+func TestMemoryStoreAccess(t *testing.T) {
+	s, address := newTCPServer(t)
+	store := NewMemoryStore()
+	store.SetAccess(HoldingRegisterTable, 10, 5, AccessRead)
+	store.SetAccess(CoilTable, 0, 8, AccessNone)
+	store.SetAccess(InputRegisterTable, 100, 1, AccessWrite)
+	s.SetDefaultStore(store)
+	client, _ := newTCPClient(t, address, 1)
+
+	tests := []struct {
+		name    string
+		request func() ([]byte, error)
+		denied  bool
+	}{
+		{"read read-only registers", func() ([]byte, error) { return client.ReadHoldingRegisters(10, 5) }, false},
+		{"write read-only register", func() ([]byte, error) { return client.WriteSingleRegister(12, 1) }, true},
+		{"write overlapping read-only registers", func() ([]byte, error) { return client.WriteMultipleRegisters(8, 3, make([]byte, 6)) }, true},
+		{"write after read-only registers", func() ([]byte, error) { return client.WriteSingleRegister(15, 1) }, false},
+		{"mask write read-only register", func() ([]byte, error) { return client.MaskWriteRegister(14, 0, 1) }, true},
+		{"read denied coils", func() ([]byte, error) { return client.ReadCoils(7, 2) }, true},
+		{"write denied coil", func() ([]byte, error) { return client.WriteSingleCoil(0, 0xFF00) }, true},
+		{"read coils after denied range", func() ([]byte, error) { return client.ReadCoils(8, 8) }, false},
+		{"read write-only input register", func() ([]byte, error) { return client.ReadInputRegisters(100, 1) }, true},
+		{"read beyond the table", func() ([]byte, error) { return client.ReadInputRegisters(65535, 2) }, true},
+	}
+	for _, test := range tests {
+		_, err := test.request()
+		if code := exceptionCode(err); (code == ExceptionCodeIllegalDataAddress) != test.denied || !test.denied && err != nil {
+			t.Errorf("%s: error %v, denied %v", test.name, err, test.denied)
+		}
+	}
+	if store.HoldingRegisters[12] != 0 || store.Coils[0] != 0 {
+		t.Error("denied write was stored")
+	}
+}
+
+func TestMemoryStoreNotify(t *testing.T) {
+	s, address := newTCPServer(t)
+	store := NewMemoryStore()
+	s.SetDefaultStore(store)
+	changes := make(chan Change, 8)
+	store.Notify(changes)
+	client, _ := newTCPClient(t, address, 1)
+
+	tests := []struct {
+		name    string
+		request func() ([]byte, error)
+		want    Change
+	}{
+		{"single coil", func() ([]byte, error) { return client.WriteSingleCoil(3, 0xFF00) }, Change{CoilTable, 3, []uint16{1}}},
+		{"multiple coils", func() ([]byte, error) { return client.WriteMultipleCoils(8, 3, []byte{0x05}) }, Change{CoilTable, 8, []uint16{1, 0, 1}}},
+		{"single register", func() ([]byte, error) { return client.WriteSingleRegister(4, 0x1234) }, Change{HoldingRegisterTable, 4, []uint16{0x1234}}},
+		{"multiple registers", func() ([]byte, error) { return client.WriteMultipleRegisters(5, 2, []byte{0, 1, 0, 2}) }, Change{HoldingRegisterTable, 5, []uint16{1, 2}}},
+		{"mask write", func() ([]byte, error) { return client.MaskWriteRegister(4, 0x00FF, 0x0100) }, Change{HoldingRegisterTable, 4, []uint16{0x0134}}},
+	}
+	for _, test := range tests {
+		if _, err := test.request(); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		select {
+		case c := <-changes:
+			if c.Table != test.want.Table || c.Address != test.want.Address || !equalUint16s(c.Values, test.want.Values) {
+				t.Errorf("%s: change %+v, want %+v", test.name, c, test.want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: no change received", test.name)
+		}
+	}
+
+	// Reads and writes from the application are not changes of a master
+	if _, err := client.ReadHoldingRegisters(0, 8); err != nil {
+		t.Fatal(err)
+	}
+	store.SetHoldingRegisters(0, 1)
+	store.Stop(changes)
+	if _, err := client.WriteSingleRegister(4, 1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case c := <-changes:
+		t.Errorf("unexpected change %+v", c)
+	default:
+	}
+}
+
+func equalUint16s(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMemoryStoreOnWriteUsesStore(t *testing.T) {
+	writes := []struct {
+		name  string
+		write func(m *MemoryStore) error
+		table Table
+		want  []uint16
+	}{
+		{"coils", func(m *MemoryStore) error { return m.WriteCoils(10, []byte{1, 0, 1}) }, CoilTable, []uint16{1, 0, 1}},
+		{"registers", func(m *MemoryStore) error { return m.WriteHoldingRegisters(10, []uint16{7, 8}) }, HoldingRegisterTable, []uint16{7, 8}},
+		{"mask", func(m *MemoryStore) error { return m.MaskWriteHoldingRegister(10, 0x00F2, 0x0025) }, HoldingRegisterTable, []uint16{0x0017}},
+	}
+	for _, w := range writes {
+		t.Run(w.name, func(t *testing.T) {
+			m := NewMemoryStore()
+			m.SetHoldingRegisters(10, 0x0012)
+			var got []uint16
+			m.OnWrite = func(table Table, address uint16, values []uint16) error {
+				// The store is not locked
+				if _, err := m.ReadHoldingRegisters(address, 1); err != nil {
+					return err
+				}
+				got = append([]uint16(nil), values...)
+				return nil
+			}
+
+			done := make(chan error, 1)
+			go func() { done <- w.write(m) }()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("deadlock in OnWrite")
+			}
+			if !equalUint16s(got, w.want) {
+				t.Fatalf("OnWrite values = %v, want %v", got, w.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreMaskWriteRetry(t *testing.T) {
+	m := NewMemoryStore()
+	m.SetHoldingRegisters(3, 0x00FF)
+	calls := 0
+	m.OnWrite = func(table Table, address uint16, values []uint16) error {
+		calls++
+		if calls == 1 {
+			// A concurrent write between the read and the write of the mask write
+			m.SetHoldingRegisters(3, 0x0F00)
+		}
+		return nil
+	}
+
+	if err := m.MaskWriteHoldingRegister(3, 0xFF00, 0x0001); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("OnWrite called %d times, want 2", calls)
+	}
+	if v := m.HoldingRegisters[3]; v != 0x0F01 {
+		t.Errorf("register = %#04x, want 0x0f01", v)
+	}
+}
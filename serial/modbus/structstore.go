@@ -0,0 +1,210 @@
+package modbus
+
+import (
+	"reflect"
+	"sync"
+)
+
+// StructStore is a RegisterStore backed by the fields of a struct with modbus tags,
+// which are mapped as described for ReadStruct. Masters read and write the fields
+// directly; addresses which no field is mapped to are answered with
+// IllegalDataAddress, as well as writes to read-only fields. The application must
+// hold the lock of the store while it accesses the struct.
+type StructStore struct {
+	// OnRead is called before values are read, e.g. to refresh the fields from a
+	// device. Returning an error rejects the request.
+	OnRead func(table Table, address, quantity uint16) error
+	// OnWrite is called before values written by a master are stored in the
+	// fields. Returning an error rejects the request. It is called with the lock
+	// of the store held, so that the fields don't change until the values are
+	// stored, and must not call Lock.
+	OnWrite func(table Table, address uint16, values []uint16) error
+
+	mu     sync.RWMutex
+	v      reflect.Value
+	layout *structLayout
+}
+
+// NewStructStore creates a store backed by the struct v points to.
+func NewStructStore(v any) (*StructStore, error) {
+	rv, l, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return &StructStore{v: rv, layout: l}, nil
+}
+
+// Lock locks the struct for the application.
+func (s *StructStore) Lock() {
+	s.mu.Lock()
+}
+
+// Unlock unlocks the struct.
+func (s *StructStore) Unlock() {
+	s.mu.Unlock()
+}
+
+// ReadCoils implements RegisterStore.
+func (s *StructStore) ReadCoils(address, quantity uint16) ([]byte, error) {
+	return s.readBits(CoilTable, address, quantity)
+}
+
+// ReadDiscreteInputs implements RegisterStore.
+func (s *StructStore) ReadDiscreteInputs(address, quantity uint16) ([]byte, error) {
+	return s.readBits(DiscreteInputTable, address, quantity)
+}
+
+// ReadHoldingRegisters implements RegisterStore.
+func (s *StructStore) ReadHoldingRegisters(address, quantity uint16) ([]uint16, error) {
+	return s.read(HoldingRegisterTable, address, quantity)
+}
+
+// ReadInputRegisters implements RegisterStore.
+func (s *StructStore) ReadInputRegisters(address, quantity uint16) ([]uint16, error) {
+	return s.read(InputRegisterTable, address, quantity)
+}
+
+// WriteCoils implements RegisterStore.
+func (s *StructStore) WriteCoils(address uint16, values []byte) error {
+	bits := make([]uint16, len(values))
+	for i, value := range values {
+		if value != 0 {
+			bits[i] = 1
+		}
+	}
+	return s.write(CoilTable, address, bits)
+}
+
+// WriteHoldingRegisters implements RegisterStore.
+func (s *StructStore) WriteHoldingRegisters(address uint16, values []uint16) error {
+	return s.write(HoldingRegisterTable, address, values)
+}
+
+func (s *StructStore) readBits(table Table, address, quantity uint16) ([]byte, error) {
+	values, err := s.read(table, address, quantity)
+	if err != nil {
+		return nil, err
+	}
+	bits := make([]byte, len(values))
+	for i, value := range values {
+		bits[i] = byte(value)
+	}
+	return bits, nil
+}
+
+func (s *StructStore) read(table Table, address, quantity uint16) ([]uint16, error) {
+	if s.OnRead != nil {
+		if err := s.OnRead(table, address, quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.readLocked(table, address, quantity)
+}
+
+func (s *StructStore) readLocked(table Table, address, quantity uint16) ([]uint16, error) {
+	values := make([]uint16, quantity)
+	covered := 0
+	for _, f := range s.fields(table, address, len(values)) {
+		for i, value := range f.encode(s.v.Field(f.index)) {
+			if a := int(f.address) + i - int(address); a >= 0 && a < len(values) {
+				values[a] = value
+				covered++
+			}
+		}
+	}
+	if covered != len(values) {
+		return nil, IllegalDataAddress
+	}
+	return values, nil
+}
+
+// MaskWriteHoldingRegister implements MaskWriter. The register is read, masked and
+// written while the store is locked.
+func (s *StructStore) MaskWriteHoldingRegister(address, andMask, orMask uint16) error {
+	if s.OnRead != nil {
+		if err := s.OnRead(HoldingRegisterTable, address, 1); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values, err := s.readLocked(HoldingRegisterTable, address, 1)
+	if err != nil {
+		return err
+	}
+	fields, err := s.writableFields(HoldingRegisterTable, address, 1)
+	if err != nil {
+		return err
+	}
+	values[0] = maskRegister(values[0], andMask, orMask)
+	if s.OnWrite != nil {
+		if err := s.OnWrite(HoldingRegisterTable, address, values); err != nil {
+			return err
+		}
+	}
+	s.writeLocked(fields, address, values)
+	return nil
+}
+
+func (s *StructStore) write(table Table, address uint16, values []uint16) error {
+	fields, err := s.writableFields(table, address, len(values))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.OnWrite != nil {
+		if err := s.OnWrite(table, address, values); err != nil {
+			return err
+		}
+	}
+	s.writeLocked(fields, address, values)
+	return nil
+}
+
+// writableFields returns the fields written by quantity values from address, or
+// IllegalDataAddress if they don't cover the values or are read-only.
+func (s *StructStore) writableFields(table Table, address uint16, quantity int) ([]*mappedField, error) {
+	fields := s.fields(table, address, quantity)
+	covered := 0
+	for _, f := range fields {
+		if f.readOnly {
+			return nil, IllegalDataAddress
+		}
+		covered += min(f.end(), int(address)+quantity) - max(int(f.address), int(address))
+	}
+	if covered != quantity {
+		return nil, IllegalDataAddress
+	}
+	return fields, nil
+}
+
+func (s *StructStore) writeLocked(fields []*mappedField, address uint16, values []uint16) {
+	for _, f := range fields {
+		// Fields written in part keep the rest of their registers
+		v := s.v.Field(f.index)
+		registers := f.encode(v)
+		for i := range registers {
+			if a := int(f.address) + i - int(address); a >= 0 && a < len(values) {
+				registers[i] = values[a]
+			}
+		}
+		f.decode(v, registers)
+	}
+}
+
+// fields returns the fields of table which overlap quantity elements from address.
+func (s *StructStore) fields(table Table, address uint16, quantity int) []*mappedField {
+	var fields []*mappedField
+	for _, f := range s.layout.fields {
+		if f.table == table && int(f.address) < int(address)+quantity && f.end() > int(address) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,59 @@
+package modbus
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStructStoreOnWrite(t *testing.T) {
+	var v struct {
+		Setpoint uint16 `modbus:"4"`
+		Limit    uint16 `modbus:"5"`
+	}
+	s, err := NewStructStore(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var locked bool
+	s.OnWrite = func(table Table, address uint16, values []uint16) error {
+		// The lock is held until the values are stored.
+		locked = !s.mu.TryLock()
+		if !locked {
+			s.mu.Unlock()
+		}
+		if values[len(values)-1] > 100 {
+			return IllegalDataValue
+		}
+		return nil
+	}
+
+	if err := s.WriteHoldingRegisters(4, []uint16{20, 50}); err != nil {
+		t.Fatal(err)
+	}
+	if !locked {
+		t.Error("OnWrite was called without the lock")
+	}
+	if v.Setpoint != 20 || v.Limit != 50 {
+		t.Errorf("fields = %d, %d, want 20, 50", v.Setpoint, v.Limit)
+	}
+
+	locked = false
+	if err := s.MaskWriteHoldingRegister(4, 0x00F0, 0x0001); err != nil {
+		t.Fatal(err)
+	}
+	if !locked {
+		t.Error("OnWrite of a mask write was called without the lock")
+	}
+	if v.Setpoint != 0x0011 {
+		t.Errorf("masked field = %#x, want 0x11", v.Setpoint)
+	}
+
+	// A rejected write keeps the fields.
+	if err := s.WriteHoldingRegisters(5, []uint16{200}); !errors.Is(err, IllegalDataValue) {
+		t.Errorf("rejected write = %v, want IllegalDataValue", err)
+	}
+	if v.Limit != 50 {
+		t.Errorf("field after rejected write = %d, want 50", v.Limit)
+	}
+}
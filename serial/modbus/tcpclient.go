@@ -5,11 +5,13 @@
 package modbus
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -24,6 +26,9 @@ const (
 	// Default TCP timeout is not set
 	tcpTimeout     = 10 * time.Second
 	tcpIdleTimeout = 60 * time.Second
+	// Reconnect backoff
+	tcpReconnectBackoff    = 100 * time.Millisecond
+	tcpMaxReconnectBackoff = 10 * time.Second
 )
 
 // TCPClientHandler implements Packager and Transporter interface.
@@ -38,6 +43,8 @@ func NewTCPClientHandler(address string) *TCPClientHandler {
 	h.Address = address
 	h.Timeout = tcpTimeout
 	h.IdleTimeout = tcpIdleTimeout
+	h.ReconnectBackoff = tcpReconnectBackoff
+	h.MaxReconnectBackoff = tcpMaxReconnectBackoff
 	return h
 }
 
@@ -136,6 +143,14 @@ type tcpTransporter struct {
 	Timeout time.Duration
 	// Idle timeout to close the connection
 	IdleTimeout time.Duration
+	// Delay before dialing again after a failed dial, which doubles with every
+	// further failure up to MaxReconnectBackoff (unlimited if zero)
+	ReconnectBackoff    time.Duration
+	MaxReconnectBackoff time.Duration
+	// Number of requests awaiting their responses at the same time, which are
+	// matched by transaction identifier. Requests are sent one at a time if it
+	// is less than 2. It must be set before the first request.
+	MaxInFlight int
 	// Transmission logger
 	Logger *log.Logger
 
@@ -144,17 +159,139 @@ type tcpTransporter struct {
 	conn         net.Conn
 	closeTimer   *time.Timer
 	lastActivity time.Time
+	backoff      time.Duration
+	nextDial     time.Time
+
+	// Requests awaiting their responses
+	inFlight chan struct{}
+	readConn net.Conn
+	pending  map[uint16]chan tcpResponse
+}
+
+// tcpResponse is the result of a request passed from the reader of the connection.
+type tcpResponse struct {
+	aduResponse []byte
+	err         error
 }
 
 // Send sends data to server and ensures response length is greater than header length.
 func (mb *tcpTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+// SendContext sends data to server and waits for the response with the same
+// transaction identifier, which is read by a goroutine of the connection, so
+// that up to MaxInFlight requests can be pending at the same time.
+func (mb *tcpTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
 	mb.mu.Lock()
-	defer mb.mu.Unlock()
+	if mb.inFlight == nil {
+		mb.inFlight = make(chan struct{}, max(mb.MaxInFlight, 1))
+	}
+	inFlight := mb.inFlight
+	mb.mu.Unlock()
+	select {
+	case inFlight <- struct{}{}:
+		defer func() { <-inFlight }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 
-	// Establish a new connection if not connected
-	if err = mb.connect(); err != nil {
+	if err = mb.lockConnected(ctx); err != nil {
 		return
 	}
+	if mb.readConn != mb.conn {
+		mb.readConn = mb.conn
+		mb.pending = make(map[uint16]chan tcpResponse)
+		go mb.readResponses(mb.conn, mb.pending)
+	}
+	pending := mb.pending
+	transactionId := binary.BigEndian.Uint16(aduRequest)
+	if _, ok := pending[transactionId]; ok {
+		mb.mu.Unlock()
+		return nil, fmt.Errorf("modbus: transaction id '%v' is already in flight", transactionId)
+	}
+	response := make(chan tcpResponse, 1)
+	pending[transactionId] = response
+	// Set timer to close when idle
+	mb.lastActivity = time.Now()
+	mb.startCloseTimer()
+	// Set write timeout
+	var timeout time.Time
+	if mb.Timeout > 0 {
+		timeout = mb.lastActivity.Add(mb.Timeout)
+	}
+	if err = mb.conn.SetWriteDeadline(timeout); err == nil {
+		// Send data
+		mb.logf("modbus: sending % x", aduRequest)
+		if _, err = mb.conn.Write(aduRequest); err != nil {
+			// A partial frame can not be completed
+			mb.close()
+		}
+	}
+	if err != nil {
+		delete(pending, transactionId)
+		mb.mu.Unlock()
+		return
+	}
+	mb.mu.Unlock()
+
+	var expired <-chan time.Time
+	if mb.Timeout > 0 {
+		timer := time.NewTimer(mb.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case r := <-response:
+		return r.aduResponse, r.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-expired:
+		err = os.ErrDeadlineExceeded
+	}
+	// A late response is skipped by the reader
+	mb.mu.Lock()
+	delete(pending, transactionId)
+	mb.mu.Unlock()
+	return
+}
+
+// readResponses passes the responses read from conn to the pending requests with
+// the same transaction identifier until conn fails, which fails all of them.
+func (mb *tcpTransporter) readResponses(conn net.Conn, pending map[uint16]chan tcpResponse) {
+	for {
+		aduResponse, err := readTCPResponse(conn)
+		mb.mu.Lock()
+		if err != nil {
+			if mb.conn == conn {
+				mb.close()
+			}
+			for transactionId, response := range pending {
+				response <- tcpResponse{err: err}
+				delete(pending, transactionId)
+			}
+			mb.mu.Unlock()
+			return
+		}
+		transactionId := binary.BigEndian.Uint16(aduResponse)
+		if response, ok := pending[transactionId]; ok {
+			mb.logf("modbus: received % x\n", aduResponse)
+			response <- tcpResponse{aduResponse: aduResponse}
+			delete(pending, transactionId)
+		} else {
+			mb.logf("modbus: skipping % x", aduResponse)
+		}
+		mb.mu.Unlock()
+	}
+}
+
+// send writes the request and reads the response with read, one request at a time.
+func (mb *tcpTransporter) send(ctx context.Context, aduRequest []byte, read func(aduRequest []byte) ([]byte, error)) (aduResponse []byte, err error) {
+	if err = mb.lockConnected(ctx); err != nil {
+		return
+	}
+	defer mb.mu.Unlock()
+
 	// Set timer to close when idle
 	mb.lastActivity = time.Now()
 	mb.startCloseTimer()
@@ -166,35 +303,56 @@ func (mb *tcpTransporter) Send(aduRequest []byte) (aduResponse []byte, err error
 	if err = mb.conn.SetDeadline(timeout); err != nil {
 		return
 	}
+	// Abort the request when ctx is done
+	conn := mb.conn
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer func() {
+		if !stop() {
+			// The response may still arrive, which would be taken for the next one
+			mb.close()
+			if err != nil {
+				err = ctx.Err()
+			}
+		}
+	}()
 	// Send data
 	mb.logf("modbus: sending % x", aduRequest)
 	if _, err = mb.conn.Write(aduRequest); err != nil {
 		return
 	}
+	if aduResponse, err = read(aduRequest); err != nil {
+		return
+	}
+	mb.logf("modbus: received % x\n", aduResponse)
+	return
+}
+
+// readTCPResponse reads an MBAP response, the header first and then the number of
+// bytes given by its length field.
+func readTCPResponse(r io.Reader) (aduResponse []byte, err error) {
 	// Read header first
-	var data [tcpMaxLength]byte
-	if _, err = io.ReadFull(mb.conn, data[:tcpHeaderSize]); err != nil {
+	data := make([]byte, tcpMaxLength)
+	if _, err = io.ReadFull(r, data[:tcpHeaderSize]); err != nil {
 		return
 	}
 	// Read length, ignore transaction & protocol id (4 bytes)
 	length := int(binary.BigEndian.Uint16(data[4:]))
 	if length <= 0 {
-		mb.flush(data[:])
 		err = fmt.Errorf("modbus: length in response header '%v' must not be zero", length)
 		return
 	}
 	if length > (tcpMaxLength - (tcpHeaderSize - 1)) {
-		mb.flush(data[:])
 		err = fmt.Errorf("modbus: length in response header '%v' must not greater than '%v'", length, tcpMaxLength-tcpHeaderSize+1)
 		return
 	}
 	// Skip unit id
 	length += tcpHeaderSize - 1
-	if _, err = io.ReadFull(mb.conn, data[tcpHeaderSize:length]); err != nil {
+	if _, err = io.ReadFull(r, data[tcpHeaderSize:length]); err != nil {
 		return
 	}
 	aduResponse = data[:length]
-	mb.logf("modbus: received % x\n", aduResponse)
 	return
 }
 
@@ -204,17 +362,47 @@ func (mb *tcpTransporter) Connect() error {
 	mb.mu.Lock()
 	defer mb.mu.Unlock()
 
-	return mb.connect()
+	return mb.connect(context.Background())
+}
+
+// lockConnected locks the mutex and connects if not connected, after waiting for
+// the reconnect backoff without holding the mutex. The mutex is held only if
+// lockConnected succeeds.
+func (mb *tcpTransporter) lockConnected(ctx context.Context) error {
+	for {
+		mb.mu.Lock()
+		wait := time.Until(mb.nextDial)
+		if mb.conn != nil || wait <= 0 {
+			break
+		}
+		mb.mu.Unlock()
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+	if err := mb.connect(ctx); err != nil {
+		mb.mu.Unlock()
+		return err
+	}
+	return nil
 }
 
-func (mb *tcpTransporter) connect() error {
+func (mb *tcpTransporter) connect(ctx context.Context) error {
 	if mb.conn == nil {
 		dialer := net.Dialer{Timeout: mb.Timeout}
-		conn, err := dialer.Dial("tcp", mb.Address)
+		conn, err := dialer.DialContext(ctx, "tcp", mb.Address)
 		if err != nil {
+			if mb.backoff *= 2; mb.backoff < mb.ReconnectBackoff {
+				mb.backoff = mb.ReconnectBackoff
+			}
+			if mb.MaxReconnectBackoff > 0 && mb.backoff > mb.MaxReconnectBackoff {
+				mb.backoff = mb.MaxReconnectBackoff
+			}
+			mb.nextDial = time.Now().Add(mb.backoff)
 			return err
 		}
 		mb.conn = conn
+		mb.backoff = 0
 	}
 	return nil
 }
@@ -278,7 +466,7 @@ func (mb *tcpTransporter) closeIdle() {
 		return
 	}
 	idle := time.Now().Sub(mb.lastActivity)
-	if idle >= mb.IdleTimeout {
+	if idle >= mb.IdleTimeout && len(mb.pending) == 0 {
 		mb.logf("modbus: closing connection due to idle timeout: %v", idle)
 		mb.close()
 	}
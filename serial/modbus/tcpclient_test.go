@@ -0,0 +1,241 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTCPServer returns a server listening on a loopback port and its address.
+func newTCPServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	s := NewServer()
+	if err := s.ListenTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, s.listeners[0].Addr().String()
+}
+
+// newTCPClient returns a client of the server at address.
+func newTCPClient(t *testing.T, address string, maxInFlight int) (Client, *TCPClientHandler) {
+	t.Helper()
+
+	handler := NewTCPClientHandler(address)
+	handler.Timeout = 5 * time.Second
+	handler.MaxInFlight = maxInFlight
+	t.Cleanup(func() { handler.Close() })
+	return NewClient(handler), handler
+}
+
+// busyHandler returns a function handler which answers the first n requests
+// with the exception 'server device busy' and the others with next.
+func busyHandler(n int32, calls *atomic.Int32, next func(*Server, Framer) ([]byte, *Exception)) func(*Server, Framer) ([]byte, *Exception) {
+	return func(s *Server, frame Framer) ([]byte, *Exception) {
+		if calls.Add(1) <= n {
+			return []byte{}, &SlaveDeviceBusy
+		}
+		return next(s, frame)
+	}
+}
+
+func TestTCPClientPipelining(t *testing.T) {
+	s, address := newTCPServer(t)
+	for i := range s.HoldingRegisters[:64] {
+		s.HoldingRegisters[i] = uint16(i * 3)
+	}
+	client, _ := newTCPClient(t, address, 8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(address uint16) {
+			defer wg.Done()
+			results, err := client.ReadHoldingRegistersContext(context.Background(), address, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := binary.BigEndian.Uint16(results); got != address*3 {
+				t.Errorf("register %d = %d, want %d", address, got, address*3)
+			}
+		}(uint16(i))
+	}
+	wg.Wait()
+}
+
+// TestTCPClientOutOfOrder answers two pipelined requests in reverse order.
+func TestTCPClientOutOfOrder(t *testing.T) {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+	go func() {
+		conn, err := listen.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var requests [2][]byte
+		for i := range requests {
+			requests[i] = make([]byte, tcpHeaderSize+5)
+			if _, err := io.ReadFull(conn, requests[i]); err != nil {
+				return
+			}
+		}
+		for i := len(requests) - 1; i >= 0; i-- {
+			req := requests[i]
+			// Answer with the value of the requested address.
+			resp := append([]byte(nil), req[:tcpHeaderSize+1]...)
+			binary.BigEndian.PutUint16(resp[4:], 5)
+			resp = append(resp, 2, req[tcpHeaderSize+1], req[tcpHeaderSize+2])
+			if _, err := conn.Write(resp); err != nil {
+				return
+			}
+		}
+		io.Copy(io.Discard, conn)
+	}()
+
+	client, _ := newTCPClient(t, listen.Addr().String(), 2)
+	var wg sync.WaitGroup
+	for _, address := range []uint16{1, 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results, err := client.ReadHoldingRegistersContext(context.Background(), address, 1)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got := binary.BigEndian.Uint16(results); got != address {
+				t.Errorf("register %d = %d, want the response to its own request", address, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTCPClientContextCancel(t *testing.T) {
+	listen, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listen.Close()
+	go func() {
+		// Never answer.
+		conn, err := listen.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	client, _ := newTCPClient(t, listen.Addr().String(), 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.ReadHoldingRegistersContext(ctx, 0, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReadHoldingRegistersContext = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	s, address := newTCPServer(t)
+	var reads, writes atomic.Int32
+	s.RegisterFunctionHandler(FuncCodeReadHoldingRegisters, busyHandler(2, &reads, ReadHoldingRegisters))
+	s.RegisterFunctionHandler(FuncCodeWriteSingleRegister, busyHandler(1, &writes, WriteHoldingRegister))
+	client, _ := newTCPClient(t, address, 1)
+
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	ctx := WithRetryPolicy(context.Background(), policy)
+
+	if _, err := client.ReadHoldingRegistersContext(ctx, 0, 1); err != nil {
+		t.Fatalf("read after 2 busy responses: %v", err)
+	}
+	if n := reads.Load(); n != 3 {
+		t.Errorf("read attempts = %d, want 3", n)
+	}
+
+	// Writes are not retried by default.
+	_, err := client.WriteSingleRegisterContext(ctx, 0, 1)
+	var mbError *ModbusError
+	if !errors.As(err, &mbError) || mbError.ExceptionCode != ExceptionCodeServerDeviceBusy {
+		t.Fatalf("write = %v, want the busy exception", err)
+	}
+	if n := writes.Load(); n != 1 {
+		t.Errorf("write attempts = %d, want 1", n)
+	}
+
+	policy.RetryNonIdempotent = true
+	writes.Store(0)
+	if _, err := client.WriteSingleRegisterContext(ctx, 0, 1); err != nil {
+		t.Fatalf("write with RetryNonIdempotent: %v", err)
+	}
+	if n := writes.Load(); n != 2 {
+		t.Errorf("write attempts = %d, want 2", n)
+	}
+
+	// Other exceptions are not retried.
+	reads.Store(10)
+	if _, err := client.ReadHoldingRegistersContext(ctx, 0xFFFF, 2); err == nil {
+		t.Fatal("read beyond the registers: expected an error")
+	}
+	if n := reads.Load() - 10; n != 1 {
+		t.Errorf("read attempts = %d, want 1", n)
+	}
+}
+
+func TestRetryPolicyIdempotent(t *testing.T) {
+	for _, tc := range []struct {
+		functionCode byte
+		retry        bool
+	}{
+		{FuncCodeReadCoils, true},
+		{FuncCodeReadHoldingRegisters, true},
+		{FuncCodeEncapsulatedInterface, true},
+		{FuncCodeReadFIFOQueue, false},
+		{FuncCodeWriteSingleCoil, false},
+		{FuncCodeWriteMultipleRegisters, false},
+		{FuncCodeReadWriteMultipleRegisters, false},
+		{FuncCodeMaskWriteRegister, false},
+		{FuncCodeDiagnostics, false},
+	} {
+		policy := &RetryPolicy{MaxAttempts: 2}
+		if got := policy.retry(1, tc.functionCode, io.EOF); got != tc.retry {
+			t.Errorf("retry of function %d = %t, want %t", tc.functionCode, got, tc.retry)
+		}
+	}
+}
+
+func TestTCPClientReconnect(t *testing.T) {
+	s, address := newTCPServer(t)
+	s.HoldingRegisters[0] = 42
+	client, handler := newTCPClient(t, address, 2)
+	handler.ReconnectBackoff = time.Millisecond
+
+	if _, err := client.ReadHoldingRegisters(0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the connection, the next request dials again.
+	handler.mu.Lock()
+	handler.conn.Close()
+	handler.mu.Unlock()
+
+	policy := &RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	results, err := client.ReadHoldingRegistersContext(WithRetryPolicy(context.Background(), policy), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := binary.BigEndian.Uint16(results); got != 42 {
+		t.Errorf("register 0 = %d, want 42", got)
+	}
+}
@@ -0,0 +1,214 @@
+package modbus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRTUFrameGap(t *testing.T) {
+	tests := []struct {
+		baudRate int
+		want     time.Duration
+	}{
+		{1200, 32083 * time.Microsecond},
+		{9600, 4010 * time.Microsecond},
+		{19200, 2005 * time.Microsecond},
+		{0, 2005 * time.Microsecond}, // the default of 19200 baud
+		{38400, 1750 * time.Microsecond},
+		{115200, 1750 * time.Microsecond},
+	}
+	for _, test := range tests {
+		if got := rtuFrameGap(test.baudRate); got != test.want {
+			t.Errorf("rtuFrameGap(%d) = %v, want %v", test.baudRate, got, test.want)
+		}
+	}
+}
+
+// testRoundTrip writes and reads registers and coils through client and
+// checks that exceptions are returned.
+func testRoundTrip(t *testing.T, client Client) {
+	t.Helper()
+
+	if _, err := client.WriteMultipleRegisters(10, 3, []byte{0, 1, 0x12, 0x34, 0xFF, 0xFF}); err != nil {
+		t.Fatal(err)
+	}
+	results, err := client.ReadHoldingRegisters(10, 3)
+	if err != nil || !bytes.Equal(results, []byte{0, 1, 0x12, 0x34, 0xFF, 0xFF}) {
+		t.Errorf("ReadHoldingRegisters = % x, %v, want 00 01 12 34 ff ff", results, err)
+	}
+	if _, err := client.WriteMultipleCoils(3, 10, []byte{0xA5, 0x02}); err != nil {
+		t.Fatal(err)
+	}
+	results, err = client.ReadCoils(3, 10)
+	if err != nil || !bytes.Equal(results, []byte{0xA5, 0x02}) {
+		t.Errorf("ReadCoils = % x, %v, want a5 02", results, err)
+	}
+	if _, err := client.ReadHoldingRegisters(65535, 2); exceptionCode(err) != ExceptionCodeIllegalDataAddress {
+		t.Errorf("ReadHoldingRegisters beyond the table: %v, want illegal data address", err)
+	}
+}
+
+func newRTUOverTCPServer(t *testing.T) (*Server, string) {
+	t.Helper()
+
+	s := NewServer()
+	if err := s.ListenRTUOverTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s, s.listeners[0].Addr().String()
+}
+
+func TestRTUOverTCP(t *testing.T) {
+	_, address := newRTUOverTCPServer(t)
+	handler := NewRTUOverTCPClientHandler(address)
+	handler.SlaveId = 1
+	handler.Timeout = 5 * time.Second
+	t.Cleanup(func() { handler.Close() })
+	testRoundTrip(t, NewClient(handler))
+}
+
+// TestRTUOverTCPFraming sends requests split across and coalesced in writes,
+// and a partial request which is discarded after the frame timeout.
+func TestRTUOverTCPFraming(t *testing.T) {
+	s, address := newRTUOverTCPServer(t)
+	s.Store(1).(*MemoryStore).SetHoldingRegisters(0, 0x1234, 0x5678)
+
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	packager := &rtuPackager{SlaveId: 1}
+	encode := func(address uint16) []byte {
+		t.Helper()
+		adu, err := packager.Encode(&ProtocolDataUnit{
+			FunctionCode: FuncCodeReadHoldingRegisters,
+			Data:         dataBlock(address, 1),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return adu
+	}
+	// The responses are read one at a time, they may be coalesced as well
+	expect := func(want uint16) {
+		t.Helper()
+		var adu [7]byte
+		if _, err := io.ReadFull(conn, adu[:]); err != nil {
+			t.Fatal(err)
+		}
+		pdu, err := packager.Decode(adu[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := binary.BigEndian.Uint16(pdu.Data[1:]); got != want {
+			t.Errorf("register = %#x, want %#x", got, want)
+		}
+	}
+
+	first, second := encode(0), encode(1)
+	conn.Write(first[:3])
+	time.Sleep(50 * time.Millisecond)
+	conn.Write(first[3:])
+	expect(0x1234)
+
+	conn.Write(append(bytes.Clone(first), second...))
+	expect(0x1234)
+	expect(0x5678)
+
+	conn.Write(second[:5])
+	time.Sleep(rtuOverTCPFrameTimeout + 100*time.Millisecond)
+	conn.Write(first)
+	expect(0x1234)
+}
+
+func TestASCIIOverTCP(t *testing.T) {
+	s := NewServer()
+	if err := s.ListenASCIIOverTCP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	address := s.listeners[0].Addr().String()
+	handler := NewASCIIOverTCPClientHandler(address)
+	handler.SlaveId = 1
+	handler.Timeout = 5 * time.Second
+	t.Cleanup(func() { handler.Close() })
+	client := NewClient(handler)
+	testRoundTrip(t, client)
+
+	// A frame with an LRC error is discarded and counted.
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(":010300000001FA\r\n"))
+	time.Sleep(100 * time.Millisecond)
+	results, err := client.Diagnostics(DiagReturnBusCommErrorCount, []byte{0, 0})
+	if err != nil || !bytes.Equal(results, []byte{0, 1}) {
+		t.Errorf("bus communication error count = %x, %v, want 0001", results, err)
+	}
+}
+
+func TestUDP(t *testing.T) {
+	s := NewServer()
+	if err := s.ListenUDP("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	address := s.packetConns[0].LocalAddr().String()
+
+	newClient := func() Client {
+		handler := NewUDPClientHandler(address)
+		handler.SlaveId = 1
+		handler.Timeout = 5 * time.Second
+		t.Cleanup(func() { handler.Close() })
+		return NewClient(handler)
+	}
+	client := newClient()
+	testRoundTrip(t, client)
+
+	// Malformed datagrams are ignored.
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.Write([]byte{0, 1, 0, 0})
+
+	// Each client gets the responses to its own requests, and requests of a
+	// client are sent one at a time.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		client := newClient()
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func(address uint16) {
+				defer wg.Done()
+				for k := uint16(0); k < 20; k++ {
+					if _, err := client.WriteSingleRegister(address, k); err != nil {
+						t.Error(err)
+						return
+					}
+					results, err := client.ReadHoldingRegisters(address, 1)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if got := binary.BigEndian.Uint16(results); got != k {
+						t.Errorf("register %d = %d, want %d", address, got, k)
+					}
+				}
+			}(uint16(100 + 2*i + j))
+		}
+	}
+	wg.Wait()
+}
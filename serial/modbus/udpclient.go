@@ -0,0 +1,132 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// UDPClientHandler implements Packager and Transporter interface for MBAP frames
+// over UDP.
+type UDPClientHandler struct {
+	tcpPackager
+	udpTransporter
+}
+
+// NewUDPClientHandler allocates a new UDPClientHandler.
+func NewUDPClientHandler(address string) *UDPClientHandler {
+	h := &UDPClientHandler{}
+	h.Address = address
+	h.Timeout = tcpTimeout
+	return h
+}
+
+// UDPClient creates UDP client with default handler and given connect string.
+func UDPClient(address string) Client {
+	handler := NewUDPClientHandler(address)
+	return NewClient(handler)
+}
+
+// udpTransporter implements Transporter interface.
+type udpTransporter struct {
+	// Connect string
+	Address string
+	// Read timeout
+	Timeout time.Duration
+	// Transmission logger
+	Logger *log.Logger
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Send sends the request in one datagram and waits for the response datagram.
+func (mb *udpTransporter) Send(aduRequest []byte) (aduResponse []byte, err error) {
+	return mb.SendContext(context.Background(), aduRequest)
+}
+
+// SendContext sends the request in one datagram and waits for the response datagram
+// with the same transaction identifier. Late responses to earlier requests are skipped.
+func (mb *udpTransporter) SendContext(ctx context.Context, aduRequest []byte) (aduResponse []byte, err error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	if err = mb.connect(); err != nil {
+		return
+	}
+	var timeout time.Time
+	if mb.Timeout > 0 {
+		timeout = time.Now().Add(mb.Timeout)
+	}
+	if err = mb.conn.SetDeadline(timeout); err != nil {
+		return
+	}
+	// Abort the request when ctx is done
+	conn := mb.conn
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer func() {
+		if !stop() && err != nil {
+			err = ctx.Err()
+		}
+	}()
+	mb.logf("modbus: sending % x", aduRequest)
+	if _, err = mb.conn.Write(aduRequest); err != nil {
+		return
+	}
+	for {
+		var n int
+		var data [tcpMaxLength]byte
+		if n, err = mb.conn.Read(data[:]); err != nil {
+			return
+		}
+		if n <= tcpHeaderSize || binary.BigEndian.Uint16(data[:]) != binary.BigEndian.Uint16(aduRequest) {
+			mb.logf("modbus: skipping % x", data[:n])
+			continue
+		}
+		aduResponse = data[:n]
+		mb.logf("modbus: received % x\n", aduResponse)
+		return
+	}
+}
+
+// Connect creates the UDP socket of the address in Address.
+func (mb *udpTransporter) Connect() error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	return mb.connect()
+}
+
+func (mb *udpTransporter) connect() error {
+	if mb.conn == nil {
+		conn, err := net.Dial("udp", mb.Address)
+		if err != nil {
+			return err
+		}
+		mb.conn = conn
+	}
+	return nil
+}
+
+// Close closes the UDP socket.
+func (mb *udpTransporter) Close() (err error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+
+	if mb.conn != nil {
+		err = mb.conn.Close()
+		mb.conn = nil
+	}
+	return
+}
+
+func (mb *udpTransporter) logf(format string, v ...interface{}) {
+	if mb.Logger != nil {
+		mb.Logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,84 @@
+package serial
+
+import (
+	"context"
+	"time"
+)
+
+// PortInfo describes a serial port found by ListPorts.
+type PortInfo struct {
+	// Device path (/dev/ttyUSB0, COM3), which can be used as Config.Address.
+	Name string
+	// USB is true for ports of USB devices. The following fields are only set for
+	// USB ports, and only on Linux.
+	USB bool
+	// USB vendor and product ID
+	VID uint16
+	PID uint16
+	// USB string descriptors
+	SerialNumber string
+	Manufacturer string
+	Product      string
+	// Number of the USB interface of a composite device.
+	Interface int
+}
+
+// ListPorts returns the serial ports of the system sorted by name.
+func ListPorts() ([]PortInfo, error) {
+	return listPorts()
+}
+
+// PortEvent is sent by WatchPorts when a port appears or disappears.
+type PortEvent struct {
+	Port PortInfo
+	// Removed is false if the port was added.
+	Removed bool
+}
+
+// WatchPorts calls ListPorts every interval and sends an event for every port
+// which was added or removed until ctx is done. The ports which are present at the
+// start are sent as added. The channel is closed when ctx is done.
+func WatchPorts(ctx context.Context, interval time.Duration) <-chan PortEvent {
+	events := make(chan PortEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		known := map[string]PortInfo{}
+		for {
+			// Errors are transient, e.g. while a device is removed.
+			if ports, err := listPorts(); err == nil {
+				current := make(map[string]PortInfo, len(ports))
+				for _, p := range ports {
+					current[p.Name] = p
+					if _, ok := known[p.Name]; !ok && !send(ctx, events, PortEvent{Port: p}) {
+						return
+					}
+				}
+				for name, p := range known {
+					if _, ok := current[name]; !ok && !send(ctx, events, PortEvent{Port: p, Removed: true}) {
+						return
+					}
+				}
+				known = current
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+func send(ctx context.Context, events chan<- PortEvent, event PortEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,84 @@
+package serial
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfs is the mount point of sysfs.
+var sysfs = "/sys"
+
+// listPorts lists the ttys in sysfs which belong to a device. The placeholder
+// ports of the 8250 driver without hardware are skipped.
+func listPorts() ([]PortInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(sysfs, "class", "tty"))
+	if err != nil {
+		return nil, err
+	}
+
+	var ports []PortInfo
+	for _, entry := range entries {
+		device, err := filepath.EvalSymlinks(filepath.Join(sysfs, "class", "tty", entry.Name(), "device"))
+		if err != nil {
+			// Virtual terminal
+			continue
+		}
+		if linkName(filepath.Join(device, "driver")) == "serial8250" {
+			continue
+		}
+
+		port := PortInfo{Name: "/dev/" + entry.Name()}
+		switch linkName(filepath.Join(device, "subsystem")) {
+		case "usb", "usb-serial":
+			readUSBInfo(device, &port)
+		}
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports, nil
+}
+
+// readUSBInfo reads the attributes of the USB interface and device which are
+// parents of the tty device.
+func readUSBInfo(device string, port *PortInfo) {
+	port.USB = true
+	port.Interface = -1
+	for dir := device; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if port.Interface < 0 {
+			if n, err := strconv.ParseUint(readAttr(dir, "bInterfaceNumber"), 16, 8); err == nil {
+				port.Interface = int(n)
+			}
+		}
+		vid, err := strconv.ParseUint(readAttr(dir, "idVendor"), 16, 16)
+		if err != nil {
+			continue
+		}
+		pid, _ := strconv.ParseUint(readAttr(dir, "idProduct"), 16, 16)
+		port.VID = uint16(vid)
+		port.PID = uint16(pid)
+		port.SerialNumber = readAttr(dir, "serial")
+		port.Manufacturer = readAttr(dir, "manufacturer")
+		port.Product = readAttr(dir, "product")
+		return
+	}
+}
+
+func readAttr(dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// linkName returns the base name of the target of the symlink path.
+func linkName(path string) string {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
@@ -0,0 +1,37 @@
+//go:build darwin || freebsd || openbsd || netbsd
+
+package serial
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// devicePatterns are the names of the callout devices of serial ports.
+var devicePatterns = map[string][]string{
+	"darwin":  {"/dev/cu.*"},
+	"freebsd": {"/dev/cuau*", "/dev/cuaU*"},
+	"openbsd": {"/dev/cua0*", "/dev/cuaU*"},
+	"netbsd":  {"/dev/dty0*", "/dev/dtyU*"},
+}
+
+// listPorts lists the device files of serial ports.
+func listPorts() ([]PortInfo, error) {
+	var ports []PortInfo
+	for _, pattern := range devicePatterns[runtime.GOOS] {
+		names, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			// Skip the .init and .lock devices of FreeBSD.
+			if filepath.Ext(name) == ".init" || filepath.Ext(name) == ".lock" {
+				continue
+			}
+			ports = append(ports, PortInfo{Name: name})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports, nil
+}
@@ -0,0 +1,46 @@
+package serial
+
+import (
+	"sort"
+	"syscall"
+	"unsafe"
+)
+
+// errorNoMoreItems is ERROR_NO_MORE_ITEMS, which is not defined in package syscall.
+const errorNoMoreItems syscall.Errno = 259
+
+// listPorts lists the COM ports in the registry key HKLM\HARDWARE\DEVICEMAP\SERIALCOMM.
+func listPorts() ([]PortInfo, error) {
+	var key syscall.Handle
+	err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE,
+		syscall.StringToUTF16Ptr(`HARDWARE\DEVICEMAP\SERIALCOMM`), 0, syscall.KEY_READ, &key)
+	if err != nil {
+		if err == syscall.ERROR_FILE_NOT_FOUND {
+			// No serial ports
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer syscall.RegCloseKey(key)
+
+	var ports []PortInfo
+	for i := uint32(0); ; i++ {
+		var name [256]uint16
+		var data [256]uint16
+		nameLen := uint32(len(name))
+		dataLen := uint32(len(data) * 2)
+		var valtype uint32
+		err := RegEnumValue(key, i, &name[0], &nameLen, nil, &valtype, (*byte)(unsafe.Pointer(&data[0])), &dataLen)
+		if err == errorNoMoreItems {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if valtype == syscall.REG_SZ {
+			ports = append(ports, PortInfo{Name: syscall.UTF16ToString(data[:dataLen/2])})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Name < ports[j].Name })
+	return ports, nil
+}
@@ -4,6 +4,7 @@ Package serial provides a cross-platform serial reader and writer.
 package serial
 
 import (
+	"context"
 	"errors"
 	"io"
 	"time"
@@ -14,6 +15,12 @@ var (
 	ErrTimeout = errors.New("serial: timeout")
 )
 
+// XON and XOFF characters of software flow control.
+const (
+	xon  = 0x11
+	xoff = 0x13
+)
+
 // Config is common configuration for serial port.
 type Config struct {
 	// Device path (/dev/ttyS0)
@@ -29,10 +36,32 @@ type Config struct {
 	Parity string
 	// Read (Write) timeout.
 	Timeout time.Duration
+	// Flow control (default FlowNone)
+	FlowControl FlowControl
+	// A read returns when no further byte arrived within InterByteTimeout after
+	// the last one (VTIME, 0.1s resolution and at most 25.5s on POSIX).
+	InterByteTimeout time.Duration
+	// A read waits for at least MinReadSize bytes once the first byte arrived
+	// (VMIN, at most 255, POSIX only). The wait is not bounded by Timeout unless
+	// InterByteTimeout is set.
+	MinReadSize int
 	// Configuration related to RS485
 	RS485 RS485Config
 }
 
+// FlowControl is the flow control of a serial port.
+type FlowControl int
+
+// Flow control modes.
+const (
+	// FlowNone disables flow control.
+	FlowNone FlowControl = iota
+	// FlowHardware enables RTS/CTS flow control.
+	FlowHardware
+	// FlowSoftware enables XON/XOFF flow control.
+	FlowSoftware
+)
+
 // platform independent RS485 config. Thie structure is ignored unless Enable is true.
 type RS485Config struct {
 	// Enable RS485 support
@@ -54,6 +83,35 @@ type Port interface {
 	io.ReadWriteCloser
 	// Connect connects to the serial port.
 	Open(*Config) error
+	// SetDTR sets (true) or clears (false) the Data Terminal Ready line.
+	SetDTR(bool) error
+	// SetRTS sets (true) or clears (false) the Request To Send line.
+	SetRTS(bool) error
+	// ModemStatus returns the state of the modem status lines.
+	ModemStatus() (ModemStatus, error)
+	// SendBreak holds the transmit line low for the duration d.
+	SendBreak(d time.Duration) error
+	// Flush discards data received but not read and data written but not sent.
+	Flush() error
+	// Drain waits until all written data has been sent.
+	Drain() error
+	// SetReadDeadline sets the deadline for Read and ReadContext, which replaces
+	// Config.Timeout. A zero value restores Config.Timeout.
+	SetReadDeadline(t time.Time) error
+	// ReadContext reads like Read, but returns ctx.Err() when ctx is done first.
+	ReadContext(ctx context.Context, b []byte) (n int, err error)
+}
+
+// ModemStatus is the state of the modem status lines.
+type ModemStatus struct {
+	// Clear To Send
+	CTS bool
+	// Data Set Ready
+	DSR bool
+	// Data Carrier Detect
+	DCD bool
+	// Ring Indicator
+	RI bool
 }
 
 // Open opens a serial port.
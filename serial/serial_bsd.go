@@ -5,6 +5,7 @@ package serial
 
 import (
 	"fmt"
+	"runtime"
 	"syscall"
 	"unsafe"
 )
@@ -31,6 +32,10 @@ var baudRates = map[int]uint32{
 	460800: syscall.B460800,
 }
 
+// crtscts enables RTS/CTS flow control (CCTS_OFLOW | CRTS_IFLOW on FreeBSD,
+// CRTSCTS on NetBSD), it is missing in package syscall.
+var crtscts = map[string]uint32{"freebsd": 0x00030000, "netbsd": 0x00010000}[runtime.GOOS]
+
 var charSizes = map[int]uint32{
 	5: syscall.CS5,
 	6: syscall.CS6,
@@ -84,7 +89,7 @@ func fdget(fd int, fds *syscall.FdSet) (index, offset int) {
 // fdset implements FD_SET macro.
 func fdset(fd int, fds *syscall.FdSet) {
 	idx, pos := fdget(fd, fds)
-	fds.X__fds_bits[idx] = 1 << uint(pos)
+	fds.X__fds_bits[idx] |= 1 << uint(pos)
 }
 
 // fdisset implements FD_ISSET macro.
@@ -92,3 +97,21 @@ func fdisset(fd int, fds *syscall.FdSet) bool {
 	idx, pos := fdget(fd, fds)
 	return fds.X__fds_bits[idx]&(1<<uint(pos)) != 0
 }
+
+// tcflush discards the input and output queues.
+// See man tcflush(3).
+func tcflush(fd int) error {
+	var which int32 // both queues
+	return ioctl(fd, syscall.TIOCFLUSH, uintptr(unsafe.Pointer(&which)))
+}
+
+// tcdrain waits until the output queue has been transmitted.
+// See man tcdrain(3).
+func tcdrain(fd int) error {
+	return ioctl(fd, syscall.TIOCDRAIN, 0)
+}
+
+// setCustomBaudRate sets a baud rate without B constant, which is not supported.
+func setCustomBaudRate(fd int, rate int) error {
+	return fmt.Errorf("serial: unsupported baud rate %v", rate)
+}
@@ -39,6 +39,9 @@ var baudRates = map[int]uint32{
 	4000000: syscall.B4000000,
 }
 
+// crtscts enables RTS/CTS flow control, it is missing in package syscall.
+const crtscts = 0x80000000
+
 var charSizes = map[int]uint32{
 	5: syscall.CS5,
 	6: syscall.CS6,
@@ -93,7 +96,7 @@ func fdget(fd int, fds *syscall.FdSet) (index, offset int) {
 // fdset implements FD_SET macro.
 func fdset(fd int, fds *syscall.FdSet) {
 	idx, pos := fdget(fd, fds)
-	fds.Bits[idx] = 1 << uint(pos)
+	fds.Bits[idx] |= 1 << uint(pos)
 }
 
 // fdisset implements FD_ISSET macro.
@@ -101,3 +104,15 @@ func fdisset(fd int, fds *syscall.FdSet) bool {
 	idx, pos := fdget(fd, fds)
 	return fds.Bits[idx]&(1<<uint(pos)) != 0
 }
+
+// tcflush discards the input and output queues.
+// See man tcflush(3).
+func tcflush(fd int) error {
+	return ioctl(fd, tcflsh, syscall.TCIOFLUSH)
+}
+
+// tcdrain waits until the output queue has been transmitted.
+// See man tcdrain(3), TCSBRK with a non-zero argument does not send a break.
+func tcdrain(fd int) error {
+	return ioctl(fd, tcsbrk, 1)
+}
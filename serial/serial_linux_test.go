@@ -0,0 +1,358 @@
+package serial
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPTY opens a pseudo-terminal and returns its master and the path of the
+// slave, which stands in for a serial port.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+
+	var unlock int32
+	if err := ioctl(int(master.Fd()), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); err != nil {
+		t.Fatal(err)
+	}
+	var n uint32
+	if err := ioctl(int(master.Fd()), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); err != nil {
+		t.Fatal(err)
+	}
+	return master, "/dev/pts/" + strconv.Itoa(int(n))
+}
+
+// openPort opens the slave of a pseudo-terminal as serial port.
+func openPort(t *testing.T, c Config) (Port, *os.File) {
+	t.Helper()
+
+	master, name := openPTY(t)
+	c.Address = name
+	p, err := Open(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p, master
+}
+
+func TestReadWrite(t *testing.T) {
+	p, master := openPort(t, Config{BaudRate: 115200, Parity: "N", Timeout: time.Second})
+
+	if _, err := p.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	b := make([]byte, 16)
+	n, err := master.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "ping" {
+		t.Errorf("master read %q, want ping", b[:n])
+	}
+
+	if _, err := master.Write([]byte("pong")); err != nil {
+		t.Fatal(err)
+	}
+	n, err = p.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "pong" {
+		t.Errorf("port read %q, want pong", b[:n])
+	}
+}
+
+func TestReadTimeout(t *testing.T) {
+	p, _ := openPort(t, Config{Parity: "N", Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := p.Read(make([]byte, 1)); err != ErrTimeout {
+		t.Fatalf("Read = %v, want ErrTimeout", err)
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("Read returned after %v, before the timeout", d)
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	p, master := openPort(t, Config{Parity: "N", Timeout: time.Hour})
+
+	if err := p.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(make([]byte, 1)); err != ErrTimeout {
+		t.Fatalf("Read = %v, want ErrTimeout", err)
+	}
+
+	// A zero deadline restores the timeout.
+	if err := p.SetReadDeadline(time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := master.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetReadDeadlinePendingRead(t *testing.T) {
+	p, _ := openPort(t, Config{Parity: "N"})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Read(make([]byte, 1))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := p.SetReadDeadline(time.Now().Add(20 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != ErrTimeout {
+			t.Errorf("Read = %v, want ErrTimeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pending Read did not return at the new deadline")
+	}
+}
+
+func TestReadContext(t *testing.T) {
+	p, _ := openPort(t, Config{Parity: "N"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.ReadContext(ctx, make([]byte, 1)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ReadContext = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestInterByteTimeout(t *testing.T) {
+	p, master := openPort(t, Config{Parity: "N", MinReadSize: 4, InterByteTimeout: 100 * time.Millisecond})
+
+	if _, err := master.Write([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		master.Write([]byte("cd"))
+	}()
+	b := make([]byte, 16)
+	n, err := p.Read(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b[:n]) != "abcd" {
+		t.Errorf("Read = %q, want abcd", b[:n])
+	}
+}
+
+func TestFlushDrain(t *testing.T) {
+	p, master := openPort(t, Config{Parity: "N", Timeout: 50 * time.Millisecond})
+
+	if _, err := master.Write([]byte("stale")); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the data to arrive at the slave.
+	time.Sleep(20 * time.Millisecond)
+	if err := p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := p.Read(make([]byte, 16)); err != ErrTimeout {
+		t.Errorf("Read after Flush = %d, %v, want ErrTimeout", n, err)
+	}
+
+	if _, err := p.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Drain(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenInvalidConfig(t *testing.T) {
+	_, name := openPTY(t)
+	for _, c := range []Config{
+		{DataBits: 9},
+		{StopBits: 3},
+		{Parity: "X"},
+		{FlowControl: 7},
+		{MinReadSize: 256},
+		{InterByteTimeout: time.Minute},
+	} {
+		c.Address = name
+		if p, err := Open(&c); err == nil {
+			p.Close()
+			t.Errorf("Open(%+v): expected an error", c)
+		}
+	}
+}
+
+func TestListPorts(t *testing.T) {
+	root := t.TempDir()
+	defer func(dir string) { sysfs = dir }(sysfs)
+	sysfs = root
+
+	mkdir := func(path string) string {
+		dir := filepath.Join(root, path)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	symlink := func(target, path string) {
+		if err := os.Symlink(target, filepath.Join(root, path)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeAttr := func(dir, name, value string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mkdir("class/tty")
+	mkdir("bus/usb")
+	mkdir("bus/platform")
+	mkdir("bus/serial8250")
+	mkdir("driver/serial8250")
+
+	// USB serial adapter: tty -> interface -> device
+	usbDevice := mkdir("devices/usb1/1-1")
+	writeAttr(usbDevice, "idVendor", "0403")
+	writeAttr(usbDevice, "idProduct", "6001")
+	writeAttr(usbDevice, "serial", "A12345")
+	writeAttr(usbDevice, "manufacturer", "FTDI")
+	writeAttr(usbDevice, "product", "FT232R USB UART")
+	usbInterface := mkdir("devices/usb1/1-1/1-1:1.0")
+	writeAttr(usbInterface, "bInterfaceNumber", "00")
+	symlink(filepath.Join(root, "bus/usb"), "devices/usb1/1-1/1-1:1.0/subsystem")
+	mkdir("class/tty/ttyUSB0")
+	symlink(usbInterface, "class/tty/ttyUSB0/device")
+
+	// Platform UART
+	uart := mkdir("devices/platform/uart0")
+	symlink(filepath.Join(root, "bus/platform"), "devices/platform/uart0/subsystem")
+	mkdir("class/tty/ttyAMA0")
+	symlink(uart, "class/tty/ttyAMA0/device")
+
+	// Placeholder port of the 8250 driver
+	placeholder := mkdir("devices/platform/serial8250")
+	symlink(filepath.Join(root, "driver/serial8250"), "devices/platform/serial8250/driver")
+	mkdir("class/tty/ttyS0")
+	symlink(placeholder, "class/tty/ttyS0/device")
+
+	// Virtual terminal without device
+	mkdir("class/tty/tty0")
+
+	ports, err := ListPorts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PortInfo{
+		{Name: "/dev/ttyAMA0"},
+		{
+			Name:         "/dev/ttyUSB0",
+			USB:          true,
+			VID:          0x0403,
+			PID:          0x6001,
+			SerialNumber: "A12345",
+			Manufacturer: "FTDI",
+			Product:      "FT232R USB UART",
+			Interface:    0,
+		},
+	}
+	if len(ports) != len(want) {
+		t.Fatalf("ListPorts = %+v, want %+v", ports, want)
+	}
+	for i := range want {
+		if ports[i] != want[i] {
+			t.Errorf("ListPorts[%d] = %+v, want %+v", i, ports[i], want[i])
+		}
+	}
+}
+
+func TestWatchPorts(t *testing.T) {
+	root := t.TempDir()
+	defer func(dir string) { sysfs = dir }(sysfs)
+	sysfs = root
+
+	if err := os.MkdirAll(filepath.Join(root, "class/tty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	device := filepath.Join(root, "devices/uart0")
+	if err := os.MkdirAll(device, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	addPort := func() {
+		dir := filepath.Join(root, "class/tty/ttyAMA0")
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(device, filepath.Join(dir, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := WatchPorts(ctx, 5*time.Millisecond)
+
+	next := func() PortEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+			return PortEvent{}
+		}
+	}
+
+	addPort()
+	if event := next(); event.Removed || event.Port.Name != "/dev/ttyAMA0" {
+		t.Errorf("event = %+v, want ttyAMA0 added", event)
+	}
+	if err := os.RemoveAll(filepath.Join(root, "class/tty/ttyAMA0")); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); !event.Removed || event.Port.Name != "/dev/ttyAMA0" {
+		t.Errorf("event = %+v, want ttyAMA0 removed", event)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestModemControl(t *testing.T) {
+	p, _ := openPort(t, Config{Parity: "N"})
+
+	// Pseudo-terminals have no modem lines, the requests must fail cleanly.
+	if err := p.SetDTR(true); err == nil {
+		t.Error("SetDTR on a pseudo-terminal: expected an error")
+	}
+	if err := p.SetRTS(true); err == nil {
+		t.Error("SetRTS on a pseudo-terminal: expected an error")
+	}
+	if _, err := p.ModemStatus(); err == nil {
+		t.Error("ModemStatus on a pseudo-terminal: expected an error")
+	}
+	if err := p.SendBreak(time.Millisecond); err != nil {
+		t.Errorf("SendBreak = %v", err)
+	}
+}
@@ -27,6 +27,9 @@ var baudRates = map[int]uint32{
 	230400: syscall.B230400,
 }
 
+// crtscts enables RTS/CTS flow control, it is missing in package syscall.
+const crtscts = 0x00010000
+
 var charSizes = map[int]uint32{
 	5: syscall.CS5,
 	6: syscall.CS6,
@@ -80,7 +83,7 @@ func fdget(fd int, fds *syscall.FdSet) (index, offset int) {
 // fdset implements FD_SET macro.
 func fdset(fd int, fds *syscall.FdSet) {
 	idx, pos := fdget(fd, fds)
-	fds.Bits[idx] = 1 << uint(pos)
+	fds.Bits[idx] |= 1 << uint(pos)
 }
 
 // fdisset implements FD_ISSET macro.
@@ -88,3 +91,21 @@ func fdisset(fd int, fds *syscall.FdSet) bool {
 	idx, pos := fdget(fd, fds)
 	return fds.Bits[idx]&(1<<uint(pos)) != 0
 }
+
+// tcflush discards the input and output queues.
+// See man tcflush(3).
+func tcflush(fd int) error {
+	var which int32 // both queues
+	return ioctl(fd, syscall.TIOCFLUSH, uintptr(unsafe.Pointer(&which)))
+}
+
+// tcdrain waits until the output queue has been transmitted.
+// See man tcdrain(3).
+func tcdrain(fd int) error {
+	return ioctl(fd, syscall.TIOCDRAIN, 0)
+}
+
+// setCustomBaudRate sets a baud rate without B constant, which is not supported.
+func setCustomBaudRate(fd int, rate int) error {
+	return fmt.Errorf("serial: unsupported baud rate %v", rate)
+}
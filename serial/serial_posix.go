@@ -4,10 +4,12 @@
 package serial
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"syscall"
 	"time"
 	"unsafe"
@@ -19,6 +21,12 @@ type port struct {
 	oldTermios *syscall.Termios
 
 	timeout time.Duration
+	// mu guards deadline, which is set while a read may be pending.
+	mu       sync.Mutex
+	deadline time.Time
+	// pipe which wakes up a read waiting in select
+	wakeR int
+	wakeW int
 }
 
 const (
@@ -39,7 +47,7 @@ type rs485_ioctl_opts struct {
 
 // New allocates and returns a new serial port controller.
 func New() Port {
-	return &port{fd: -1}
+	return &port{fd: -1, wakeR: -1, wakeW: -1}
 }
 
 // Open connects to the given serial port.
@@ -64,11 +72,31 @@ func (p *port) Open(c *Config) (err error) {
 		p.oldTermios = nil
 		return err
 	}
+	if _, ok := baudRates[c.BaudRate]; c.BaudRate != 0 && !ok {
+		if err = setCustomBaudRate(p.fd, c.BaudRate); err != nil {
+			p.Close()
+			return err
+		}
+	}
+	// VMIN and VTIME only apply to blocking reads.
+	if c.InterByteTimeout > 0 || c.MinReadSize > 0 {
+		if err = syscall.SetNonblock(p.fd, false); err != nil {
+			p.Close()
+			return err
+		}
+	}
 	if err = enableRS485(p.fd, &c.RS485); err != nil {
 		p.Close()
 		return err
 	}
+	if err = p.openWakePipe(); err != nil {
+		p.Close()
+		return err
+	}
 	p.timeout = c.Timeout
+	p.mu.Lock()
+	p.deadline = time.Time{}
+	p.mu.Unlock()
 	return
 }
 
@@ -80,39 +108,103 @@ func (p *port) Close() (err error) {
 	err = syscall.Close(p.fd)
 	p.fd = -1
 	p.oldTermios = nil
+	if p.wakeR != -1 {
+		syscall.Close(p.wakeR)
+		syscall.Close(p.wakeW)
+		p.wakeR, p.wakeW = -1, -1
+	}
 	return
 }
 
 // Read reads from serial port. Port must be opened before calling this method.
 // It is blocked until all data received or timeout after p.timeout.
 func (p *port) Read(b []byte) (n int, err error) {
-	var rfds syscall.FdSet
-
-	fd := p.fd
-	fdset(fd, &rfds)
+	return p.ReadContext(context.Background(), b)
+}
 
-	var tv *syscall.Timeval
-	if p.timeout > 0 {
-		timeout := syscall.NsecToTimeval(p.timeout.Nanoseconds())
-		tv = &timeout
+// ReadContext reads like Read, but returns early with ctx.Err() when ctx is done.
+func (p *port) ReadContext(ctx context.Context, b []byte) (n int, err error) {
+	start := time.Now()
+	deadline := p.readDeadline(start)
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, p.wake)
+		defer stop()
 	}
+
+	fd, wake := p.fd, p.wakeR
 	for {
-		// If syscall.Select() returns EINTR (Interrupted system call), retry it
-		if err = syscallSelect(fd+1, &rfds, nil, nil, tv); err == nil {
-			break
+		var rfds syscall.FdSet
+		fdset(fd, &rfds)
+		nfd := fd
+		if wake != -1 {
+			fdset(wake, &rfds)
+			nfd = max(fd, wake)
 		}
-		if err != syscall.EINTR {
+
+		var tv *syscall.Timeval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				err = ErrTimeout
+				return
+			}
+			timeout := syscall.NsecToTimeval(remaining.Nanoseconds())
+			tv = &timeout
+		}
+		// If syscall.Select() returns EINTR (Interrupted system call), retry it
+		if err = syscallSelect(nfd+1, &rfds, nil, nil, tv); err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
 			err = fmt.Errorf("serial: could not select: %v", err)
 			return
 		}
-	}
-	if !fdisset(fd, &rfds) {
-		// Timeout
-		err = ErrTimeout
+		if wake != -1 && fdisset(wake, &rfds) {
+			// Woken up by the context or a new deadline. The wake up may also be
+			// left over from an earlier call.
+			var buf [16]byte
+			syscall.Read(wake, buf[:])
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			deadline = p.readDeadline(start)
+			continue
+		}
+		if !fdisset(fd, &rfds) {
+			// Timeout
+			err = ErrTimeout
+			return
+		}
+		n, err = syscall.Read(fd, b)
 		return
 	}
-	n, err = syscall.Read(fd, b)
-	return
+}
+
+// readDeadline returns the deadline of a read which started at start.
+func (p *port) readDeadline(start time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.deadline.IsZero() && p.timeout > 0 {
+		return start.Add(p.timeout)
+	}
+	return p.deadline
+}
+
+// SetReadDeadline sets the deadline of reads, a zero value restores the timeout.
+// A pending read continues with the new deadline.
+func (p *port) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	p.deadline = t
+	p.mu.Unlock()
+	p.wake()
+	return nil
+}
+
+// wake wakes up a pending read.
+func (p *port) wake() {
+	if p.wakeW != -1 {
+		syscall.Write(p.wakeW, []byte{0})
+	}
 }
 
 // Write writes data to the serial port.
@@ -121,6 +213,94 @@ func (p *port) Write(b []byte) (n int, err error) {
 	return
 }
 
+// SetDTR sets or clears the DTR line.
+func (p *port) SetDTR(dtr bool) error {
+	return p.setModemBits(syscall.TIOCM_DTR, dtr)
+}
+
+// SetRTS sets or clears the RTS line.
+func (p *port) SetRTS(rts bool) error {
+	return p.setModemBits(syscall.TIOCM_RTS, rts)
+}
+
+// ModemStatus returns the state of the CTS, DSR, DCD and RI lines.
+func (p *port) ModemStatus() (status ModemStatus, err error) {
+	var bits int32
+	if err = ioctl(p.fd, syscall.TIOCMGET, uintptr(unsafe.Pointer(&bits))); err != nil {
+		err = fmt.Errorf("serial: could not get modem status: %v", err)
+		return
+	}
+	status.CTS = bits&syscall.TIOCM_CTS != 0
+	status.DSR = bits&syscall.TIOCM_DSR != 0
+	status.DCD = bits&syscall.TIOCM_CAR != 0
+	status.RI = bits&syscall.TIOCM_RNG != 0
+	return
+}
+
+// SendBreak sends a break of duration d.
+func (p *port) SendBreak(d time.Duration) (err error) {
+	if err = ioctl(p.fd, syscall.TIOCSBRK, 0); err != nil {
+		return fmt.Errorf("serial: could not send break: %v", err)
+	}
+	time.Sleep(d)
+	if err = ioctl(p.fd, syscall.TIOCCBRK, 0); err != nil {
+		return fmt.Errorf("serial: could not send break: %v", err)
+	}
+	return
+}
+
+// Flush discards the data in the input and output buffers.
+func (p *port) Flush() (err error) {
+	if err = tcflush(p.fd); err != nil {
+		err = fmt.Errorf("serial: could not flush: %v", err)
+	}
+	return
+}
+
+// Drain waits until the output buffer has been sent.
+func (p *port) Drain() (err error) {
+	if err = tcdrain(p.fd); err != nil {
+		err = fmt.Errorf("serial: could not drain: %v", err)
+	}
+	return
+}
+
+func (p *port) setModemBits(bits int32, set bool) (err error) {
+	req := uintptr(syscall.TIOCMBIC)
+	if set {
+		req = syscall.TIOCMBIS
+	}
+	if err = ioctl(p.fd, req, uintptr(unsafe.Pointer(&bits))); err != nil {
+		err = fmt.Errorf("serial: could not set modem lines: %v", err)
+	}
+	return
+}
+
+// ioctl calls the ioctl system call and returns the errno as error.
+func ioctl(fd int, req uintptr, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// openWakePipe creates the non-blocking pipe which wakes up ReadContext.
+func (p *port) openWakePipe() (err error) {
+	var fds [2]int
+	if err = syscall.Pipe(fds[:]); err != nil {
+		return
+	}
+	p.wakeR, p.wakeW = fds[0], fds[1]
+	for _, fd := range fds {
+		syscall.CloseOnExec(fd)
+		if err = syscall.SetNonblock(fd, true); err != nil {
+			return
+		}
+	}
+	return
+}
+
 func (p *port) setTermios(termios *syscall.Termios) (err error) {
 	if err = tcsetattr(p.fd, termios); err != nil {
 		err = fmt.Errorf("serial: could not set setting: %v", err)
@@ -169,8 +349,8 @@ func newTermios(c *Config) (termios *syscall.Termios, err error) {
 		var ok bool
 		flag, ok = baudRates[c.BaudRate]
 		if !ok {
-			err = fmt.Errorf("serial: unsupported baud rate %v", c.BaudRate)
-			return
+			// Set by setCustomBaudRate after applying termios.
+			flag = syscall.B38400
 		}
 	}
 	termios.Cflag |= flag
@@ -223,10 +403,42 @@ func newTermios(c *Config) (termios *syscall.Termios, err error) {
 	// CREAD: Enable receiver.
 	// CLOCAL: Ignore control lines.
 	termios.Cflag |= syscall.CREAD | syscall.CLOCAL
+	switch c.FlowControl {
+	case FlowNone:
+		// noop
+	case FlowHardware:
+		// CRTSCTS: Enable RTS/CTS flow control.
+		termios.Cflag |= crtscts
+	case FlowSoftware:
+		// IXON: Enable XON/XOFF flow control on output.
+		// IXOFF: Enable XON/XOFF flow control on input.
+		termios.Iflag |= syscall.IXON | syscall.IXOFF
+		termios.Cc[syscall.VSTART] = xon
+		termios.Cc[syscall.VSTOP] = xoff
+	default:
+		err = fmt.Errorf("serial: unsupported flow control %v", c.FlowControl)
+		return
+	}
 	// Special characters.
 	// VMIN: Minimum number of characters for noncanonical read.
 	// VTIME: Time in deciseconds for noncanonical read.
-	// Both are unused as NDELAY is we utilized when opening device.
+	// Both only apply if the port is switched to blocking mode, as NDELAY is
+	// utilized when opening device.
+	if c.MinReadSize < 0 || c.MinReadSize > 255 {
+		err = fmt.Errorf("serial: unsupported minimum read size %v", c.MinReadSize)
+		return
+	}
+	vtime := (c.InterByteTimeout + 100*time.Millisecond - 1) / (100 * time.Millisecond)
+	if vtime < 0 || vtime > 255 {
+		err = fmt.Errorf("serial: unsupported inter-byte timeout %v", c.InterByteTimeout)
+		return
+	}
+	termios.Cc[syscall.VMIN] = uint8(c.MinReadSize)
+	termios.Cc[syscall.VTIME] = uint8(vtime)
+	if vtime > 0 && c.MinReadSize == 0 {
+		// With VMIN 0, VTIME would be the timeout of the whole read.
+		termios.Cc[syscall.VMIN] = 1
+	}
 	return
 }
 
@@ -1,8 +1,26 @@
 package serial
 
 import (
+	"context"
+	"encoding/binary"
 	"fmt"
+	"sync"
 	"syscall"
+	"time"
+)
+
+// pollInterval is the longest wait of a single ReadFile of ReadContext, after
+// which the context is checked again.
+const pollInterval = 100 * time.Millisecond
+
+// DCB flags in Pad_cgo_0.
+const (
+	dcbBinary          = 0x0001
+	dcbParity          = 0x0002
+	dcbOutxCtsFlow     = 0x0004
+	dcbOutX            = 0x0100
+	dcbInX             = 0x0200
+	dcbRtsControlShake = 0x2000
 )
 
 type port struct {
@@ -10,6 +28,10 @@ type port struct {
 
 	oldDCB      c_DCB
 	oldTimeouts c_COMMTIMEOUTS
+	timeouts    c_COMMTIMEOUTS
+	// mu guards deadline, which is set while a read may be pending.
+	mu       sync.Mutex
+	deadline time.Time
 }
 
 // New allocates and returns a new serial port controller.
@@ -60,6 +82,12 @@ func (p *port) Close() (err error) {
 // Read reads from serial port.
 // It is blocked until data received or timeout after p.timeout.
 func (p *port) Read(b []byte) (n int, err error) {
+	p.mu.Lock()
+	deadline := p.deadline
+	p.mu.Unlock()
+	if !deadline.IsZero() {
+		return p.ReadContext(context.Background(), b)
+	}
 	var done uint32
 	if err = syscall.ReadFile(p.handle, b, &done, nil); err != nil {
 		return
@@ -82,6 +110,116 @@ func (p *port) Write(b []byte) (n int, err error) {
 	return
 }
 
+// SetDTR sets or clears the DTR line.
+func (p *port) SetDTR(dtr bool) error {
+	if dtr {
+		return EscapeCommFunction(p.handle, c_SETDTR)
+	}
+	return EscapeCommFunction(p.handle, c_CLRDTR)
+}
+
+// SetRTS sets or clears the RTS line.
+func (p *port) SetRTS(rts bool) error {
+	if rts {
+		return EscapeCommFunction(p.handle, c_SETRTS)
+	}
+	return EscapeCommFunction(p.handle, c_CLRRTS)
+}
+
+// ModemStatus returns the state of the CTS, DSR, DCD and RI lines.
+func (p *port) ModemStatus() (status ModemStatus, err error) {
+	var bits uint32
+	if err = GetCommModemStatus(p.handle, &bits); err != nil {
+		return
+	}
+	status.CTS = bits&c_MS_CTS_ON != 0
+	status.DSR = bits&c_MS_DSR_ON != 0
+	status.DCD = bits&c_MS_RLSD_ON != 0
+	status.RI = bits&c_MS_RING_ON != 0
+	return
+}
+
+// SendBreak sends a break of duration d.
+func (p *port) SendBreak(d time.Duration) (err error) {
+	if err = EscapeCommFunction(p.handle, c_SETBREAK); err != nil {
+		return
+	}
+	time.Sleep(d)
+	return EscapeCommFunction(p.handle, c_CLRBREAK)
+}
+
+// Flush discards the data in the input and output buffers.
+func (p *port) Flush() error {
+	return PurgeComm(p.handle, c_PURGE_RXCLEAR|c_PURGE_TXCLEAR)
+}
+
+// Drain waits until the output buffer has been sent.
+func (p *port) Drain() error {
+	return syscall.FlushFileBuffers(p.handle)
+}
+
+// ReadContext reads like Read, but returns early with ctx.Err() when ctx is done.
+// ReadFile cannot be interrupted, so it waits for at most pollInterval at a time.
+func (p *port) ReadContext(ctx context.Context, b []byte) (n int, err error) {
+	start := time.Now()
+	defer SetCommTimeouts(p.handle, &p.timeouts)
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+		// The deadline may be changed while the read is pending.
+		deadline := p.readDeadline(start)
+		wait := pollInterval
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				err = ErrTimeout
+				return
+			}
+			wait = min(wait, remaining)
+		}
+
+		timeouts := p.timeouts
+		if timeouts.ReadIntervalTimeout == 0 || timeouts.ReadIntervalTimeout == c_MAXDWORD {
+			// Return as soon as a byte arrived.
+			timeouts.ReadIntervalTimeout = c_MAXDWORD
+			timeouts.ReadTotalTimeoutMultiplier = c_MAXDWORD
+		}
+		timeouts.ReadTotalTimeoutConstant = toDWORD(int(max(wait/time.Millisecond, 1)))
+		if err = SetCommTimeouts(p.handle, &timeouts); err != nil {
+			return
+		}
+		var done uint32
+		if err = syscall.ReadFile(p.handle, b, &done, nil); err != nil {
+			return
+		}
+		if done > 0 {
+			n = int(done)
+			return
+		}
+	}
+}
+
+// readDeadline returns the deadline of a read which started at start.
+func (p *port) readDeadline(start time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.deadline.IsZero() && p.timeouts.ReadTotalTimeoutConstant > 0 {
+		return start.Add(time.Duration(p.timeouts.ReadTotalTimeoutConstant) * time.Millisecond)
+	}
+	return p.deadline
+}
+
+// SetReadDeadline sets the deadline of reads, a zero value restores the timeout.
+// A pending read continues with the new deadline within pollInterval.
+func (p *port) SetReadDeadline(t time.Time) error {
+	p.mu.Lock()
+	p.deadline = t
+	p.mu.Unlock()
+	return nil
+}
+
 func (p *port) setTimeouts(c *Config) error {
 	var timeouts c_COMMTIMEOUTS
 	// Read and write timeout
@@ -93,6 +231,15 @@ func (p *port) setTimeouts(c *Config) error {
 		timeouts.ReadTotalTimeoutConstant = timeout
 		timeouts.WriteTotalTimeoutConstant = timeout
 	}
+	if c.InterByteTimeout > 0 {
+		// return when no byte arrived within the interval after the first one
+		timeouts.ReadIntervalTimeout = toDWORD(int(max(c.InterByteTimeout/time.Millisecond, 1)))
+		timeouts.ReadTotalTimeoutMultiplier = 0
+	}
+	p.timeouts = timeouts
+	p.mu.Lock()
+	p.deadline = time.Time{}
+	p.mu.Unlock()
 	err := GetCommTimeouts(p.handle, &p.oldTimeouts)
 	if err != nil {
 		return err
@@ -107,6 +254,7 @@ func (p *port) setTimeouts(c *Config) error {
 
 func (p *port) setSerialConfig(c *Config) error {
 	var dcb c_DCB
+	var flags uint32
 	if c.BaudRate == 0 {
 		dcb.BaudRate = 19200
 	} else {
@@ -133,16 +281,32 @@ func (p *port) setSerialConfig(c *Config) error {
 	case "", "E":
 		// Default parity mode is Even.
 		dcb.Parity = c_EVENPARITY
-		dcb.Pad_cgo_0[0] |= 0x02 // fParity
+		flags |= dcbParity
 	case "O":
 		dcb.Parity = c_ODDPARITY
-		dcb.Pad_cgo_0[0] |= 0x02 // fParity
+		flags |= dcbParity
 	case "N":
 		dcb.Parity = c_NOPARITY
 	default:
 		return fmt.Errorf("serial: unsupported parity %v", c.Parity)
 	}
-	dcb.Pad_cgo_0[0] |= 0x01 // fBinary
+	flags |= dcbBinary
+	// Flow control
+	switch c.FlowControl {
+	case FlowNone:
+		// noop
+	case FlowHardware:
+		flags |= dcbOutxCtsFlow | dcbRtsControlShake
+	case FlowSoftware:
+		flags |= dcbOutX | dcbInX
+		dcb.XonChar = xon
+		dcb.XoffChar = xoff
+		dcb.XonLim = 2048
+		dcb.XoffLim = 512
+	default:
+		return fmt.Errorf("serial: unsupported flow control %v", c.FlowControl)
+	}
+	binary.LittleEndian.PutUint32(dcb.Pad_cgo_0[:], flags)
 
 	err := GetCommState(p.handle, &p.oldDCB)
 	if err != nil {
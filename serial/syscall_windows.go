@@ -10,3 +10,7 @@ package serial
 //sys SetCommState(handle syscall.Handle, dcb *c_DCB) (err error)
 //sys GetCommTimeouts(handle syscall.Handle, timeouts *c_COMMTIMEOUTS) (err error)
 //sys SetCommTimeouts(handle syscall.Handle, timeouts *c_COMMTIMEOUTS) (err error)
+//sys EscapeCommFunction(handle syscall.Handle, function uint32) (err error)
+//sys GetCommModemStatus(handle syscall.Handle, status *uint32) (err error)
+//sys PurgeComm(handle syscall.Handle, flags uint32) (err error)
+//sys RegEnumValue(key syscall.Handle, index uint32, name *uint16, nameLen *uint32, reserved *uint32, valtype *uint32, buf *byte, buflen *uint32) (regerrno error) = advapi32.RegEnumValueW
@@ -6,12 +6,24 @@ package serial
 import "C"
 
 const (
-	c_MAXDWORD    = C.MAXDWORD
-	c_ONESTOPBIT  = C.ONESTOPBIT
-	c_TWOSTOPBITS = C.TWOSTOPBITS
-	c_EVENPARITY  = C.EVENPARITY
-	c_ODDPARITY   = C.ODDPARITY
-	c_NOPARITY    = C.NOPARITY
+	c_MAXDWORD      = C.MAXDWORD
+	c_ONESTOPBIT    = C.ONESTOPBIT
+	c_TWOSTOPBITS   = C.TWOSTOPBITS
+	c_EVENPARITY    = C.EVENPARITY
+	c_ODDPARITY     = C.ODDPARITY
+	c_NOPARITY      = C.NOPARITY
+	c_SETRTS        = C.SETRTS
+	c_CLRRTS        = C.CLRRTS
+	c_SETDTR        = C.SETDTR
+	c_CLRDTR        = C.CLRDTR
+	c_SETBREAK      = C.SETBREAK
+	c_CLRBREAK      = C.CLRBREAK
+	c_MS_CTS_ON     = C.MS_CTS_ON
+	c_MS_DSR_ON     = C.MS_DSR_ON
+	c_MS_RING_ON    = C.MS_RING_ON
+	c_MS_RLSD_ON    = C.MS_RLSD_ON
+	c_PURGE_TXCLEAR = C.PURGE_TXCLEAR
+	c_PURGE_RXCLEAR = C.PURGE_RXCLEAR
 )
 
 type c_COMMTIMEOUTS C.COMMTIMEOUTS
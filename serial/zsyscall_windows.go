@@ -11,11 +11,16 @@ var _ unsafe.Pointer
 
 var (
 	modkernel32 = syscall.NewLazyDLL("kernel32.dll")
+	modadvapi32 = syscall.NewLazyDLL("advapi32.dll")
 
-	procGetCommState    = modkernel32.NewProc("GetCommState")
-	procSetCommState    = modkernel32.NewProc("SetCommState")
-	procGetCommTimeouts = modkernel32.NewProc("GetCommTimeouts")
-	procSetCommTimeouts = modkernel32.NewProc("SetCommTimeouts")
+	procGetCommState       = modkernel32.NewProc("GetCommState")
+	procSetCommState       = modkernel32.NewProc("SetCommState")
+	procGetCommTimeouts    = modkernel32.NewProc("GetCommTimeouts")
+	procSetCommTimeouts    = modkernel32.NewProc("SetCommTimeouts")
+	procEscapeCommFunction = modkernel32.NewProc("EscapeCommFunction")
+	procGetCommModemStatus = modkernel32.NewProc("GetCommModemStatus")
+	procPurgeComm          = modkernel32.NewProc("PurgeComm")
+	procRegEnumValueW      = modadvapi32.NewProc("RegEnumValueW")
 )
 
 func GetCommState(handle syscall.Handle, dcb *c_DCB) (err error) {
@@ -65,3 +70,47 @@ func SetCommTimeouts(handle syscall.Handle, timeouts *c_COMMTIMEOUTS) (err error
 	}
 	return
 }
+
+func EscapeCommFunction(handle syscall.Handle, function uint32) (err error) {
+	r1, _, e1 := syscall.Syscall(procEscapeCommFunction.Addr(), 2, uintptr(handle), uintptr(function), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func GetCommModemStatus(handle syscall.Handle, status *uint32) (err error) {
+	r1, _, e1 := syscall.Syscall(procGetCommModemStatus.Addr(), 2, uintptr(handle), uintptr(unsafe.Pointer(status)), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func PurgeComm(handle syscall.Handle, flags uint32) (err error) {
+	r1, _, e1 := syscall.Syscall(procPurgeComm.Addr(), 2, uintptr(handle), uintptr(flags), 0)
+	if r1 == 0 {
+		if e1 != 0 {
+			err = error(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
+func RegEnumValue(key syscall.Handle, index uint32, name *uint16, nameLen *uint32, reserved *uint32, valtype *uint32, buf *byte, buflen *uint32) (regerrno error) {
+	r0, _, _ := syscall.Syscall9(procRegEnumValueW.Addr(), 8, uintptr(key), uintptr(index), uintptr(unsafe.Pointer(name)), uintptr(unsafe.Pointer(nameLen)), uintptr(unsafe.Pointer(reserved)), uintptr(unsafe.Pointer(valtype)), uintptr(unsafe.Pointer(buf)), uintptr(unsafe.Pointer(buflen)), 0)
+	if r0 != 0 {
+		regerrno = syscall.Errno(r0)
+	}
+	return
+}
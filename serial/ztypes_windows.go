@@ -4,12 +4,24 @@
 package serial
 
 const (
-	c_MAXDWORD    = 0xffffffff
-	c_ONESTOPBIT  = 0x0
-	c_TWOSTOPBITS = 0x2
-	c_EVENPARITY  = 0x2
-	c_ODDPARITY   = 0x1
-	c_NOPARITY    = 0x0
+	c_MAXDWORD      = 0xffffffff
+	c_ONESTOPBIT    = 0x0
+	c_TWOSTOPBITS   = 0x2
+	c_EVENPARITY    = 0x2
+	c_ODDPARITY     = 0x1
+	c_NOPARITY      = 0x0
+	c_SETRTS        = 0x3
+	c_CLRRTS        = 0x4
+	c_SETDTR        = 0x5
+	c_CLRDTR        = 0x6
+	c_SETBREAK      = 0x8
+	c_CLRBREAK      = 0x9
+	c_MS_CTS_ON     = 0x10
+	c_MS_DSR_ON     = 0x20
+	c_MS_RING_ON    = 0x40
+	c_MS_RLSD_ON    = 0x80
+	c_PURGE_TXCLEAR = 0x4
+	c_PURGE_RXCLEAR = 0x8
 )
 
 type c_COMMTIMEOUTS struct {
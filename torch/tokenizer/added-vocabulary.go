@@ -531,10 +531,3 @@ type AddedTokenWithId struct {
 	Special bool       // whether this is a special token
 	Token   AddedToken // the target AddedToken
 }
-
-// Implement Serialize interface for AddedVocabular:
-// =================================================
-
-// Serialize implements Serialize interface for AddedVocabular
-// TODO. implement it
-// func(av AddedVocabulary) Serialize(s Serializer)(retVal ...)
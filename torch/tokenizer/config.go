@@ -64,6 +64,7 @@ func ConfigFromFile(file string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 
 	dec := json.NewDecoder(f)
 
@@ -0,0 +1,54 @@
+package decoder
+
+import (
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// BpeDecoder decodes tokens produced by a BPE model trained with an
+// end-of-word suffix.
+type BpeDecoder struct {
+	// The suffix that was used to characterize an end-of-word
+	Suffix string
+}
+
+var _ tokenizer.Decoder = new(BpeDecoder)
+
+// NewBpeDecoder creates a new BpeDecoder.
+func NewBpeDecoder(suffix string) *BpeDecoder {
+	return &BpeDecoder{Suffix: suffix}
+}
+
+// DefaultBpeDecoder creates a BpeDecoder with suffix `</w>`.
+func DefaultBpeDecoder() *BpeDecoder {
+	return NewBpeDecoder("</w>")
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *BpeDecoder) DecodeChain(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		replacement := " "
+		if i == len(tokens)-1 {
+			replacement = ""
+		}
+		out[i] = strings.ReplaceAll(token, d.Suffix, replacement)
+	}
+
+	return out
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *BpeDecoder) Decode(tokens []string) string {
+	return strings.Join(d.DecodeChain(tokens), "")
+}
+
+// MarshalJSON implements json.Marshaler for BpeDecoder.
+func (d *BpeDecoder) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type   string `json:"type"`
+		Suffix string `json:"suffix"`
+	}{"BPEDecoder", d.Suffix})
+}
@@ -0,0 +1,81 @@
+package decoder
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// ByteFallback converts `<0xXX>` byte tokens back to the characters they
+// encode. Invalid UTF-8 sequences are replaced by `�`, one for each byte.
+type ByteFallback struct{}
+
+var _ tokenizer.Decoder = new(ByteFallback)
+
+// NewByteFallback creates a new ByteFallback decoder.
+func NewByteFallback() *ByteFallback {
+	return new(ByteFallback)
+}
+
+// parseByte returns the byte encoded by a `<0xXX>` token.
+func parseByte(token string) (byte, bool) {
+	if len(token) != 6 || !strings.HasPrefix(token, "<0x") || !strings.HasSuffix(token, ">") {
+		return 0, false
+	}
+
+	b, err := strconv.ParseUint(token[3:5], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+
+	return byte(b), true
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *ByteFallback) DecodeChain(tokens []string) []string {
+	var (
+		out   []string
+		bytes []byte
+	)
+
+	flush := func() {
+		if len(bytes) == 0 {
+			return
+		}
+		if utf8.Valid(bytes) {
+			out = append(out, string(bytes))
+		} else {
+			for range bytes {
+				out = append(out, string(utf8.RuneError))
+			}
+		}
+		bytes = nil
+	}
+
+	for _, token := range tokens {
+		if b, ok := parseByte(token); ok {
+			bytes = append(bytes, b)
+			continue
+		}
+		flush()
+		out = append(out, token)
+	}
+	flush()
+
+	return out
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *ByteFallback) Decode(tokens []string) string {
+	return strings.Join(d.DecodeChain(tokens), "")
+}
+
+// MarshalJSON implements json.Marshaler for ByteFallback.
+func (d *ByteFallback) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type string `json:"type"`
+	}{"ByteFallback"})
+}
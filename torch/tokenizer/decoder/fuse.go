@@ -0,0 +1,36 @@
+package decoder
+
+import (
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// Fuse fuses all tokens into a single one. It is usually the last
+// decoding step before another decoder that works on whole strings.
+type Fuse struct{}
+
+var _ tokenizer.Decoder = new(Fuse)
+
+// NewFuse creates a new Fuse decoder.
+func NewFuse() *Fuse {
+	return new(Fuse)
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *Fuse) DecodeChain(tokens []string) []string {
+	return []string{strings.Join(tokens, "")}
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *Fuse) Decode(tokens []string) string {
+	return strings.Join(tokens, "")
+}
+
+// MarshalJSON implements json.Marshaler for Fuse.
+func (d *Fuse) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type string `json:"type"`
+	}{"Fuse"})
+}
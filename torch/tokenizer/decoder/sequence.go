@@ -0,0 +1,54 @@
+package decoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// Sequence chains decoders, each one working on the output of the previous one.
+type Sequence struct {
+	decoders []tokenizer.Decoder
+}
+
+var _ tokenizer.Decoder = new(Sequence)
+
+// NewSequence creates a new Sequence decoder.
+func NewSequence(decoders []tokenizer.Decoder) *Sequence {
+	return &Sequence{decoders}
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *Sequence) DecodeChain(tokens []string) []string {
+	for _, dec := range d.decoders {
+		tokens = dec.DecodeChain(tokens)
+	}
+
+	return tokens
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *Sequence) Decode(tokens []string) string {
+	return strings.Join(d.DecodeChain(tokens), "")
+}
+
+// MarshalJSON implements json.Marshaler for Sequence.
+func (d *Sequence) MarshalJSON() ([]byte, error) {
+	decoders := make([]json.Marshaler, 0, len(d.decoders))
+	for _, dec := range d.decoders {
+		m, ok := dec.(json.Marshaler)
+		if !ok {
+			err := fmt.Errorf("Decoder of type %T cannot be serialized", dec)
+			return nil, err
+		}
+		decoders = append(decoders, m)
+	}
+
+	return util.MarshalJSON(struct {
+		Type     string           `json:"type"`
+		Decoders []json.Marshaler `json:"decoders"`
+	}{"Sequence", decoders})
+}
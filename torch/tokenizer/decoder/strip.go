@@ -0,0 +1,58 @@
+package decoder
+
+import (
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// Strip strips up to `Start` leading and `Stop` trailing occurrences of
+// `Content` from each token.
+type Strip struct {
+	Content string
+	Start   int
+	Stop    int
+}
+
+var _ tokenizer.Decoder = new(Strip)
+
+// NewStrip creates a new Strip decoder.
+func NewStrip(content string, start, stop int) *Strip {
+	return &Strip{
+		Content: content,
+		Start:   start,
+		Stop:    stop,
+	}
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *Strip) DecodeChain(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		for n := 0; n < d.Start && d.Content != "" && strings.HasPrefix(token, d.Content); n++ {
+			token = strings.TrimPrefix(token, d.Content)
+		}
+		for n := 0; n < d.Stop && d.Content != "" && strings.HasSuffix(token, d.Content); n++ {
+			token = strings.TrimSuffix(token, d.Content)
+		}
+		out[i] = token
+	}
+
+	return out
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *Strip) Decode(tokens []string) string {
+	return strings.Join(d.DecodeChain(tokens), "")
+}
+
+// MarshalJSON implements json.Marshaler for Strip.
+func (d *Strip) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+		Start   int    `json:"start"`
+		Stop    int    `json:"stop"`
+	}{"Strip", d.Content, d.Start, d.Stop})
+}
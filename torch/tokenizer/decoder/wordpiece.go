@@ -0,0 +1,90 @@
+// Package decoder provides decoders that merge tokens back to a string.
+//
+// NOTE. `ByteLevel` and `Metaspace` decoders live in the `pretokenizer`
+// package and `Replace` in the `normalizer` package.
+package decoder
+
+import (
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// WordPieceDecoder takes care of decoding a list of wordpiece tokens
+// back into a readable string.
+type WordPieceDecoder struct {
+	// The prefix to be used for continuing subwords
+	Prefix string
+	// Whether to cleanup some tokenization artifacts (spaces before punctuation, ...)
+	Cleanup bool
+}
+
+var _ tokenizer.Decoder = new(WordPieceDecoder)
+
+// NewWordPieceDecoder creates a new WordPieceDecoder.
+func NewWordPieceDecoder(prefix string, cleanup bool) *WordPieceDecoder {
+	return &WordPieceDecoder{
+		Prefix:  prefix,
+		Cleanup: cleanup,
+	}
+}
+
+// DefaultWordPieceDecoder creates a WordPieceDecoder with prefix `##` and cleanup enabled.
+func DefaultWordPieceDecoder() *WordPieceDecoder {
+	return NewWordPieceDecoder("##", true)
+}
+
+// cleanup removes spaces left around punctuation and contractions.
+func cleanup(s string) string {
+	r := strings.NewReplacer(
+		" .", ".",
+		" ?", "?",
+		" !", "!",
+		" ,", ",",
+		" ' ", "'",
+		" n't", "n't",
+		" 'm", "'m",
+		" do not", " don't",
+		" 's", "'s",
+		" 've", "'ve",
+		" 're", "'re",
+	)
+
+	return r.Replace(s)
+}
+
+// DecodeChain implements tokenizer.Decoder.
+func (d *WordPieceDecoder) DecodeChain(tokens []string) []string {
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		if i != 0 {
+			if strings.HasPrefix(token, d.Prefix) {
+				token = strings.Replace(token, d.Prefix, "", 1)
+			} else {
+				token = " " + token
+			}
+		}
+
+		if d.Cleanup {
+			token = cleanup(token)
+		}
+		out[i] = token
+	}
+
+	return out
+}
+
+// Decode implements tokenizer.Decoder.
+func (d *WordPieceDecoder) Decode(tokens []string) string {
+	return strings.Join(d.DecodeChain(tokens), "")
+}
+
+// MarshalJSON implements json.Marshaler for WordPieceDecoder.
+func (d *WordPieceDecoder) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type    string `json:"type"`
+		Prefix  string `json:"prefix"`
+		Cleanup bool   `json:"cleanup"`
+	}{"WordPiece", d.Prefix, d.Cleanup})
+}
@@ -402,6 +402,59 @@ func (e *Encoding) Truncate(maxLen int, stride int) (retVal *Encoding, err error
 	return e, nil
 }
 
+// TruncateLeft truncates the encoding from the left, keeping its last maxLen
+// tokens. The removed tokens are split leftwards into overflowing encodings of
+// at most maxLen tokens, each one sharing stride tokens with the previous one.
+func (e *Encoding) TruncateLeft(maxLen int, stride int) (retVal *Encoding, err error) {
+	if stride >= maxLen || maxLen == 0 {
+		return retVal, fmt.Errorf("Invalid input maxLen or stride (stride must be less than maxLen and maxLen must be greater than zero.)")
+	}
+
+	if maxLen >= len(e.Ids) {
+		// do nothing
+		return e, nil
+	}
+
+	var parts []Encoding
+	for stop := len(e.Ids); ; stop -= maxLen - stride {
+		start := stop - maxLen
+		if start < 0 {
+			start = 0
+		}
+		parts = append(parts, e.slice(start, stop))
+		if start == 0 {
+			break
+		}
+	}
+
+	*e = parts[0]
+	e.Overflowing = parts[1:]
+
+	return e, nil
+}
+
+// slice returns a copy of the tokens from start (inclusive) to stop (exclusive).
+func (e *Encoding) slice(start, stop int) Encoding {
+	return Encoding{
+		Ids:              sliceCopy(e.Ids, start, stop),
+		TypeIds:          sliceCopy(e.TypeIds, start, stop),
+		Tokens:           sliceCopy(e.Tokens, start, stop),
+		Offsets:          sliceCopy(e.Offsets, start, stop),
+		SpecialTokenMask: sliceCopy(e.SpecialTokenMask, start, stop),
+		AttentionMask:    sliceCopy(e.AttentionMask, start, stop),
+		Words:            sliceCopy(e.Words, start, stop),
+		Overflowing:      make([]Encoding, 0),
+	}
+}
+
+// sliceCopy copies s[start:stop], or returns s if it is shorter than stop.
+func sliceCopy[T any](s []T, start, stop int) []T {
+	if len(s) < stop {
+		return s
+	}
+	return append([]T(nil), s[start:stop]...)
+}
+
 // Merge merges all Encodings together
 func (e *Encoding) Merge(encodings []Encoding, growingOffsets bool) (retVal *Encoding) {
 	retVal = e
@@ -9,7 +9,6 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"sort"
 
 	// "strconv"
 	"log"
@@ -36,6 +35,9 @@ type Config struct {
 	unkToken                *string
 	continuingSubwordPrefix *string
 	endOfWordSuffix         *string
+	fuseUnk                 bool
+	byteFallback            bool
+	ignoreMerges            bool
 }
 
 // BpeBuilder can be used to create a `BPE` model with
@@ -100,6 +102,23 @@ func (bb *BpeBuilder) EndOfWordSuffix(endOfWordSuffix string) {
 	bb.config.endOfWordSuffix = &endOfWordSuffix
 }
 
+// FuseUnk set whether consecutive unknown tokens are fused into a single one.
+func (bb *BpeBuilder) FuseUnk(fuseUnk bool) {
+	bb.config.fuseUnk = fuseUnk
+}
+
+// ByteFallback set whether unknown characters are encoded as `<0xXX>` byte tokens
+// instead of the `UNK` token.
+func (bb *BpeBuilder) ByteFallback(byteFallback bool) {
+	bb.config.byteFallback = byteFallback
+}
+
+// IgnoreMerges set whether words found in the vocab are returned directly
+// without applying merges.
+func (bb *BpeBuilder) IgnoreMerges(ignoreMerges bool) {
+	bb.config.ignoreMerges = ignoreMerges
+}
+
 // Build returns a `BPE` model that uses the BpeBuilder configuration
 func (bb *BpeBuilder) Build() (*BPE, error) {
 	var (
@@ -154,6 +173,9 @@ func (bb *BpeBuilder) Build() (*BPE, error) {
 		UnkToken:                bb.config.unkToken,
 		ContinuingSubwordPrefix: bb.config.continuingSubwordPrefix,
 		EndOfWordSuffix:         bb.config.endOfWordSuffix,
+		FuseUnk:                 bb.config.fuseUnk,
+		ByteFallback:            bb.config.byteFallback,
+		IgnoreMerges:            bb.config.ignoreMerges,
 	}
 
 	return &bpe, nil
@@ -191,6 +213,22 @@ type BPE struct {
 	// EndOfWordSuffix is an optional suffix
 	// to caracterize and end-of-word subword
 	EndOfWordSuffix *string
+
+	// FuseUnk specifies whether consecutive unknown tokens are fused into one.
+	FuseUnk bool
+
+	// ByteFallback specifies whether to use spm byte-fallback trick
+	// (unknown chars are encoded as `<0xXX>` tokens).
+	ByteFallback bool
+
+	// IgnoreMerges specifies whether words found in the vocab are
+	// tokenized directly without applying merges.
+	IgnoreMerges bool
+
+	// MergesAsPairs specifies whether merges are serialized as `["a", "b"]`
+	// pairs instead of `"a b"` strings. Pairs are always written if a merged
+	// token contains a space.
+	MergesAsPairs bool
 }
 
 func (b *BPE) builder() *BpeBuilder {
@@ -394,6 +432,7 @@ func (b *BPE) MergeWord(w string) *Word {
 
 	chars := []rune(w)
 	currRuneIdx := 0
+	isUnk := false // whether the last added symbol is `unk`
 	for byteIdx, r := range w {
 		var (
 			s       string
@@ -415,12 +454,23 @@ func (b *BPE) MergeWord(w string) *Word {
 		vocab := *b.Vocab
 		if id, ok := vocab[s]; ok { // found
 			word.Add(id, byteLen)
+			isUnk = false
+		} else if ids, ok := b.byteFallbackIds(string(r)); ok { // encoded as `<0xXX>` tokens
+			for _, id := range ids {
+				word.Add(id, 1)
+			}
+			isUnk = false
 		} else { // not found, add `unk`
 			if b.UnkToken != nil {
 				// get `unk` id
 				unkId := (*b.Vocab)[*b.UnkToken]
-				// add `unk`
-				word.Add(unkId, byteLen)
+				// add `unk`, fusing it with the previous one if required
+				if b.FuseUnk && isUnk {
+					word.Symbols[len(word.Symbols)-1].Len += byteLen
+				} else {
+					word.Add(unkId, byteLen)
+				}
+				isUnk = true
 			} else {
 				fmt.Printf("cannot find '%s' in the vocab. \n", s)
 				panic("Can't find `unk` token in the vocab. Have you added one when initiating the model?")
@@ -437,6 +487,25 @@ func (b *BPE) MergeWord(w string) *Word {
 	return word
 }
 
+// byteFallbackIds returns ids of the `<0xXX>` tokens of the given char
+// if byte-fallback is enabled and all of them are in the vocab.
+func (b *BPE) byteFallbackIds(char string) ([]int, bool) {
+	if !b.ByteFallback {
+		return nil, false
+	}
+
+	var ids []int
+	for _, c := range []byte(char) {
+		id, ok := (*b.Vocab)[fmt.Sprintf("<0x%02X>", c)]
+		if !ok {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, true
+}
+
 // WordToTokens slices word to tokens
 func (b *BPE) WordToTokens(word Word) []tokenizer.Token {
 	var tokens []tokenizer.Token
@@ -484,6 +553,12 @@ func (b BPE) Tokenize(sequence string) (retVal []tokenizer.Token, err error) {
 		return []tokenizer.Token{}, nil
 	}
 
+	if b.IgnoreMerges {
+		if id, ok := (*b.Vocab)[sequence]; ok {
+			return []tokenizer.Token{{Id: id, Value: sequence, Offsets: []int{0, len(sequence)}}}, nil
+		}
+	}
+
 	if b.Dropout == nil {
 		return b.TokenizeWithCache(sequence), nil
 	}
@@ -555,32 +630,7 @@ func (b BPE) Save(dir string, nameOpt ...string) error {
 
 	// Write merges.txt
 	// each line is a pair separated by a space
-	var lines []string
-	type pairRank struct {
-		Pair Pair
-		Rank int
-	}
-	var pairRanks []pairRank
-	for pair, pairVal := range *b.Merges {
-		pairRanks = append(pairRanks, pairRank{
-			Pair: pair,
-			Rank: pairVal.Rank,
-		})
-	}
-
-	// sort pairRanks by `Rank` field in-place
-	sort.Slice(pairRanks, func(i, j int) bool {
-		return pairRanks[i].Rank < pairRanks[j].Rank
-	})
-
-	// Create lines of merges
-	for _, p := range pairRanks {
-		// line := fmt.Sprintf("%v %v", p.Pair.C1, p.Pair.C2)
-		c1, _ := b.IdToToken(p.Pair.C1)
-		c2, _ := b.IdToToken(p.Pair.C2)
-		line := fmt.Sprintf("%v %v", c1, c2)
-		lines = append(lines, line)
-	}
+	lines := b.MergesData()
 
 	// write to file
 	file, err := os.Create(mfile)
@@ -618,17 +668,28 @@ func makeFilePath(filename string) error {
 }
 
 func CreateMerges(vocab map[string]int, mergesData []string) (*Merges, error) {
-	var (
-		lineNum int    = 0
-		merges  Merges = make(map[Pair]PairVal)
-	)
-	for _, line := range mergesData {
+	var pairs [][2]string
+	for lineNum, line := range mergesData {
 		parts := strings.Split(line, " ")
 		if len(parts) != 2 {
 			err := fmt.Errorf("Read merges error: invalid data at line %d\n", lineNum)
 			return nil, err
 		}
 
+		pairs = append(pairs, [2]string{parts[0], parts[1]})
+	}
+
+	return CreateMergesFromPairs(vocab, pairs)
+}
+
+// CreateMergesFromPairs creates merges from token pairs ordered by rank.
+// Unlike `CreateMerges`, tokens can contain spaces.
+func CreateMergesFromPairs(vocab map[string]int, pairs [][2]string) (*Merges, error) {
+	var (
+		lineNum int    = 0
+		merges  Merges = make(map[Pair]PairVal)
+	)
+	for _, parts := range pairs {
 		a, ok := vocab[parts[0]]
 		if !ok {
 			// err = fmt.Errorf("Read merge file error: part a value for '%s' key not found.", parts[0])
@@ -644,7 +705,6 @@ func CreateMerges(vocab map[string]int, mergesData []string) (*Merges, error) {
 		}
 
 		pair := Pair{a, b}
-		// newToken := fmt.Sprintf("%v%v", parts[0], parts[1])
 		newToken := fmt.Sprintf("%v%v", parts[0], parts[1])
 		newId, ok := vocab[newToken]
 		if !ok {
@@ -0,0 +1,97 @@
+package bpe
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// MergesData returns the merges as `"a b"` strings ordered by rank, the
+// form they take in `merges.txt` and `tokenizer.json` files.
+func (b BPE) MergesData() []string {
+	pairs := b.MergesPairs()
+	lines := make([]string, 0, len(pairs))
+	for _, p := range pairs {
+		lines = append(lines, fmt.Sprintf("%v %v", p[0], p[1]))
+	}
+
+	return lines
+}
+
+// MergesPairs returns the merges as pairs of tokens ordered by rank.
+func (b BPE) MergesPairs() [][2]string {
+	type pairRank struct {
+		Pair Pair
+		Rank int
+	}
+	var pairRanks []pairRank
+	for pair, pairVal := range *b.Merges {
+		pairRanks = append(pairRanks, pairRank{
+			Pair: pair,
+			Rank: pairVal.Rank,
+		})
+	}
+
+	sort.Slice(pairRanks, func(i, j int) bool {
+		return pairRanks[i].Rank < pairRanks[j].Rank
+	})
+
+	pairs := make([][2]string, 0, len(pairRanks))
+	for _, p := range pairRanks {
+		c1, _ := b.IdToToken(p.Pair.C1)
+		c2, _ := b.IdToToken(p.Pair.C2)
+		pairs = append(pairs, [2]string{c1, c2})
+	}
+
+	return pairs
+}
+
+// mergesJSON returns the merges in the form written to `tokenizer.json`:
+// `["a", "b"]` pairs if MergesAsPairs is set or a token contains a space,
+// which the `"a b"` string form cannot represent, and strings otherwise.
+func (b BPE) mergesJSON() interface{} {
+	pairs := b.MergesPairs()
+	asPairs := b.MergesAsPairs
+	for _, p := range pairs {
+		if strings.Contains(p[0], " ") || strings.Contains(p[1], " ") {
+			asPairs = true
+			break
+		}
+	}
+	if asPairs {
+		return pairs
+	}
+
+	return b.MergesData()
+}
+
+// MarshalJSON implements json.Marshaler for BPE so that it can be written
+// as the `model` of a `tokenizer.json` file.
+func (b BPE) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type                    string                 `json:"type"`
+		Dropout                 *float32               `json:"dropout"`
+		UnkToken                *string                `json:"unk_token"`
+		ContinuingSubwordPrefix *string                `json:"continuing_subword_prefix"`
+		EndOfWordSuffix         *string                `json:"end_of_word_suffix"`
+		FuseUnk                 bool                   `json:"fuse_unk"`
+		ByteFallback            bool                   `json:"byte_fallback"`
+		IgnoreMerges            bool                   `json:"ignore_merges"`
+		Vocab                   tokenizer.OrderedVocab `json:"vocab"`
+		Merges                  interface{}            `json:"merges"`
+	}{
+		Type:                    "BPE",
+		Dropout:                 b.Dropout,
+		UnkToken:                b.UnkToken,
+		ContinuingSubwordPrefix: b.ContinuingSubwordPrefix,
+		EndOfWordSuffix:         b.EndOfWordSuffix,
+		FuseUnk:                 b.FuseUnk,
+		ByteFallback:            b.ByteFallback,
+		IgnoreMerges:            b.IgnoreMerges,
+		Vocab:                   tokenizer.OrderedVocab(*b.Vocab),
+		Merges:                  b.mergesJSON(),
+	})
+}
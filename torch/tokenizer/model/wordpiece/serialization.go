@@ -0,0 +1,24 @@
+package wordpiece
+
+import (
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// MarshalJSON implements json.Marshaler for WordPiece so that it can be
+// written as the `model` of a `tokenizer.json` file.
+func (wp WordPiece) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type                    string                 `json:"type"`
+		UnkToken                string                 `json:"unk_token"`
+		ContinuingSubwordPrefix string                 `json:"continuing_subword_prefix"`
+		MaxInputCharsPerWord    int                    `json:"max_input_chars_per_word"`
+		Vocab                   tokenizer.OrderedVocab `json:"vocab"`
+	}{
+		Type:                    "WordPiece",
+		UnkToken:                wp.unkToken,
+		ContinuingSubwordPrefix: wp.continueSubwordPrefix,
+		MaxInputCharsPerWord:    wp.maxInputCharsPerWord,
+		Vocab:                   tokenizer.OrderedVocab(*wp.vocab),
+	})
+}
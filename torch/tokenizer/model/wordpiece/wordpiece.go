@@ -418,11 +418,17 @@ func New(
 	if opts.Has("unk_token") {
 		unkToken = opts.Get("unk_token").(string)
 	}
-	if opts.Has("continuingSubwordPrefix") {
+	if opts.Has("continuing_subword_prefix") {
 		continuingSubwordPrefix = opts.Get("continuing_subword_prefix").(string)
 	}
 	if opts.Has("max_input_chars_per_word") {
-		maxInputCharsPerWord = opts.Get("max_input_chars_per_word").(int)
+		// NOTE. numbers decoded from JSON are float64
+		switch v := opts.Get("max_input_chars_per_word").(type) {
+		case int:
+			maxInputCharsPerWord = v
+		case float64:
+			maxInputCharsPerWord = int(v)
+		}
 	}
 
 	builder := WordPieceBuilder{
@@ -5,10 +5,10 @@ import (
 )
 
 type BertNormalizer struct {
-	CleanText          bool `json:"clean_text"`           // Whether to remove Control characters and all sorts of whitespaces replaced with single ` ` space
-	Lowercase          bool `json:"lowercase"`            // Whether to do lowercase
-	HandleChineseChars bool `json:"handle_chinese_chars"` // Whether to put spaces around chinese characters so they get split
-	StripAccents       bool `json:"strip_accents"`        // whether to remove accents
+	CleanText          bool  `json:"clean_text"`           // Whether to remove Control characters and all sorts of whitespaces replaced with single ` ` space
+	Lowercase          bool  `json:"lowercase"`            // Whether to do lowercase
+	HandleChineseChars bool  `json:"handle_chinese_chars"` // Whether to put spaces around chinese characters so they get split
+	StripAccents       *bool `json:"strip_accents"`        // whether to remove accents, if nil it follows Lowercase
 }
 
 func NewBertNormalizer(cleanText, lowercase, handleChineseChars, stripAccents bool) *BertNormalizer {
@@ -16,7 +16,7 @@ func NewBertNormalizer(cleanText, lowercase, handleChineseChars, stripAccents bo
 		CleanText:          cleanText,
 		Lowercase:          lowercase,
 		HandleChineseChars: handleChineseChars,
-		StripAccents:       stripAccents,
+		StripAccents:       &stripAccents,
 	}
 }
 
@@ -202,7 +202,7 @@ func (bn *BertNormalizer) Normalize(n *NormalizedString) (*NormalizedString, err
 		n = doLowercase(n)
 	}
 
-	if bn.StripAccents {
+	if (bn.StripAccents != nil && *bn.StripAccents) || (bn.StripAccents == nil && bn.Lowercase) {
 		n = stripAccents(n)
 	}
 
@@ -0,0 +1,268 @@
+package normalizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"torch/tokenizer/spm"
+	"torch/tokenizer/util"
+	"torch/util/norm"
+)
+
+// This file implements `json.Marshaler` for all normalizers so that they can
+// be written into a `tokenizer.json` file. Each normalizer is written as an
+// object tagged with its HuggingFace `type` name, fields in the same order as
+// HuggingFace Tokenizers writes them.
+
+// PatternConfig is the `tokenizer.json` form of a Pattern. Only one of its
+// fields is set, i.e. `{"String": " "}` or `{"Regex": "\\s+"}`.
+type PatternConfig struct {
+	String *string `json:"String,omitempty"`
+	Regex  *string `json:"Regex,omitempty"`
+}
+
+// NewPatternConfig creates a PatternConfig from a StringPattern, RunePattern
+// or RegexpPattern.
+func NewPatternConfig(p Pattern) (*PatternConfig, error) {
+	switch pat := p.(type) {
+	case *StringPattern:
+		s := pat.string
+		return &PatternConfig{String: &s}, nil
+	case *RunePattern:
+		s := string(pat.rune)
+		return &PatternConfig{String: &s}, nil
+	case *RegexpPattern:
+		s := pat.re.String()
+		return &PatternConfig{Regex: &s}, nil
+	default:
+		err := fmt.Errorf("Pattern of type %T cannot be serialized", p)
+		return nil, err
+	}
+}
+
+// NewPatternConfigFrom parses a pattern value decoded from `tokenizer.json`.
+func NewPatternConfigFrom(v interface{}) (*PatternConfig, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("Invalid pattern %v: expected an object", v)
+		return nil, err
+	}
+
+	if s, ok := m["String"].(string); ok {
+		return &PatternConfig{String: &s}, nil
+	}
+	if s, ok := m["Regex"].(string); ok {
+		return &PatternConfig{Regex: &s}, nil
+	}
+
+	err := fmt.Errorf("Invalid pattern %v: expected 'String' or 'Regex' field", v)
+	return nil, err
+}
+
+// Pattern builds the Pattern described by the config. It returns an error
+// if the regular expression is not supported by Go `regexp` package.
+func (c *PatternConfig) Pattern() (Pattern, error) {
+	switch {
+	case c.String != nil:
+		return NewStringPattern(*c.String), nil
+	case c.Regex != nil:
+		re, err := regexp.Compile(*c.Regex)
+		if err != nil {
+			err = fmt.Errorf("Unsupported regular expression %q: %w", *c.Regex, err)
+			return nil, err
+		}
+		return &RegexpPattern{re: re}, nil
+	default:
+		return nil, fmt.Errorf("Empty pattern")
+	}
+}
+
+// String returns the `tokenizer.json` name of the split behavior.
+func (b SplitDelimiterBehavior) String() string {
+	switch b {
+	case RemovedBehavior:
+		return "Removed"
+	case IsolatedBehavior:
+		return "Isolated"
+	case MergedWithPreviousBehavior:
+		return "MergedWithPrevious"
+	case MergedWithNextBehavior:
+		return "MergedWithNext"
+	case ContiguousBehavior:
+		return "Contiguous"
+	default:
+		return fmt.Sprintf("SplitDelimiterBehavior(%d)", int(b))
+	}
+}
+
+// MarshalJSON implements json.Marshaler for SplitDelimiterBehavior.
+func (b SplitDelimiterBehavior) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// NewSplitDelimiterBehavior parses a split behavior name as found in `tokenizer.json`.
+func NewSplitDelimiterBehavior(name string) (SplitDelimiterBehavior, error) {
+	switch name {
+	case "Removed":
+		return RemovedBehavior, nil
+	case "Isolated":
+		return IsolatedBehavior, nil
+	case "MergedWithPrevious":
+		return MergedWithPreviousBehavior, nil
+	case "MergedWithNext":
+		return MergedWithNextBehavior, nil
+	case "Contiguous":
+		return ContiguousBehavior, nil
+	default:
+		err := fmt.Errorf("Unsupported split delimiter behavior %q", name)
+		return IsolatedBehavior, err
+	}
+}
+
+// typeOnly is the serialized form of normalizers without parameters.
+type typeOnly struct {
+	Type string `json:"type"`
+}
+
+// MarshalJSON implements json.Marshaler for BertNormalizer.
+func (bn *BertNormalizer) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type               string `json:"type"`
+		CleanText          bool   `json:"clean_text"`
+		HandleChineseChars bool   `json:"handle_chinese_chars"`
+		StripAccents       *bool  `json:"strip_accents"`
+		Lowercase          bool   `json:"lowercase"`
+	}{"BertNormalizer", bn.CleanText, bn.HandleChineseChars, bn.StripAccents, bn.Lowercase})
+}
+
+// MarshalJSON implements json.Marshaler for DefaultNormalizer. It is written
+// as `Lowercase`, `Strip` or a `Sequence` of both.
+func (dn *DefaultNormalizer) MarshalJSON() ([]byte, error) {
+	switch {
+	case dn.lower && dn.strip:
+		return util.MarshalJSON(NewSequence([]Normalizer{Lowercase(), NewStrip(true, true)}))
+	case dn.lower:
+		return util.MarshalJSON(typeOnly{"Lowercase"})
+	case dn.strip:
+		return util.MarshalJSON(NewStrip(true, true))
+	default:
+		return util.MarshalJSON(NewSequence(nil))
+	}
+}
+
+// MarshalJSON implements json.Marshaler for Strip.
+func (s *Strip) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type       string `json:"type"`
+		StripLeft  bool   `json:"strip_left"`
+		StripRight bool   `json:"strip_right"`
+	}{"Strip", s.stripLeft, s.stripRight})
+}
+
+// MarshalJSON implements json.Marshaler for StripAccents.
+func (sa *StripAccents) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"StripAccents"})
+}
+
+// MarshalJSON implements json.Marshaler for UnicodeNormalizer.
+func (un *UnicodeNormalizer) MarshalJSON() ([]byte, error) {
+	switch un.Form {
+	case norm.NFC:
+		return util.MarshalJSON(typeOnly{"NFC"})
+	case norm.NFD:
+		return util.MarshalJSON(typeOnly{"NFD"})
+	case norm.NFKC:
+		return util.MarshalJSON(typeOnly{"NFKC"})
+	case norm.NFKD:
+		return util.MarshalJSON(typeOnly{"NFKD"})
+	default:
+		err := fmt.Errorf("Unsupported unicode normalization form %v", un.Form)
+		return nil, err
+	}
+}
+
+// MarshalJSON implements json.Marshaler for NFC.
+func (n *NFC) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"NFC"})
+}
+
+// MarshalJSON implements json.Marshaler for NFD.
+func (n *NFD) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"NFD"})
+}
+
+// MarshalJSON implements json.Marshaler for NFKC.
+func (n *NFKC) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"NFKC"})
+}
+
+// MarshalJSON implements json.Marshaler for NFKD.
+func (n *NFKD) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"NFKD"})
+}
+
+// MarshalJSON implements json.Marshaler for Prepend.
+func (p *Prepend) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type    string `json:"type"`
+		Prepend string `json:"prepend"`
+	}{"Prepend", p.Prepend})
+}
+
+// MarshalJSON implements json.Marshaler for Replace.
+func (r *Replace) MarshalJSON() ([]byte, error) {
+	pattern, err := NewPatternConfig(r.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.MarshalJSON(struct {
+		Type    string         `json:"type"`
+		Pattern *PatternConfig `json:"pattern"`
+		Content string         `json:"content"`
+	}{"Replace", pattern, r.Content})
+}
+
+// MarshalJSON implements json.Marshaler for Sequence.
+func (s *Sequence) MarshalJSON() ([]byte, error) {
+	normalizers := s.Normalizers
+	if normalizers == nil {
+		normalizers = []Normalizer{}
+	}
+	for _, n := range normalizers {
+		if _, ok := n.(json.Marshaler); !ok {
+			err := fmt.Errorf("Normalizer of type %T cannot be serialized", n)
+			return nil, err
+		}
+	}
+
+	return util.MarshalJSON(struct {
+		Type        string       `json:"type"`
+		Normalizers []Normalizer `json:"normalizers"`
+	}{"Sequence", normalizers})
+}
+
+// NewPrecompiled creates a Precompiled normalizer from a base64 encoded
+// `precompiled_charsmap` as found in `tokenizer.json`.
+func NewPrecompiled(charsmap string) (*Precompiled, error) {
+	data, err := spm.FromBase64(charsmap)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := spm.NewPrecompiledFrom(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Precompiled{p}, nil
+}
+
+// MarshalJSON implements json.Marshaler for Precompiled.
+func (m *Precompiled) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type                string `json:"type"`
+		PrecompiledCharsmap string `json:"precompiled_charsmap"`
+	}{"Precompiled", spm.AsBase64(m.PrecompiledCharsmap)})
+}
@@ -143,14 +143,19 @@ type ByteLevel struct {
 	// Whether the post processing step should trim offsets
 	// to avoid including whitespaces.
 	TrimOffsets bool
+
+	// Whether to split the input with the GPT-2 regular expression
+	// before the byte-level transformation.
+	UseRegex bool
 }
 
-// NewByteLevel returns a default ByteLevel with both
-// AddPrefixSpace and TrimOffsets set true
+// NewByteLevel returns a default ByteLevel with AddPrefixSpace,
+// TrimOffsets and UseRegex set true
 func NewByteLevel() *ByteLevel {
 	return &ByteLevel{
 		AddPrefixSpace: true,
 		TrimOffsets:    true,
+		UseRegex:       true,
 	}
 }
 
@@ -174,6 +179,11 @@ func (bl *ByteLevel) SetTrimOffsets(v bool) {
 	bl.TrimOffsets = v
 }
 
+// SetUseRegex set `UseRegex` property
+func (bl *ByteLevel) SetUseRegex(v bool) {
+	bl.UseRegex = v
+}
+
 // Implement `PreTokenizer` methods for `ByteLevel`:
 // =================================================
 
@@ -187,6 +197,10 @@ func (bl *ByteLevel) PreTokenize(pretokenized *tokenizer.PreTokenizedString) (*t
 			newNormalized = normalized.Prepend(" ")
 		}
 
+		if !bl.UseRegex {
+			return []tokenizer.SplitIdx{{Normalized: newNormalized, Tokens: nil}}
+		}
+
 		splitPattern := normalizer.NewRegexpPattern(splitRegStr)
 		splits := newNormalized.Split(splitPattern, normalizer.IsolatedBehavior)
 
@@ -0,0 +1,122 @@
+package pretokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"torch/tokenizer/normalizer"
+	"torch/tokenizer/util"
+)
+
+// This file implements `json.Marshaler` for all pre-tokenizers so that they
+// can be written into a `tokenizer.json` file. `ByteLevel` and `Metaspace`
+// are also used as decoders (and `ByteLevel` as a post-processor) and are
+// written the same way in all those places.
+
+type typeOnly struct {
+	Type string `json:"type"`
+}
+
+// MarshalJSON implements json.Marshaler for BertPreTokenizer.
+func (bt *BertPreTokenizer) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"BertPreTokenizer"})
+}
+
+// MarshalJSON implements json.Marshaler for ByteLevel.
+func (bl *ByteLevel) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type           string `json:"type"`
+		AddPrefixSpace bool   `json:"add_prefix_space"`
+		TrimOffsets    bool   `json:"trim_offsets"`
+		UseRegex       bool   `json:"use_regex"`
+	}{"ByteLevel", bl.AddPrefixSpace, bl.TrimOffsets, bl.UseRegex})
+}
+
+// MarshalJSON implements json.Marshaler for CharDelimiterSplit.
+func (d *CharDelimiterSplit) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type      string `json:"type"`
+		Delimiter string `json:"delimiter"`
+	}{"CharDelimiterSplit", string(d.Delimiter)})
+}
+
+// MarshalJSON implements json.Marshaler for Digits.
+func (p *Digits) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type             string `json:"type"`
+		IndividualDigits bool   `json:"individual_digits"`
+	}{"Digits", p.IndividualDigits})
+}
+
+// MarshalJSON implements json.Marshaler for Metaspace.
+//
+// NOTE. `AddPrefixSpace` is written as the `prepend_scheme` "always" or "never".
+func (m *Metaspace) MarshalJSON() ([]byte, error) {
+	prependScheme := "never"
+	if m.AddPrefixSpace {
+		prependScheme = "always"
+	}
+
+	return util.MarshalJSON(struct {
+		Type          string `json:"type"`
+		Replacement   string `json:"replacement"`
+		PrependScheme string `json:"prepend_scheme"`
+		Split         bool   `json:"split"`
+	}{"Metaspace", m.Replacement, prependScheme, true})
+}
+
+// MarshalJSON implements json.Marshaler for Punctuation.
+func (p *Punctuation) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type     string                            `json:"type"`
+		Behavior normalizer.SplitDelimiterBehavior `json:"behavior"`
+	}{"Punctuation", p.Behavior})
+}
+
+// MarshalJSON implements json.Marshaler for Sequence.
+func (p *Sequence) MarshalJSON() ([]byte, error) {
+	pretokenizers := make([]json.Marshaler, 0, len(p.pretokenizers))
+	for _, pretok := range p.pretokenizers {
+		m, ok := pretok.(json.Marshaler)
+		if !ok {
+			err := fmt.Errorf("PreTokenizer of type %T cannot be serialized", pretok)
+			return nil, err
+		}
+		pretokenizers = append(pretokenizers, m)
+	}
+
+	return util.MarshalJSON(struct {
+		Type          string           `json:"type"`
+		Pretokenizers []json.Marshaler `json:"pretokenizers"`
+	}{"Sequence", pretokenizers})
+}
+
+// MarshalJSON implements json.Marshaler for Split.
+func (s *Split) MarshalJSON() ([]byte, error) {
+	pattern, err := normalizer.NewPatternConfig(s.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return util.MarshalJSON(struct {
+		Type     string                            `json:"type"`
+		Pattern  *normalizer.PatternConfig         `json:"pattern"`
+		Behavior normalizer.SplitDelimiterBehavior `json:"behavior"`
+		Invert   bool                              `json:"invert"`
+	}{"Split", pattern, s.Behavior, s.Invert})
+}
+
+// MarshalJSON implements json.Marshaler for UnicodeScript.
+func (us *UnicodeScript) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"UnicodeScripts"})
+}
+
+// MarshalJSON implements json.Marshaler for Whitespace.
+func (p *Whitespace) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"Whitespace"})
+}
+
+// MarshalJSON implements json.Marshaler for WhitespaceSplit.
+func (p *WhitespaceSplit) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(typeOnly{"WhitespaceSplit"})
+}
@@ -0,0 +1,170 @@
+package pretrained
+
+import (
+	"fmt"
+	"sort"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+// CreateAddedTokens adds the `added_tokens` of `tokenizer.json` to the
+// tokenizer. Tokens are added in id order so that tokens missing from the
+// model vocabulary get the same ids as in the file.
+func CreateAddedTokens(tk *tokenizer.Tokenizer, configs []tokenizer.TokenConfig) error {
+	configs = append([]tokenizer.TokenConfig{}, configs...)
+	sort.SliceStable(configs, func(i, j int) bool {
+		return configs[i].Id < configs[j].Id
+	})
+
+	// Add consecutive tokens of the same kind in one go.
+	var batch []tokenizer.AddedToken
+	special := false
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if special {
+			tk.AddSpecialTokens(batch)
+		} else {
+			tk.AddTokens(batch)
+		}
+		batch = nil
+	}
+
+	for _, c := range configs {
+		if c.Special != special {
+			flush()
+			special = c.Special
+		}
+
+		tok := tokenizer.NewAddedToken(c.Content, c.Special,
+			tokenizer.WithSingleWord(c.SingleWord),
+			tokenizer.WithLStrip(c.Lstrip),
+			tokenizer.WithRStrip(c.Rstrip),
+			tokenizer.WithNormalized(c.Normalized),
+		)
+		batch = append(batch, tok)
+	}
+	flush()
+
+	for _, c := range configs {
+		if id, ok := tk.TokenToId(c.Content); !ok || int64(id) != c.Id {
+			err := fmt.Errorf("Added token %q has id %d instead of %d", c.Content, id, c.Id)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreateTruncationParams creates TruncationParams from the `truncation` field
+// of `tokenizer.json`. It returns nil if config is nil.
+func CreateTruncationParams(config map[string]interface{}) (*tokenizer.TruncationParams, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+
+	name, err := getString(params, "direction", "Right")
+	if err != nil {
+		return nil, err
+	}
+	direction, err := tokenizer.NewTruncationDirection(name)
+	if err != nil {
+		return nil, err
+	}
+
+	maxLength, err := getInt(params, "max_length", 512)
+	if err != nil {
+		return nil, err
+	}
+	stride, err := getInt(params, "stride", 0)
+	if err != nil {
+		return nil, err
+	}
+	name, err = getString(params, "strategy", "LongestFirst")
+	if err != nil {
+		return nil, err
+	}
+	strategy, err := tokenizer.NewTruncationStrategy(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenizer.TruncationParams{
+		MaxLength: maxLength,
+		Strategy:  strategy,
+		Stride:    stride,
+		Direction: direction,
+	}, nil
+}
+
+// CreatePaddingParams creates PaddingParams from the `padding` field of
+// `tokenizer.json`. It returns nil if config is nil.
+func CreatePaddingParams(config map[string]interface{}) (*tokenizer.PaddingParams, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+
+	var strategy *tokenizer.PaddingStrategy
+	switch v := params.Get("strategy", "BatchLongest").(type) {
+	case string:
+		if v != "BatchLongest" {
+			err := fmt.Errorf("Unsupported padding strategy %q", v)
+			return nil, err
+		}
+		strategy = tokenizer.NewPaddingStrategy(tokenizer.WithBatchLongest())
+	case map[string]interface{}:
+		fixed, err := getInt(util.NewParams(v), "Fixed", -1)
+		if err != nil {
+			return nil, err
+		}
+		if fixed < 0 {
+			err := fmt.Errorf("Unsupported padding strategy %v", v)
+			return nil, err
+		}
+		strategy = tokenizer.NewPaddingStrategy(tokenizer.WithFixed(fixed))
+	default:
+		err := fmt.Errorf("Unsupported padding strategy %v", v)
+		return nil, err
+	}
+
+	name, err := getString(params, "direction", "Right")
+	if err != nil {
+		return nil, err
+	}
+	direction, err := tokenizer.NewPaddingDirection(name)
+	if err != nil {
+		return nil, err
+	}
+
+	padToMultipleOf, err := getInt(params, "pad_to_multiple_of", 0)
+	if err != nil {
+		return nil, err
+	}
+	padId, err := getInt(params, "pad_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	padTypeId, err := getInt(params, "pad_type_id", 0)
+	if err != nil {
+		return nil, err
+	}
+	padToken, err := getString(params, "pad_token", "[PAD]")
+	if err != nil {
+		return nil, err
+	}
+
+	return &tokenizer.PaddingParams{
+		Strategy:        *strategy,
+		Direction:       direction,
+		PadToMultipleOf: padToMultipleOf,
+		PadId:           padId,
+		PadTypeId:       padTypeId,
+		PadToken:        padToken,
+	}, nil
+}
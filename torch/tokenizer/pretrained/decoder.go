@@ -0,0 +1,105 @@
+package pretrained
+
+import (
+	"fmt"
+
+	"torch/tokenizer"
+	"torch/tokenizer/decoder"
+	"torch/tokenizer/normalizer"
+	"torch/tokenizer/util"
+)
+
+// CreateDecoder creates a Decoder from the `decoder` field of `tokenizer.json`.
+// It returns nil if config is nil.
+func CreateDecoder(config map[string]interface{}) (tokenizer.Decoder, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+	typ, err := getString(params, "type", "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "ByteLevel":
+		return createByteLevel(params)
+
+	case "Metaspace":
+		return createMetaspace(params)
+
+	case "Replace":
+		patternType, pattern, err := createPattern(params)
+		if err != nil {
+			return nil, err
+		}
+		content, err := getString(params, "content", "")
+		if err != nil {
+			return nil, err
+		}
+		return &normalizer.Replace{
+			PatternType: patternType,
+			Pattern:     pattern,
+			Content:     content,
+		}, nil
+
+	case "WordPiece":
+		prefix, err := getString(params, "prefix", "##")
+		if err != nil {
+			return nil, err
+		}
+		cleanup, err := getBool(params, "cleanup", true)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.NewWordPieceDecoder(prefix, cleanup), nil
+
+	case "BPEDecoder":
+		suffix, err := getString(params, "suffix", "</w>")
+		if err != nil {
+			return nil, err
+		}
+		return decoder.NewBpeDecoder(suffix), nil
+
+	case "ByteFallback":
+		return decoder.NewByteFallback(), nil
+
+	case "Fuse":
+		return decoder.NewFuse(), nil
+
+	case "Strip":
+		content, err := getString(params, "content", " ")
+		if err != nil {
+			return nil, err
+		}
+		start, err := getInt(params, "start", 0)
+		if err != nil {
+			return nil, err
+		}
+		stop, err := getInt(params, "stop", 0)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.NewStrip(content, start, stop), nil
+
+	case "Sequence":
+		configs, err := getMaps(params, "decoders")
+		if err != nil {
+			return nil, err
+		}
+		var decoders []tokenizer.Decoder
+		for _, c := range configs {
+			d, err := CreateDecoder(c)
+			if err != nil {
+				return nil, err
+			}
+			decoders = append(decoders, d)
+		}
+		return decoder.NewSequence(decoders), nil
+
+	default:
+		err := fmt.Errorf("Unsupported decoder type %q", typ)
+		return nil, err
+	}
+}
@@ -0,0 +1,210 @@
+package pretrained
+
+import (
+	"fmt"
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/model"
+	"torch/tokenizer/model/bpe"
+	"torch/tokenizer/model/wordpiece"
+	"torch/tokenizer/util"
+)
+
+// CreateModel creates a tokenizer Model from the `model` field of `tokenizer.json`.
+// Supported models are `BPE` and `WordPiece`.
+func CreateModel(config map[string]interface{}) (tokenizer.Model, error) {
+	if config == nil {
+		err := fmt.Errorf("Missing model config")
+		return nil, err
+	}
+
+	params := util.NewParams(config)
+	typ, err := getString(params, "type", "")
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE. Old files do not tag the model type.
+	if typ == "" {
+		switch {
+		case params.Has("merges"):
+			typ = "BPE"
+		case params.Has("max_input_chars_per_word"):
+			typ = "WordPiece"
+		}
+	}
+
+	switch typ {
+	case "BPE":
+		return createBPE(params)
+	case "WordPiece":
+		return createWordPiece(params)
+	default:
+		err := fmt.Errorf("Unsupported model type %q", typ)
+		return nil, err
+	}
+}
+
+func createVocab(params *util.Params) (model.Vocab, error) {
+	m, ok := params.Get("vocab", map[string]interface{}{}).(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("Invalid 'vocab': expected an object")
+		return nil, err
+	}
+
+	vocab := make(model.Vocab, len(m))
+	for tok, v := range m {
+		id, err := toInt("vocab", v)
+		if err != nil {
+			return nil, err
+		}
+		vocab[tok] = id
+	}
+
+	return vocab, nil
+}
+
+// createMergesPairs reads merges written either as `"a b"` strings or
+// as `["a", "b"]` pairs. It reports whether pairs were used.
+func createMergesPairs(params *util.Params) ([][2]string, bool, error) {
+	items, ok := params.Get("merges", []interface{}{}).([]interface{})
+	if !ok {
+		err := fmt.Errorf("Invalid 'merges': expected a list")
+		return nil, false, err
+	}
+
+	pairs := make([][2]string, 0, len(items))
+	asPairs := false
+	for i, item := range items {
+		var parts []string
+		switch v := item.(type) {
+		case string:
+			parts = strings.Split(v, " ")
+		case []interface{}:
+			asPairs = true
+			for _, p := range v {
+				if s, ok := p.(string); ok {
+					parts = append(parts, s)
+				}
+			}
+		}
+
+		if len(parts) != 2 {
+			err := fmt.Errorf("Invalid merge %v at line %d: expected a pair of tokens", item, i)
+			return nil, false, err
+		}
+		pairs = append(pairs, [2]string{parts[0], parts[1]})
+	}
+
+	return pairs, asPairs, nil
+}
+
+func createBPE(params *util.Params) (tokenizer.Model, error) {
+	vocab, err := createVocab(params)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs, asPairs, err := createMergesPairs(params)
+	if err != nil {
+		return nil, err
+	}
+
+	merges, err := bpe.CreateMergesFromPairs(vocab, pairs)
+	if err != nil {
+		return nil, err
+	}
+
+	builder := bpe.NewBpeBuilder()
+	builder.VocabAndMerges(vocab, *merges)
+
+	if v := params.Get("dropout"); v != nil {
+		dropout, ok := v.(float64)
+		if !ok {
+			err := fmt.Errorf("Invalid 'dropout' value %v: expected a number", v)
+			return nil, err
+		}
+		if dropout > 0 {
+			builder.Dropout(float32(dropout))
+		}
+	}
+
+	unkToken, err := getStringPtr(params, "unk_token")
+	if err != nil {
+		return nil, err
+	}
+	if unkToken != nil {
+		builder.UnkToken(*unkToken)
+	}
+
+	prefix, err := getStringPtr(params, "continuing_subword_prefix")
+	if err != nil {
+		return nil, err
+	}
+	if prefix != nil {
+		builder.ContinuingSubwordPrefix(*prefix)
+	}
+
+	suffix, err := getStringPtr(params, "end_of_word_suffix")
+	if err != nil {
+		return nil, err
+	}
+	if suffix != nil {
+		builder.EndOfWordSuffix(*suffix)
+	}
+
+	fuseUnk, err := getBool(params, "fuse_unk", false)
+	if err != nil {
+		return nil, err
+	}
+	builder.FuseUnk(fuseUnk)
+
+	byteFallback, err := getBool(params, "byte_fallback", false)
+	if err != nil {
+		return nil, err
+	}
+	builder.ByteFallback(byteFallback)
+
+	ignoreMerges, err := getBool(params, "ignore_merges", false)
+	if err != nil {
+		return nil, err
+	}
+	builder.IgnoreMerges(ignoreMerges)
+
+	b, err := builder.Build()
+	if err != nil {
+		return nil, err
+	}
+	b.MergesAsPairs = asPairs
+
+	return b, nil
+}
+
+func createWordPiece(params *util.Params) (tokenizer.Model, error) {
+	vocab, err := createVocab(params)
+	if err != nil {
+		return nil, err
+	}
+
+	unkToken, err := getString(params, "unk_token", "[UNK]")
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := getString(params, "continuing_subword_prefix", "##")
+	if err != nil {
+		return nil, err
+	}
+	maxInputCharsPerWord, err := getInt(params, "max_input_chars_per_word", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := util.NewParams(map[string]interface{}{
+		"unk_token":                 unkToken,
+		"continuing_subword_prefix": prefix,
+		"max_input_chars_per_word":  maxInputCharsPerWord,
+	})
+
+	return wordpiece.New(vocab, opts)
+}
@@ -0,0 +1,146 @@
+package pretrained
+
+import (
+	"fmt"
+
+	"torch/tokenizer/normalizer"
+	"torch/tokenizer/util"
+)
+
+// CreateNormalizer creates a Normalizer from the `normalizer` field of
+// `tokenizer.json`. It returns nil if config is nil.
+func CreateNormalizer(config map[string]interface{}) (normalizer.Normalizer, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+	typ, err := getString(params, "type", "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "BertNormalizer":
+		cleanText, err := getBool(params, "clean_text", true)
+		if err != nil {
+			return nil, err
+		}
+		handleChineseChars, err := getBool(params, "handle_chinese_chars", true)
+		if err != nil {
+			return nil, err
+		}
+		lowercase, err := getBool(params, "lowercase", true)
+		if err != nil {
+			return nil, err
+		}
+		// NOTE. `strip_accents: null` follows `lowercase` and is kept as nil.
+		var stripAccents *bool
+		if params.Get("strip_accents") != nil {
+			v, err := getBool(params, "strip_accents", lowercase)
+			if err != nil {
+				return nil, err
+			}
+			stripAccents = &v
+		}
+		return &normalizer.BertNormalizer{
+			CleanText:          cleanText,
+			Lowercase:          lowercase,
+			HandleChineseChars: handleChineseChars,
+			StripAccents:       stripAccents,
+		}, nil
+
+	case "Lowercase":
+		return normalizer.Lowercase(), nil
+
+	case "Strip":
+		left, err := getBool(params, "strip_left", true)
+		if err != nil {
+			return nil, err
+		}
+		right, err := getBool(params, "strip_right", true)
+		if err != nil {
+			return nil, err
+		}
+		return normalizer.NewStrip(left, right), nil
+
+	case "StripAccents":
+		return normalizer.NewStripAccents(), nil
+
+	case "NFC":
+		return normalizer.NewNFC(), nil
+	case "NFD":
+		return normalizer.NewNFD(), nil
+	case "NFKC":
+		return normalizer.NewNFKC(), nil
+	case "NFKD":
+		return normalizer.NewNFKD(), nil
+
+	case "Prepend":
+		prepend, err := getString(params, "prepend", "")
+		if err != nil {
+			return nil, err
+		}
+		return normalizer.NewPrepend(prepend), nil
+
+	case "Replace":
+		patternType, pattern, err := createPattern(params)
+		if err != nil {
+			return nil, err
+		}
+		content, err := getString(params, "content", "")
+		if err != nil {
+			return nil, err
+		}
+		return &normalizer.Replace{
+			PatternType: patternType,
+			Pattern:     pattern,
+			Content:     content,
+		}, nil
+
+	case "Precompiled":
+		charsmap, err := getString(params, "precompiled_charsmap", "")
+		if err != nil {
+			return nil, err
+		}
+		return normalizer.NewPrecompiled(charsmap)
+
+	case "Sequence":
+		configs, err := getMaps(params, "normalizers")
+		if err != nil {
+			return nil, err
+		}
+		var normalizers []normalizer.Normalizer
+		for _, c := range configs {
+			n, err := CreateNormalizer(c)
+			if err != nil {
+				return nil, err
+			}
+			normalizers = append(normalizers, n)
+		}
+		return normalizer.NewSequence(normalizers), nil
+
+	default:
+		err := fmt.Errorf("Unsupported normalizer type %q", typ)
+		return nil, err
+	}
+}
+
+// createPattern reads the `pattern` field of a Replace or Split config.
+func createPattern(params *util.Params) (normalizer.ReplacePattern, normalizer.Pattern, error) {
+	config, err := normalizer.NewPatternConfigFrom(params.Get("pattern"))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	pattern, err := config.Pattern()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if config.Regex != nil {
+		return normalizer.Regex, pattern, nil
+	}
+
+	return normalizer.String, pattern, nil
+}
@@ -0,0 +1,249 @@
+package pretrained
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"torch/tokenizer"
+	"torch/tokenizer/processor"
+	"torch/tokenizer/util"
+)
+
+// CreatePostProcessor creates a PostProcessor from the `post_processor` field
+// of `tokenizer.json`. It returns nil if config is nil.
+func CreatePostProcessor(config map[string]interface{}) (tokenizer.PostProcessor, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+	typ, err := getString(params, "type", "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "BertProcessing":
+		sep, err := createPostToken(params, "sep")
+		if err != nil {
+			return nil, err
+		}
+		cls, err := createPostToken(params, "cls")
+		if err != nil {
+			return nil, err
+		}
+		return processor.NewBertProcessing(sep, cls), nil
+
+	case "RobertaProcessing":
+		sep, err := createPostToken(params, "sep")
+		if err != nil {
+			return nil, err
+		}
+		cls, err := createPostToken(params, "cls")
+		if err != nil {
+			return nil, err
+		}
+		trimOffsets, err := getBool(params, "trim_offsets", true)
+		if err != nil {
+			return nil, err
+		}
+		addPrefixSpace, err := getBool(params, "add_prefix_space", true)
+		if err != nil {
+			return nil, err
+		}
+		return processor.NewRobertaProcessing(sep, cls, trimOffsets, addPrefixSpace), nil
+
+	case "ByteLevel":
+		bl, err := createByteLevel(params)
+		if err != nil {
+			return nil, err
+		}
+		return processor.NewByteLevelProcessing(bl), nil
+
+	case "TemplateProcessing":
+		return createTemplateProcessing(params)
+
+	case "Sequence":
+		configs, err := getMaps(params, "processors")
+		if err != nil {
+			return nil, err
+		}
+		var processors []tokenizer.PostProcessor
+		for _, c := range configs {
+			p, err := CreatePostProcessor(c)
+			if err != nil {
+				return nil, err
+			}
+			processors = append(processors, p)
+		}
+		return processor.NewSequence(processors), nil
+
+	default:
+		err := fmt.Errorf("Unsupported post-processor type %q", typ)
+		return nil, err
+	}
+}
+
+// createPostToken reads a `[token, id]` tuple.
+func createPostToken(params *util.Params, key string) (processor.PostToken, error) {
+	tuple, ok := params.Get(key).([]interface{})
+	if !ok || len(tuple) != 2 {
+		err := fmt.Errorf("Invalid %q value %v: expected a [token, id] pair", key, params.Get(key))
+		return processor.PostToken{}, err
+	}
+
+	value, ok := tuple[0].(string)
+	if !ok {
+		err := fmt.Errorf("Invalid %q token %v: expected a string", key, tuple[0])
+		return processor.PostToken{}, err
+	}
+	id, err := toInt(key, tuple[1])
+	if err != nil {
+		return processor.PostToken{}, err
+	}
+
+	return processor.PostToken{Value: value, Id: id}, nil
+}
+
+func createTemplateProcessing(params *util.Params) (tokenizer.PostProcessor, error) {
+	single, err := createTemplate(params.Get("single"))
+	if err != nil {
+		return nil, err
+	}
+	pair, err := createTemplate(params.Get("pair"))
+	if err != nil {
+		return nil, err
+	}
+
+	tokenMap, ok := params.Get("special_tokens", map[string]interface{}{}).(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("Invalid 'special_tokens': expected an object")
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(tokenMap))
+	for k := range tokenMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var specialTokens []processor.SpecialToken
+	for _, k := range keys {
+		m, ok := tokenMap[k].(map[string]interface{})
+		if !ok {
+			err := fmt.Errorf("Invalid special token %q: expected an object", k)
+			return nil, err
+		}
+		p := util.NewParams(m)
+
+		id, err := getString(p, "id", k)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []int
+		idItems, _ := p.Get("ids", []interface{}{}).([]interface{})
+		for _, v := range idItems {
+			n, err := toInt("ids", v)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, n)
+		}
+
+		var tokens []string
+		tokItems, _ := p.Get("tokens", []interface{}{}).([]interface{})
+		for _, v := range tokItems {
+			s, ok := v.(string)
+			if !ok {
+				err := fmt.Errorf("Invalid special token %q: 'tokens' must be strings", k)
+				return nil, err
+			}
+			tokens = append(tokens, s)
+		}
+
+		if len(ids) != len(tokens) {
+			err := fmt.Errorf("Invalid special token %q: 'ids' and 'tokens' must have the same length", k)
+			return nil, err
+		}
+
+		specialTokens = append(specialTokens, *processor.NewSpecialToken(id, ids, tokens))
+	}
+
+	return processor.NewTemplateProcessing(single, pair, processor.NewTokensFrom(specialTokens)), nil
+}
+
+// createTemplate reads a template written either as a string
+// (e.g. "[CLS] $A [SEP]") or as a list of pieces.
+func createTemplate(v interface{}) (processor.Template, error) {
+	switch tpl := v.(type) {
+	case nil:
+		return processor.Template{}, nil
+	case string:
+		return processor.NewTemplateFromOne(tpl)
+	case []interface{}:
+		var pieces []string
+		var template processor.Template
+		for _, item := range tpl {
+			if s, ok := item.(string); ok {
+				pieces = append(pieces, s)
+				continue
+			}
+
+			piece, err := createPiece(item)
+			if err != nil {
+				return nil, err
+			}
+			template = append(template, piece)
+		}
+		if len(pieces) > 0 {
+			return processor.NewTemplateFromOne(strings.Join(pieces, " "))
+		}
+		return template, nil
+	default:
+		err := fmt.Errorf("Invalid template %v", v)
+		return nil, err
+	}
+}
+
+// createPiece reads a `{"Sequence": {...}}` or `{"SpecialToken": {...}}` piece.
+func createPiece(v interface{}) (processor.Piece, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		err := fmt.Errorf("Invalid template piece %v", v)
+		return nil, err
+	}
+
+	for kind, body := range m {
+		fields, ok := body.(map[string]interface{})
+		if !ok {
+			err := fmt.Errorf("Invalid template piece %v", v)
+			return nil, err
+		}
+		p := util.NewParams(fields)
+
+		id, err := getString(p, "id", "")
+		if err != nil {
+			return nil, err
+		}
+		typeId, err := getInt(p, "type_id", 0)
+		if err != nil {
+			return nil, err
+		}
+
+		switch kind {
+		case "Sequence":
+			if id != "A" && id != "B" {
+				err := fmt.Errorf("Invalid sequence id %q: expected 'A' or 'B'", id)
+				return nil, err
+			}
+			return processor.NewSequencePiece(id, typeId), nil
+		case "SpecialToken":
+			return processor.NewSpecialTokenPiece(id, typeId), nil
+		}
+	}
+
+	err := fmt.Errorf("Invalid template piece %v", v)
+	return nil, err
+}
@@ -0,0 +1,173 @@
+package pretrained
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"torch/tokenizer"
+	"torch/tokenizer/normalizer"
+	"torch/tokenizer/pretokenizer"
+	"torch/tokenizer/util"
+)
+
+// CreatePreTokenizer creates a PreTokenizer from the `pre_tokenizer` field of
+// `tokenizer.json`. It returns nil if config is nil.
+func CreatePreTokenizer(config map[string]interface{}) (tokenizer.PreTokenizer, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	params := util.NewParams(config)
+	typ, err := getString(params, "type", "")
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "BertPreTokenizer":
+		return pretokenizer.NewBertPreTokenizer(), nil
+
+	case "ByteLevel":
+		return createByteLevel(params)
+
+	case "CharDelimiterSplit":
+		delimiter, err := getString(params, "delimiter", "")
+		if err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(delimiter) != 1 {
+			err := fmt.Errorf("Invalid 'delimiter' %q: expected a single character", delimiter)
+			return nil, err
+		}
+		r, _ := utf8.DecodeRuneInString(delimiter)
+		return pretokenizer.NewCharDelimiterSplit(r), nil
+
+	case "Digits":
+		individualDigits, err := getBool(params, "individual_digits", false)
+		if err != nil {
+			return nil, err
+		}
+		return pretokenizer.NewDigits(individualDigits), nil
+
+	case "Metaspace":
+		return createMetaspace(params)
+
+	case "Punctuation":
+		behavior, err := createBehavior(params, normalizer.IsolatedBehavior)
+		if err != nil {
+			return nil, err
+		}
+		return pretokenizer.NewPunctuation(behavior), nil
+
+	case "Split":
+		_, pattern, err := createPattern(params)
+		if err != nil {
+			return nil, err
+		}
+		behavior, err := createBehavior(params, normalizer.IsolatedBehavior)
+		if err != nil {
+			return nil, err
+		}
+		invert, err := getBool(params, "invert", false)
+		if err != nil {
+			return nil, err
+		}
+		return pretokenizer.NewSplit(pattern, behavior, invert), nil
+
+	case "UnicodeScripts":
+		return pretokenizer.NewUnicodeScript(), nil
+
+	case "Whitespace":
+		return pretokenizer.NewWhitespace(), nil
+
+	case "WhitespaceSplit":
+		return pretokenizer.NewWhitespaceSplit(), nil
+
+	case "Sequence":
+		configs, err := getMaps(params, "pretokenizers")
+		if err != nil {
+			return nil, err
+		}
+		var pretokenizers []tokenizer.PreTokenizer
+		for _, c := range configs {
+			p, err := CreatePreTokenizer(c)
+			if err != nil {
+				return nil, err
+			}
+			pretokenizers = append(pretokenizers, p)
+		}
+		return pretokenizer.NewSequence(pretokenizers), nil
+
+	default:
+		err := fmt.Errorf("Unsupported pre-tokenizer type %q", typ)
+		return nil, err
+	}
+}
+
+// createByteLevel creates a ByteLevel used as pre-tokenizer, post-processor or decoder.
+func createByteLevel(params *util.Params) (*pretokenizer.ByteLevel, error) {
+	bl := pretokenizer.NewByteLevel()
+
+	addPrefixSpace, err := getBool(params, "add_prefix_space", true)
+	if err != nil {
+		return nil, err
+	}
+	trimOffsets, err := getBool(params, "trim_offsets", true)
+	if err != nil {
+		return nil, err
+	}
+	useRegex, err := getBool(params, "use_regex", true)
+	if err != nil {
+		return nil, err
+	}
+
+	bl.SetAddPrefixSpace(addPrefixSpace)
+	bl.SetTrimOffsets(trimOffsets)
+	bl.SetUseRegex(useRegex)
+
+	return bl, nil
+}
+
+// createMetaspace creates a Metaspace used as pre-tokenizer or decoder. It
+// reads both the `prepend_scheme` and the older `add_prefix_space` fields.
+func createMetaspace(params *util.Params) (*pretokenizer.Metaspace, error) {
+	replacement, err := getString(params, "replacement", "▁")
+	if err != nil {
+		return nil, err
+	}
+
+	addPrefixSpace, err := getBool(params, "add_prefix_space", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Has("prepend_scheme") {
+		scheme, err := getString(params, "prepend_scheme", "always")
+		if err != nil {
+			return nil, err
+		}
+		switch scheme {
+		case "always", "first":
+			addPrefixSpace = true
+		case "never":
+			addPrefixSpace = false
+		default:
+			err := fmt.Errorf("Unsupported 'prepend_scheme' %q", scheme)
+			return nil, err
+		}
+	}
+
+	return pretokenizer.NewMetaspace(replacement, addPrefixSpace), nil
+}
+
+func createBehavior(params *util.Params, defaultValue normalizer.SplitDelimiterBehavior) (normalizer.SplitDelimiterBehavior, error) {
+	name, err := getString(params, "behavior", "")
+	if err != nil {
+		return defaultValue, err
+	}
+	if name == "" {
+		return defaultValue, nil
+	}
+
+	return normalizer.NewSplitDelimiterBehavior(name)
+}
@@ -0,0 +1,187 @@
+// Package pretrained builds a Tokenizer from a HuggingFace `tokenizer.json` file.
+//
+// Importing this package also registers its loader to `tokenizer.NewTokenizerFromFile`.
+package pretrained
+
+import (
+	"fmt"
+
+	"torch/tokenizer"
+	"torch/tokenizer/util"
+)
+
+func init() {
+	tokenizer.RegisterConfigLoader(FromConfig)
+}
+
+// FromFile constructs a new Tokenizer from a `tokenizer.json` file.
+func FromFile(file string) (*tokenizer.Tokenizer, error) {
+	config, err := tokenizer.ConfigFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromConfig(config)
+}
+
+// FromConfig constructs a new Tokenizer from a parsed `tokenizer.json` config.
+func FromConfig(config *tokenizer.Config) (*tokenizer.Tokenizer, error) {
+	model, err := CreateModel(config.Model)
+	if err != nil {
+		err = fmt.Errorf("Creating Model failed: %v", err)
+		return nil, err
+	}
+
+	tk := tokenizer.NewTokenizer(model)
+
+	normalizer, err := CreateNormalizer(config.Normalizer)
+	if err != nil {
+		err = fmt.Errorf("Creating Normalizer failed: %v", err)
+		return nil, err
+	}
+	if normalizer != nil {
+		tk.WithNormalizer(normalizer)
+	}
+
+	preTokenizer, err := CreatePreTokenizer(config.PreTokenizer)
+	if err != nil {
+		err = fmt.Errorf("Creating PreTokenizer failed: %v", err)
+		return nil, err
+	}
+	if preTokenizer != nil {
+		tk.WithPreTokenizer(preTokenizer)
+	}
+
+	postProcessor, err := CreatePostProcessor(config.PostProcessor)
+	if err != nil {
+		err = fmt.Errorf("Creating PostProcessor failed: %v", err)
+		return nil, err
+	}
+	if postProcessor != nil {
+		tk.WithPostProcessor(postProcessor)
+	}
+
+	decoder, err := CreateDecoder(config.Decoder)
+	if err != nil {
+		err = fmt.Errorf("Creating Decoder failed: %v", err)
+		return nil, err
+	}
+	if decoder != nil {
+		tk.WithDecoder(decoder)
+	}
+
+	trunc, err := CreateTruncationParams(config.Truncation)
+	if err != nil {
+		err = fmt.Errorf("Creating TruncationParams failed: %v", err)
+		return nil, err
+	}
+	tk.WithTruncation(trunc)
+
+	padding, err := CreatePaddingParams(config.Padding)
+	if err != nil {
+		err = fmt.Errorf("Creating PaddingParams failed: %v", err)
+		return nil, err
+	}
+	tk.WithPadding(padding)
+
+	if err := CreateAddedTokens(tk, config.AddedTokens); err != nil {
+		err = fmt.Errorf("Creating AddedTokens failed: %v", err)
+		return nil, err
+	}
+
+	return tk, nil
+}
+
+// Helpers to read typed values from `tokenizer.json` params.
+// NOTE. JSON numbers are decoded as float64.
+
+func getString(params *util.Params, key string, defaultValue string) (string, error) {
+	v := params.Get(key)
+	if v == nil {
+		return defaultValue, nil
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		err := fmt.Errorf("Invalid %q value %v: expected a string", key, v)
+		return "", err
+	}
+
+	return s, nil
+}
+
+func getBool(params *util.Params, key string, defaultValue bool) (bool, error) {
+	v := params.Get(key)
+	if v == nil {
+		return defaultValue, nil
+	}
+
+	b, ok := v.(bool)
+	if !ok {
+		err := fmt.Errorf("Invalid %q value %v: expected a boolean", key, v)
+		return false, err
+	}
+
+	return b, nil
+}
+
+func getInt(params *util.Params, key string, defaultValue int) (int, error) {
+	v := params.Get(key)
+	if v == nil {
+		return defaultValue, nil
+	}
+
+	return toInt(key, v)
+}
+
+func toInt(key string, v interface{}) (int, error) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), nil
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	default:
+		err := fmt.Errorf("Invalid %q value %v: expected a number", key, v)
+		return 0, err
+	}
+}
+
+func getStringPtr(params *util.Params, key string) (*string, error) {
+	if !params.Has(key) {
+		return nil, nil
+	}
+
+	s, err := getString(params, key, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+func getMaps(params *util.Params, key string) ([]map[string]interface{}, error) {
+	v := params.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+
+	items, ok := v.([]interface{})
+	if !ok {
+		err := fmt.Errorf("Invalid %q value %v: expected a list", key, v)
+		return nil, err
+	}
+
+	var out []map[string]interface{}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			err := fmt.Errorf("Invalid %q item %v: expected an object", key, item)
+			return nil, err
+		}
+		out = append(out, m)
+	}
+
+	return out, nil
+}
@@ -218,5 +218,3 @@ func (rp *RobertaProcessing) pairAddSpecialToken(pair *tokenizer.Encoding) *toke
 	pairWordsOpt := tokenizer.WithWordsEncodingOpt(pairWords)
 	return tokenizer.NewEncoding(pairIds, pairTypeIds, pairTokens, pairOffsets, pairSpecialTokens, pairAttentionMask, []tokenizer.Encoding{}, pairWordsOpt)
 }
-
-// TODO: implement Serialize interface for RobertaProcessing
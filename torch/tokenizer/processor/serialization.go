@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"torch/tokenizer/util"
+)
+
+// This file implements `json.Marshaler` for all post-processors so that they
+// can be written into a `tokenizer.json` file.
+
+// MarshalJSON implements json.Marshaler for PostToken. It is written as a
+// `[token, id]` tuple.
+func (pt PostToken) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON([]interface{}{pt.Value, pt.Id})
+}
+
+// MarshalJSON implements json.Marshaler for BertProcessing.
+func (bp *BertProcessing) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type string    `json:"type"`
+		Sep  PostToken `json:"sep"`
+		Cls  PostToken `json:"cls"`
+	}{"BertProcessing", bp.sep, bp.cls})
+}
+
+// MarshalJSON implements json.Marshaler for RobertaProcessing.
+func (rp *RobertaProcessing) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Type           string    `json:"type"`
+		Sep            PostToken `json:"sep"`
+		Cls            PostToken `json:"cls"`
+		TrimOffsets    bool      `json:"trim_offsets"`
+		AddPrefixSpace bool      `json:"add_prefix_space"`
+	}{"RobertaProcessing", rp.sep, rp.cls, rp.trimOffsets, rp.addPrefixSpace})
+}
+
+// MarshalJSON implements json.Marshaler for ByteLevelProcessing.
+func (blp *ByteLevelProcessing) MarshalJSON() ([]byte, error) {
+	return blp.pretok.MarshalJSON()
+}
+
+// MarshalJSON implements json.Marshaler for Sequence.
+func (seq *Sequence) MarshalJSON() ([]byte, error) {
+	processors := make([]json.Marshaler, 0, len(seq.processors))
+	for _, p := range seq.processors {
+		m, ok := p.(json.Marshaler)
+		if !ok {
+			err := fmt.Errorf("PostProcessor of type %T cannot be serialized", p)
+			return nil, err
+		}
+		processors = append(processors, m)
+	}
+
+	return util.MarshalJSON(struct {
+		Type       string           `json:"type"`
+		Processors []json.Marshaler `json:"processors"`
+	}{"Sequence", processors})
+}
+
+// MarshalJSON implements json.Marshaler for SequenceEnum. It is written as
+// "A" or "B".
+func (s SequenceEnum) MarshalJSON() ([]byte, error) {
+	switch s {
+	case A:
+		return util.MarshalJSON("A")
+	case B:
+		return util.MarshalJSON("B")
+	default:
+		err := fmt.Errorf("Unsupported SequenceEnum %d", int(s))
+		return nil, err
+	}
+}
+
+// MarshalJSON implements json.Marshaler for Template. Each piece is wrapped
+// into an object keyed by its kind, i.e. `{"Sequence": {...}}` or
+// `{"SpecialToken": {...}}`.
+func (t Template) MarshalJSON() ([]byte, error) {
+	pieces := make([]map[string]Piece, 0, len(t))
+	for _, p := range t {
+		switch p.(type) {
+		case *SequencePiece:
+			pieces = append(pieces, map[string]Piece{"Sequence": p})
+		case *SpecialTokenPiece:
+			pieces = append(pieces, map[string]Piece{"SpecialToken": p})
+		default:
+			err := fmt.Errorf("Unsupported template piece of type %T", p)
+			return nil, err
+		}
+	}
+
+	return util.MarshalJSON(pieces)
+}
+
+// MarshalJSON implements json.Marshaler for Tokens. Special tokens are
+// written as an object keyed (and sorted) by their id.
+func (t *Tokens) MarshalJSON() ([]byte, error) {
+	tokenMap := t.TokenMap
+	if tokenMap == nil {
+		tokenMap = make(map[string]SpecialToken)
+	}
+
+	return util.MarshalJSON(tokenMap)
+}
+
+// MarshalJSON implements json.Marshaler for TemplateProcessing.
+func (tp *TemplateProcessing) MarshalJSON() ([]byte, error) {
+	specialTokens := tp.SpecialTokens
+	if specialTokens == nil {
+		specialTokens = DefaultTokens()
+	}
+
+	return util.MarshalJSON(struct {
+		Type          string   `json:"type"`
+		Single        Template `json:"single"`
+		Pair          Template `json:"pair"`
+		SpecialTokens *Tokens  `json:"special_tokens"`
+	}{"TemplateProcessing", tp.Single, tp.Pair, specialTokens})
+}
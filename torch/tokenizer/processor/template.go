@@ -152,13 +152,13 @@ func NewPiece(s string) (Piece, error) {
 // some cases, it might be interesting to have multiple ids/tokens.
 type SpecialToken struct {
 	// A unique id used to identify this SpecialToken in the template
-	Id string
+	Id string `json:"id"`
 
 	// The list of associated ids
-	Ids []int
+	Ids []int `json:"ids"`
 
 	// The list of associated tokens
-	Tokens []string
+	Tokens []string `json:"tokens"`
 }
 
 func NewSpecialToken(id string, ids []int, tokens []string) *SpecialToken {
@@ -0,0 +1,257 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"torch/tokenizer/util"
+)
+
+// SerializationVersion is the `tokenizer.json` format version written by `Tokenizer.Serialize`.
+const SerializationVersion = "1.0"
+
+// tokenizerJSON is the layout of a `tokenizer.json` file. Its members are
+// declared in the same order as HuggingFace Tokenizers writes them so that
+// both libraries produce identical files.
+type tokenizerJSON struct {
+	Version       string            `json:"version"`
+	Truncation    *TruncationParams `json:"truncation"`
+	Padding       *PaddingParams    `json:"padding"`
+	AddedTokens   []TokenConfig     `json:"added_tokens"`
+	Normalizer    interface{}       `json:"normalizer"`
+	PreTokenizer  interface{}       `json:"pre_tokenizer"`
+	PostProcessor interface{}       `json:"post_processor"`
+	Decoder       interface{}       `json:"decoder"`
+	Model         interface{}       `json:"model"`
+}
+
+// configLoader builds a Tokenizer from a parsed `tokenizer.json`.
+// It is provided by the `pretrained` sub-package which knows about all
+// concrete models and pipeline components.
+var configLoader func(config *Config) (*Tokenizer, error)
+
+// RegisterConfigLoader registers the function used by `NewTokenizerFromFile`
+// to build a Tokenizer from a `Config`. Importing `torch/tokenizer/pretrained`
+// registers the default loader.
+func RegisterConfigLoader(fn func(config *Config) (*Tokenizer, error)) {
+	configLoader = fn
+}
+
+// serializable checks whether a pipeline part knows how to write itself into
+// a `tokenizer.json` file. Nil parts are serialized as `null`.
+func serializable(name string, v interface{}) (interface{}, error) {
+	if util.IsNil(v) {
+		return nil, nil
+	}
+
+	if _, ok := v.(json.Marshaler); !ok {
+		err := fmt.Errorf("Serialize failed: %s of type %T does not support serialization", name, v)
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// OrderedVocab is a vocabulary (token -> id) that is serialized with its
+// entries sorted by id, as found in `tokenizer.json` files.
+type OrderedVocab map[string]int
+
+// MarshalJSON implements json.Marshaler for OrderedVocab.
+func (v OrderedVocab) MarshalJSON() ([]byte, error) {
+	tokens := make([]string, 0, len(v))
+	for tok := range v {
+		tokens = append(tokens, tok)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if v[tokens[i]] == v[tokens[j]] {
+			return tokens[i] < tokens[j]
+		}
+		return v[tokens[i]] < v[tokens[j]]
+	})
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, tok := range tokens {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := util.MarshalJSON(tok)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		fmt.Fprintf(&buf, "%d", v[tok])
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// TokenConfigs returns all added tokens, special and classic ones, sorted by
+// their id as they are listed in the `added_tokens` field of `tokenizer.json`.
+func (av *AddedVocabulary) TokenConfigs(model Model) []TokenConfig {
+	var configs []TokenConfig
+	seen := make(map[string]bool)
+	tokens := append(append([]AddedToken{}, av.specialTokens...), av.addedTokens...)
+	for _, tok := range tokens {
+		if seen[tok.Content] {
+			continue
+		}
+		seen[tok.Content] = true
+
+		id, ok := av.TokenToId(tok.Content, model)
+		if !ok {
+			continue
+		}
+
+		configs = append(configs, TokenConfig{
+			Id:         int64(id),
+			Content:    tok.Content,
+			SingleWord: tok.SingleWord,
+			Lstrip:     tok.LStrip,
+			Rstrip:     tok.RStrip,
+			Normalized: tok.Normalized,
+			Special:    av.IsSpecialToken(tok.Content),
+		})
+	}
+
+	sort.SliceStable(configs, func(i, j int) bool {
+		return configs[i].Id < configs[j].Id
+	})
+
+	return configs
+}
+
+// String returns the `tokenizer.json` name of the truncation strategy.
+func (s TruncationStrategy) String() string {
+	switch s {
+	case LongestFirst:
+		return "LongestFirst"
+	case OnlyFirst:
+		return "OnlyFirst"
+	case OnlySecond:
+		return "OnlySecond"
+	default:
+		return fmt.Sprintf("TruncationStrategy(%d)", int(s))
+	}
+}
+
+// NewTruncationStrategy parses a truncation strategy name as found in `tokenizer.json`.
+func NewTruncationStrategy(name string) (TruncationStrategy, error) {
+	switch name {
+	case "LongestFirst":
+		return LongestFirst, nil
+	case "OnlyFirst":
+		return OnlyFirst, nil
+	case "OnlySecond":
+		return OnlySecond, nil
+	default:
+		err := fmt.Errorf("Unsupported truncation strategy %q", name)
+		return LongestFirst, err
+	}
+}
+
+// String returns the `tokenizer.json` name of the padding direction.
+func (d PaddingDirection) String() string {
+	switch d {
+	case Left:
+		return "Left"
+	case Right:
+		return "Right"
+	default:
+		return fmt.Sprintf("PaddingDirection(%d)", int(d))
+	}
+}
+
+// NewPaddingDirection parses a padding direction name as found in `tokenizer.json`.
+func NewPaddingDirection(name string) (PaddingDirection, error) {
+	switch name {
+	case "Left":
+		return Left, nil
+	case "Right":
+		return Right, nil
+	default:
+		err := fmt.Errorf("Unsupported padding direction %q", name)
+		return Right, err
+	}
+}
+
+// String returns the `tokenizer.json` name of the truncation direction.
+func (d TruncationDirection) String() string {
+	switch d {
+	case TruncateLeft:
+		return "Left"
+	case TruncateRight:
+		return "Right"
+	default:
+		return fmt.Sprintf("TruncationDirection(%d)", int(d))
+	}
+}
+
+// NewTruncationDirection parses a truncation direction name as found in `tokenizer.json`.
+func NewTruncationDirection(name string) (TruncationDirection, error) {
+	switch name {
+	case "Left":
+		return TruncateLeft, nil
+	case "Right":
+		return TruncateRight, nil
+	default:
+		err := fmt.Errorf("Unsupported truncation direction %q", name)
+		return TruncateRight, err
+	}
+}
+
+// MarshalJSON implements json.Marshaler for TruncationParams.
+func (p TruncationParams) MarshalJSON() ([]byte, error) {
+	return util.MarshalJSON(struct {
+		Direction string `json:"direction"`
+		MaxLength int    `json:"max_length"`
+		Strategy  string `json:"strategy"`
+		Stride    int    `json:"stride"`
+	}{
+		Direction: p.Direction.String(),
+		MaxLength: p.MaxLength,
+		Strategy:  p.Strategy.String(),
+		Stride:    p.Stride,
+	})
+}
+
+// MarshalJSON implements json.Marshaler for PaddingStrategy. It is either
+// the string `"BatchLongest"` or an object `{"Fixed": size}`.
+func (ps PaddingStrategy) MarshalJSON() ([]byte, error) {
+	switch ps.Name {
+	case "BatchLongest":
+		return util.MarshalJSON(ps.Name)
+	case "Fixed":
+		return util.MarshalJSON(map[string]interface{}{"Fixed": ps.Value})
+	default:
+		err := fmt.Errorf("Unsupported padding strategy %q", ps.Name)
+		return nil, err
+	}
+}
+
+// MarshalJSON implements json.Marshaler for PaddingParams.
+func (p PaddingParams) MarshalJSON() ([]byte, error) {
+	var padToMultipleOf *int
+	if p.PadToMultipleOf > 0 {
+		padToMultipleOf = &p.PadToMultipleOf
+	}
+	return util.MarshalJSON(struct {
+		Strategy        PaddingStrategy `json:"strategy"`
+		Direction       string          `json:"direction"`
+		PadToMultipleOf *int            `json:"pad_to_multiple_of"`
+		PadId           int             `json:"pad_id"`
+		PadTypeId       int             `json:"pad_type_id"`
+		PadToken        string          `json:"pad_token"`
+	}{
+		Strategy:        p.Strategy,
+		Direction:       p.Direction.String(),
+		PadToMultipleOf: padToMultipleOf,
+		PadId:           p.PadId,
+		PadTypeId:       p.PadTypeId,
+		PadToken:        p.PadToken,
+	})
+}
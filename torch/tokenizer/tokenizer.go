@@ -598,6 +598,7 @@ func (t *Tokenizer) PostProcess(encoding, pairEncoding *Encoding, addSpecialToke
 				MaxLength: maxLength,
 				Strategy:  trunc.Strategy,
 				Stride:    trunc.Stride,
+				Direction: trunc.Direction,
 			}
 			tEncoding, tPairEncoding = TruncateEncodings(encoding, pairEncoding, params)
 		} else {
@@ -712,25 +713,76 @@ func (t *Tokenizer) TrainAndReplace(trainer Model, files []string) (err error) {
 	return
 }
 
-// NewTokenizerFromFile instantiates a new Tokenizer from the given file
-func NewTokenizerFromFile(file string) (retVal *Tokenizer) {
+// NewTokenizerFromFile instantiates a new Tokenizer from the given `tokenizer.json` file.
+//
+// NOTE. The concrete models and pipeline components are built by the loader
+// registered with `RegisterConfigLoader`. Import `torch/tokenizer/pretrained`
+// to register the default one.
+func NewTokenizerFromFile(file string) (*Tokenizer, error) {
+	if configLoader == nil {
+		err := fmt.Errorf("NewTokenizerFromFile failed: no config loader registered. Have you imported 'torch/tokenizer/pretrained'?")
+		return nil, err
+	}
 
-	// TODO: implement
-	return
+	config, err := ConfigFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return configLoader(config)
 }
 
-// Serialize serializes current Tokenizer to string
-func (t *Tokenizer) Serialize(pretty bool) (retVal string) {
+// Serialize serializes current Tokenizer to a `tokenizer.json` string.
+// If pretty is true, the output is indented with 2 spaces.
+func (t *Tokenizer) Serialize(pretty bool) (string, error) {
+	out := tokenizerJSON{
+		Version:     SerializationVersion,
+		Truncation:  t.trunc,
+		Padding:     t.padding,
+		AddedTokens: t.addedVocabulary.TokenConfigs(t.model),
+	}
+	if out.AddedTokens == nil {
+		out.AddedTokens = []TokenConfig{}
+	}
 
-	// TODO: implement
-	return
+	var err error
+	if out.Normalizer, err = serializable("normalizer", t.normalizer); err != nil {
+		return "", err
+	}
+	if out.PreTokenizer, err = serializable("pre-tokenizer", t.preTokenizer); err != nil {
+		return "", err
+	}
+	if out.PostProcessor, err = serializable("post-processor", t.postProcessor); err != nil {
+		return "", err
+	}
+	if out.Decoder, err = serializable("decoder", t.decoder); err != nil {
+		return "", err
+	}
+	if out.Model, err = serializable("model", t.model); err != nil {
+		return "", err
+	}
+
+	var data []byte
+	if pretty {
+		data, err = util.MarshalIndentJSON(out, "", "  ")
+	} else {
+		data, err = util.MarshalJSON(out)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
 }
 
-// Save saves the current tokenizer at the given path
-func (t *Tokenizer) Save(path string, pretty bool) (err error) {
+// Save saves the current tokenizer at the given path as a `tokenizer.json` file.
+func (t *Tokenizer) Save(path string, pretty bool) error {
+	data, err := t.Serialize(pretty)
+	if err != nil {
+		return err
+	}
 
-	// TODO: implement
-	return
+	return os.WriteFile(path, []byte(data), 0644)
 }
 
 // Train trains a model and replaces the current model using a given trainer
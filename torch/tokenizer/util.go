@@ -9,14 +9,16 @@ type TruncationParams struct {
 	MaxLength int
 	Strategy  TruncationStrategy
 	Stride    int
+	Direction TruncationDirection
 }
 
 type PaddingParams struct {
-	Strategy  PaddingStrategy
-	Direction PaddingDirection
-	PadId     int
-	PadTypeId int
-	PadToken  string
+	Strategy        PaddingStrategy
+	Direction       PaddingDirection
+	PadToMultipleOf int // if > 0, the padded length is rounded up to a multiple of it
+	PadId           int
+	PadTypeId       int
+	PadToken        string
 }
 
 // PaddingStrategy is a enum of either
@@ -75,6 +77,14 @@ const (
 	OnlySecond
 )
 
+// TruncationDirection is enum of int type represents the side sequences are truncated from
+type TruncationDirection int
+
+const (
+	TruncateRight TruncationDirection = iota // keep the beginning of the sequence
+	TruncateLeft                             // keep the end of the sequence
+)
+
 const (
 	SecondSequenceNotProvided = "Truncation error: Second sequence not provided"
 	SequenceTooShort          = "Truncation error: Sequence to truncate too short to respect the provided max_length"
@@ -114,16 +124,16 @@ func TruncateEncodings(encoding, pairEncoding *Encoding, params *TruncationParam
 			nSecond -= 1
 		}
 
-		encoding.Truncate(nFirst, params.Stride)
+		truncate(encoding, nFirst, params)
 		if pairEncoding != nil {
-			pairEncoding.Truncate(nSecond, params.Stride)
+			truncate(pairEncoding, nSecond, params)
 		}
 
 	case OnlyFirst, OnlySecond:
 		var truncateFunc = func(target *Encoding) (*Encoding, error) {
 			targetLength := len(target.GetIds())
 			if targetLength > toRemove {
-				target.Truncate(targetLength-toRemove, params.Stride)
+				truncate(target, targetLength-toRemove, params)
 				return target, nil
 			} else {
 				err := errors.New(SequenceTooShort)
@@ -148,6 +158,15 @@ func TruncateEncodings(encoding, pairEncoding *Encoding, params *TruncationParam
 	return encoding, pairEncoding
 }
 
+// truncate truncates an encoding to maxLen from the side given by params.
+func truncate(e *Encoding, maxLen int, params *TruncationParams) {
+	if params.Direction == TruncateLeft {
+		e.TruncateLeft(maxLen, params.Stride)
+	} else {
+		e.Truncate(maxLen, params.Stride)
+	}
+}
+
 func PadEncodings(encodings []Encoding, params PaddingParams) []Encoding {
 	if len(encodings) == 0 {
 		return encodings
@@ -168,6 +187,10 @@ func PadEncodings(encodings []Encoding, params PaddingParams) []Encoding {
 		padLength = max
 	}
 
+	if m := params.PadToMultipleOf; m > 0 && padLength%m != 0 {
+		padLength += m - padLength%m
+	}
+
 	// TODO: implement concurrency with for loop
 	var newEncodings []Encoding
 	for _, e := range encodings {
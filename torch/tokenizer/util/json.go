@@ -0,0 +1,36 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalJSON marshals v the same way `json.Marshal` does except that
+// HTML characters (`<`, `>`, `&`) are not escaped. This keeps special tokens
+// such as `<s>` or `</w>` readable and identical to the `tokenizer.json` files
+// written by HuggingFace Tokenizers.
+func MarshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	// `Encoder.Encode` terminates each value with a newline.
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// MarshalIndentJSON is like MarshalJSON but applies `json.MarshalIndent`
+// style indentation with the given prefix and indent.
+func MarshalIndentJSON(v interface{}, prefix, indent string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, indent)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
@@ -74,8 +74,12 @@ func (u UUID) MarshalText() (text []byte, err error) {
 //   "{6ba7b810-9dad-11d1-80b4-00c04fd430c8}",
 //   "urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8"
 //   "6ba7b8109dad11d180b400c04fd430c8"
+//   "3bmyw117dd278r1d00r17x8c68" (EncodingBase32)
+//   "EJ34kCVxxF9jHMKD4EgrAK" (EncodingBase58)
+// EncodingBase64 has the same length as EncodingBase58 and cannot be told
+// apart from it, it is decoded by FormatOptions.Parse.
 // ABNF for supported UUID text representation follows:
-//   uuid := canonical | hashlike | braced | urn
+//   uuid := canonical | hashlike | braced | urn | base32 | base58
 //   plain := canonical | hashlike
 //   canonical := 4hexoct '-' 2hexoct '-' 2hexoct '-' 6hexoct
 //   hashlike := 12hexoct
@@ -91,6 +95,8 @@ func (u UUID) MarshalText() (text []byte, err error) {
 //   hexdig := '0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9' |
 //             'a' | 'b' | 'c' | 'd' | 'e' | 'f' |
 //             'A' | 'B' | 'C' | 'D' | 'E' | 'F'
+//   base32 := 26 digits of Crockford's Base32
+//   base58 := 22 digits of Base58 with the Bitcoin alphabet
 func (u *UUID) UnmarshalText(text []byte) (err error) {
 	switch len(text) {
 	case 32:
@@ -103,6 +109,12 @@ func (u *UUID) UnmarshalText(text []byte) (err error) {
 		fallthrough
 	case 45:
 		return u.decodeURN(text)
+	case 26:
+		*u, err = decode(EncodingBase32, text)
+		return err
+	case 22:
+		*u, err = decode(EncodingBase58, text)
+		return err
 	default:
 		return fmt.Errorf("uuid: incorrect UUID length: %s", text)
 	}
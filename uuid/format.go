@@ -0,0 +1,250 @@
+package uuid
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+)
+
+// Encoding is a text encoding of UUID.
+type Encoding byte
+
+// Text encodings of UUID. Base32 and Base58 encodings have a fixed length
+// and sort in the same order as the bytes of the UUID, so time-based
+// UUIDs of version 6 and 7 stay sortable by time in both encodings.
+const (
+	EncodingCanonical Encoding = iota // 6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	EncodingHash                      // 6ba7b8109dad11d180b400c04fd430c8
+	EncodingBraced                    // {6ba7b810-9dad-11d1-80b4-00c04fd430c8}
+	EncodingURN                       // urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8
+	EncodingBase32                    // 3bmyw117dd278r1d00r17x8c68, Crockford's alphabet
+	EncodingBase58                    // EJ34kCVxxF9jHMKD4EgrAK, Bitcoin alphabet
+	EncodingBase64                    // a6e4EJ2tEdGAtADAT9QwyA, URL-safe alphabet without padding
+)
+
+var encodingNames = [...]string{"canonical", "hash", "braced", "urn", "base32", "base58", "base64"}
+
+var encodingLens = [...]int{36, 32, 38, 45, 26, 22, 22}
+
+// String returns the name of the encoding.
+func (e Encoding) String() string {
+	if int(e) < len(encodingNames) {
+		return encodingNames[e]
+	}
+	return fmt.Sprintf("Encoding(%d)", e)
+}
+
+// EncodedLen returns the length of UUID in the encoding.
+func (e Encoding) EncodedLen() int {
+	if int(e) < len(encodingLens) {
+		return encodingLens[e]
+	}
+	return 0
+}
+
+// Alphabets of the encodings.
+const (
+	hexLower    = "0123456789abcdef"
+	hexUpper    = "0123456789ABCDEF"
+	base32Lower = "0123456789abcdefghjkmnpqrstvwxyz"
+	base32Upper = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	base58      = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+)
+
+var base64Encoding = base64.RawURLEncoding.Strict()
+
+// Decoding tables of the alphabets, invalid characters are 0xff.
+var (
+	hexTable    = decodeTable(hexLower, true)
+	base32Table = crockfordTable()
+	base58Table = decodeTable(base58, false)
+)
+
+// decodeTable returns the decoding table of the alphabet, in which upper
+// case letters decode as lower case ones if fold is set.
+func decodeTable(alphabet string, fold bool) (table [256]byte) {
+	for i := range table {
+		table[i] = 0xff
+	}
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		table[c] = byte(i)
+		if fold && 'a' <= c && c <= 'z' {
+			table[c-'a'+'A'] = byte(i)
+		}
+	}
+	return table
+}
+
+// crockfordTable returns the decoding table of Crockford's Base32, which
+// decodes O as 0 and I and L as 1.
+func crockfordTable() [256]byte {
+	table := decodeTable(base32Lower, true)
+	for _, c := range "oO" {
+		table[c] = 0
+	}
+	for _, c := range "iIlL" {
+		table[c] = 1
+	}
+	return table
+}
+
+// FormatOptions configures the text encoding of UUID.
+type FormatOptions struct {
+	Encoding Encoding
+	Upper    bool // upper case letters in hex and Base32 encodings
+}
+
+// Format returns UUID in the encoding.
+func (o FormatOptions) Format(u UUID) string {
+	var buf [45]byte
+	return string(o.Append(buf[:0], u))
+}
+
+// Append appends UUID in the encoding to dst and returns the extended slice.
+func (o FormatOptions) Append(dst []byte, u UUID) []byte {
+	n := len(dst)
+	dst = append(dst, make([]byte, o.Encoding.EncodedLen())...)
+	buf := dst[n:]
+	hexDigits := hexLower
+	if o.Upper {
+		hexDigits = hexUpper
+	}
+	switch o.Encoding {
+	case EncodingCanonical:
+		encodeCanonical(buf, u, hexDigits)
+	case EncodingHash:
+		encodeHex(buf, u[:], hexDigits)
+	case EncodingBraced:
+		buf[0], buf[37] = '{', '}'
+		encodeCanonical(buf[1:], u, hexDigits)
+	case EncodingURN:
+		copy(buf, urnPrefix)
+		encodeCanonical(buf[9:], u, hexDigits)
+	case EncodingBase32:
+		digits := base32Lower
+		if o.Upper {
+			digits = base32Upper
+		}
+		hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+		for i := 25; i >= 0; i-- {
+			buf[i] = digits[lo&0x1f]
+			lo = lo>>5 | hi<<59
+			hi >>= 5
+		}
+	case EncodingBase58:
+		hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+		for i := 21; i >= 0; i-- {
+			var r uint64
+			hi, r = bits.Div64(0, hi, 58)
+			lo, r = bits.Div64(r, lo, 58)
+			buf[i] = base58[r]
+		}
+	case EncodingBase64:
+		base64Encoding.Encode(buf, u[:])
+	}
+	return dst
+}
+
+func encodeHex(dst, src []byte, digits string) {
+	for i, b := range src {
+		dst[2*i], dst[2*i+1] = digits[b>>4], digits[b&0x0f]
+	}
+}
+
+func encodeCanonical(dst []byte, u UUID, digits string) {
+	encodeHex(dst[0:8], u[0:4], digits)
+	dst[8] = '-'
+	encodeHex(dst[9:13], u[4:6], digits)
+	dst[13] = '-'
+	encodeHex(dst[14:18], u[6:8], digits)
+	dst[18] = '-'
+	encodeHex(dst[19:23], u[8:10], digits)
+	dst[23] = '-'
+	encodeHex(dst[24:], u[10:], digits)
+}
+
+// Parse returns UUID decoded from s in the encoding.
+// Letters are decoded regardless of their case.
+func (o FormatOptions) Parse(s string) (UUID, error) {
+	return decode(o.Encoding, s)
+}
+
+// ParseBytes returns UUID decoded from b in the encoding.
+func (o FormatOptions) ParseBytes(b []byte) (UUID, error) {
+	return decode(o.Encoding, b)
+}
+
+// decode decodes s without allocating unless it is invalid.
+func decode[T string | []byte](e Encoding, s T) (u UUID, err error) {
+	if len(s) != e.EncodedLen() {
+		return Nil, fmt.Errorf("uuid: incorrect %s UUID length: %s", e, string(s))
+	}
+	ok := true
+	switch e {
+	case EncodingCanonical:
+		ok = parseCanonical(&u, s)
+	case EncodingHash:
+		ok = parseHex(u[:], s)
+	case EncodingBraced:
+		ok = s[0] == '{' && s[37] == '}' && parseCanonical(&u, s[1:37])
+	case EncodingURN:
+		ok = string(s[:9]) == string(urnPrefix) && parseCanonical(&u, s[9:])
+	case EncodingBase32:
+		var hi, lo uint64
+		ok = base32Table[s[0]] < 8
+		for i := 0; i < 26; i++ {
+			v := base32Table[s[i]]
+			if v == 0xff {
+				ok = false
+			}
+			hi = hi<<5 | lo>>59
+			lo = lo<<5 | uint64(v&0x1f)
+		}
+		binary.BigEndian.PutUint64(u[:8], hi)
+		binary.BigEndian.PutUint64(u[8:], lo)
+	case EncodingBase58:
+		var hi, lo uint64
+		for i := 0; i < 22 && ok; i++ {
+			v := base58Table[s[i]]
+			overflow, hiLo := bits.Mul64(hi, 58)
+			loHi, loLo := bits.Mul64(lo, 58)
+			var carry uint64
+			lo, carry = bits.Add64(loLo, uint64(v), 0)
+			hi, carry = bits.Add64(hiLo, loHi, carry)
+			ok = v != 0xff && overflow == 0 && carry == 0
+		}
+		binary.BigEndian.PutUint64(u[:8], hi)
+		binary.BigEndian.PutUint64(u[8:], lo)
+	case EncodingBase64:
+		var buf [22]byte
+		copy(buf[:], s)
+		_, err := base64Encoding.Decode(u[:], buf[:])
+		ok = err == nil
+	}
+	if !ok {
+		return Nil, fmt.Errorf("uuid: incorrect %s UUID format: %s", e, string(s))
+	}
+	return u, nil
+}
+
+func parseHex[T string | []byte](dst []byte, s T) bool {
+	for i := range dst {
+		hi, lo := hexTable[s[2*i]], hexTable[s[2*i+1]]
+		if hi > 0x0f || lo > 0x0f {
+			return false
+		}
+		dst[i] = hi<<4 | lo
+	}
+	return true
+}
+
+func parseCanonical[T string | []byte](u *UUID, s T) bool {
+	return s[8] == '-' && s[13] == '-' && s[18] == '-' && s[23] == '-' &&
+		parseHex(u[0:4], s[0:8]) &&
+		parseHex(u[4:6], s[9:13]) &&
+		parseHex(u[6:8], s[14:18]) &&
+		parseHex(u[8:10], s[19:23]) &&
+		parseHex(u[10:], s[24:])
+}
@@ -0,0 +1,287 @@
+package uuid
+
+import (
+	"encoding/json"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var benchmarkUUID = Must(FromString("6ba7b810-9dad-11d1-80b4-00c04fd430c8"))
+
+var encodings = []Encoding{
+	EncodingCanonical,
+	EncodingHash,
+	EncodingBraced,
+	EncodingURN,
+	EncodingBase32,
+	EncodingBase58,
+	EncodingBase64,
+}
+
+var maxUUID = Must(FromString("ffffffff-ffff-ffff-ffff-ffffffffffff"))
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		u     UUID
+		e     Encoding
+		upper bool
+		want  string
+	}{
+		{benchmarkUUID, EncodingCanonical, false, "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{benchmarkUUID, EncodingHash, true, "6BA7B8109DAD11D180B400C04FD430C8"},
+		{benchmarkUUID, EncodingBraced, false, "{6ba7b810-9dad-11d1-80b4-00c04fd430c8}"},
+		{benchmarkUUID, EncodingURN, false, "urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+		{benchmarkUUID, EncodingBase32, false, "3bmyw117dd278r1d00r17x8c68"},
+		{benchmarkUUID, EncodingBase32, true, "3BMYW117DD278R1D00R17X8C68"},
+		{benchmarkUUID, EncodingBase58, false, "EJ34kCVxxF9jHMKD4EgrAK"},
+		{benchmarkUUID, EncodingBase64, false, "a6e4EJ2tEdGAtADAT9QwyA"},
+		{Nil, EncodingBase32, false, "00000000000000000000000000"},
+		{Nil, EncodingBase58, false, "1111111111111111111111"},
+		{Nil, EncodingBase64, false, "AAAAAAAAAAAAAAAAAAAAAA"},
+		{maxUUID, EncodingBase32, false, "7zzzzzzzzzzzzzzzzzzzzzzzzz"},
+		{maxUUID, EncodingBase58, false, "YcVfxkQb6JRzqk5kF2tNLv"},
+		{maxUUID, EncodingBase64, false, "_____________________w"},
+	}
+	for _, test := range tests {
+		o := FormatOptions{Encoding: test.e, Upper: test.upper}
+		if got := o.Format(test.u); got != test.want {
+			t.Errorf("%s Format(%v) = %s, want %s", test.e, test.u, got, test.want)
+		}
+		if got := string(o.Append([]byte("x"), test.u)); got != "x"+test.want {
+			t.Errorf("%s Append(%v) = %s, want x%s", test.e, test.u, got, test.want)
+		}
+		for _, s := range []string{test.want, strings.ToLower(test.want)} {
+			// Base58 and Base64 are case sensitive
+			if s != test.want && (test.e == EncodingBase58 || test.e == EncodingBase64) {
+				continue
+			}
+			if u, err := o.Parse(s); err != nil || u != test.u {
+				t.Errorf("%s Parse(%s) = %v, %v, want %v", test.e, s, u, err, test.u)
+			}
+			if u, err := o.ParseBytes([]byte(s)); err != nil || u != test.u {
+				t.Errorf("%s ParseBytes(%s) = %v, %v, want %v", test.e, s, u, err, test.u)
+			}
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		var u UUID
+		r.Read(u[:])
+		for _, e := range encodings {
+			o := FormatOptions{Encoding: e, Upper: i%2 == 1}
+			s := o.Format(u)
+			if len(s) != e.EncodedLen() {
+				t.Fatalf("%s Format(%v) = %s, want length %d", e, u, s, e.EncodedLen())
+			}
+			if got, err := o.Parse(s); err != nil || got != u {
+				t.Fatalf("%s Parse(%s) = %v, %v, want %v", e, s, got, err, u)
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		e Encoding
+		s string
+	}{
+		{EncodingBase32, "3bmyw117dd278r1d00r17x8c6"},  // too short
+		{EncodingBase32, "80000000000000000000000000"}, // 2^128
+		{EncodingBase32, "zzzzzzzzzzzzzzzzzzzzzzzzzz"}, // overflow
+		{EncodingBase32, "3bmyw117dd278r1d00r17x8c6u"}, // u is not a digit
+		{EncodingBase32, "3bmyw117dd278r1d00r17x8c6-"}, // invalid character
+		{EncodingBase58, "YcVfxkQb6JRzqk5kF2tNLw"},     // 2^128
+		{EncodingBase58, "zzzzzzzzzzzzzzzzzzzzzz"},     // overflow
+		{EncodingBase58, "EJ34kCVxxF9jHMKD4EgrA0"},     // 0 is not a digit
+		{EncodingBase58, "EJ34kCVxxF9jHMKD4EgrAO"},     // O is not a digit
+		{EncodingBase58, "EJ34kCVxxF9jHMKD4EgrAI"},     // I is not a digit
+		{EncodingBase58, "EJ34kCVxxF9jHMKD4Egral"},     // l is not a digit
+		{EncodingBase64, "a6e4EJ2tEdGAtADAT9QwyB"},     // trailing bits set
+		{EncodingBase64, "a6e4EJ2tEdGAtADAT9Qwy+"},     // not URL-safe
+		{EncodingBase64, "a6e4EJ2tEdGAtADAT9Qw=="},     // padding
+		{EncodingBase64, "a6e4EJ2tEdGAtADAT9QwyAA"},    // too long
+		{EncodingHash, "6ba7b8109dad11d180b400c04fd430cg"},
+		{EncodingCanonical, "6ba7b810-9dad-11d1-80b4+00c04fd430c8"},
+		{EncodingBraced, "(6ba7b810-9dad-11d1-80b4-00c04fd430c8)"},
+		{EncodingURN, "urn:uid:-6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+	}
+	for _, test := range tests {
+		o := FormatOptions{Encoding: test.e}
+		if u, err := o.Parse(test.s); err == nil {
+			t.Errorf("%s Parse(%s) = %v, want error", test.e, test.s, u)
+		}
+		if u, err := o.ParseBytes([]byte(test.s)); err == nil {
+			t.Errorf("%s ParseBytes(%s) = %v, want error", test.e, test.s, u)
+		}
+	}
+}
+
+func TestParseCrockford(t *testing.T) {
+	o := FormatOptions{Encoding: EncodingBase32}
+	// O decodes as 0, I and L as 1, in both cases
+	for _, s := range []string{
+		"3BMYW117DD278R1D00R17X8C68",
+		"3bmywiL7dd278riDOor17x8c68",
+		"3bmywl17dd278r1doOr17x8c68",
+		"3BMYWIl7DD278RLD0OR17X8C68",
+	} {
+		if u, err := o.Parse(s); err != nil || u != benchmarkUUID {
+			t.Errorf("Parse(%s) = %v, %v, want %v", s, u, err, benchmarkUUID)
+		}
+	}
+}
+
+func TestFormatOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	uuids := []UUID{Nil, maxUUID}
+	for i := 0; i < 1000; i++ {
+		var u UUID
+		// Share prefixes to compare the later digits as well
+		r.Read(u[r.Intn(Size):])
+		uuids = append(uuids, u)
+	}
+	slices.SortFunc(uuids, Compare)
+	for _, e := range []Encoding{EncodingHash, EncodingBase32, EncodingBase58} {
+		o := FormatOptions{Encoding: e}
+		for i := 1; i < len(uuids); i++ {
+			a, b := o.Format(uuids[i-1]), o.Format(uuids[i])
+			if want := Compare(uuids[i-1], uuids[i]); strings.Compare(a, b) != want {
+				t.Errorf("%s %s and %s compare %d, want %d", e, a, b, strings.Compare(a, b), want)
+			}
+		}
+	}
+}
+
+func TestUnmarshalTextEncodings(t *testing.T) {
+	for _, e := range []Encoding{EncodingBase32, EncodingBase58} {
+		var u UUID
+		if err := u.UnmarshalText([]byte(FormatOptions{Encoding: e}.Format(benchmarkUUID))); err != nil || u != benchmarkUUID {
+			t.Errorf("%s UnmarshalText = %v, %v, want %v", e, u, err, benchmarkUUID)
+		}
+	}
+	var u UUID
+	if err := u.UnmarshalText([]byte("zzzzzzzzzzzzzzzzzzzzzz")); err == nil {
+		t.Errorf("UnmarshalText of an overflowing Base58 UUID = %v, want error", u)
+	}
+}
+
+func TestNullUUIDJSON(t *testing.T) {
+	tests := []struct {
+		data string
+		want NullUUID
+		err  bool
+	}{
+		{`null`, NullUUID{}, false},
+		{`""`, NullUUID{}, false},
+		{`"6ba7b810-9dad-11d1-80b4-00c04fd430c8"`, NullUUID{benchmarkUUID, true}, false},
+		{`"3bmyw117dd278r1d00r17x8c68"`, NullUUID{benchmarkUUID, true}, false},
+		{`"6ba7b810"`, NullUUID{}, true},
+		{`6`, NullUUID{}, true},
+	}
+	for _, test := range tests {
+		// Decoding replaces a previous valid value
+		u := NullUUID{maxUUID, true}
+		err := json.Unmarshal([]byte(test.data), &u)
+		if test.err {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %+v, want error", test.data, u)
+			}
+			continue
+		}
+		if err != nil || u != test.want {
+			t.Errorf("Unmarshal(%s) = %+v, %v, want %+v", test.data, u, err, test.want)
+		}
+	}
+
+	var v struct {
+		A NullUUID `json:"a"`
+		B NullUUID `json:"b"`
+	}
+	v.B = NullUUID{benchmarkUUID, true}
+	data, err := json.Marshal(v)
+	if want := `{"a":null,"b":"6ba7b810-9dad-11d1-80b4-00c04fd430c8"}`; err != nil || string(data) != want {
+		t.Errorf("Marshal = %s, %v, want %s", data, err, want)
+	}
+}
+
+func BenchmarkFormat(b *testing.B) {
+	for _, e := range encodings {
+		b.Run(e.String(), func(b *testing.B) {
+			o := FormatOptions{Encoding: e}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				o.Format(benchmarkUUID)
+			}
+		})
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	for _, e := range encodings {
+		b.Run(e.String(), func(b *testing.B) {
+			o := FormatOptions{Encoding: e}
+			buf := make([]byte, 0, 64)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buf = o.Append(buf[:0], benchmarkUUID)
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	for _, e := range encodings {
+		b.Run(e.String(), func(b *testing.B) {
+			o := FormatOptions{Encoding: e}
+			s := o.Format(benchmarkUUID)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := o.Parse(s); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	for _, e := range encodings {
+		b.Run(e.String(), func(b *testing.B) {
+			o := FormatOptions{Encoding: e}
+			text := []byte(o.Format(benchmarkUUID))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := o.ParseBytes(text); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkUnmarshalText(b *testing.B) {
+	for _, e := range encodings {
+		if e == EncodingBase64 {
+			// Not accepted, it has the same length as Base58
+			continue
+		}
+		b.Run(e.String(), func(b *testing.B) {
+			text := []byte(FormatOptions{Encoding: e}.Format(benchmarkUUID))
+			var u UUID
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := u.UnmarshalText(text); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if u != benchmarkUUID {
+				b.Fatalf("UnmarshalText = %v, want %v", u, benchmarkUUID)
+			}
+		})
+	}
+}
@@ -74,6 +74,29 @@ func NewV5(ns UUID, name string) UUID {
 	return global.NewV5(ns, name)
 }
 
+// NewV6 returns UUID based on reordered current timestamp and random node,
+// which sorts by its timestamp unlike V1.
+func NewV6() (UUID, error) {
+	return global.NewV6()
+}
+
+// NewV7 returns UUID based on current Unix timestamp in milliseconds and
+// random data. UUIDs generated by the same generator sort in generation
+// order, also within the same millisecond.
+func NewV7() (UUID, error) {
+	return global.NewV7()
+}
+
+// NewV8 returns UUID with custom data, in which the version and variant
+// bits are set.
+func NewV8(data [Size]byte) UUID {
+	u := UUID(data)
+	u.SetVersion(V8)
+	u.SetVariant(VariantRFC4122)
+
+	return u
+}
+
 // Generator provides interface for generating UUIDs.
 type Generator interface {
 	NewV1() (UUID, error)
@@ -83,6 +106,48 @@ type Generator interface {
 	NewV5(ns UUID, name string) UUID
 }
 
+// TimeOrderedGenerator provides interface for generating UUIDs, including the
+// time-ordered versions 6 and 7.
+type TimeOrderedGenerator interface {
+	Generator
+	NewV6() (UUID, error)
+	NewV7() (UUID, error)
+}
+
+// GenOption configures the generator returned by NewGenerator.
+type GenOption func(*rfc4122Generator)
+
+// WithEpochFunc sets the clock of the generator, time.Now by default.
+func WithEpochFunc(fn func() time.Time) GenOption {
+	return func(g *rfc4122Generator) {
+		g.epochFunc = fn
+	}
+}
+
+// WithHWAddrFunc sets the source of the hardware address of V1 and V2 UUIDs.
+func WithHWAddrFunc(fn func() (net.HardwareAddr, error)) GenOption {
+	return func(g *rfc4122Generator) {
+		g.hwAddrFunc = fn
+	}
+}
+
+// WithRandomReader sets the source of random data, crypto/rand by default.
+func WithRandomReader(r io.Reader) GenOption {
+	return func(g *rfc4122Generator) {
+		g.rand = r
+	}
+}
+
+// NewGenerator returns a generator configured by the options, which is safe
+// for concurrent use.
+func NewGenerator(opts ...GenOption) TimeOrderedGenerator {
+	g := newRFC4122Generator()
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
 // Default generator implementation.
 type rfc4122Generator struct {
 	clockSequenceOnce sync.Once
@@ -96,9 +161,13 @@ type rfc4122Generator struct {
 	lastTime      uint64
 	clockSequence uint16
 	hardwareAddr  [6]byte
+
+	// V7 state, guarded by storageMutex.
+	lastUnixMilli uint64
+	v7Counter     uint16
 }
 
-func newRFC4122Generator() Generator {
+func newRFC4122Generator() *rfc4122Generator {
 	return &rfc4122Generator{
 		epochFunc:  time.Now,
 		hwAddrFunc: defaultHWAddrFunc,
@@ -183,6 +252,68 @@ func (g *rfc4122Generator) NewV5(ns UUID, name string) UUID {
 	return u
 }
 
+// NewV6 returns UUID based on reordered current timestamp and random node.
+func (g *rfc4122Generator) NewV6() (UUID, error) {
+	u := UUID{}
+
+	timeNow, clockSeq, err := g.getClockSequence()
+	if err != nil {
+		return Nil, err
+	}
+	binary.BigEndian.PutUint32(u[0:], uint32(timeNow>>28))
+	binary.BigEndian.PutUint16(u[4:], uint16(timeNow>>12))
+	binary.BigEndian.PutUint16(u[6:], uint16(timeNow&0x0fff))
+	binary.BigEndian.PutUint16(u[8:], clockSeq)
+
+	if _, err := io.ReadFull(g.rand, u[10:]); err != nil {
+		return Nil, err
+	}
+
+	u.SetVersion(V6)
+	u.SetVariant(VariantRFC4122)
+
+	return u, nil
+}
+
+// NewV7 returns UUID based on current Unix timestamp in milliseconds and
+// random data. Within a millisecond the 12 bits following the timestamp are
+// a counter starting at a random value, see RFC 9562 section 6.2 method 1.
+func (g *rfc4122Generator) NewV7() (UUID, error) {
+	u := UUID{}
+	if _, err := io.ReadFull(g.rand, u[6:]); err != nil {
+		return Nil, err
+	}
+
+	g.storageMutex.Lock()
+	milli := uint64(g.epochFunc().UnixMilli())
+	if milli <= g.lastUnixMilli {
+		// Same millisecond or clock moved backwards: keep the last
+		// timestamp and increase the counter, carrying into the timestamp
+		// when the counter overflows.
+		milli = g.lastUnixMilli
+		g.v7Counter++
+		if g.v7Counter > 0x0fff {
+			milli++
+			g.v7Counter = 0
+		}
+	} else {
+		// Leave the most significant counter bit clear for increments.
+		g.v7Counter = binary.BigEndian.Uint16(u[6:]) & 0x07ff
+	}
+	g.lastUnixMilli = milli
+	counter := g.v7Counter
+	g.storageMutex.Unlock()
+
+	binary.BigEndian.PutUint32(u[0:], uint32(milli>>16))
+	binary.BigEndian.PutUint16(u[4:], uint16(milli))
+	binary.BigEndian.PutUint16(u[6:], counter)
+
+	u.SetVersion(V7)
+	u.SetVariant(VariantRFC4122)
+
+	return u, nil
+}
+
 // Returns epoch and clock sequence.
 func (g *rfc4122Generator) getClockSequence() (uint64, uint16, error) {
 	var err error
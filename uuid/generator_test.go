@@ -0,0 +1,247 @@
+package uuid
+
+import (
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// zeroReader is a random source of zero bytes.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// testClock is a clock which is set by the test.
+type testClock struct {
+	mu  sync.Mutex
+	now time.Time
+	inc time.Duration // added after each reading
+}
+
+func (c *testClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now
+	c.now = c.now.Add(c.inc)
+	return now
+}
+
+func (c *testClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func v7Counter(u UUID) uint16 {
+	return uint16(u[6]&0x0f)<<8 | uint16(u[7])
+}
+
+func TestVersionVariant(t *testing.T) {
+	g := NewGenerator(WithHWAddrFunc(func() (net.HardwareAddr, error) {
+		return net.HardwareAddr{1, 2, 3, 4, 5, 6}, nil
+	}))
+	must := func(u UUID, err error) UUID {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	tests := []struct {
+		u       UUID
+		version byte
+	}{
+		{must(g.NewV1()), V1},
+		{must(g.NewV2(DomainGroup)), V2},
+		{g.NewV3(NamespaceDNS, "python.org"), V3},
+		{must(g.NewV4()), V4},
+		{g.NewV5(NamespaceDNS, "python.org"), V5},
+		{must(g.NewV6()), V6},
+		{must(g.NewV7()), V7},
+		{NewV8([Size]byte{0: 0xff, 6: 0xff, 8: 0xff}), V8},
+	}
+	for _, test := range tests {
+		if v := test.u.Version(); v != test.version {
+			t.Errorf("%v version %d, want %d", test.u, v, test.version)
+		}
+		if v := test.u.Variant(); v != VariantRFC4122 {
+			t.Errorf("%v variant %d, want %d", test.u, v, VariantRFC4122)
+		}
+	}
+
+	// Known name-based UUIDs
+	if u := tests[2].u.String(); u != "6fa459ea-ee8a-3ca4-894e-db77e160355e" {
+		t.Errorf("NewV3 = %s", u)
+	}
+	if u := tests[4].u.String(); u != "886313e1-3b8a-5372-9b90-0c9aee199e5d" {
+		t.Errorf("NewV5 = %s", u)
+	}
+	if u := tests[0].u; [6]byte(u[10:]) != [6]byte{1, 2, 3, 4, 5, 6} {
+		t.Errorf("NewV1 node %x, want the hardware address", u[10:])
+	}
+}
+
+func TestVariant(t *testing.T) {
+	for _, variant := range []byte{VariantNCS, VariantRFC4122, VariantMicrosoft, VariantFuture} {
+		for _, b := range []byte{0x00, 0xff} {
+			u := UUID{8: b}
+			u.SetVariant(variant)
+			u.SetVersion(V4)
+			if u.Variant() != variant || u.Version() != V4 {
+				t.Errorf("%x: variant %d version %d, want %d and %d", u, u.Variant(), u.Version(), variant, V4)
+			}
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	// All of them are past the range of time.Duration from the UUID epoch
+	for _, now := range []time.Time{
+		time.Date(2024, 5, 6, 7, 8, 9, 123456700, time.UTC),
+		time.Date(1900, 1, 2, 3, 4, 5, 600, time.UTC),
+		time.Date(2250, 1, 1, 0, 0, 0, 100, time.UTC),
+	} {
+		g := NewGenerator(WithEpochFunc(func() time.Time { return now }))
+		for _, test := range []struct {
+			version byte
+			new     func() (UUID, error)
+			want    time.Time
+		}{
+			{V1, g.NewV1, now},
+			{V6, g.NewV6, now},
+			{V7, g.NewV7, now.Truncate(time.Millisecond)},
+		} {
+			if test.version == V7 && now.Before(time.Unix(0, 0)) {
+				// The V7 timestamp is unsigned
+				continue
+			}
+			u, err := test.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := u.Time()
+			if err != nil || !got.Equal(test.want) {
+				t.Errorf("V%d Time() = %v, %v, want %v", test.version, got, err, test.want)
+			}
+		}
+	}
+
+	for _, u := range []UUID{Must(NewV4()), NewV5(NamespaceDNS, "python.org"), Nil} {
+		if _, err := u.Time(); err == nil {
+			t.Errorf("V%d Time() succeeded", u.Version())
+		}
+	}
+}
+
+func TestNewV6Order(t *testing.T) {
+	clock := &testClock{now: time.Now(), inc: 100 * time.Nanosecond}
+	g := NewGenerator(WithEpochFunc(clock.Now))
+	var uuids []UUID
+	for i := 0; i < 1000; i++ {
+		u, err := g.NewV6()
+		if err != nil {
+			t.Fatal(err)
+		}
+		uuids = append(uuids, u)
+	}
+	if !slices.IsSortedFunc(uuids, Compare) {
+		t.Error("V6 UUIDs are not ordered by time")
+	}
+
+	// The time-ordered encodings keep the order
+	for _, e := range []Encoding{EncodingCanonical, EncodingBase32, EncodingBase58} {
+		o := FormatOptions{Encoding: e}
+		for i := 1; i < len(uuids); i++ {
+			if a, b := o.Format(uuids[i-1]), o.Format(uuids[i]); a >= b {
+				t.Fatalf("%s %s is not before %s", e, a, b)
+			}
+		}
+	}
+}
+
+func TestNewV7Monotonic(t *testing.T) {
+	clock := &testClock{now: time.UnixMilli(1700000000000)}
+	g := NewGenerator(WithEpochFunc(clock.Now), WithRandomReader(zeroReader{}))
+	next := func() UUID {
+		t.Helper()
+		u, err := g.NewV7()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// Within the frozen millisecond the counter increases from its start
+	// value until it carries into the timestamp.
+	var last UUID
+	for i := 0; i <= 0x1000; i++ {
+		u := next()
+		if Compare(last, u) >= 0 {
+			t.Fatalf("%d: %v is not after %v", i, u, last)
+		}
+		last = u
+		want, counter := clock.now, uint16(i)
+		if i == 0x1000 {
+			want, counter = want.Add(time.Millisecond), 0
+		}
+		if tm, _ := u.Time(); !tm.Equal(want) || v7Counter(u) != counter {
+			t.Fatalf("%d: time %v counter %#x, want %v and %#x", i, tm, v7Counter(u), want, counter)
+		}
+	}
+
+	// The timestamp does not move backwards with the clock.
+	clock.Set(clock.now.Add(-time.Second))
+	if u := next(); Compare(last, u) >= 0 {
+		t.Errorf("%v after the clock moved backwards is not after %v", u, last)
+	}
+
+	// A new millisecond starts the counter at a random value with the most
+	// significant bit clear.
+	g = NewGenerator(WithEpochFunc(clock.Now))
+	for i := 0; i < 100; i++ {
+		clock.Set(clock.now.Add(time.Millisecond))
+		if u := next(); v7Counter(u) > 0x07ff {
+			t.Fatalf("counter %#x of a new millisecond", v7Counter(u))
+		}
+	}
+}
+
+func TestNewV7Concurrent(t *testing.T) {
+	g := NewGenerator()
+	const goroutines, n = 8, 2000
+	results := make([][]UUID, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				u, err := g.NewV7()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[i] = append(results[i], u)
+			}
+		}()
+	}
+	wg.Wait()
+
+	seen := make(map[UUID]bool, goroutines*n)
+	for _, uuids := range results {
+		if !slices.IsSortedFunc(uuids, Compare) {
+			t.Error("V7 UUIDs of a goroutine are not increasing")
+		}
+		for _, u := range uuids {
+			if seen[u] {
+				t.Fatalf("duplicate UUID %v", u)
+			}
+			seen[u] = true
+		}
+	}
+}
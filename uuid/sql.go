@@ -22,7 +22,9 @@
 package uuid
 
 import (
+	"bytes"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 )
 
@@ -76,3 +78,26 @@ func (u *NullUUID) Scan(src interface{}) error {
 	u.Valid = true
 	return u.UUID.Scan(src)
 }
+
+// MarshalJSON implements the json.Marshaler interface.
+// An invalid NullUUID is encoded as null.
+func (u NullUUID) MarshalJSON() ([]byte, error) {
+	if !u.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(u.UUID)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+// Both null and the empty string are decoded as an invalid NullUUID.
+func (u *NullUUID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) || bytes.Equal(data, []byte(`""`)) {
+		u.UUID, u.Valid = Nil, false
+		return nil
+	}
+	if err := json.Unmarshal(data, &u.UUID); err != nil {
+		return err
+	}
+	u.Valid = true
+	return nil
+}
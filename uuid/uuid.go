@@ -20,13 +20,16 @@
 // WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
 
 // Package uuid provides implementation of Universally Unique Identifier (UUID).
-// Supported versions are 1, 3, 4 and 5 (as specified in RFC 4122) and
-// version 2 (as specified in DCE 1.1).
+// Supported versions are 1, 3, 4 and 5 (as specified in RFC 4122),
+// 6, 7 and 8 (as specified in RFC 9562) and version 2 (as specified in DCE 1.1).
 package uuid
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
+	"fmt"
+	"time"
 )
 
 // Size of a UUID in bytes.
@@ -44,6 +47,9 @@ const (
 	V3
 	V4
 	V5
+	V6
+	V7
+	V8
 )
 
 // UUID layout variants.
@@ -84,6 +90,13 @@ func Equal(u1 UUID, u2 UUID) bool {
 	return bytes.Equal(u1[:], u2[:])
 }
 
+// Compare returns an integer comparing the bytes of u1 and u2, which orders
+// UUIDs of version 6 and 7 by time. The result is 0 if u1 == u2, -1 if
+// u1 < u2, and +1 if u1 > u2.
+func Compare(u1 UUID, u2 UUID) int {
+	return bytes.Compare(u1[:], u2[:])
+}
+
 // Version returns algorithm version used to generate UUID.
 func (u UUID) Version() byte {
 	return u[6] >> 4
@@ -105,6 +118,31 @@ func (u UUID) Variant() byte {
 	}
 }
 
+// Time returns the timestamp of a time-based UUID of version 1, 6 or 7.
+// The timestamp of V1 and V6 UUIDs has a precision of 100 nanoseconds,
+// the timestamp of V7 UUIDs a precision of one millisecond.
+func (u UUID) Time() (time.Time, error) {
+	var t uint64
+	switch u.Version() {
+	case V1:
+		t = uint64(binary.BigEndian.Uint16(u[6:])&0x0fff)<<48 |
+			uint64(binary.BigEndian.Uint16(u[4:]))<<32 |
+			uint64(binary.BigEndian.Uint32(u[0:]))
+	case V6:
+		t = uint64(binary.BigEndian.Uint32(u[0:]))<<28 |
+			uint64(binary.BigEndian.Uint16(u[4:]))<<12 |
+			uint64(binary.BigEndian.Uint16(u[6:])&0x0fff)
+	case V7:
+		milli := uint64(binary.BigEndian.Uint32(u[0:]))<<16 | uint64(binary.BigEndian.Uint16(u[4:]))
+		return time.UnixMilli(int64(milli)), nil
+	default:
+		return time.Time{}, fmt.Errorf("uuid: version %d UUID has no timestamp", u.Version())
+	}
+	// The 60-bit timestamp overflows time.Duration
+	ticks := int64(t) - epochStart
+	return time.Unix(ticks/1e7, ticks%1e7*100), nil
+}
+
 // Bytes returns bytes slice representation of UUID.
 func (u UUID) Bytes() []byte {
 	return u[:]